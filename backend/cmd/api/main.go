@@ -31,21 +31,50 @@ func main() {
 	// Extract the pool for repositories that need direct access
 	// Note: queries is *db. Queries which wraps the pool
 	// We'll need to modify config. NewDatabase to return the pool as well
+	ruleRepo := repositories.NewRuleRepository(pool)
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, ruleRepo); err != nil {
+		log.Fatal("rules:", err)
+	}
+
 	userRepo := repositories.NewUserRepository(queries)
 	accountRepo := repositories.NewAccountRepository(queries)
 	userHandler := handlers.NewUserHandler(userRepo)
 	accountHandler := handlers.NewAccountHandler(accountRepo, userRepo)
 	candleRepo := repositories.NewCandleRepository(queries)
-	candleHandler := handlers.NewCandleHandler(candleRepo)
+	conditionResultRepo := repositories.NewRuleConditionResultRepository(pool)
 	indicatorRepo := repositories.NewIndicatorRepository(queries)
-	indicatorHandler := handlers.NewIndicatorHandler(indicatorRepo, candleRepo)
-	tradeRepo := repositories.NewTradeRepository(queries)
-	tradeService := services.NewTradeService(tradeRepo, accountRepo, candleRepo)
+	ruleResultRepo := repositories.NewRuleResultRepository(queries, pool)
+	ruleEvaluationService := services.NewRuleEvaluationService(candleRepo, indicatorRepo, ruleResultRepo)
+	swingRepo := repositories.NewSwingRepository(pool)
+	indicatorService := services.NewIndicatorService(candleRepo, indicatorRepo, swingRepo, ruleEvaluationService)
+	instrumentRepo := repositories.NewInstrumentRepository(pool)
+	instrumentHandler := handlers.NewInstrumentHandler(instrumentRepo)
+	indicatorHandler := handlers.NewIndicatorHandler(indicatorService)
+	swingHandler := handlers.NewSwingHandler(swingRepo)
+	tradeRepo := repositories.NewTradeRepository(queries, pool)
+	fxRateRepo := repositories.NewFXRateRepository(pool)
+	tradeService := services.NewTradeService(tradeRepo, accountRepo, candleRepo, instrumentRepo, fxRateRepo)
 	tradeHandler := handlers.NewTradeHandler(tradeService)
 	execRepo := repositories.NewExecutionRepository(pool)
 	intentRepo := repositories.NewIntentRepository(pool)
-	executionService := services.NewExecutionService(tradeRepo, execRepo, intentRepo, pool)
+	ledgerRepo := repositories.NewLedgerRepository(pool)
+	executionService := services.NewExecutionService(tradeRepo, execRepo, intentRepo, ledgerRepo, instrumentRepo, pool)
 	executionHandler := handlers.NewExecutionHandler(executionService, execRepo)
+	adjustmentRepo := repositories.NewAdjustmentRepository(pool)
+	adjustmentService := services.NewAdjustmentService(tradeRepo, execRepo, intentRepo, adjustmentRepo, swingRepo, instrumentRepo, pool)
+	adjustmentHandler := handlers.NewAdjustmentHandler(adjustmentService, executionService, adjustmentRepo)
+	tradeMonitor := services.NewTradeMonitor(candleRepo, execRepo, executionService, cfg.SameCandleRule)
+	candleHandler := handlers.NewCandleHandler(candleRepo, conditionResultRepo, instrumentRepo, indicatorService, tradeMonitor)
+	feedbackRepo := repositories.NewFeedbackRepository(pool)
+	feedbackService := services.NewFeedbackService(tradeRepo, feedbackRepo, executionService)
+	feedbackHandler := handlers.NewFeedbackHandler(feedbackService)
+	analyticsRepo := repositories.NewAnalyticsRepository(pool)
+	analyticsService := services.NewAnalyticsService(analyticsRepo, accountRepo, instrumentRepo)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	ledgerService := services.NewLedgerService(pool, ledgerRepo, accountRepo)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	ruleVersionService := services.NewRuleVersionService(ruleRepo, ruleEvaluationService)
+	ruleHandler := handlers.NewRuleHandler(ruleVersionService)
 
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
@@ -54,15 +83,34 @@ func main() {
 	{
 		api.POST("/users", userHandler.CreateUser)
 		api.POST("/accounts", accountHandler.CreateAccount)
+		api.GET("/accounts/:id/equity", analyticsHandler.GetAccountEquity)
+		api.POST("/accounts/:id/ledger", ledgerHandler.PostLedgerEntry)
+		api.GET("/accounts/:id/ledger", ledgerHandler.GetLedger)
 		api.POST("/candles", candleHandler.CreateCandle)
 		api.GET("/candles/latest", candleHandler.GetLatestCandles)
+		api.GET("/candles/:id/rules", candleHandler.GetCandleRules)
 		api.POST("/indicators/compute", indicatorHandler.ComputeIndicator)
+		api.GET("/instruments", instrumentHandler.ListInstruments)
+		api.GET("/swings", swingHandler.GetSwings)
 		api.POST("/trades", tradeHandler.CreateTrade)
 		api.POST("/trades/:id/execute", executionHandler. ExecuteTrade)
 		api.POST("/trades/:id/close", executionHandler.CloseTrade)
+		api.POST("/trades/:id/partial-close", executionHandler.PartialCloseTrade)
 		api.POST("/trades/:id/cancel", executionHandler.CancelTrade)
 		api.GET("/trades/:id/state", executionHandler.GetTradeState)
 		api.GET("/trades/:id/executions", executionHandler.GetTradeExecutions)
+		api.POST("/trades/:id/adjustments", adjustmentHandler.AdjustTrade)
+		api.GET("/trades/:id/adjustments", adjustmentHandler.GetTradeAdjustments)
+		api.POST("/trades/:id/feedback", feedbackHandler.CreateFeedback)
+		api.GET("/trades/:id/feedback", feedbackHandler.GetFeedback)
+		api.PUT("/trades/:id/feedback", feedbackHandler.UpdateFeedback)
+		api.GET("/analytics/summary", analyticsHandler.GetSummary)
+		api.GET("/analytics/edge", analyticsHandler.GetEdge)
+		api.GET("/analytics/scale-out", analyticsHandler.GetScaleOut)
+		api.GET("/rules/versions", ruleHandler.ListRuleVersions)
+		api.POST("/rules/:code/versions/:version/evaluate", ruleHandler.EvaluateRuleVersion)
+		api.POST("/rules/:code/versions/:version/promote", ruleHandler.PromoteRuleVersion)
+		api.GET("/rules/:code/compare", ruleHandler.CompareRuleVersions)
 	}
 
 	r.GET("/health", func(c *gin.Context) {
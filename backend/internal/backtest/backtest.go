@@ -0,0 +1,525 @@
+// Package backtest replays weekly candles through the rule registry and
+// simulates the trades a rule would have produced.
+//
+// NO LOOKAHEAD: the rule is evaluated on a candle with indicators computed from
+// that candle and the ones before it only (the same incremental computation as
+// ingestion), swing levels are the last CONFIRMED swings, and a trade is only
+// resolved against candles after its entry.
+//
+// Simulated trades never touch the trades table.
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+// EntryTemplate decides the entry price of a signal
+type EntryTemplate string
+
+const (
+	// EntryNextOpen enters at the open of the candle after the signal
+	EntryNextOpen EntryTemplate = "next_open"
+	// EntrySignalClose enters at the close of the signal candle
+	EntrySignalClose EntryTemplate = "signal_close"
+)
+
+// StopKind selects how the stop loss is placed
+type StopKind string
+
+const (
+	// StopSwing: below the last confirmed swing low (long) / above the last
+	// confirmed swing high (short), plus BufferPips
+	StopSwing StopKind = "swing"
+	// StopFixedPips: Pips away from the entry
+	StopFixedPips StopKind = "fixed_pips"
+	// StopATR: ATRMultiple × atr14 of the signal candle away from the entry
+	StopATR StopKind = "atr"
+)
+
+// StopTemplate places the stop loss of a simulated trade
+type StopTemplate struct {
+	Kind        StopKind `json:"kind"`
+	BufferPips  float64  `json:"buffer_pips,omitempty"`
+	Pips        float64  `json:"pips,omitempty"`
+	ATRMultiple float64  `json:"atr_multiple,omitempty"`
+}
+
+// TargetKind selects how the take profit is placed
+type TargetKind string
+
+const (
+	// TargetFixedR: R × the stop distance away from the entry
+	TargetFixedR TargetKind = "fixed_r"
+	// TargetFixedPips: Pips away from the entry
+	TargetFixedPips TargetKind = "fixed_pips"
+)
+
+// TargetTemplate places the take profit of a simulated trade
+type TargetTemplate struct {
+	Kind TargetKind `json:"kind"`
+	R    float64    `json:"r,omitempty"`
+	Pips float64    `json:"pips,omitempty"`
+}
+
+// Config describes one backtest run
+type Config struct {
+	RuleCode       rules.RuleCode `json:"rule_code"`
+	RuleVersion    int            `json:"rule_version,omitempty"` // 0 = active version
+	Bias           string         `json:"bias"`                   // "long" or "short"
+	Entry          EntryTemplate  `json:"entry"`
+	Stop           StopTemplate   `json:"stop"`
+	Target         TargetTemplate `json:"target"`
+	InitialBalance float64        `json:"initial_balance"`
+	RiskPct        float64        `json:"risk_pct"` // of the simulated balance at entry
+
+	// Instrument the candles are quoted in. Zero value = domain.EURUSD.
+	// The simulated balance is in its quote currency: no FX conversion.
+	Instrument domain.Instrument `json:"instrument"`
+
+	// Replaces registered conditions for this run (research variants, e.g.
+	// rules.TouchEMA20Within). Results then no longer match the rule definition.
+	Conditions map[rules.ConditionCode]rules.ConditionFunc `json:"-"`
+}
+
+// Validate checks the config before a run
+func (c Config) Validate() error {
+	if c.RuleVersion > 0 {
+		if _, ok := rules.GetRuleVersion(c.RuleCode, c.RuleVersion); !ok {
+			return fmt.Errorf("rule not found: %s v%d", c.RuleCode, c.RuleVersion)
+		}
+	} else if _, ok := rules.RuleRegistry[c.RuleCode]; !ok {
+		return fmt.Errorf("rule not found: %s", c.RuleCode)
+	}
+	if c.Bias != "long" && c.Bias != "short" {
+		return errors.New("bias must be 'long' or 'short'")
+	}
+	if c.Entry != EntryNextOpen && c.Entry != EntrySignalClose {
+		return fmt.Errorf("unknown entry template %q", c.Entry)
+	}
+
+	switch c.Stop.Kind {
+	case StopSwing:
+		if c.Stop.BufferPips < 0 {
+			return errors.New("stop buffer must not be negative")
+		}
+	case StopFixedPips:
+		if c.Stop.Pips <= 0 {
+			return errors.New("fixed stop needs positive pips")
+		}
+	case StopATR:
+		if c.Stop.ATRMultiple <= 0 {
+			return errors.New("atr stop needs a positive multiple")
+		}
+	default:
+		return fmt.Errorf("unknown stop template %q", c.Stop.Kind)
+	}
+
+	switch c.Target.Kind {
+	case TargetFixedR:
+		if c.Target.R <= 0 {
+			return errors.New("fixed R target needs a positive R")
+		}
+	case TargetFixedPips:
+		if c.Target.Pips <= 0 {
+			return errors.New("fixed pips target needs positive pips")
+		}
+	default:
+		return fmt.Errorf("unknown target template %q", c.Target.Kind)
+	}
+
+	if c.InitialBalance <= 0 {
+		return errors.New("initial balance must be positive")
+	}
+	if c.RiskPct <= 0 || c.RiskPct > 100 {
+		return errors.New("risk percentage must be between 0 and 100")
+	}
+	return c.instrument().Validate()
+}
+
+// instrument returns the configured instrument, EURUSD when unset
+func (c Config) instrument() domain.Instrument {
+	if c.Instrument.Symbol == "" {
+		return domain.EURUSD
+	}
+	return c.Instrument
+}
+
+// ExitReason tells how a simulated trade was closed
+type ExitReason string
+
+const (
+	ExitStopLoss   ExitReason = "sl"
+	ExitTakeProfit ExitReason = "tp"
+	ExitEndOfData  ExitReason = "end_of_data" // still open on the last candle, closed at its close
+)
+
+// Trade is a simulated trade
+type Trade struct {
+	Bias         string     `json:"bias"`
+	SignalTime   time.Time  `json:"signal_time"`
+	EntryTime    time.Time  `json:"entry_time"`
+	EntryPrice   float64    `json:"entry_price"`
+	StopLoss     float64    `json:"stop_loss"`
+	TakeProfit   float64    `json:"take_profit"`
+	PlannedRR    float64    `json:"planned_rr"`
+	RiskAmount   float64    `json:"risk_amount"`
+	PositionSize float64    `json:"position_size"`
+	ExitTime     time.Time  `json:"exit_time"`
+	ExitPrice    float64    `json:"exit_price"`
+	ExitReason   ExitReason `json:"exit_reason"`
+	BarsHeld     int        `json:"bars_held"` // candles from entry to exit, the exit candle included
+	PnLPips      float64    `json:"pnl_pips"`
+	PnLMoney     float64    `json:"pnl_money"`
+	RMultiple    float64    `json:"r_multiple"` // pips won or lost / stop distance in pips
+	BalanceAfter float64    `json:"balance_after"`
+
+	entryIndex int
+	stopPips   float64
+}
+
+// Result is the outcome of a backtest run
+type Result struct {
+	Config  Config         `json:"config"`
+	Trades  []Trade        `json:"trades"`
+	Stats   Stats          `json:"stats"`
+	Skipped map[string]int `json:"skipped"` // PASS signals not traded, by reason
+}
+
+// Bar is a candle with everything a rule can see when it closes
+type Bar struct {
+	Candle     rules.Candle
+	Indicators rules.Indicators
+	Computed   services.WeeklyIndicators
+}
+
+// Replay computes the indicators of every candle incrementally, exactly as
+// IndicatorService does on ingestion: bar i only depends on candles 0..i.
+func Replay(candles []services.Candle) []Bar {
+	return ReplayWith(candles, services.DefaultEMAPeriods)
+}
+
+// ReplayWith is Replay with other EMA periods behind the ema20/ema50/ema200 fields
+func ReplayWith(candles []services.Candle, periods services.EMAPeriods) []Bar {
+	bars := make([]Bar, len(candles))
+	window := periods.Window()
+
+	var prev *services.WeeklyIndicators
+	for i := range candles {
+		start := i + 1 - window
+		if start < 0 {
+			start = 0
+		}
+		computed, _ := services.ComputeIndicatorsWith(prev, candles[start:i+1], periods)
+
+		bars[i] = Bar{
+			Candle:     ruleCandle(candles[i]),
+			Indicators: ruleIndicators(computed),
+			Computed:   computed,
+		}
+		if i > 0 {
+			// Same previous-bar context as RuleEvaluationService
+			prevInd := ruleIndicators(*prev)
+			if prevInd.IsValid(rules.FieldEMA50) {
+				ema50 := prevInd.EMA50
+				bars[i].Indicators.EMA50Prev = &ema50
+			}
+			bars[i].Indicators.Prev = &prevInd
+			bars[i].Indicators.PrevCandle = &bars[i-1].Candle
+		}
+
+		prev = &computed
+	}
+	return bars
+}
+
+func ruleCandle(c services.Candle) rules.Candle {
+	return rules.Candle{
+		Open:         c.Open,
+		High:         c.High,
+		Low:          c.Low,
+		Close:        c.Close,
+		TimestampUTC: c.TimestampUTC,
+	}
+}
+
+// ruleIndicators converts computed indicators like parseRuleIndicators does
+// for stored rows: values that are not warmed up are 0 and listed in Invalid
+func ruleIndicators(w services.WeeklyIndicators) rules.Indicators {
+	ind := rules.Indicators{
+		RangeSize: w.RangeSize,
+		BodySize:  w.BodySize,
+		UpperWick: w.UpperWick,
+		LowerWick: w.LowerWick,
+		MidPrice:  w.MidPrice,
+		Invalid:   map[string]bool{},
+		Values:    w.Values,
+	}
+
+	emas := []struct {
+		field string
+		v     *float64
+		dst   *float64
+	}{
+		{rules.FieldEMA20, w.EMA20, &ind.EMA20},
+		{rules.FieldEMA50, w.EMA50, &ind.EMA50},
+		{rules.FieldEMA200, w.EMA200, &ind.EMA200},
+	}
+	for _, e := range emas {
+		if e.v == nil {
+			ind.Invalid[e.field] = true
+			continue
+		}
+		*e.dst = *e.v
+	}
+	return ind
+}
+
+// Run replays candles (oldest first) and simulates every PASS of the rule.
+// One position at a time: a PASS while a trade is open or pending is skipped.
+func Run(candles []services.Candle, cfg Config) (*Result, error) {
+	return Simulate(Replay(candles), cfg)
+}
+
+// Simulate runs the trade simulation over replayed bars, so several configs
+// can share one (expensive) replay
+func Simulate(bars []Bar, cfg Config) (*Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Config: cfg, Trades: []Trade{}, Skipped: map[string]int{}}
+	balance := cfg.InitialBalance
+
+	var open *Trade
+	pendingSignal := -1 // signal bar waiting for the next open
+
+	for i, bar := range bars {
+		if pendingSignal >= 0 {
+			trade, reason := openTrade(cfg, bars[pendingSignal], bar.Candle.Open, bar.Candle.TimestampUTC, i, balance)
+			if trade == nil {
+				result.Skipped[reason]++
+			}
+			open = trade
+			pendingSignal = -1
+		}
+
+		if open != nil {
+			if price, reason, closed := resolveExit(*open, bar.Candle); closed {
+				balance = closeTrade(open, cfg.instrument(), bar.Candle.TimestampUTC, price, reason, i, balance)
+				result.Trades = append(result.Trades, *open)
+				open = nil
+			}
+		}
+
+		// The candle has closed: evaluate the rule with what is known now
+		res, err := evaluate(cfg, bar)
+		if err != nil {
+			return nil, fmt.Errorf("candle %s: %w", bar.Candle.TimestampUTC.Format("2006-01-02"), err)
+		}
+		if res.Result != rules.ResultPass {
+			continue
+		}
+		if open != nil {
+			result.Skipped["position_open"]++
+			continue
+		}
+		if i == len(bars)-1 && cfg.Entry == EntryNextOpen {
+			result.Skipped["no_next_candle"]++
+			continue
+		}
+
+		switch cfg.Entry {
+		case EntryNextOpen:
+			pendingSignal = i
+		case EntrySignalClose:
+			trade, reason := openTrade(cfg, bar, bar.Candle.Close, bar.Candle.TimestampUTC, i+1, balance)
+			if trade == nil {
+				result.Skipped[reason]++
+			}
+			open = trade
+		}
+	}
+
+	if open != nil {
+		last := bars[len(bars)-1].Candle
+		balance = closeTrade(open, cfg.instrument(), last.TimestampUTC, last.Close, ExitEndOfData, len(bars)-1, balance)
+		result.Trades = append(result.Trades, *open)
+	}
+
+	result.Stats = ComputeStats(result.Trades, cfg.InitialBalance)
+	for _, n := range result.Skipped {
+		result.Stats.Skipped += n
+	}
+	result.Stats.Signals = result.Stats.Trades + result.Stats.Skipped
+
+	return result, nil
+}
+
+func evaluate(cfg Config, bar Bar) (rules.RuleResult, error) {
+	if len(cfg.Conditions) > 0 {
+		spec, ok := rules.RuleRegistry[cfg.RuleCode]
+		if cfg.RuleVersion > 0 {
+			spec, ok = rules.GetRuleVersion(cfg.RuleCode, cfg.RuleVersion)
+		}
+		if !ok {
+			return rules.RuleResult{}, fmt.Errorf("rule not found: %s", cfg.RuleCode)
+		}
+		return rules.EvaluateRuleSpecWith(spec, bar.Candle, bar.Indicators, cfg.Conditions)
+	}
+	if cfg.RuleVersion > 0 {
+		return rules.EvaluateRuleVersion(cfg.RuleCode, cfg.RuleVersion, bar.Candle, bar.Indicators)
+	}
+	return rules.EvaluateRule(cfg.RuleCode, bar.Candle, bar.Indicators)
+}
+
+// openTrade places SL/TP from the templates and sizes the trade with the same
+// risk math and guards as TradeService. Returns the skip reason when the
+// signal cannot be traded. entryIndex is the first bar the exit is resolved on.
+func openTrade(cfg Config, signal Bar, entry float64, entryTime time.Time, entryIndex int, balance float64) (*Trade, string) {
+	sl, ok := stopLevel(cfg, signal, entry)
+	if !ok {
+		return nil, "no_stop_level"
+	}
+	stopDistance := math.Abs(entry - sl)
+
+	var tp float64
+	dir := 1.0
+	if cfg.Bias == "short" {
+		dir = -1.0
+	}
+	switch cfg.Target.Kind {
+	case TargetFixedR:
+		tp = entry + dir*cfg.Target.R*stopDistance
+	case TargetFixedPips:
+		tp = entry + dir*cfg.Target.Pips*cfg.instrument().PipSize
+	}
+
+	if err := services.ValidateTradeGeometry(entry, sl, tp, cfg.Bias); err != nil {
+		return nil, "invalid_geometry"
+	}
+
+	stopPips, err := services.ComputeStopDistancePips(stopDistance, cfg.instrument())
+	if err != nil || stopPips < constants.MinStopLossPips || stopPips > constants.MaxStopLossPips {
+		return nil, "stop_out_of_range"
+	}
+
+	rr, err := services.ComputeRR(entry, sl, tp, cfg.Bias)
+	if err != nil || rr < constants.MinimumRR {
+		return nil, "rr_below_minimum"
+	}
+
+	riskAmount, err := services.ComputeRiskAmount(balance, cfg.RiskPct)
+	if err != nil {
+		return nil, "no_balance"
+	}
+	size, err := services.ComputePositionSize(riskAmount, stopPips, cfg.instrument(), 1)
+	if err != nil {
+		return nil, "position_size"
+	}
+
+	return &Trade{
+		Bias:         cfg.Bias,
+		SignalTime:   signal.Candle.TimestampUTC,
+		EntryTime:    entryTime,
+		EntryPrice:   entry,
+		StopLoss:     sl,
+		TakeProfit:   tp,
+		PlannedRR:    rr,
+		RiskAmount:   riskAmount,
+		PositionSize: size,
+		entryIndex:   entryIndex,
+		stopPips:     stopPips,
+	}, ""
+}
+
+// stopLevel places the stop from what is known at the signal candle's close
+func stopLevel(cfg Config, signal Bar, entry float64) (float64, bool) {
+	pip := cfg.instrument().PipSize
+	long := cfg.Bias == "long"
+
+	switch cfg.Stop.Kind {
+	case StopSwing:
+		if long {
+			if signal.Computed.LastSwingLowPrice == nil {
+				return 0, false
+			}
+			return *signal.Computed.LastSwingLowPrice - cfg.Stop.BufferPips*pip, true
+		}
+		if signal.Computed.LastSwingHighPrice == nil {
+			return 0, false
+		}
+		return *signal.Computed.LastSwingHighPrice + cfg.Stop.BufferPips*pip, true
+
+	case StopFixedPips:
+		if long {
+			return entry - cfg.Stop.Pips*pip, true
+		}
+		return entry + cfg.Stop.Pips*pip, true
+
+	case StopATR:
+		atr, ok := signal.Computed.Values["atr14"]
+		if !ok {
+			return 0, false
+		}
+		if long {
+			return entry - cfg.Stop.ATRMultiple*atr, true
+		}
+		return entry + cfg.Stop.ATRMultiple*atr, true
+	}
+	return 0, false
+}
+
+// resolveExit checks whether a candle closes the trade.
+// A candle opening beyond a level fills at the open (gap). When a candle
+// reaches both SL and TP the order inside the candle is unknown: the stop is
+// assumed to be hit first (pessimistic).
+func resolveExit(t Trade, c rules.Candle) (price float64, reason ExitReason, closed bool) {
+	if t.Bias == "long" {
+		switch {
+		case c.Open <= t.StopLoss:
+			return c.Open, ExitStopLoss, true
+		case c.Open >= t.TakeProfit:
+			return c.Open, ExitTakeProfit, true
+		case c.Low <= t.StopLoss:
+			return t.StopLoss, ExitStopLoss, true
+		case c.High >= t.TakeProfit:
+			return t.TakeProfit, ExitTakeProfit, true
+		}
+		return 0, "", false
+	}
+
+	switch {
+	case c.Open >= t.StopLoss:
+		return c.Open, ExitStopLoss, true
+	case c.Open <= t.TakeProfit:
+		return c.Open, ExitTakeProfit, true
+	case c.High >= t.StopLoss:
+		return t.StopLoss, ExitStopLoss, true
+	case c.Low <= t.TakeProfit:
+		return t.TakeProfit, ExitTakeProfit, true
+	}
+	return 0, "", false
+}
+
+// closeTrade prices the exit with ComputeExecutionPnL and returns the new balance
+func closeTrade(t *Trade, inst domain.Instrument, exitTime time.Time, exitPrice float64, reason ExitReason, exitIndex int, balance float64) float64 {
+	pnlMoney, pnlPips, _ := services.ComputeExecutionPnL(t.Bias, t.EntryPrice, exitPrice, t.PositionSize, inst, 1)
+
+	t.ExitTime = exitTime
+	t.ExitPrice = exitPrice
+	t.ExitReason = reason
+	t.BarsHeld = exitIndex - t.entryIndex + 1
+	t.PnLPips = pnlPips
+	t.PnLMoney = pnlMoney
+	t.RMultiple = pnlPips / t.stopPips
+	t.BalanceAfter = balance + pnlMoney
+	return t.BalanceAfter
+}
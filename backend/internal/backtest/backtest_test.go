@@ -0,0 +1,247 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+// loadEURUSDWeekly reads the shipped EURUSD weekly candles, oldest first
+func loadEURUSDWeekly(t *testing.T) []services.Candle {
+	t.Helper()
+
+	f, err := os.Open("../../mt4_ready/EURUSD_weekly_2015_2025.csv")
+	if err != nil {
+		t.Skipf("EURUSD weekly data not available: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	candles := make([]services.Candle, 0, len(records)-1)
+	for _, r := range records[1:] {
+		ts, err := time.Parse("2006-01-02", r[0])
+		if err != nil {
+			t.Fatalf("parse date %q: %v", r[0], err)
+		}
+		var ohlc [4]float64
+		for i := range ohlc {
+			if ohlc[i], err = strconv.ParseFloat(r[i+1], 64); err != nil {
+				t.Fatalf("parse price %q: %v", r[i+1], err)
+			}
+		}
+		candles = append(candles, services.Candle{
+			TimestampUTC: ts,
+			Open:         ohlc[0],
+			High:         ohlc[1],
+			Low:          ohlc[2],
+			Close:        ohlc[3],
+		})
+	}
+	return candles
+}
+
+func bullishSwingConfig() Config {
+	return Config{
+		RuleCode:       rules.W1TrendBullish,
+		Bias:           "long",
+		Entry:          EntryNextOpen,
+		Stop:           StopTemplate{Kind: StopSwing, BufferPips: 5},
+		Target:         TargetTemplate{Kind: TargetFixedR, R: 2},
+		InitialBalance: 10000,
+		RiskPct:        1,
+	}
+}
+
+func TestResolveExit(t *testing.T) {
+	long := Trade{Bias: "long", EntryPrice: 1.1000, StopLoss: 1.0950, TakeProfit: 1.1100}
+	short := Trade{Bias: "short", EntryPrice: 1.1000, StopLoss: 1.1050, TakeProfit: 1.0900}
+
+	tests := []struct {
+		name   string
+		trade  Trade
+		candle rules.Candle
+		price  float64
+		reason ExitReason
+		closed bool
+	}{
+		{"long inside range", long, rules.Candle{Open: 1.1000, High: 1.1050, Low: 1.0960, Close: 1.1020}, 0, "", false},
+		{"long stop", long, rules.Candle{Open: 1.1000, High: 1.1050, Low: 1.0940, Close: 1.0970}, 1.0950, ExitStopLoss, true},
+		{"long target", long, rules.Candle{Open: 1.1000, High: 1.1120, Low: 1.0960, Close: 1.1090}, 1.1100, ExitTakeProfit, true},
+		{"long both: stop first", long, rules.Candle{Open: 1.1000, High: 1.1120, Low: 1.0940, Close: 1.1090}, 1.0950, ExitStopLoss, true},
+		{"long gap below stop", long, rules.Candle{Open: 1.0930, High: 1.0990, Low: 1.0920, Close: 1.0980}, 1.0930, ExitStopLoss, true},
+		{"long gap above target", long, rules.Candle{Open: 1.1130, High: 1.1150, Low: 1.1120, Close: 1.1140}, 1.1130, ExitTakeProfit, true},
+		{"short stop", short, rules.Candle{Open: 1.1000, High: 1.1060, Low: 1.0950, Close: 1.1040}, 1.1050, ExitStopLoss, true},
+		{"short target", short, rules.Candle{Open: 1.1000, High: 1.1040, Low: 1.0890, Close: 1.0920}, 1.0900, ExitTakeProfit, true},
+		{"short both: stop first", short, rules.Candle{Open: 1.1000, High: 1.1060, Low: 1.0890, Close: 1.0920}, 1.1050, ExitStopLoss, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, reason, closed := resolveExit(tt.trade, tt.candle)
+			if closed != tt.closed || reason != tt.reason || math.Abs(price-tt.price) > 1e-9 {
+				t.Errorf("expected (%v, %s, %v), got (%v, %s, %v)", tt.price, tt.reason, tt.closed, price, reason, closed)
+			}
+		})
+	}
+}
+
+func TestOpenTrade_SizingMatchesRiskCalculator(t *testing.T) {
+	swingLow := 1.0800
+	signal := Bar{
+		Candle:   rules.Candle{TimestampUTC: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), Close: 1.0900},
+		Computed: services.WeeklyIndicators{LastSwingLowPrice: &swingLow},
+	}
+
+	trade, reason := openTrade(bullishSwingConfig(), signal, 1.0900, signal.Candle.TimestampUTC, 1, 10000)
+	if trade == nil {
+		t.Fatalf("expected trade, skipped: %s", reason)
+	}
+
+	// SL = swing low - 5 pips, TP = 2R
+	if math.Abs(trade.StopLoss-1.0795) > 1e-9 || math.Abs(trade.TakeProfit-1.1110) > 1e-9 {
+		t.Errorf("unexpected levels SL %v TP %v", trade.StopLoss, trade.TakeProfit)
+	}
+
+	riskAmount, _ := services.ComputeRiskAmount(10000, 1)
+	size, _ := services.ComputePositionSize(riskAmount, (1.0900-1.0795)/constants.PipValueEURUSD, domain.EURUSD, 1)
+	if trade.RiskAmount != riskAmount || math.Abs(trade.PositionSize-math.Round(size*100)/100) > 1e-9 {
+		t.Errorf("expected risk %v size %.2f, got %v %v", riskAmount, size, trade.RiskAmount, trade.PositionSize)
+	}
+
+	t.Run("entry below swing low is skipped", func(t *testing.T) {
+		if trade, reason := openTrade(bullishSwingConfig(), signal, 1.0790, signal.Candle.TimestampUTC, 1, 10000); trade != nil || reason != "invalid_geometry" {
+			t.Errorf("expected invalid_geometry skip, got %+v %q", trade, reason)
+		}
+	})
+
+	t.Run("no confirmed swing is skipped", func(t *testing.T) {
+		noSwing := signal
+		noSwing.Computed.LastSwingLowPrice = nil
+		if trade, reason := openTrade(bullishSwingConfig(), noSwing, 1.0900, signal.Candle.TimestampUTC, 1, 10000); trade != nil || reason != "no_stop_level" {
+			t.Errorf("expected no_stop_level skip, got %+v %q", trade, reason)
+		}
+	})
+}
+
+func TestRun_EURUSD(t *testing.T) {
+	candles := loadEURUSDWeekly(t)
+
+	configs := map[string]Config{
+		"bullish swing 2R": bullishSwingConfig(),
+		"bearish atr 3R signal close": {
+			RuleCode:       rules.W1TrendBearish,
+			Bias:           "short",
+			Entry:          EntrySignalClose,
+			Stop:           StopTemplate{Kind: StopATR, ATRMultiple: 1.5},
+			Target:         TargetTemplate{Kind: TargetFixedR, R: 3},
+			InitialBalance: 10000,
+			RiskPct:        1,
+		},
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			result, err := Run(candles, cfg)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if len(result.Trades) == 0 {
+				t.Fatal("expected trades over 2015-2025")
+			}
+
+			balance := cfg.InitialBalance
+			var lastExit time.Time
+			for i, tr := range result.Trades {
+				if !tr.EntryTime.After(lastExit) && i > 0 && cfg.Entry == EntryNextOpen {
+					t.Errorf("trade %d: entry %s overlaps previous trade exiting %s", i, tr.EntryTime, lastExit)
+				}
+				if tr.EntryTime.Before(tr.SignalTime) || tr.ExitTime.Before(tr.EntryTime) {
+					t.Errorf("trade %d: times out of order", i)
+				}
+				if tr.ExitReason == ExitStopLoss && tr.RMultiple > 0 {
+					t.Errorf("trade %d: stop loss with positive R %v", i, tr.RMultiple)
+				}
+				if tr.ExitReason == ExitTakeProfit && tr.RMultiple < cfg.Target.R-1e-6 {
+					t.Errorf("trade %d: take profit below %vR: %v", i, cfg.Target.R, tr.RMultiple)
+				}
+				balance += tr.PnLMoney
+				if math.Abs(balance-tr.BalanceAfter) > 1e-6 {
+					t.Errorf("trade %d: balance chain broken", i)
+				}
+				lastExit = tr.ExitTime
+			}
+
+			if result.Stats.Trades != len(result.Trades) || result.Stats.Wins+result.Stats.Losses > result.Stats.Trades {
+				t.Errorf("inconsistent stats %+v", result.Stats)
+			}
+			if math.Abs(result.Stats.FinalBalance-balance) > 1e-6 {
+				t.Errorf("final balance %v, expected %v", result.Stats.FinalBalance, balance)
+			}
+		})
+	}
+}
+
+// A trade closed before the end of a shorter history must be identical:
+// later candles cannot change earlier decisions
+func TestRun_NoLookahead(t *testing.T) {
+	candles := loadEURUSDWeekly(t)
+	cfg := bullishSwingConfig()
+
+	full, err := Run(candles, cfg)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	for _, cut := range []int{260, 350, 450} {
+		partial, err := Run(candles[:cut], cfg)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+
+		last := candles[cut-1].TimestampUTC
+		for i, tr := range partial.Trades {
+			if tr.ExitReason == ExitEndOfData && tr.ExitTime.Equal(last) {
+				continue
+			}
+			if i >= len(full.Trades) || full.Trades[i] != tr {
+				t.Fatalf("cut %d: trade %d differs from the full run", cut, i)
+			}
+		}
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	trades := []Trade{
+		{PnLMoney: 200, RMultiple: 2},
+		{PnLMoney: -100, RMultiple: -1},
+		{PnLMoney: -100, RMultiple: -1},
+		{PnLMoney: 300, RMultiple: 3},
+	}
+
+	stats := ComputeStats(trades, 1000)
+	if stats.Wins != 2 || stats.Losses != 2 || stats.WinRate != 0.5 {
+		t.Errorf("unexpected counts %+v", stats)
+	}
+	if stats.NetPnL != 300 || stats.FinalBalance != 1300 || stats.AverageR != 0.75 {
+		t.Errorf("unexpected totals %+v", stats)
+	}
+	if stats.ProfitFactor != 2.5 || stats.MaxConsecutiveLosses != 2 {
+		t.Errorf("unexpected profit factor/streak %+v", stats)
+	}
+	// Peak 1200 -> trough 1000
+	if stats.MaxDrawdown != 200 || math.Abs(stats.MaxDrawdownPct-200.0/1200*100) > 1e-9 {
+		t.Errorf("unexpected drawdown %+v", stats)
+	}
+}
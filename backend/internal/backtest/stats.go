@@ -0,0 +1,67 @@
+package backtest
+
+// Stats summarises the trades of a run
+type Stats struct {
+	Signals              int     `json:"signals"` // PASS candles: traded + skipped
+	Skipped              int     `json:"skipped"`
+	Trades               int     `json:"trades"`
+	Wins                 int     `json:"wins"`
+	Losses               int     `json:"losses"`
+	WinRate              float64 `json:"win_rate"` // wins / trades, 0-1
+	NetPnL               float64 `json:"net_pnl"`
+	NetR                 float64 `json:"net_r"`
+	AverageR             float64 `json:"average_r"`        // expectancy per trade in R
+	ProfitFactor         float64 `json:"profit_factor"`    // gross profit / gross loss, 0 without losses
+	MaxDrawdown          float64 `json:"max_drawdown"`     // money, peak to trough of closed-trade equity
+	MaxDrawdownPct       float64 `json:"max_drawdown_pct"` // % of the peak
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"`
+	FinalBalance         float64 `json:"final_balance"`
+}
+
+// ComputeStats derives the summary statistics from a trade list (exit order)
+func ComputeStats(trades []Trade, initialBalance float64) Stats {
+	stats := Stats{Trades: len(trades), FinalBalance: initialBalance}
+
+	var grossProfit, grossLoss float64
+	peak := initialBalance
+	equity := initialBalance
+	streak := 0
+
+	for _, t := range trades {
+		stats.NetPnL += t.PnLMoney
+		stats.NetR += t.RMultiple
+
+		switch {
+		case t.PnLMoney > 0:
+			stats.Wins++
+			grossProfit += t.PnLMoney
+			streak = 0
+		case t.PnLMoney < 0:
+			stats.Losses++
+			grossLoss -= t.PnLMoney
+			streak++
+			if streak > stats.MaxConsecutiveLosses {
+				stats.MaxConsecutiveLosses = streak
+			}
+		}
+
+		equity += t.PnLMoney
+		if equity > peak {
+			peak = equity
+		}
+		if dd := peak - equity; dd > stats.MaxDrawdown {
+			stats.MaxDrawdown = dd
+			stats.MaxDrawdownPct = dd / peak * 100
+		}
+	}
+
+	stats.FinalBalance = equity
+	if stats.Trades > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Trades)
+		stats.AverageR = stats.NetR / float64(stats.Trades)
+	}
+	if grossLoss > 0 {
+		stats.ProfitFactor = grossProfit / grossLoss
+	}
+	return stats
+}
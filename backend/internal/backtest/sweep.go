@@ -0,0 +1,338 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+// Variant is one point of a parameter grid
+type Variant struct {
+	EMA            services.EMAPeriods `json:"ema"`
+	TouchProximity float64             `json:"touch_proximity"` // θ factor of touch_ema20
+	Stop           StopTemplate        `json:"stop"`
+	Target         TargetTemplate      `json:"target"`
+}
+
+// Name is a short human-readable label, unique within a grid
+func (v Variant) Name() string {
+	stop := string(v.Stop.Kind)
+	switch v.Stop.Kind {
+	case StopSwing:
+		stop = fmt.Sprintf("swing+%gp", v.Stop.BufferPips)
+	case StopFixedPips:
+		stop = fmt.Sprintf("%gp", v.Stop.Pips)
+	case StopATR:
+		stop = fmt.Sprintf("%gxATR", v.Stop.ATRMultiple)
+	}
+
+	target := string(v.Target.Kind)
+	switch v.Target.Kind {
+	case TargetFixedR:
+		target = fmt.Sprintf("%gR", v.Target.R)
+	case TargetFixedPips:
+		target = fmt.Sprintf("%gp", v.Target.Pips)
+	}
+
+	return fmt.Sprintf("ema %d/%d/%d θ%g %s %s", v.EMA.Fast, v.EMA.Trend, v.EMA.Slow, v.TouchProximity, stop, target)
+}
+
+// Grid lists the values to combine; every combination is one Variant
+type Grid struct {
+	EMAPeriods     []services.EMAPeriods
+	TouchProximity []float64
+	Stops          []StopTemplate
+	Targets        []TargetTemplate
+}
+
+// Variants returns the cartesian product of the grid.
+// EMA sets that are not strictly fast < trend < slow are left out.
+func (g Grid) Variants() []Variant {
+	var variants []Variant
+	for _, ema := range g.EMAPeriods {
+		if ema.Fast <= 0 || ema.Fast >= ema.Trend || ema.Trend >= ema.Slow {
+			continue
+		}
+		for _, theta := range g.TouchProximity {
+			for _, stop := range g.Stops {
+				for _, target := range g.Targets {
+					variants = append(variants, Variant{EMA: ema, TouchProximity: theta, Stop: stop, Target: target})
+				}
+			}
+		}
+	}
+	return variants
+}
+
+// Split is one walk-forward fold: in-sample [InStart, OutStart),
+// out-of-sample [OutStart, OutEnd)
+type Split struct {
+	InStart  time.Time `json:"in_start"`
+	OutStart time.Time `json:"out_start"`
+	OutEnd   time.Time `json:"out_end"`
+}
+
+// WalkForwardSplits rolls an in-sample window of inYears followed by an
+// out-of-sample window of outYears across [first, last], stepping by outYears.
+// Out-of-sample windows never overlap and always follow their in-sample window.
+func WalkForwardSplits(first, last time.Time, inYears, outYears int) []Split {
+	if inYears <= 0 || outYears <= 0 {
+		return nil
+	}
+
+	var splits []Split
+	for start := first; ; start = start.AddDate(outYears, 0, 0) {
+		outStart := start.AddDate(inYears, 0, 0)
+		if outStart.After(last) {
+			break
+		}
+		splits = append(splits, Split{
+			InStart:  start,
+			OutStart: outStart,
+			OutEnd:   outStart.AddDate(outYears, 0, 0),
+		})
+	}
+	return splits
+}
+
+// FoldResult is a variant's performance on one split
+type FoldResult struct {
+	Split       Split `json:"split"`
+	InSample    Stats `json:"in_sample"`
+	OutOfSample Stats `json:"out_of_sample"`
+
+	outTrades []Trade
+}
+
+// VariantResult is a variant's performance over every split
+type VariantResult struct {
+	Rank             int          `json:"rank"`
+	Name             string       `json:"name"`
+	Variant          Variant      `json:"variant"`
+	Folds            []FoldResult `json:"folds"`
+	InSampleAverageR float64      `json:"in_sample_average_r"` // mean of the folds' in-sample expectancy
+	OutOfSample      Stats        `json:"out_of_sample"`       // every out-of-sample trade combined
+	// Out-of-sample minus in-sample expectancy: strongly negative = overfit
+	Degradation float64 `json:"degradation"`
+}
+
+// FoldSelection is the walk-forward choice on one split: the variant with the
+// best in-sample expectancy, and how it then did out of sample
+type FoldSelection struct {
+	Split       Split  `json:"split"`
+	Name        string `json:"name"`
+	InSample    Stats  `json:"in_sample"`
+	OutOfSample Stats  `json:"out_of_sample"`
+}
+
+// SweepResult holds the ranked variants and the walk-forward selection
+type SweepResult struct {
+	Variants         []VariantResult `json:"variants"` // best out-of-sample expectancy first
+	WalkForward      []FoldSelection `json:"walk_forward"`
+	WalkForwardStats Stats           `json:"walk_forward_stats"` // selected variants' out-of-sample trades
+}
+
+// Sweep simulates every variant of grid on every split, in parallel.
+// base supplies the rule, bias, entry template, balance and risk; each variant
+// overrides EMA periods, touch proximity, stop and target. workers <= 0 uses
+// one goroutine per CPU.
+func Sweep(candles []services.Candle, base Config, grid Grid, splits []Split, workers int) (*SweepResult, error) {
+	variants := grid.Variants()
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("grid has no valid variant")
+	}
+	if len(splits) == 0 {
+		return nil, fmt.Errorf("no walk-forward split")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	// One replay per EMA set, shared read-only by the workers
+	replays := make(map[services.EMAPeriods][]Bar)
+	for _, v := range variants {
+		if _, ok := replays[v.EMA]; !ok {
+			replays[v.EMA] = ReplayWith(candles, v.EMA)
+		}
+	}
+
+	results := make([]VariantResult, len(variants))
+	errs := make([]error, len(variants))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = runVariant(replays[variants[i].EMA], base, variants[i], splits)
+			}
+		}()
+	}
+	for i := range variants {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("variant %s: %w", variants[i].Name(), err)
+		}
+	}
+
+	sweep := &SweepResult{Variants: results}
+	sweep.selectWalkForward(splits, base.InitialBalance)
+	rankVariants(sweep.Variants)
+	return sweep, nil
+}
+
+func runVariant(bars []Bar, base Config, v Variant, splits []Split) (VariantResult, error) {
+	cfg := base
+	cfg.Stop = v.Stop
+	cfg.Target = v.Target
+	cfg.Conditions = map[rules.ConditionCode]rules.ConditionFunc{
+		rules.TouchEMA20: rules.TouchEMA20Within(v.TouchProximity),
+	}
+	if err := cfg.Validate(); err != nil {
+		return VariantResult{}, err
+	}
+
+	result := VariantResult{Name: v.Name(), Variant: v}
+
+	var outTrades []Trade
+	var outSignals, outSkipped int
+	var inAvgR float64
+	for _, split := range splits {
+		in, err := Simulate(barsBetween(bars, split.InStart, split.OutStart), cfg)
+		if err != nil {
+			return VariantResult{}, err
+		}
+		out, err := Simulate(barsBetween(bars, split.OutStart, split.OutEnd), cfg)
+		if err != nil {
+			return VariantResult{}, err
+		}
+
+		result.Folds = append(result.Folds, FoldResult{
+			Split:       split,
+			InSample:    in.Stats,
+			OutOfSample: out.Stats,
+			outTrades:   out.Trades,
+		})
+		inAvgR += in.Stats.AverageR
+		outTrades = append(outTrades, out.Trades...)
+		outSignals += out.Stats.Signals
+		outSkipped += out.Stats.Skipped
+	}
+
+	result.InSampleAverageR = inAvgR / float64(len(splits))
+	result.OutOfSample = ComputeStats(outTrades, base.InitialBalance)
+	result.OutOfSample.Signals = outSignals
+	result.OutOfSample.Skipped = outSkipped
+	result.Degradation = result.OutOfSample.AverageR - result.InSampleAverageR
+	return result, nil
+}
+
+// barsBetween returns the bars with from <= timestamp < to.
+// Their indicators were computed from the full history before them.
+func barsBetween(bars []Bar, from, to time.Time) []Bar {
+	lo := sort.Search(len(bars), func(i int) bool { return !bars[i].Candle.TimestampUTC.Before(from) })
+	hi := sort.Search(len(bars), func(i int) bool { return !bars[i].Candle.TimestampUTC.Before(to) })
+	return bars[lo:hi]
+}
+
+// selectWalkForward picks, per split, the variant with the best in-sample
+// expectancy (ties: more trades, then name) and records its out-of-sample result
+func (s *SweepResult) selectWalkForward(splits []Split, initialBalance float64) {
+	var trades []Trade
+	for f, split := range splits {
+		best := -1
+		for i, v := range s.Variants {
+			fold := v.Folds[f].InSample
+			if fold.Trades == 0 {
+				continue
+			}
+			if best < 0 || betterStats(fold, v.Name, s.Variants[best].Folds[f].InSample, s.Variants[best].Name) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		chosen := s.Variants[best]
+		s.WalkForward = append(s.WalkForward, FoldSelection{
+			Split:       split,
+			Name:        chosen.Name,
+			InSample:    chosen.Folds[f].InSample,
+			OutOfSample: chosen.Folds[f].OutOfSample,
+		})
+		trades = append(trades, chosen.Folds[f].outTrades...)
+	}
+	s.WalkForwardStats = ComputeStats(trades, initialBalance)
+}
+
+// rankVariants orders by out-of-sample expectancy; variants without
+// out-of-sample trades go last
+func rankVariants(variants []VariantResult) {
+	sort.SliceStable(variants, func(i, j int) bool {
+		a, b := variants[i], variants[j]
+		if (a.OutOfSample.Trades == 0) != (b.OutOfSample.Trades == 0) {
+			return a.OutOfSample.Trades > 0
+		}
+		return betterStats(a.OutOfSample, a.Name, b.OutOfSample, b.Name)
+	})
+	for i := range variants {
+		variants[i].Rank = i + 1
+	}
+}
+
+func betterStats(a Stats, aName string, b Stats, bName string) bool {
+	if a.AverageR != b.AverageR {
+		return a.AverageR > b.AverageR
+	}
+	if a.Trades != b.Trades {
+		return a.Trades > b.Trades
+	}
+	return aName < bName
+}
+
+// WriteTable writes the ranked variants as CSV
+func WriteTable(w io.Writer, s *SweepResult) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"rank", "variant", "ema_fast", "ema_trend", "ema_slow", "touch_proximity",
+		"is_avg_r", "oos_trades", "oos_win_rate", "oos_avg_r", "oos_net_r",
+		"oos_profit_factor", "oos_max_drawdown_pct", "degradation",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	for _, v := range s.Variants {
+		oos := v.OutOfSample
+		row := []string{
+			strconv.Itoa(v.Rank), v.Name,
+			strconv.Itoa(v.Variant.EMA.Fast), strconv.Itoa(v.Variant.EMA.Trend), strconv.Itoa(v.Variant.EMA.Slow),
+			f(v.Variant.TouchProximity),
+			f(v.InSampleAverageR), strconv.Itoa(oos.Trades), f(oos.WinRate), f(oos.AverageR), f(oos.NetR),
+			f(oos.ProfitFactor), f(oos.MaxDrawdownPct), f(v.Degradation),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,123 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+func testGrid() Grid {
+	return Grid{
+		EMAPeriods: []services.EMAPeriods{
+			services.DefaultEMAPeriods,
+			{Fast: 10, Trend: 40, Slow: 150},
+			{Fast: 50, Trend: 20, Slow: 200}, // invalid: fast > trend
+		},
+		TouchProximity: []float64{0.3, 0.5},
+		Stops:          []StopTemplate{{Kind: StopSwing, BufferPips: 5}, {Kind: StopATR, ATRMultiple: 1.5}},
+		Targets:        []TargetTemplate{{Kind: TargetFixedR, R: 2}},
+	}
+}
+
+func TestGrid_Variants(t *testing.T) {
+	variants := testGrid().Variants()
+	if len(variants) != 2*2*2*1 {
+		t.Fatalf("expected 8 variants, got %d", len(variants))
+	}
+
+	names := make(map[string]bool)
+	for _, v := range variants {
+		if v.EMA.Fast >= v.EMA.Trend {
+			t.Errorf("invalid EMA set kept: %+v", v.EMA)
+		}
+		if names[v.Name()] {
+			t.Errorf("duplicate variant name %s", v.Name())
+		}
+		names[v.Name()] = true
+	}
+}
+
+func TestWalkForwardSplits(t *testing.T) {
+	first := time.Date(2015, 1, 4, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2025, 6, 29, 0, 0, 0, 0, time.UTC)
+
+	splits := WalkForwardSplits(first, last, 4, 1)
+	if len(splits) != 7 { // out-of-sample 2019, 2020, ..., 2025
+		t.Fatalf("expected 7 splits, got %d", len(splits))
+	}
+	for i, s := range splits {
+		if !s.InStart.Before(s.OutStart) || !s.OutStart.Before(s.OutEnd) {
+			t.Errorf("split %d out of order: %+v", i, s)
+		}
+		if i > 0 && s.OutStart.Before(splits[i-1].OutEnd) {
+			t.Errorf("split %d: out-of-sample overlaps the previous one", i)
+		}
+	}
+
+	if WalkForwardSplits(first, last, 0, 1) != nil {
+		t.Error("expected no split without an in-sample window")
+	}
+}
+
+func TestSweep_EURUSD(t *testing.T) {
+	candles := loadEURUSDWeekly(t)
+	splits := WalkForwardSplits(candles[0].TimestampUTC, candles[len(candles)-1].TimestampUTC, 4, 1)
+
+	base := bullishSwingConfig()
+	base.RuleCode = rules.W1TouchEMA20 // uses θ, so proximity variants differ
+
+	parallel, err := Sweep(candles, base, testGrid(), splits, 4)
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	serial, err := Sweep(candles, base, testGrid(), splits, 1)
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if !reflect.DeepEqual(parallel, serial) {
+		t.Fatal("parallel sweep differs from serial sweep")
+	}
+
+	if len(parallel.Variants) != 8 {
+		t.Fatalf("expected 8 variants, got %d", len(parallel.Variants))
+	}
+	for i, v := range parallel.Variants {
+		if v.Rank != i+1 || len(v.Folds) != len(splits) {
+			t.Errorf("variant %s: rank %d, %d folds", v.Name, v.Rank, len(v.Folds))
+		}
+		if i > 0 {
+			prev := parallel.Variants[i-1].OutOfSample
+			if v.OutOfSample.Trades > 0 && prev.Trades > 0 && v.OutOfSample.AverageR > prev.AverageR {
+				t.Errorf("rank %d: expectancy %v above rank %d's %v", v.Rank, v.OutOfSample.AverageR, i, prev.AverageR)
+			}
+		}
+		for _, fold := range v.Folds {
+			for _, tr := range fold.outTrades {
+				if tr.SignalTime.Before(fold.Split.OutStart) || !tr.SignalTime.Before(fold.Split.OutEnd) {
+					t.Errorf("%s: out-of-sample trade signalled %s outside %+v", v.Name, tr.SignalTime, fold.Split)
+				}
+			}
+		}
+	}
+
+	if len(parallel.WalkForward) == 0 {
+		t.Error("expected walk-forward selections")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, parallel); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read table: %v", err)
+	}
+	if len(rows) != 1+len(parallel.Variants) || rows[1][0] != "1" {
+		t.Errorf("unexpected table: %d rows, first %v", len(rows), rows[1])
+	}
+}
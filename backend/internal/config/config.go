@@ -6,6 +6,7 @@ import (
 	"strconv"
 	
 	"github.com/joho/godotenv"
+	"set-and-trend/backend/internal/domain"
 )
 
 type Config struct {
@@ -16,6 +17,9 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 	Port       int
+	RulesDir   string // Directory with declarative rule definitions
+	// Level assumed hit first when a candle spans both SL and TP
+	SameCandleRule domain.SameCandleRule
 }
 
 func Load() (*Config, error) {
@@ -24,6 +28,19 @@ func Load() (*Config, error) {
 	}
 	
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
+
+	rulesDir := os.Getenv("RULES_DIR")
+	if rulesDir == "" {
+		rulesDir = "rules"
+	}
+
+	sameCandleRule := domain.SameCandleRule(os.Getenv("SAME_CANDLE_RULE"))
+	if sameCandleRule == "" {
+		sameCandleRule = domain.SameCandleSLFirst
+	}
+	if !sameCandleRule.IsValid() {
+		return nil, fmt.Errorf("invalid SAME_CANDLE_RULE %q (sl_first, tp_first or nearest_open)", sameCandleRule)
+	}
 	
 	return &Config{
 		DBHost:     os.Getenv("DB_HOST"),
@@ -33,5 +50,7 @@ func Load() (*Config, error) {
 		DBName:     os.Getenv("DB_NAME"),
 		DBSSLMode:  os.Getenv("DB_SSLMODE"),
 		Port:       port,
+		RulesDir:   rulesDir,
+		SameCandleRule: sameCandleRule,
 	}, nil
 }
@@ -1,7 +1,7 @@
 package constants
 
 const (
-	// EURUSD specifics
+	// EURUSD specifics (seed of the instruments catalogue, see domain.EURUSD)
 	SymbolEURUSD = "EURUSD"
 
 	// Timeframes
@@ -12,6 +12,8 @@ const (
 
 	// Broker conventions
 	PricePrecisionEURUSD = 5
+	// Stored prices are numeric(12,5) whatever the instrument
+	PriceStoragePrecision = 5
 
 	// Risk math guards
 	MinStopLossPips = 5
@@ -21,6 +23,20 @@ const (
 	ContractSizeEURUSD = 100000.0  // Standard lot = 100,000 units
 	// Risk/Reward guards
 	MinimumRR = 1.5
+	// EMA touch proximity: θ = 0.3 × candle range (FIXED)
+	EMATouchProximityFactor = 0.3
+	// Swing definition: 2-bar lookback/lookforward (FIXED)
+	// A swing at t is only confirmed at t + SwingLookbackBars
+	SwingLookbackBars = 2
+	// Candlestick pattern defaults (fractions of the candle range / previous body)
+	PinBarMinWickRatio    = 0.6 // rejection wick >= 60% of range
+	PinBarMaxBodyRatio    = 0.3 // body <= 30% of range
+	DojiMaxBodyRatio      = 0.1 // body <= 10% of range
+	EngulfingMinBodyRatio = 1.0 // body >= previous body
 	// Entry execution tolerances (pips)
-	MaxEntrySlippagePips = 20.0 // Max 20 pips slippage allowed
+	MaxEntrySlippagePips = 20.0 // Fills further than 20 pips from plan are logged, not refused
+	MaxEntryDeviationPct = 10.0 // Fills more than 10% from plan are refused as invalid prices
+	// Scale-out plan adherence: an exit follows its leg within these tolerances
+	ScaleOutPctTolerance = 1.0 // percentage points of the position
+	ScaleOutRTolerance   = 0.1 // R-multiple
 )
@@ -16,6 +16,7 @@ import (
 const createCandle = `-- name: CreateCandle :one
 INSERT INTO candles_weekly (
     id,
+    symbol,
     timestamp_utc,
     open,
     high,
@@ -23,13 +24,14 @@ INSERT INTO candles_weekly (
     close,
     volume
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, timestamp_utc, open, high, low, close, volume, created_at
+RETURNING id, timestamp_utc, open, high, low, close, volume, created_at, symbol
 `
 
 type CreateCandleParams struct {
 	ID           uuid.UUID          `json:"id"`
+	Symbol       string             `json:"symbol"`
 	TimestampUtc pgtype.Timestamptz `json:"timestamp_utc"`
 	Open         decimal.Decimal    `json:"open"`
 	High         decimal.Decimal    `json:"high"`
@@ -41,6 +43,7 @@ type CreateCandleParams struct {
 func (q *Queries) CreateCandle(ctx context.Context, arg CreateCandleParams) (CandlesWeekly, error) {
 	row := q.db.QueryRow(ctx, createCandle,
 		arg.ID,
+		arg.Symbol,
 		arg.TimestampUtc,
 		arg.Open,
 		arg.High,
@@ -58,13 +61,14 @@ func (q *Queries) CreateCandle(ctx context.Context, arg CreateCandleParams) (Can
 		&i.Close,
 		&i.Volume,
 		&i.CreatedAt,
+		&i.Symbol,
 	)
 	return i, err
 }
 
 const getAllCandlesOrdered = `-- name: GetAllCandlesOrdered :many
-SELECT id, timestamp_utc, open, high, low, close, volume, created_at FROM candles_weekly 
-ORDER BY timestamp_utc ASC
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly 
+ORDER BY symbol ASC, timestamp_utc ASC
 `
 
 func (q *Queries) GetAllCandlesOrdered(ctx context.Context) ([]CandlesWeekly, error) {
@@ -85,6 +89,7 @@ func (q *Queries) GetAllCandlesOrdered(ctx context.Context) ([]CandlesWeekly, er
 			&i.Close,
 			&i.Volume,
 			&i.CreatedAt,
+			&i.Symbol,
 		); err != nil {
 			return nil, err
 		}
@@ -97,7 +102,7 @@ func (q *Queries) GetAllCandlesOrdered(ctx context.Context) ([]CandlesWeekly, er
 }
 
 const getCandleByID = `-- name: GetCandleByID :one
-SELECT id, timestamp_utc, open, high, low, close, volume, created_at FROM candles_weekly 
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly 
 WHERE id = $1
 `
 
@@ -113,17 +118,23 @@ func (q *Queries) GetCandleByID(ctx context.Context, id uuid.UUID) (CandlesWeekl
 		&i.Close,
 		&i.Volume,
 		&i.CreatedAt,
+		&i.Symbol,
 	)
 	return i, err
 }
 
 const getCandleByTimestamp = `-- name: GetCandleByTimestamp :one
-SELECT id, timestamp_utc, open, high, low, close, volume, created_at FROM candles_weekly 
-WHERE timestamp_utc = $1
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly 
+WHERE symbol = $1 AND timestamp_utc = $2
 `
 
-func (q *Queries) GetCandleByTimestamp(ctx context.Context, timestampUtc pgtype.Timestamptz) (CandlesWeekly, error) {
-	row := q.db.QueryRow(ctx, getCandleByTimestamp, timestampUtc)
+type GetCandleByTimestampParams struct {
+	Symbol       string             `json:"symbol"`
+	TimestampUtc pgtype.Timestamptz `json:"timestamp_utc"`
+}
+
+func (q *Queries) GetCandleByTimestamp(ctx context.Context, arg GetCandleByTimestampParams) (CandlesWeekly, error) {
+	row := q.db.QueryRow(ctx, getCandleByTimestamp, arg.Symbol, arg.TimestampUtc)
 	var i CandlesWeekly
 	err := row.Scan(
 		&i.ID,
@@ -134,23 +145,61 @@ func (q *Queries) GetCandleByTimestamp(ctx context.Context, timestampUtc pgtype.
 		&i.Close,
 		&i.Volume,
 		&i.CreatedAt,
+		&i.Symbol,
 	)
 	return i, err
 }
 
+const getCandlesBySymbol = `-- name: GetCandlesBySymbol :many
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly
+WHERE symbol = $1
+ORDER BY timestamp_utc ASC
+`
+
+func (q *Queries) GetCandlesBySymbol(ctx context.Context, symbol string) ([]CandlesWeekly, error) {
+	rows, err := q.db.Query(ctx, getCandlesBySymbol, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CandlesWeekly
+	for rows.Next() {
+		var i CandlesWeekly
+		if err := rows.Scan(
+			&i.ID,
+			&i.TimestampUtc,
+			&i.Open,
+			&i.High,
+			&i.Low,
+			&i.Close,
+			&i.Volume,
+			&i.CreatedAt,
+			&i.Symbol,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getCandlesInRange = `-- name: GetCandlesInRange :many
-SELECT id, timestamp_utc, open, high, low, close, volume, created_at FROM candles_weekly 
-WHERE timestamp_utc BETWEEN $1 AND $2
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly 
+WHERE symbol = $1 AND timestamp_utc BETWEEN $2 AND $3
 ORDER BY timestamp_utc ASC
 `
 
 type GetCandlesInRangeParams struct {
+	Symbol         string             `json:"symbol"`
 	TimestampUtc   pgtype.Timestamptz `json:"timestamp_utc"`
 	TimestampUtc_2 pgtype.Timestamptz `json:"timestamp_utc_2"`
 }
 
 func (q *Queries) GetCandlesInRange(ctx context.Context, arg GetCandlesInRangeParams) ([]CandlesWeekly, error) {
-	rows, err := q.db.Query(ctx, getCandlesInRange, arg.TimestampUtc, arg.TimestampUtc_2)
+	rows, err := q.db.Query(ctx, getCandlesInRange, arg.Symbol, arg.TimestampUtc, arg.TimestampUtc_2)
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +216,7 @@ func (q *Queries) GetCandlesInRange(ctx context.Context, arg GetCandlesInRangePa
 			&i.Close,
 			&i.Volume,
 			&i.CreatedAt,
+			&i.Symbol,
 		); err != nil {
 			return nil, err
 		}
@@ -178,14 +228,21 @@ func (q *Queries) GetCandlesInRange(ctx context.Context, arg GetCandlesInRangePa
 	return items, nil
 }
 
-const getLatestCandles = `-- name: GetLatestCandles :many
-SELECT id, timestamp_utc, open, high, low, close, volume, created_at FROM candles_weekly 
+const getCandlesUpTo = `-- name: GetCandlesUpTo :many
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly
+WHERE symbol = $1 AND timestamp_utc <= $2
 ORDER BY timestamp_utc DESC
-LIMIT $1
+LIMIT $3
 `
 
-func (q *Queries) GetLatestCandles(ctx context.Context, limit int32) ([]CandlesWeekly, error) {
-	rows, err := q.db.Query(ctx, getLatestCandles, limit)
+type GetCandlesUpToParams struct {
+	Symbol       string             `json:"symbol"`
+	TimestampUtc pgtype.Timestamptz `json:"timestamp_utc"`
+	Limit        int32              `json:"limit"`
+}
+
+func (q *Queries) GetCandlesUpTo(ctx context.Context, arg GetCandlesUpToParams) ([]CandlesWeekly, error) {
+	rows, err := q.db.Query(ctx, getCandlesUpTo, arg.Symbol, arg.TimestampUtc, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -202,6 +259,7 @@ func (q *Queries) GetLatestCandles(ctx context.Context, limit int32) ([]CandlesW
 			&i.Close,
 			&i.Volume,
 			&i.CreatedAt,
+			&i.Symbol,
 		); err != nil {
 			return nil, err
 		}
@@ -213,28 +271,44 @@ func (q *Queries) GetLatestCandles(ctx context.Context, limit int32) ([]CandlesW
 	return items, nil
 }
 
-const updateIndicatorEMAs = `-- name: UpdateIndicatorEMAs :exec
-UPDATE indicators_weekly 
-SET 
-    ema20 = $2,
-    ema50 = $3,
-    ema200 = $4
-WHERE id = $1
+const getLatestCandles = `-- name: GetLatestCandles :many
+SELECT id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM candles_weekly 
+WHERE symbol = $1
+ORDER BY timestamp_utc DESC
+LIMIT $2
 `
 
-type UpdateIndicatorEMAsParams struct {
-	ID     uuid.UUID       `json:"id"`
-	Ema20  decimal.Decimal `json:"ema20"`
-	Ema50  decimal.Decimal `json:"ema50"`
-	Ema200 decimal.Decimal `json:"ema200"`
+type GetLatestCandlesParams struct {
+	Symbol string `json:"symbol"`
+	Limit  int32  `json:"limit"`
 }
 
-func (q *Queries) UpdateIndicatorEMAs(ctx context.Context, arg UpdateIndicatorEMAsParams) error {
-	_, err := q.db.Exec(ctx, updateIndicatorEMAs,
-		arg.ID,
-		arg.Ema20,
-		arg.Ema50,
-		arg.Ema200,
-	)
-	return err
+func (q *Queries) GetLatestCandles(ctx context.Context, arg GetLatestCandlesParams) ([]CandlesWeekly, error) {
+	rows, err := q.db.Query(ctx, getLatestCandles, arg.Symbol, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CandlesWeekly
+	for rows.Next() {
+		var i CandlesWeekly
+		if err := rows.Scan(
+			&i.ID,
+			&i.TimestampUtc,
+			&i.Open,
+			&i.High,
+			&i.Low,
+			&i.Close,
+			&i.Volume,
+			&i.CreatedAt,
+			&i.Symbol,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
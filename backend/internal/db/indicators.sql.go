@@ -36,16 +36,16 @@ RETURNING id, candle_id, ema20, ema50, ema200, range_size, body_size, upper_wick
 type CreateIndicatorParams struct {
 	ID                 uuid.UUID       `json:"id"`
 	CandleID           uuid.UUID       `json:"candle_id"`
-	Ema20              decimal.Decimal `json:"ema20"`
-	Ema50              decimal.Decimal `json:"ema50"`
-	Ema200             decimal.Decimal `json:"ema200"`
+	Ema20              NullDecimal     `json:"ema20"`
+	Ema50              NullDecimal     `json:"ema50"`
+	Ema200             NullDecimal     `json:"ema200"`
 	RangeSize          decimal.Decimal `json:"range_size"`
 	BodySize           decimal.Decimal `json:"body_size"`
 	UpperWick          decimal.Decimal `json:"upper_wick"`
 	LowerWick          decimal.Decimal `json:"lower_wick"`
 	MidPrice           decimal.Decimal `json:"mid_price"`
-	LastSwingHighPrice decimal.Decimal `json:"last_swing_high_price"`
-	LastSwingLowPrice  decimal.Decimal `json:"last_swing_low_price"`
+	LastSwingHighPrice NullDecimal     `json:"last_swing_high_price"`
+	LastSwingLowPrice  NullDecimal     `json:"last_swing_low_price"`
 }
 
 func (q *Queries) CreateIndicator(ctx context.Context, arg CreateIndicatorParams) (IndicatorsWeekly, error) {
@@ -108,6 +108,37 @@ func (q *Queries) GetIndicatorByCandleID(ctx context.Context, candleID uuid.UUID
 	return i, err
 }
 
+const getIndicatorValuesByCandleID = `-- name: GetIndicatorValuesByCandleID :many
+SELECT name, value FROM indicator_values
+WHERE candle_id = $1
+ORDER BY name
+`
+
+type GetIndicatorValuesByCandleIDRow struct {
+	Name  string          `json:"name"`
+	Value decimal.Decimal `json:"value"`
+}
+
+func (q *Queries) GetIndicatorValuesByCandleID(ctx context.Context, candleID uuid.UUID) ([]GetIndicatorValuesByCandleIDRow, error) {
+	rows, err := q.db.Query(ctx, getIndicatorValuesByCandleID, candleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIndicatorValuesByCandleIDRow
+	for rows.Next() {
+		var i GetIndicatorValuesByCandleIDRow
+		if err := rows.Scan(&i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLatestIndicators = `-- name: GetLatestIndicators :many
 SELECT 
     i.id, i.candle_id, i.ema20, i.ema50, i.ema200, i.range_size, i.body_size, i.upper_wick, i.lower_wick, i.mid_price, i.last_swing_high_price, i.last_swing_low_price, i.computed_at,
@@ -118,23 +149,29 @@ SELECT
     c.close
 FROM indicators_weekly i
 JOIN candles_weekly c ON i.candle_id = c.id
+WHERE c.symbol = $1
 ORDER BY c.timestamp_utc DESC
-LIMIT $1
+LIMIT $2
 `
 
+type GetLatestIndicatorsParams struct {
+	Symbol string `json:"symbol"`
+	Limit  int32  `json:"limit"`
+}
+
 type GetLatestIndicatorsRow struct {
 	ID                 uuid.UUID          `json:"id"`
 	CandleID           uuid.UUID          `json:"candle_id"`
-	Ema20              decimal.Decimal    `json:"ema20"`
-	Ema50              decimal.Decimal    `json:"ema50"`
-	Ema200             decimal.Decimal    `json:"ema200"`
+	Ema20              NullDecimal        `json:"ema20"`
+	Ema50              NullDecimal        `json:"ema50"`
+	Ema200             NullDecimal        `json:"ema200"`
 	RangeSize          decimal.Decimal    `json:"range_size"`
 	BodySize           decimal.Decimal    `json:"body_size"`
 	UpperWick          decimal.Decimal    `json:"upper_wick"`
 	LowerWick          decimal.Decimal    `json:"lower_wick"`
 	MidPrice           decimal.Decimal    `json:"mid_price"`
-	LastSwingHighPrice decimal.Decimal    `json:"last_swing_high_price"`
-	LastSwingLowPrice  decimal.Decimal    `json:"last_swing_low_price"`
+	LastSwingHighPrice NullDecimal        `json:"last_swing_high_price"`
+	LastSwingLowPrice  NullDecimal        `json:"last_swing_low_price"`
 	ComputedAt         pgtype.Timestamptz `json:"computed_at"`
 	TimestampUtc       pgtype.Timestamptz `json:"timestamp_utc"`
 	Open               decimal.Decimal    `json:"open"`
@@ -143,8 +180,8 @@ type GetLatestIndicatorsRow struct {
 	Close              decimal.Decimal    `json:"close"`
 }
 
-func (q *Queries) GetLatestIndicators(ctx context.Context, limit int32) ([]GetLatestIndicatorsRow, error) {
-	rows, err := q.db.Query(ctx, getLatestIndicators, limit)
+func (q *Queries) GetLatestIndicators(ctx context.Context, arg GetLatestIndicatorsParams) ([]GetLatestIndicatorsRow, error) {
+	rows, err := q.db.Query(ctx, getLatestIndicators, arg.Symbol, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -183,26 +220,31 @@ func (q *Queries) GetLatestIndicators(ctx context.Context, limit int32) ([]GetLa
 }
 
 const getPreviousIndicatorByTimestamp = `-- name: GetPreviousIndicatorByTimestamp :one
-SELECT i.id, candle_id, ema20, ema50, ema200, range_size, body_size, upper_wick, lower_wick, mid_price, last_swing_high_price, last_swing_low_price, computed_at, c.id, timestamp_utc, open, high, low, close, volume, created_at FROM indicators_weekly i
+SELECT i.id, candle_id, ema20, ema50, ema200, range_size, body_size, upper_wick, lower_wick, mid_price, last_swing_high_price, last_swing_low_price, computed_at, c.id, timestamp_utc, open, high, low, close, volume, created_at, symbol FROM indicators_weekly i
 JOIN candles_weekly c ON i.candle_id = c.id
-WHERE c.timestamp_utc < $1
+WHERE c.symbol = $1 AND c.timestamp_utc < $2
 ORDER BY c.timestamp_utc DESC
 LIMIT 1
 `
 
+type GetPreviousIndicatorByTimestampParams struct {
+	Symbol       string             `json:"symbol"`
+	TimestampUtc pgtype.Timestamptz `json:"timestamp_utc"`
+}
+
 type GetPreviousIndicatorByTimestampRow struct {
 	ID                 uuid.UUID          `json:"id"`
 	CandleID           uuid.UUID          `json:"candle_id"`
-	Ema20              decimal.Decimal    `json:"ema20"`
-	Ema50              decimal.Decimal    `json:"ema50"`
-	Ema200             decimal.Decimal    `json:"ema200"`
+	Ema20              NullDecimal        `json:"ema20"`
+	Ema50              NullDecimal        `json:"ema50"`
+	Ema200             NullDecimal        `json:"ema200"`
 	RangeSize          decimal.Decimal    `json:"range_size"`
 	BodySize           decimal.Decimal    `json:"body_size"`
 	UpperWick          decimal.Decimal    `json:"upper_wick"`
 	LowerWick          decimal.Decimal    `json:"lower_wick"`
 	MidPrice           decimal.Decimal    `json:"mid_price"`
-	LastSwingHighPrice decimal.Decimal    `json:"last_swing_high_price"`
-	LastSwingLowPrice  decimal.Decimal    `json:"last_swing_low_price"`
+	LastSwingHighPrice NullDecimal        `json:"last_swing_high_price"`
+	LastSwingLowPrice  NullDecimal        `json:"last_swing_low_price"`
 	ComputedAt         pgtype.Timestamptz `json:"computed_at"`
 	ID_2               uuid.UUID          `json:"id_2"`
 	TimestampUtc       pgtype.Timestamptz `json:"timestamp_utc"`
@@ -212,10 +254,11 @@ type GetPreviousIndicatorByTimestampRow struct {
 	Close              decimal.Decimal    `json:"close"`
 	Volume             pgtype.Int8        `json:"volume"`
 	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	Symbol             string             `json:"symbol"`
 }
 
-func (q *Queries) GetPreviousIndicatorByTimestamp(ctx context.Context, timestampUtc pgtype.Timestamptz) (GetPreviousIndicatorByTimestampRow, error) {
-	row := q.db.QueryRow(ctx, getPreviousIndicatorByTimestamp, timestampUtc)
+func (q *Queries) GetPreviousIndicatorByTimestamp(ctx context.Context, arg GetPreviousIndicatorByTimestampParams) (GetPreviousIndicatorByTimestampRow, error) {
+	row := q.db.QueryRow(ctx, getPreviousIndicatorByTimestamp, arg.Symbol, arg.TimestampUtc)
 	var i GetPreviousIndicatorByTimestampRow
 	err := row.Scan(
 		&i.ID,
@@ -239,6 +282,112 @@ func (q *Queries) GetPreviousIndicatorByTimestamp(ctx context.Context, timestamp
 		&i.Close,
 		&i.Volume,
 		&i.CreatedAt,
+		&i.Symbol,
 	)
 	return i, err
 }
+
+const upsertIndicator = `-- name: UpsertIndicator :one
+INSERT INTO indicators_weekly (
+    id,
+    candle_id,
+    ema20,
+    ema50,
+    ema200,
+    range_size,
+    body_size,
+    upper_wick,
+    lower_wick,
+    mid_price,
+    last_swing_high_price,
+    last_swing_low_price
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (candle_id) DO UPDATE SET
+    ema20 = EXCLUDED.ema20,
+    ema50 = EXCLUDED.ema50,
+    ema200 = EXCLUDED.ema200,
+    range_size = EXCLUDED.range_size,
+    body_size = EXCLUDED.body_size,
+    upper_wick = EXCLUDED.upper_wick,
+    lower_wick = EXCLUDED.lower_wick,
+    mid_price = EXCLUDED.mid_price,
+    last_swing_high_price = EXCLUDED.last_swing_high_price,
+    last_swing_low_price = EXCLUDED.last_swing_low_price,
+    computed_at = NOW()
+RETURNING id, candle_id, ema20, ema50, ema200, range_size, body_size, upper_wick, lower_wick, mid_price, last_swing_high_price, last_swing_low_price, computed_at
+`
+
+type UpsertIndicatorParams struct {
+	ID                 uuid.UUID       `json:"id"`
+	CandleID           uuid.UUID       `json:"candle_id"`
+	Ema20              NullDecimal     `json:"ema20"`
+	Ema50              NullDecimal     `json:"ema50"`
+	Ema200             NullDecimal     `json:"ema200"`
+	RangeSize          decimal.Decimal `json:"range_size"`
+	BodySize           decimal.Decimal `json:"body_size"`
+	UpperWick          decimal.Decimal `json:"upper_wick"`
+	LowerWick          decimal.Decimal `json:"lower_wick"`
+	MidPrice           decimal.Decimal `json:"mid_price"`
+	LastSwingHighPrice NullDecimal     `json:"last_swing_high_price"`
+	LastSwingLowPrice  NullDecimal     `json:"last_swing_low_price"`
+}
+
+func (q *Queries) UpsertIndicator(ctx context.Context, arg UpsertIndicatorParams) (IndicatorsWeekly, error) {
+	row := q.db.QueryRow(ctx, upsertIndicator,
+		arg.ID,
+		arg.CandleID,
+		arg.Ema20,
+		arg.Ema50,
+		arg.Ema200,
+		arg.RangeSize,
+		arg.BodySize,
+		arg.UpperWick,
+		arg.LowerWick,
+		arg.MidPrice,
+		arg.LastSwingHighPrice,
+		arg.LastSwingLowPrice,
+	)
+	var i IndicatorsWeekly
+	err := row.Scan(
+		&i.ID,
+		&i.CandleID,
+		&i.Ema20,
+		&i.Ema50,
+		&i.Ema200,
+		&i.RangeSize,
+		&i.BodySize,
+		&i.UpperWick,
+		&i.LowerWick,
+		&i.MidPrice,
+		&i.LastSwingHighPrice,
+		&i.LastSwingLowPrice,
+		&i.ComputedAt,
+	)
+	return i, err
+}
+
+const upsertIndicatorValue = `-- name: UpsertIndicatorValue :exec
+INSERT INTO indicator_values (
+    candle_id,
+    name,
+    value
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (candle_id, name) DO UPDATE SET
+    value = EXCLUDED.value,
+    computed_at = NOW()
+`
+
+type UpsertIndicatorValueParams struct {
+	CandleID uuid.UUID       `json:"candle_id"`
+	Name     string          `json:"name"`
+	Value    decimal.Decimal `json:"value"`
+}
+
+func (q *Queries) UpsertIndicatorValue(ctx context.Context, arg UpsertIndicatorValueParams) error {
+	_, err := q.db.Exec(ctx, upsertIndicatorValue, arg.CandleID, arg.Name, arg.Value)
+	return err
+}
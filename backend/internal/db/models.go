@@ -145,11 +145,100 @@ func (ns NullExecutionEventType) Value() (driver.Value, error) {
 	return string(ns.ExecutionEventType), nil
 }
 
+type LedgerEntryType string
+
+const (
+	LedgerEntryTypeTradePnl   LedgerEntryType = "trade_pnl"
+	LedgerEntryTypeDeposit    LedgerEntryType = "deposit"
+	LedgerEntryTypeWithdrawal LedgerEntryType = "withdrawal"
+	LedgerEntryTypeFee        LedgerEntryType = "fee"
+	LedgerEntryTypeAdjustment LedgerEntryType = "adjustment"
+)
+
+func (e *LedgerEntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerEntryType(s)
+	case string:
+		*e = LedgerEntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerEntryType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerEntryType struct {
+	LedgerEntryType LedgerEntryType `json:"ledger_entry_type"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerEntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerEntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerEntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerEntryType), nil
+}
+
+type OrderType string
+
+const (
+	OrderTypeMarket OrderType = "market"
+	OrderTypeLimit  OrderType = "limit"
+	OrderTypeStop   OrderType = "stop"
+)
+
+func (e *OrderType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OrderType(s)
+	case string:
+		*e = OrderType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OrderType: %T", src)
+	}
+	return nil
+}
+
+type NullOrderType struct {
+	OrderType OrderType `json:"order_type"`
+	Valid     bool      `json:"valid"` // Valid is true if OrderType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOrderType) Scan(value interface{}) error {
+	if value == nil {
+		ns.OrderType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OrderType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOrderType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OrderType), nil
+}
+
 type RuleResultType string
 
 const (
-	RuleResultTypePASS RuleResultType = "PASS"
-	RuleResultTypeFAIL RuleResultType = "FAIL"
+	RuleResultTypePASS             RuleResultType = "PASS"
+	RuleResultTypeFAIL             RuleResultType = "FAIL"
+	RuleResultTypeINSUFFICIENTDATA RuleResultType = "INSUFFICIENT_DATA"
 )
 
 func (e *RuleResultType) Scan(src interface{}) error {
@@ -272,6 +361,50 @@ func (ns NullSessionType) Value() (driver.Value, error) {
 	return string(ns.SessionType), nil
 }
 
+type TradeAdjustmentType string
+
+const (
+	TradeAdjustmentTypeMoveSl       TradeAdjustmentType = "move_sl"
+	TradeAdjustmentTypeMoveTp       TradeAdjustmentType = "move_tp"
+	TradeAdjustmentTypeTrailToSwing TradeAdjustmentType = "trail_to_swing"
+	TradeAdjustmentTypeBreakEven    TradeAdjustmentType = "break_even"
+)
+
+func (e *TradeAdjustmentType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TradeAdjustmentType(s)
+	case string:
+		*e = TradeAdjustmentType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TradeAdjustmentType: %T", src)
+	}
+	return nil
+}
+
+type NullTradeAdjustmentType struct {
+	TradeAdjustmentType TradeAdjustmentType `json:"trade_adjustment_type"`
+	Valid               bool                `json:"valid"` // Valid is true if TradeAdjustmentType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTradeAdjustmentType) Scan(value interface{}) error {
+	if value == nil {
+		ns.TradeAdjustmentType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TradeAdjustmentType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTradeAdjustmentType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TradeAdjustmentType), nil
+}
+
 type TradeBias string
 
 const (
@@ -372,6 +505,17 @@ type Account struct {
 	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
 }
 
+type AccountLedger struct {
+	ID          uuid.UUID          `json:"id"`
+	AccountID   uuid.UUID          `json:"account_id"`
+	EntryType   LedgerEntryType    `json:"entry_type"`
+	Amount      decimal.Decimal    `json:"amount"`
+	TradeID     pgtype.UUID        `json:"trade_id"`
+	ExecutionID pgtype.UUID        `json:"execution_id"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type CandlesWeekly struct {
 	ID           uuid.UUID          `json:"id"`
 	TimestampUtc pgtype.Timestamptz `json:"timestamp_utc"`
@@ -381,24 +525,53 @@ type CandlesWeekly struct {
 	Close        decimal.Decimal    `json:"close"`
 	Volume       pgtype.Int8        `json:"volume"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	Symbol       string             `json:"symbol"`
+}
+
+type FxRate struct {
+	BaseCurrency  string             `json:"base_currency"`
+	QuoteCurrency string             `json:"quote_currency"`
+	RateDate      pgtype.Date        `json:"rate_date"`
+	Rate          decimal.Decimal    `json:"rate"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type IndicatorValue struct {
+	CandleID   uuid.UUID          `json:"candle_id"`
+	Name       string             `json:"name"`
+	Value      decimal.Decimal    `json:"value"`
+	ComputedAt pgtype.Timestamptz `json:"computed_at"`
 }
 
 type IndicatorsWeekly struct {
 	ID                 uuid.UUID          `json:"id"`
 	CandleID           uuid.UUID          `json:"candle_id"`
-	Ema20              decimal.Decimal    `json:"ema20"`
-	Ema50              decimal.Decimal    `json:"ema50"`
-	Ema200             decimal.Decimal    `json:"ema200"`
+	Ema20              NullDecimal        `json:"ema20"`
+	Ema50              NullDecimal        `json:"ema50"`
+	Ema200             NullDecimal        `json:"ema200"`
 	RangeSize          decimal.Decimal    `json:"range_size"`
 	BodySize           decimal.Decimal    `json:"body_size"`
 	UpperWick          decimal.Decimal    `json:"upper_wick"`
 	LowerWick          decimal.Decimal    `json:"lower_wick"`
 	MidPrice           decimal.Decimal    `json:"mid_price"`
-	LastSwingHighPrice decimal.Decimal    `json:"last_swing_high_price"`
-	LastSwingLowPrice  decimal.Decimal    `json:"last_swing_low_price"`
+	LastSwingHighPrice NullDecimal        `json:"last_swing_high_price"`
+	LastSwingLowPrice  NullDecimal        `json:"last_swing_low_price"`
 	ComputedAt         pgtype.Timestamptz `json:"computed_at"`
 }
 
+type Instrument struct {
+	Symbol         string             `json:"symbol"`
+	Description    string             `json:"description"`
+	PipSize        decimal.Decimal    `json:"pip_size"`
+	PricePrecision int16              `json:"price_precision"`
+	ContractSize   decimal.Decimal    `json:"contract_size"`
+	QuoteCurrency  string             `json:"quote_currency"`
+	MinLot         decimal.Decimal    `json:"min_lot"`
+	LotStep        decimal.Decimal    `json:"lot_step"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
 type Rule struct {
 	ID          uuid.UUID          `json:"id"`
 	Code        string             `json:"code"`
@@ -408,6 +581,18 @@ type Rule struct {
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
+// Structured explanation of a rule result: one row per condition with its boolean and input values.
+type RuleConditionResult struct {
+	ID               uuid.UUID          `json:"id"`
+	RuleResultID     uuid.UUID          `json:"rule_result_id"`
+	ConditionCode    string             `json:"condition_code"`
+	Position         int32              `json:"position"`
+	Passed           bool               `json:"passed"`
+	Inputs           []byte             `json:"inputs"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	InsufficientData bool               `json:"insufficient_data"`
+}
+
 type RuleResult struct {
 	ID              uuid.UUID          `json:"id"`
 	RuleID          uuid.UUID          `json:"rule_id"`
@@ -415,6 +600,32 @@ type RuleResult struct {
 	Result          RuleResultType     `json:"result"`
 	EvaluatedAt     pgtype.Timestamptz `json:"evaluated_at"`
 	ConfidenceScore decimal.Decimal    `json:"confidence_score"`
+	RuleVersion     int32              `json:"rule_version"`
+	DefinitionHash  pgtype.Text        `json:"definition_hash"`
+}
+
+// Frozen rule definitions. A (rule, version) hash never changes; new semantics require a new version.
+type RuleVersion struct {
+	ID             uuid.UUID          `json:"id"`
+	RuleID         uuid.UUID          `json:"rule_id"`
+	Version        int32              `json:"version"`
+	DefinitionHash string             `json:"definition_hash"`
+	Definition     []byte             `json:"definition"`
+	IsActive       bool               `json:"is_active"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	PromotedAt     pgtype.Timestamptz `json:"promoted_at"`
+}
+
+// Confirmed 2-bar swing highs/lows. swing_timestamp_utc = pivot candle, confirmed_at_utc = close of the candle that confirmed it (pivot + 2).
+type SwingPoint struct {
+	ID                uuid.UUID          `json:"id"`
+	CandleID          uuid.UUID          `json:"candle_id"`
+	ConfirmedCandleID uuid.UUID          `json:"confirmed_candle_id"`
+	SwingType         string             `json:"swing_type"`
+	Price             decimal.Decimal    `json:"price"`
+	SwingTimestampUtc pgtype.Timestamptz `json:"swing_timestamp_utc"`
+	ConfirmedAtUtc    pgtype.Timestamptz `json:"confirmed_at_utc"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
 }
 
 // Trade state derived from trade_executions and trade_intents.
@@ -455,6 +666,24 @@ type Trade struct {
 	DurationSeconds           pgtype.Int4        `json:"duration_seconds"`
 	Session                   NullSessionType    `json:"session"`
 	CreatedAt                 pgtype.Timestamptz `json:"created_at"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
+	OrderType                 OrderType          `json:"order_type"`
+	ExpiresAt                 pgtype.Timestamptz `json:"expires_at"`
+}
+
+// Append-only SL/TP moves of a trade. The levels in force at a time are the planned ones replayed with the adjustments up to it.
+type TradeAdjustment struct {
+	ID             uuid.UUID           `json:"id"`
+	TradeID        uuid.UUID           `json:"trade_id"`
+	AdjustmentType TradeAdjustmentType `json:"adjustment_type"`
+	PreviousPrice  decimal.Decimal     `json:"previous_price"`
+	NewPrice       decimal.Decimal     `json:"new_price"`
+	MarketPrice    decimal.Decimal     `json:"market_price"`
+	SwingPointID   pgtype.UUID         `json:"swing_point_id"`
+	Reason         pgtype.Text         `json:"reason"`
+	AdjustedAt     pgtype.Timestamptz  `json:"adjusted_at"`
+	CreatedAt      pgtype.Timestamptz  `json:"created_at"`
 }
 
 // Append-only execution event log.  Contains MARKET INTERACTIONS only.  State is computed via:  SELECT event_type FROM trade_executions WHERE trade_id = ?  ORDER BY executed_at
@@ -467,10 +696,12 @@ type TradeExecution struct {
 	ExecutedAt   pgtype.Timestamptz `json:"executed_at"`
 	Session      NullSessionType    `json:"session"`
 	Reason       pgtype.Text        `json:"reason"`
+	// Entry slippage vs planned entry in pips, signed: positive = adverse
 	SlippagePips decimal.Decimal    `json:"slippage_pips"`
 	Pnl          decimal.Decimal    `json:"pnl"`
 	PnlPips      decimal.Decimal    `json:"pnl_pips"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	Source       string             `json:"source"`
 }
 
 type TradeFeedback struct {
@@ -494,6 +725,15 @@ type TradeIntent struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
+// Planned exits of a trade, in leg order. Legs sum to 100% of the planned position; no rows = single exit.
+type TradeScaleOutLeg struct {
+	TradeID   uuid.UUID          `json:"trade_id"`
+	Leg       int16              `json:"leg"`
+	ClosePct  decimal.Decimal    `json:"close_pct"`
+	TargetR   NullDecimal        `json:"target_r"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type User struct {
 	ID        uuid.UUID          `json:"id"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
@@ -8,38 +8,48 @@ import (
 	"context"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
 type Querier interface {
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateCandle(ctx context.Context, arg CreateCandleParams) (CandlesWeekly, error)
 	CreateIndicator(ctx context.Context, arg CreateIndicatorParams) (IndicatorsWeekly, error)
-	CreateRuleResult(ctx context.Context, arg CreateRuleResultParams) error
+	CreateRuleResult(ctx context.Context, arg CreateRuleResultParams) (uuid.UUID, error)
 	CreateTrade(ctx context.Context, arg CreateTradeParams) (CreateTradeRow, error)
 	CreateTradeExecution(ctx context.Context, arg CreateTradeExecutionParams) (TradeExecution, error)
+	CreateTradeScaleOutLeg(ctx context.Context, arg CreateTradeScaleOutLegParams) error
 	CreateUser(ctx context.Context, id uuid.UUID) (User, error)
 	GetAccountByID(ctx context.Context, id uuid.UUID) (Account, error)
+	GetAccountLedgerBalance(ctx context.Context, accountID uuid.UUID) (decimal.Decimal, error)
+	// Trades that can count towards the daily risk cap: set up since the start
+	// of the trading day, or entered and not yet fully closed.
+	GetAccountRiskTrades(ctx context.Context, arg GetAccountRiskTradesParams) ([]GetAccountRiskTradesRow, error)
 	GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]Account, error)
 	GetAllCandlesOrdered(ctx context.Context) ([]CandlesWeekly, error)
 	GetCandleByID(ctx context.Context, id uuid.UUID) (CandlesWeekly, error)
-	GetCandleByTimestamp(ctx context.Context, timestampUtc pgtype.Timestamptz) (CandlesWeekly, error)
+	GetCandleByTimestamp(ctx context.Context, arg GetCandleByTimestampParams) (CandlesWeekly, error)
+	GetCandlesBySymbol(ctx context.Context, symbol string) ([]CandlesWeekly, error)
 	GetCandlesInRange(ctx context.Context, arg GetCandlesInRangeParams) ([]CandlesWeekly, error)
+	GetCandlesUpTo(ctx context.Context, arg GetCandlesUpToParams) ([]CandlesWeekly, error)
 	GetIndicatorByCandleID(ctx context.Context, candleID uuid.UUID) (IndicatorsWeekly, error)
-	GetLatestCandles(ctx context.Context, limit int32) ([]CandlesWeekly, error)
-	GetLatestIndicators(ctx context.Context, limit int32) ([]GetLatestIndicatorsRow, error)
-	GetPreviousIndicatorByTimestamp(ctx context.Context, timestampUtc pgtype.Timestamptz) (GetPreviousIndicatorByTimestampRow, error)
+	GetIndicatorValuesByCandleID(ctx context.Context, candleID uuid.UUID) ([]GetIndicatorValuesByCandleIDRow, error)
+	GetLatestCandles(ctx context.Context, arg GetLatestCandlesParams) ([]CandlesWeekly, error)
+	GetLatestIndicators(ctx context.Context, arg GetLatestIndicatorsParams) ([]GetLatestIndicatorsRow, error)
+	GetPreviousIndicatorByTimestamp(ctx context.Context, arg GetPreviousIndicatorByTimestampParams) (GetPreviousIndicatorByTimestampRow, error)
 	GetRuleResultsByCandleID(ctx context.Context, candleID uuid.UUID) ([]GetRuleResultsByCandleIDRow, error)
 	GetTradeByID(ctx context.Context, id uuid.UUID) (GetTradeByIDRow, error)
 	GetTradeExecutions(ctx context.Context, tradeID uuid.UUID) ([]TradeExecution, error)
+	GetTradeScaleOutLegs(ctx context.Context, tradeID uuid.UUID) ([]GetTradeScaleOutLegsRow, error)
 	GetTradesByAccountAndCandle(ctx context.Context, arg GetTradesByAccountAndCandleParams) ([]GetTradesByAccountAndCandleRow, error)
 	GetTradesByUserID(ctx context.Context, arg GetTradesByUserIDParams) ([]GetTradesByUserIDRow, error)
 	GetUser(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	TruncateRuleResults(ctx context.Context) error
-	UpdateIndicatorEMAs(ctx context.Context, arg UpdateIndicatorEMAsParams) error
 	UpdateTradeClosure(ctx context.Context, arg UpdateTradeClosureParams) error
 	UpdateTradeExecution(ctx context.Context, arg UpdateTradeExecutionParams) error
+	UpsertIndicator(ctx context.Context, arg UpsertIndicatorParams) (IndicatorsWeekly, error)
+	UpsertIndicatorValue(ctx context.Context, arg UpsertIndicatorValueParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,4 @@
+-- name: GetAccountLedgerBalance :one
+SELECT COALESCE(SUM(amount), 0)::numeric(15,2) AS balance
+FROM account_ledger
+WHERE account_id = $1;
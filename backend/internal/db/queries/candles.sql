@@ -1,6 +1,7 @@
 -- name: CreateCandle :one
 INSERT INTO candles_weekly (
     id,
+    symbol,
     timestamp_utc,
     open,
     high,
@@ -8,23 +9,30 @@ INSERT INTO candles_weekly (
     close,
     volume
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
 -- name: GetCandleByTimestamp :one
 SELECT * FROM candles_weekly 
-WHERE timestamp_utc = $1;
+WHERE symbol = $1 AND timestamp_utc = $2;
 
 -- name: GetCandlesInRange :many
 SELECT * FROM candles_weekly 
-WHERE timestamp_utc BETWEEN $1 AND $2
+WHERE symbol = $1 AND timestamp_utc BETWEEN $2 AND $3
 ORDER BY timestamp_utc ASC;
 
+-- name: GetCandlesUpTo :many
+SELECT * FROM candles_weekly
+WHERE symbol = $1 AND timestamp_utc <= $2
+ORDER BY timestamp_utc DESC
+LIMIT $3;
+
 -- name: GetLatestCandles :many
 SELECT * FROM candles_weekly 
+WHERE symbol = $1
 ORDER BY timestamp_utc DESC
-LIMIT $1;
+LIMIT $2;
 
 -- name: GetCandleByID :one
 SELECT * FROM candles_weekly 
@@ -32,13 +40,9 @@ WHERE id = $1;
 
 -- name: GetAllCandlesOrdered :many
 SELECT * FROM candles_weekly 
-ORDER BY timestamp_utc ASC;
-
--- name: UpdateIndicatorEMAs :exec
-UPDATE indicators_weekly 
-SET 
-    ema20 = $2,
-    ema50 = $3,
-    ema200 = $4
-WHERE id = $1;
+ORDER BY symbol ASC, timestamp_utc ASC;
 
+-- name: GetCandlesBySymbol :many
+SELECT * FROM candles_weekly
+WHERE symbol = $1
+ORDER BY timestamp_utc ASC;
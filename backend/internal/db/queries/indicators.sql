@@ -21,6 +21,11 @@ RETURNING *;
 SELECT * FROM indicators_weekly 
 WHERE candle_id = $1;
 
+-- name: GetIndicatorValuesByCandleID :many
+SELECT name, value FROM indicator_values
+WHERE candle_id = $1
+ORDER BY name;
+
 -- name: GetLatestIndicators :many
 SELECT 
     i.*,
@@ -31,12 +36,56 @@ SELECT
     c.close
 FROM indicators_weekly i
 JOIN candles_weekly c ON i.candle_id = c.id
+WHERE c.symbol = $1
 ORDER BY c.timestamp_utc DESC
-LIMIT $1;
+LIMIT $2;
 
 -- name: GetPreviousIndicatorByTimestamp :one
 SELECT * FROM indicators_weekly i
 JOIN candles_weekly c ON i.candle_id = c.id
-WHERE c.timestamp_utc < $1
+WHERE c.symbol = $1 AND c.timestamp_utc < $2
 ORDER BY c.timestamp_utc DESC
 LIMIT 1;
+
+-- name: UpsertIndicator :one
+INSERT INTO indicators_weekly (
+    id,
+    candle_id,
+    ema20,
+    ema50,
+    ema200,
+    range_size,
+    body_size,
+    upper_wick,
+    lower_wick,
+    mid_price,
+    last_swing_high_price,
+    last_swing_low_price
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (candle_id) DO UPDATE SET
+    ema20 = EXCLUDED.ema20,
+    ema50 = EXCLUDED.ema50,
+    ema200 = EXCLUDED.ema200,
+    range_size = EXCLUDED.range_size,
+    body_size = EXCLUDED.body_size,
+    upper_wick = EXCLUDED.upper_wick,
+    lower_wick = EXCLUDED.lower_wick,
+    mid_price = EXCLUDED.mid_price,
+    last_swing_high_price = EXCLUDED.last_swing_high_price,
+    last_swing_low_price = EXCLUDED.last_swing_low_price,
+    computed_at = NOW()
+RETURNING *;
+
+-- name: UpsertIndicatorValue :exec
+INSERT INTO indicator_values (
+    candle_id,
+    name,
+    value
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (candle_id, name) DO UPDATE SET
+    value = EXCLUDED.value,
+    computed_at = NOW();
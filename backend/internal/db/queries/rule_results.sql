@@ -1,10 +1,12 @@
--- name: CreateRuleResult :exec
+-- name: CreateRuleResult :one
 INSERT INTO rule_results (
     id,
     rule_id,
     candle_id,
     result,
     confidence_score,
+    rule_version,
+    definition_hash,
     evaluated_at
 )
 SELECT 
@@ -13,10 +15,13 @@ SELECT
     @candle_id,
     @result::rule_result_type,
     @confidence,
+    @rule_version::int,
+    @definition_hash::text,
     NOW()
 FROM rules r
 WHERE r.code = @rule_code
-ON CONFLICT (rule_id, candle_id) DO NOTHING;
+ON CONFLICT (rule_id, candle_id, rule_version) DO NOTHING
+RETURNING id;
 
 -- name: GetRuleResultsByCandleID :many
 SELECT 
@@ -26,7 +31,7 @@ SELECT
 FROM rule_results rr
 JOIN rules r ON rr.rule_id = r.id
 WHERE rr.candle_id = $1
-ORDER BY r.code;
+ORDER BY r.code, rr.rule_version;
 
 -- name: TruncateRuleResults :exec
-TRUNCATE TABLE rule_results;
+TRUNCATE TABLE rule_results CASCADE;
@@ -20,27 +20,34 @@ INSERT INTO trades (
     planned_risk_amount,
     planned_position_size,
     reason_for_trade,
+    account_currency_at_setup,
+    quote_to_account_rate_at_setup,
+    order_type,
+    expires_at,
     created_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW()
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NOW()
 )
 RETURNING id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at;
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, order_type, expires_at, created_at;
 
 -- name: GetTradeByID :one
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, order_type, expires_at, created_at
 FROM trades WHERE id = $1;
 
 -- name: GetTradesByUserID :many
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, created_at
 FROM trades 
 WHERE user_id = $1
 ORDER BY created_at DESC
@@ -75,11 +82,44 @@ WHERE id = $1;
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at 
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, created_at 
 FROM trades
 WHERE account_id = $1 AND candle_id = $2
 ORDER BY created_at DESC;
 
+-- name: GetAccountRiskTrades :many
+-- Trades that can count towards the daily risk cap: set up since the start
+-- of the trading day, entered and not yet fully closed, or limit/stop orders
+-- still waiting for their entry.
+SELECT t.id, t.setup_timestamp_utc, t.planned_risk_pct,
+    t.order_type <> 'market' AS pending_order,
+    EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type = 'entry'
+    ) AS entered,
+    EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type IN ('tp_hit', 'sl_hit', 'manual_close')
+    ) AS closed,
+    EXISTS (
+        SELECT 1 FROM trade_intents i WHERE i.trade_id = t.id
+    ) AS withdrawn
+FROM trades t
+WHERE t.account_id = $1
+  AND (
+    t.setup_timestamp_utc >= $2
+    OR EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type = 'entry'
+    )
+    OR (
+        t.order_type <> 'market'
+        AND NOT EXISTS (SELECT 1 FROM trade_intents i WHERE i.trade_id = t.id)
+    )
+  )
+ORDER BY t.setup_timestamp_utc ASC;
+
 -- name: GetTradeExecutions :many
 SELECT * FROM trade_executions
 WHERE trade_id = $1
@@ -102,4 +142,18 @@ INSERT INTO trade_executions (
 )
 RETURNING *;
 
+-- name: CreateTradeScaleOutLeg :exec
+INSERT INTO trade_scale_out_legs (
+    trade_id,
+    leg,
+    close_pct,
+    target_r
+) VALUES (
+    $1, $2, $3, $4
+);
 
+-- name: GetTradeScaleOutLegs :many
+SELECT trade_id, leg, close_pct, target_r
+FROM trade_scale_out_legs
+WHERE trade_id = $1
+ORDER BY leg ASC;
@@ -13,13 +13,15 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-const createRuleResult = `-- name: CreateRuleResult :exec
+const createRuleResult = `-- name: CreateRuleResult :one
 INSERT INTO rule_results (
     id,
     rule_id,
     candle_id,
     result,
     confidence_score,
+    rule_version,
+    definition_hash,
     evaluated_at
 )
 SELECT 
@@ -28,38 +30,47 @@ SELECT
     $1,
     $2::rule_result_type,
     $3,
+    $4::int,
+    $5::text,
     NOW()
 FROM rules r
-WHERE r.code = $4
-ON CONFLICT (rule_id, candle_id) DO NOTHING
+WHERE r.code = $6
+ON CONFLICT (rule_id, candle_id, rule_version) DO NOTHING
+RETURNING id
 `
 
 type CreateRuleResultParams struct {
-	CandleID   uuid.UUID       `json:"candle_id"`
-	Result     RuleResultType  `json:"result"`
-	Confidence decimal.Decimal `json:"confidence"`
-	RuleCode   string          `json:"rule_code"`
+	CandleID       uuid.UUID       `json:"candle_id"`
+	Result         RuleResultType  `json:"result"`
+	Confidence     decimal.Decimal `json:"confidence"`
+	RuleVersion    int32           `json:"rule_version"`
+	DefinitionHash string          `json:"definition_hash"`
+	RuleCode       string          `json:"rule_code"`
 }
 
-func (q *Queries) CreateRuleResult(ctx context.Context, arg CreateRuleResultParams) error {
-	_, err := q.db.Exec(ctx, createRuleResult,
+func (q *Queries) CreateRuleResult(ctx context.Context, arg CreateRuleResultParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createRuleResult,
 		arg.CandleID,
 		arg.Result,
 		arg.Confidence,
+		arg.RuleVersion,
+		arg.DefinitionHash,
 		arg.RuleCode,
 	)
-	return err
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
 
 const getRuleResultsByCandleID = `-- name: GetRuleResultsByCandleID :many
 SELECT 
-    rr.id, rr.rule_id, rr.candle_id, rr.result, rr.evaluated_at, rr.confidence_score,
+    rr.id, rr.rule_id, rr.candle_id, rr.result, rr.evaluated_at, rr.confidence_score, rr.rule_version, rr.definition_hash,
     r.code as rule_code,
     r.name as rule_name
 FROM rule_results rr
 JOIN rules r ON rr.rule_id = r.id
 WHERE rr.candle_id = $1
-ORDER BY r.code
+ORDER BY r.code, rr.rule_version
 `
 
 type GetRuleResultsByCandleIDRow struct {
@@ -69,6 +80,8 @@ type GetRuleResultsByCandleIDRow struct {
 	Result          RuleResultType     `json:"result"`
 	EvaluatedAt     pgtype.Timestamptz `json:"evaluated_at"`
 	ConfidenceScore decimal.Decimal    `json:"confidence_score"`
+	RuleVersion     int32              `json:"rule_version"`
+	DefinitionHash  pgtype.Text        `json:"definition_hash"`
 	RuleCode        string             `json:"rule_code"`
 	RuleName        string             `json:"rule_name"`
 }
@@ -89,6 +102,8 @@ func (q *Queries) GetRuleResultsByCandleID(ctx context.Context, candleID uuid.UU
 			&i.Result,
 			&i.EvaluatedAt,
 			&i.ConfidenceScore,
+			&i.RuleVersion,
+			&i.DefinitionHash,
 			&i.RuleCode,
 			&i.RuleName,
 		); err != nil {
@@ -103,7 +118,7 @@ func (q *Queries) GetRuleResultsByCandleID(ctx context.Context, candleID uuid.UU
 }
 
 const truncateRuleResults = `-- name: TruncateRuleResults :exec
-TRUNCATE TABLE rule_results
+TRUNCATE TABLE rule_results CASCADE
 `
 
 func (q *Queries) TruncateRuleResults(ctx context.Context) error {
@@ -35,14 +35,19 @@ INSERT INTO trades (
     planned_risk_amount,
     planned_position_size,
     reason_for_trade,
+    account_currency_at_setup,
+    quote_to_account_rate_at_setup,
+    order_type,
+    expires_at,
     created_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW()
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NOW()
 )
 RETURNING id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, order_type, expires_at, created_at
 `
 
 type CreateTradeParams struct {
@@ -66,6 +71,10 @@ type CreateTradeParams struct {
 	PlannedRiskAmount         decimal.Decimal    `json:"planned_risk_amount"`
 	PlannedPositionSize       decimal.Decimal    `json:"planned_position_size"`
 	ReasonForTrade            string             `json:"reason_for_trade"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
+	OrderType                 OrderType          `json:"order_type"`
+	ExpiresAt                 pgtype.Timestamptz `json:"expires_at"`
 }
 
 type CreateTradeRow struct {
@@ -89,6 +98,10 @@ type CreateTradeRow struct {
 	PlannedRiskAmount         decimal.Decimal    `json:"planned_risk_amount"`
 	PlannedPositionSize       decimal.Decimal    `json:"planned_position_size"`
 	ReasonForTrade            string             `json:"reason_for_trade"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
+	OrderType                 OrderType          `json:"order_type"`
+	ExpiresAt                 pgtype.Timestamptz `json:"expires_at"`
 	CreatedAt                 pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -114,6 +127,10 @@ func (q *Queries) CreateTrade(ctx context.Context, arg CreateTradeParams) (Creat
 		arg.PlannedRiskAmount,
 		arg.PlannedPositionSize,
 		arg.ReasonForTrade,
+		arg.AccountCurrencyAtSetup,
+		arg.QuoteToAccountRateAtSetup,
+		arg.OrderType,
+		arg.ExpiresAt,
 	)
 	var i CreateTradeRow
 	err := row.Scan(
@@ -137,6 +154,10 @@ func (q *Queries) CreateTrade(ctx context.Context, arg CreateTradeParams) (Creat
 		&i.PlannedRiskAmount,
 		&i.PlannedPositionSize,
 		&i.ReasonForTrade,
+		&i.AccountCurrencyAtSetup,
+		&i.QuoteToAccountRateAtSetup,
+		&i.OrderType,
+		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -157,7 +178,7 @@ INSERT INTO trade_executions (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 )
-RETURNING id, trade_id, event_type, price, position_size, executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+RETURNING id, trade_id, event_type, price, position_size, executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at, source
 `
 
 type CreateTradeExecutionParams struct {
@@ -200,15 +221,96 @@ func (q *Queries) CreateTradeExecution(ctx context.Context, arg CreateTradeExecu
 		&i.Pnl,
 		&i.PnlPips,
 		&i.CreatedAt,
+		&i.Source,
 	)
 	return i, err
 }
 
+const getAccountRiskTrades = `-- name: GetAccountRiskTrades :many
+-- Trades that can count towards the daily risk cap: set up since the start
+-- of the trading day, entered and not yet fully closed, or limit/stop orders
+-- still waiting for their entry.
+SELECT t.id, t.setup_timestamp_utc, t.planned_risk_pct,
+    t.order_type <> 'market' AS pending_order,
+    EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type = 'entry'
+    ) AS entered,
+    EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type IN ('tp_hit', 'sl_hit', 'manual_close')
+    ) AS closed,
+    EXISTS (
+        SELECT 1 FROM trade_intents i WHERE i.trade_id = t.id
+    ) AS withdrawn
+FROM trades t
+WHERE t.account_id = $1
+  AND (
+    t.setup_timestamp_utc >= $2
+    OR EXISTS (
+        SELECT 1 FROM trade_executions e
+        WHERE e.trade_id = t.id AND e.event_type = 'entry'
+    )
+    OR (
+        t.order_type <> 'market'
+        AND NOT EXISTS (SELECT 1 FROM trade_intents i WHERE i.trade_id = t.id)
+    )
+  )
+ORDER BY t.setup_timestamp_utc ASC
+`
+
+type GetAccountRiskTradesParams struct {
+	AccountID         uuid.UUID          `json:"account_id"`
+	SetupTimestampUtc pgtype.Timestamptz `json:"setup_timestamp_utc"`
+}
+
+type GetAccountRiskTradesRow struct {
+	ID                uuid.UUID          `json:"id"`
+	SetupTimestampUtc pgtype.Timestamptz `json:"setup_timestamp_utc"`
+	PlannedRiskPct    decimal.Decimal    `json:"planned_risk_pct"`
+	PendingOrder      bool               `json:"pending_order"`
+	Entered           bool               `json:"entered"`
+	Closed            bool               `json:"closed"`
+	Withdrawn         bool               `json:"withdrawn"`
+}
+
+// Trades that can count towards the daily risk cap: set up since the start
+// of the trading day, entered and not yet fully closed, or limit/stop orders
+// still waiting for their entry.
+func (q *Queries) GetAccountRiskTrades(ctx context.Context, arg GetAccountRiskTradesParams) ([]GetAccountRiskTradesRow, error) {
+	rows, err := q.db.Query(ctx, getAccountRiskTrades, arg.AccountID, arg.SetupTimestampUtc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAccountRiskTradesRow
+	for rows.Next() {
+		var i GetAccountRiskTradesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SetupTimestampUtc,
+			&i.PlannedRiskPct,
+			&i.PendingOrder,
+			&i.Entered,
+			&i.Closed,
+			&i.Withdrawn,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTradeByID = `-- name: GetTradeByID :one
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, order_type, expires_at, created_at
 FROM trades WHERE id = $1
 `
 
@@ -233,6 +335,10 @@ type GetTradeByIDRow struct {
 	PlannedRiskAmount         decimal.Decimal    `json:"planned_risk_amount"`
 	PlannedPositionSize       decimal.Decimal    `json:"planned_position_size"`
 	ReasonForTrade            string             `json:"reason_for_trade"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
+	OrderType                 OrderType          `json:"order_type"`
+	ExpiresAt                 pgtype.Timestamptz `json:"expires_at"`
 	CreatedAt                 pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -260,13 +366,17 @@ func (q *Queries) GetTradeByID(ctx context.Context, id uuid.UUID) (GetTradeByIDR
 		&i.PlannedRiskAmount,
 		&i.PlannedPositionSize,
 		&i.ReasonForTrade,
+		&i.AccountCurrencyAtSetup,
+		&i.QuoteToAccountRateAtSetup,
+		&i.OrderType,
+		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getTradeExecutions = `-- name: GetTradeExecutions :many
-SELECT id, trade_id, event_type, price, position_size, executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at FROM trade_executions
+SELECT id, trade_id, event_type, price, position_size, executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at, source FROM trade_executions
 WHERE trade_id = $1
 ORDER BY executed_at ASC
 `
@@ -293,6 +403,7 @@ func (q *Queries) GetTradeExecutions(ctx context.Context, tradeID uuid.UUID) ([]
 			&i.Pnl,
 			&i.PnlPips,
 			&i.CreatedAt,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -308,7 +419,8 @@ const getTradesByAccountAndCandle = `-- name: GetTradesByAccountAndCandle :many
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at 
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, created_at 
 FROM trades
 WHERE account_id = $1 AND candle_id = $2
 ORDER BY created_at DESC
@@ -340,6 +452,8 @@ type GetTradesByAccountAndCandleRow struct {
 	PlannedRiskAmount         decimal.Decimal    `json:"planned_risk_amount"`
 	PlannedPositionSize       decimal.Decimal    `json:"planned_position_size"`
 	ReasonForTrade            string             `json:"reason_for_trade"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
 	CreatedAt                 pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -373,6 +487,8 @@ func (q *Queries) GetTradesByAccountAndCandle(ctx context.Context, arg GetTrades
 			&i.PlannedRiskAmount,
 			&i.PlannedPositionSize,
 			&i.ReasonForTrade,
+			&i.AccountCurrencyAtSetup,
+			&i.QuoteToAccountRateAtSetup,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -389,7 +505,8 @@ const getTradesByUserID = `-- name: GetTradesByUserID :many
 SELECT id, user_id, account_id, candle_id, symbol, timeframe, setup_timestamp_utc,
     account_balance_at_setup, leverage_at_setup, max_risk_per_trade_pct_at_setup,
     timezone_at_setup, bias, planned_entry, planned_sl, planned_tp, planned_rr,
-    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade, created_at
+    planned_risk_pct, planned_risk_amount, planned_position_size, reason_for_trade,
+    account_currency_at_setup, quote_to_account_rate_at_setup, created_at
 FROM trades 
 WHERE user_id = $1
 ORDER BY created_at DESC
@@ -422,6 +539,8 @@ type GetTradesByUserIDRow struct {
 	PlannedRiskAmount         decimal.Decimal    `json:"planned_risk_amount"`
 	PlannedPositionSize       decimal.Decimal    `json:"planned_position_size"`
 	ReasonForTrade            string             `json:"reason_for_trade"`
+	AccountCurrencyAtSetup    string             `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup decimal.Decimal    `json:"quote_to_account_rate_at_setup"`
 	CreatedAt                 pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -455,6 +574,8 @@ func (q *Queries) GetTradesByUserID(ctx context.Context, arg GetTradesByUserIDPa
 			&i.PlannedRiskAmount,
 			&i.PlannedPositionSize,
 			&i.ReasonForTrade,
+			&i.AccountCurrencyAtSetup,
+			&i.QuoteToAccountRateAtSetup,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -545,3 +666,70 @@ func (q *Queries) UpdateTradeExecution(ctx context.Context, arg UpdateTradeExecu
 	)
 	return err
 }
+
+const createTradeScaleOutLeg = `-- name: CreateTradeScaleOutLeg :exec
+INSERT INTO trade_scale_out_legs (
+    trade_id,
+    leg,
+    close_pct,
+    target_r
+) VALUES (
+    $1, $2, $3, $4
+)
+`
+
+type CreateTradeScaleOutLegParams struct {
+	TradeID  uuid.UUID       `json:"trade_id"`
+	Leg      int16           `json:"leg"`
+	ClosePct decimal.Decimal `json:"close_pct"`
+	TargetR  NullDecimal     `json:"target_r"`
+}
+
+func (q *Queries) CreateTradeScaleOutLeg(ctx context.Context, arg CreateTradeScaleOutLegParams) error {
+	_, err := q.db.Exec(ctx, createTradeScaleOutLeg,
+		arg.TradeID,
+		arg.Leg,
+		arg.ClosePct,
+		arg.TargetR,
+	)
+	return err
+}
+
+const getTradeScaleOutLegs = `-- name: GetTradeScaleOutLegs :many
+SELECT trade_id, leg, close_pct, target_r
+FROM trade_scale_out_legs
+WHERE trade_id = $1
+ORDER BY leg ASC
+`
+
+type GetTradeScaleOutLegsRow struct {
+	TradeID  uuid.UUID       `json:"trade_id"`
+	Leg      int16           `json:"leg"`
+	ClosePct decimal.Decimal `json:"close_pct"`
+	TargetR  NullDecimal     `json:"target_r"`
+}
+
+func (q *Queries) GetTradeScaleOutLegs(ctx context.Context, tradeID uuid.UUID) ([]GetTradeScaleOutLegsRow, error) {
+	rows, err := q.db.Query(ctx, getTradeScaleOutLegs, tradeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTradeScaleOutLegsRow
+	for rows.Next() {
+		var i GetTradeScaleOutLegsRow
+		if err := rows.Scan(
+			&i.TradeID,
+			&i.Leg,
+			&i.ClosePct,
+			&i.TargetR,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
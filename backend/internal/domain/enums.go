@@ -64,3 +64,22 @@ func (e Emotion) IsValid() bool {
 		return false
 	}
 }
+
+type LedgerEntryType string
+
+const (
+	LedgerTradePnL   LedgerEntryType = "trade_pnl"
+	LedgerDeposit    LedgerEntryType = "deposit"
+	LedgerWithdrawal LedgerEntryType = "withdrawal"
+	LedgerFee        LedgerEntryType = "fee"
+	LedgerAdjustment LedgerEntryType = "adjustment"
+)
+
+func (t LedgerEntryType) IsValid() bool {
+	switch t {
+	case LedgerTradePnL, LedgerDeposit, LedgerWithdrawal, LedgerFee, LedgerAdjustment:
+		return true
+	default:
+		return false
+	}
+}
@@ -45,3 +45,36 @@ func IsClosingEvent(eventType ExecutionEventType) bool {
 		eventType == EventSLHit ||
 		eventType == EventManualClose
 }
+
+// IsReducingEvent returns true if this event reduces the open position
+// (partial close or close) and so realises PnL
+func IsReducingEvent(eventType ExecutionEventType) bool {
+	return eventType == EventPartialClose || IsClosingEvent(eventType)
+}
+
+// ExecutionSource is who recorded an execution
+type ExecutionSource string
+
+const (
+	SourceUser   ExecutionSource = "user"   // journaled through the API
+	SourceSystem ExecutionSource = "system" // SL/TP hit detected by the trade monitor
+)
+
+// SameCandleRule decides which level was hit first when one candle's range
+// contains both the SL and the TP: OHLC cannot tell
+type SameCandleRule string
+
+const (
+	SameCandleSLFirst     SameCandleRule = "sl_first"     // pessimistic (default)
+	SameCandleTPFirst     SameCandleRule = "tp_first"     // optimistic
+	SameCandleNearestOpen SameCandleRule = "nearest_open" // the level closer to the open
+)
+
+func (r SameCandleRule) IsValid() bool {
+	switch r {
+	case SameCandleSLFirst, SameCandleTPFirst, SameCandleNearestOpen:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"set-and-trend/backend/internal/constants"
+)
+
+// Instrument is the trading specification of a symbol.
+// Pure domain object: risk and PnL math take it instead of EURUSD constants.
+type Instrument struct {
+	Symbol         string  `json:"symbol"`
+	Description    string  `json:"description"`
+	PipSize        float64 `json:"pip_size"`        // price increment of one pip
+	PricePrecision int     `json:"price_precision"` // decimals quoted by the broker
+	ContractSize   float64 `json:"contract_size"`   // units per standard lot
+	QuoteCurrency  string  `json:"quote_currency"`  // currency PnL is realised in
+	MinLot         float64 `json:"min_lot"`
+	LotStep        float64 `json:"lot_step"`
+}
+
+// EURUSD is the instrument the journal started with. Backtests fall back to it
+// when no instrument is given.
+var EURUSD = Instrument{
+	Symbol:         constants.SymbolEURUSD,
+	Description:    "Euro vs US Dollar",
+	PipSize:        constants.PipValueEURUSD,
+	PricePrecision: constants.PricePrecisionEURUSD,
+	ContractSize:   constants.ContractSizeEURUSD,
+	QuoteCurrency:  "USD",
+	MinLot:         0.01,
+	LotStep:        0.01,
+}
+
+// Validate checks the specification before it is used for risk math
+func (i Instrument) Validate() error {
+	if i.Symbol == "" {
+		return errors.New("instrument symbol is required")
+	}
+	if i.PipSize <= 0 {
+		return fmt.Errorf("%s: pip size must be positive", i.Symbol)
+	}
+	if i.ContractSize <= 0 {
+		return fmt.Errorf("%s: contract size must be positive", i.Symbol)
+	}
+	if i.MinLot <= 0 || i.LotStep <= 0 {
+		return fmt.Errorf("%s: min lot and lot step must be positive", i.Symbol)
+	}
+	return nil
+}
+
+// PipValuePerLot is the value of one pip for one standard lot, in the quote
+// currency (EURUSD: 0.0001 × 100,000 = $10)
+func (i Instrument) PipValuePerLot() float64 {
+	return i.PipSize * i.ContractSize
+}
+
+// Pips converts a price distance to pips
+func (i Instrument) Pips(priceDistance float64) float64 {
+	return priceDistance / i.PipSize
+}
+
+// FloorLots rounds a lot size down to the lot step, so the sized position
+// never risks more than planned
+func (i Instrument) FloorLots(lots float64) float64 {
+	steps := math.Floor(lots/i.LotStep + 1e-9) // tolerate float noise (0.29/0.01)
+	return math.Round(steps*i.LotStep*1e8) / 1e8
+}
+
+// FormatPrice formats a price with the instrument's quoted precision
+func (i Instrument) FormatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', i.PricePrecision, 64)
+}
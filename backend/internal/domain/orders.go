@@ -0,0 +1,25 @@
+package domain
+
+// OrderType is how a planned trade is entered
+type OrderType string
+
+const (
+	OrderMarket OrderType = "market" // executed by the trader
+	OrderLimit  OrderType = "limit"  // pending: entry at a better price than the market
+	OrderStop   OrderType = "stop"   // pending: entry once price breaks through the entry
+)
+
+// IsValidOrderType checks if order type is valid
+func IsValidOrderType(orderType string) bool {
+	switch OrderType(orderType) {
+	case OrderMarket, OrderLimit, OrderStop:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPending returns true if candles trigger the entry (limit and stop orders)
+func (t OrderType) IsPending() bool {
+	return t == OrderLimit || t == OrderStop
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ScaleOutLeg is one planned exit of a trade
+type ScaleOutLeg struct {
+	ClosePct float64  `json:"close_pct"`          // share of the planned position, 0-100
+	TargetR  *float64 `json:"target_r,omitempty"` // multiple of the planned risk, nil = at the planned TP
+}
+
+// ScaleOutPlan is the planned exits of a trade, in leg order (e.g. 50% at 1R,
+// the rest at TP). An empty plan is a single exit of the whole position.
+type ScaleOutPlan []ScaleOutLeg
+
+// Validate checks the plan against the trade's planned reward:risk: legs sum
+// to 100%, R targets increase and stay before the TP, and only the last leg
+// may close at the TP
+func (p ScaleOutPlan) Validate(plannedRR float64) error {
+	var total, lastR float64
+	for i, leg := range p {
+		if leg.ClosePct <= 0 || leg.ClosePct > 100 {
+			return fmt.Errorf("leg %d: close pct must be in (0, 100]", i+1)
+		}
+		total += leg.ClosePct
+
+		if leg.TargetR == nil {
+			if i != len(p)-1 {
+				return fmt.Errorf("leg %d: only the last leg can close at TP", i+1)
+			}
+			continue
+		}
+		if *leg.TargetR <= lastR {
+			return fmt.Errorf("leg %d: target %.2fR must be above the previous leg", i+1, *leg.TargetR)
+		}
+		if *leg.TargetR > plannedRR-1e-6 { // float noise in the RR (0.0150/0.0050)
+			return fmt.Errorf("leg %d: target %.2fR is at or beyond TP (%.2fR)", i+1, *leg.TargetR, plannedRR)
+		}
+		lastR = *leg.TargetR
+	}
+
+	if len(p) > 0 && math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("legs close %.2f%% of the position, must be 100%%", total)
+	}
+	return nil
+}
+
+// LegSizes splits a position over the legs: every leg but the last is floored
+// to the lot step, the last closes what remains
+func (p ScaleOutPlan) LegSizes(positionSize float64, inst Instrument) ([]float64, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+
+	sizes := make([]float64, len(p))
+	remaining := positionSize
+	for i, leg := range p[:len(p)-1] {
+		sizes[i] = inst.FloorLots(positionSize * leg.ClosePct / 100)
+		if sizes[i] < inst.MinLot {
+			return nil, fmt.Errorf("leg %d: %.2f%% of %.2f lots is below the minimum lot %.2f",
+				i+1, leg.ClosePct, positionSize, inst.MinLot)
+		}
+		remaining -= sizes[i]
+	}
+
+	remaining = math.Round(remaining*1e8) / 1e8
+	if remaining <= 0 {
+		return nil, errors.New("last leg has no position left to close")
+	}
+	sizes[len(p)-1] = remaining
+	return sizes, nil
+}
+
+// TargetPrice is the exit price of a leg: TargetR × the planned risk from the
+// planned entry, or the planned TP
+func (l ScaleOutLeg) TargetPrice(bias string, entry, sl, tp float64) float64 {
+	if l.TargetR == nil {
+		return tp
+	}
+	risk := math.Abs(entry - sl)
+	if bias == "short" {
+		return entry - *l.TargetR*risk
+	}
+	return entry + *l.TargetR*risk
+}
@@ -0,0 +1,26 @@
+package domain
+
+// AdjustmentType is a move of an open trade's SL or TP
+type AdjustmentType string
+
+const (
+	AdjustMoveSL       AdjustmentType = "move_sl"
+	AdjustMoveTP       AdjustmentType = "move_tp"
+	AdjustTrailToSwing AdjustmentType = "trail_to_swing" // SL to the last confirmed swing
+	AdjustBreakEven    AdjustmentType = "break_even"     // SL to the actual entry
+)
+
+// IsValidAdjustment checks if adjustment type is valid
+func IsValidAdjustment(adjustmentType string) bool {
+	switch AdjustmentType(adjustmentType) {
+	case AdjustMoveSL, AdjustMoveTP, AdjustTrailToSwing, AdjustBreakEven:
+		return true
+	default:
+		return false
+	}
+}
+
+// MovesSL returns true if the adjustment moves the stop (every type but move_tp)
+func (t AdjustmentType) MovesSL() bool {
+	return t != AdjustMoveTP
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/services"
+)
+
+type AdjustmentHandler struct {
+	adjustmentService *services.AdjustmentService
+	executionService  *services.ExecutionService
+	adjustmentRepo    *repositories.AdjustmentRepository
+}
+
+func NewAdjustmentHandler(
+	adjustmentService *services.AdjustmentService,
+	executionService *services.ExecutionService,
+	adjustmentRepo *repositories.AdjustmentRepository,
+) *AdjustmentHandler {
+	return &AdjustmentHandler{
+		adjustmentService: adjustmentService,
+		executionService:  executionService,
+		adjustmentRepo:    adjustmentRepo,
+	}
+}
+
+type AdjustTradeRequest struct {
+	AdjustmentType string     `json:"adjustment_type" binding:"required,oneof=move_sl move_tp trail_to_swing break_even"`
+	Price          *float64   `json:"price" binding:"omitempty,gt=0"` // new level, move_sl and move_tp only
+	MarketPrice    float64    `json:"market_price" binding:"required,gt=0"`
+	AdjustedAt     *time.Time `json:"adjusted_at"` // defaults to now
+	Reason         *string    `json:"reason"`
+}
+
+func (h *AdjustmentHandler) AdjustTrade(c *gin.Context) {
+	tradeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	var req AdjustTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment, err := h.adjustmentService.AdjustTrade(c.Request.Context(), services.AdjustTradeInput{
+		TradeID:        tradeID,
+		AdjustmentType: domain.AdjustmentType(req.AdjustmentType),
+		Price:          req.Price,
+		MarketPrice:    req.MarketPrice,
+		AdjustedAt:     executedAtOrNow(req.AdjustedAt),
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"trade_id":   tradeID,
+		"adjustment": adjustment,
+		"message":    "trade adjusted",
+	})
+}
+
+// GetTradeAdjustments returns the adjustments of a trade and, while it is
+// open, the levels in force and the risk left at the stop
+func (h *AdjustmentHandler) GetTradeAdjustments(c *gin.Context) {
+	tradeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	adjustments, err := h.adjustmentRepo.GetAdjustmentsByTradeID(c.Request.Context(), tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get adjustments"})
+		return
+	}
+
+	state, err := h.executionService.GetTradeState(c.Request.Context(), tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get trade state"})
+		return
+	}
+
+	response := gin.H{
+		"status":      "success",
+		"trade_id":    tradeID,
+		"state":       state,
+		"adjustments": adjustments,
+		"count":       len(adjustments),
+	}
+
+	if state == services.StateOpen || state == services.StatePartial {
+		risk, err := h.adjustmentService.GetTradeRisk(c.Request.Context(), tradeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute trade risk"})
+			return
+		}
+		response["risk"] = risk
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetSummary returns outcome statistics of an account's closed trades:
+// GET /analytics/summary?account_id=...&from=RFC3339&to=RFC3339
+// Trades count in the range when they were closed; from defaults to the
+// beginning, to defaults to now.
+func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
+	accountID, from, to, ok := analyticsParams(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.analyticsService.Summary(c.Request.Context(), accountID, from, to)
+	if err != nil {
+		log.Error().Err(err).Str("account_id", accountID.String()).Msg("analytics summary failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"account_id": accountID,
+			"from":       from,
+			"to":         to,
+			"summary":    summary,
+		},
+	})
+}
+
+// GetEdge returns outcome statistics of the closed trades matching a filter,
+// with sample sizes and 95% intervals, next to the unfiltered baseline:
+// GET /analytics/edge?account_id=...&rule=W1_TREND_BULLISH:PASS&emotion_before=calm
+// Filters (all optional, combined with AND): rule=CODE:RESULT (repeatable),
+// bias, emotion_before, emotion_during, emotion_after, followed_plan, session.
+func (h *AnalyticsHandler) GetEdge(c *gin.Context) {
+	accountID, from, to, ok := analyticsParams(c)
+	if !ok {
+		return
+	}
+
+	filter, err := edgeFilter(c)
+	if err == nil {
+		err = filter.Validate()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.analyticsService.Edge(c.Request.Context(), accountID, from, to, filter)
+	if err != nil {
+		log.Error().Err(err).Str("account_id", accountID.String()).Msg("edge analytics failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"account_id": accountID,
+			"from":       from,
+			"to":         to,
+			"report":     report,
+		},
+	})
+}
+
+// GetScaleOut compares the scale-out plans of an account's closed trades
+// with the exits actually executed:
+// GET /analytics/scale-out?account_id=...&from=RFC3339&to=RFC3339
+func (h *AnalyticsHandler) GetScaleOut(c *gin.Context) {
+	accountID, from, to, ok := analyticsParams(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.analyticsService.ScaleOut(c.Request.Context(), accountID, from, to)
+	if err != nil {
+		log.Error().Err(err).Str("account_id", accountID.String()).Msg("scale-out analytics failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"account_id": accountID,
+			"from":       from,
+			"to":         to,
+			"report":     report,
+		},
+	})
+}
+
+// GetAccountEquity returns the realized equity curve with drawdown stats:
+// GET /accounts/:id/equity (JSON) or /accounts/:id/equity?format=csv (points)
+func (h *AnalyticsHandler) GetAccountEquity(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	curve, err := h.analyticsService.Equity(c.Request.Context(), accountID, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		log.Error().Err(err).Str("account_id", accountID.String()).Msg("equity curve failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=equity_%s.csv", accountID))
+		if err := services.WriteEquityCSV(c.Writer, curve); err != nil {
+			log.Error().Err(err).Str("account_id", accountID.String()).Msg("write equity csv failed")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "account_id": accountID, "data": curve})
+}
+
+func edgeFilter(c *gin.Context) (services.EdgeFilter, error) {
+	var filter services.EdgeFilter
+
+	for _, raw := range c.QueryArray("rule") {
+		code, result, found := strings.Cut(raw, ":")
+		if !found || code == "" {
+			return filter, fmt.Errorf("invalid rule filter %q, use CODE:RESULT", raw)
+		}
+		if filter.RuleResults == nil {
+			filter.RuleResults = make(map[rules.RuleCode]string)
+		}
+		filter.RuleResults[rules.RuleCode(code)] = strings.ToUpper(result)
+	}
+
+	if raw := c.Query("bias"); raw != "" {
+		bias := domain.TradeBias(raw)
+		filter.Bias = &bias
+	}
+	emotions := []struct {
+		param string
+		dst   **domain.Emotion
+	}{
+		{"emotion_before", &filter.EmotionBefore},
+		{"emotion_during", &filter.EmotionDuring},
+		{"emotion_after", &filter.EmotionAfter},
+	}
+	for _, e := range emotions {
+		if raw := c.Query(e.param); raw != "" {
+			emotion := domain.Emotion(raw)
+			*e.dst = &emotion
+		}
+	}
+	if raw := c.Query("followed_plan"); raw != "" {
+		followed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid followed_plan %q", raw)
+		}
+		filter.FollowedPlan = &followed
+	}
+	if raw := c.Query("session"); raw != "" {
+		session := domain.Session(raw)
+		filter.Session = &session
+	}
+
+	return filter, nil
+}
+
+func analyticsParams(c *gin.Context) (uuid.UUID, time.Time, time.Time, bool) {
+	accountID, err := uuid.Parse(c.Query("account_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+		return uuid.Nil, time.Time{}, time.Time{}, false
+	}
+
+	from := time.Unix(0, 0).UTC()
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from format, use RFC3339"})
+			return uuid.Nil, time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to format, use RFC3339"})
+			return uuid.Nil, time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return uuid.Nil, time.Time{}, time.Time{}, false
+	}
+
+	return accountID, from, to, true
+}
@@ -1,24 +1,45 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/constants"
 	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/services"
 )
 
 type CandleHandler struct {
-	candleRepo *repositories.CandleRepository
+	candleRepo          *repositories.CandleRepository
+	conditionResultRepo *repositories.RuleConditionResultRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	indicatorService    *services.IndicatorService
+	tradeMonitor        *services.TradeMonitor
 }
 
-func NewCandleHandler(candleRepo *repositories.CandleRepository) *CandleHandler {
-	return &CandleHandler{candleRepo: candleRepo}
+func NewCandleHandler(
+	candleRepo *repositories.CandleRepository,
+	conditionResultRepo *repositories.RuleConditionResultRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	indicatorService *services.IndicatorService,
+	tradeMonitor *services.TradeMonitor,
+) *CandleHandler {
+	return &CandleHandler{
+		candleRepo:          candleRepo,
+		conditionResultRepo: conditionResultRepo,
+		instrumentRepo:      instrumentRepo,
+		indicatorService:    indicatorService,
+		tradeMonitor:        tradeMonitor,
+	}
 }
 
 type CreateCandleRequest struct {
+	Symbol       string  `json:"symbol"` // default EURUSD
 	TimestampUTC string  `json:"timestamp_utc" binding:"required"`
 	Open         string  `json:"open" binding:"required"`
 	High         string  `json:"high" binding:"required"`
@@ -42,8 +63,23 @@ func (h *CandleHandler) CreateCandle(c *gin.Context) {
 		return
 	}
 
+	symbol := req.Symbol
+	if symbol == "" {
+		symbol = constants.SymbolEURUSD
+	}
+	if _, err := h.instrumentRepo.GetInstrument(c.Request.Context(), symbol); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown symbol " + symbol})
+			return
+		}
+		log.Error().Err(err).Msg("get instrument failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
 	candle, err := h.candleRepo.CreateCandle(c.Request.Context(), repositories.CandleCreateParams{
 		ID:           uuid.New(),
+		Symbol:       symbol,
 		TimestampUTC: timestamp,
 		Open:         req.Open,
 		High:         req.High,
@@ -59,14 +95,49 @@ func (h *CandleHandler) CreateCandle(c *gin.Context) {
 
 	log.Info().
 		Str("candle_id", candle.ID.String()).
+		Str("symbol", candle.Symbol).
 		Time("timestamp", candle.TimestampUTC).
 		Msg("candle created")
 
-	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": candle})
+	// Incremental indicators + rule evaluation for the new candle
+	indicator, err := h.indicatorService.ProcessCandle(c.Request.Context(), candle.ID)
+	if err != nil {
+		log.Error().Err(err).Str("candle_id", candle.ID.String()).Msg("indicator computation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "candle stored but indicator computation failed",
+			"candle_id": candle.ID,
+		})
+		return
+	}
+
+	// Pending orders first: an order entered by this candle is only stopped out
+	// by it (CheckPendingOrders), CheckCandle skips it until the next one. The
+	// candle is stored either way.
+	orderExecutions, orderInvalidations, err := h.tradeMonitor.CheckPendingOrders(c.Request.Context(), candle.ID)
+	if err != nil {
+		log.Error().Err(err).Str("candle_id", candle.ID.String()).Msg("pending order check failed")
+	}
+
+	// SL/TP hits of open trades
+	levelHits, err := h.tradeMonitor.CheckCandle(c.Request.Context(), candle.ID)
+	if err != nil {
+		log.Error().Err(err).Str("candle_id", candle.ID.String()).Msg("trade monitor failed")
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": gin.H{
+		"candle":              candle,
+		"indicators":          indicator,
+		"level_hits":          levelHits,
+		"order_executions":    orderExecutions,
+		"order_invalidations": orderInvalidations,
+	}})
 }
 
+// GetLatestCandles returns the last 20 candles of ?symbol= (default EURUSD)
 func (h *CandleHandler) GetLatestCandles(c *gin.Context) {
-	candles, err := h.candleRepo.GetLatestCandles(c.Request.Context(), 20)
+	symbol := c.DefaultQuery("symbol", constants.SymbolEURUSD)
+
+	candles, err := h.candleRepo.GetLatestCandles(c.Request.Context(), symbol, 20)
 	if err != nil {
 		log.Error().Err(err).Msg("get candles failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
@@ -75,3 +146,32 @@ func (h *CandleHandler) GetLatestCandles(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": candles})
 }
+
+// GetCandleRules returns every rule result of a candle with the outcome and
+// input values of each condition
+func (h *CandleHandler) GetCandleRules(c *gin.Context) {
+	candleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid candle ID"})
+		return
+	}
+
+	if _, err := h.candleRepo.GetCandleByID(c.Request.Context(), candleID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "candle not found"})
+			return
+		}
+		log.Error().Err(err).Msg("get candle failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	breakdown, err := h.conditionResultRepo.GetRuleBreakdownByCandleID(c.Request.Context(), candleID)
+	if err != nil {
+		log.Error().Err(err).Str("candle_id", candleID.String()).Msg("get rule breakdown failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": breakdown})
+}
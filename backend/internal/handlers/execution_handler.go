@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -26,8 +27,9 @@ func NewExecutionHandler(
 }
 
 type ExecuteTradeRequest struct {
-	ActualEntry float64 `json:"actual_entry" binding:"required,gt=0"`
-	Reason      *string `json:"reason"`
+	ActualEntry float64    `json:"actual_entry" binding:"required,gt=0"`
+	ExecutedAt  *time.Time `json:"executed_at"` // fill time, defaults to now
+	Reason      *string    `json:"reason"`
 }
 
 func (h *ExecutionHandler) ExecuteTrade(c *gin.Context) {
@@ -46,9 +48,14 @@ func (h *ExecutionHandler) ExecuteTrade(c *gin.Context) {
 	err = h.executionService.ExecuteTrade(c.Request.Context(), services.ExecuteTradeInput{
 		TradeID:     tradeID,
 		ActualEntry: req.ActualEntry,
-		ExecutedAt:  time.Now().UTC(),
+		ExecutedAt:  executedAtOrNow(req.ExecutedAt),
 		Reason:      req.Reason,
 	})
+	var rejected *services.TradeRejectedError
+	if errors.As(err, &rejected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": rejected.Message, "code": rejected.Code})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -65,8 +72,9 @@ func (h *ExecutionHandler) ExecuteTrade(c *gin.Context) {
 }
 
 type CloseTradeRequest struct {
-	ClosePrice float64 `json:"close_price" binding:"required,gt=0"`
-	Reason     *string `json:"reason"`
+	ClosePrice float64    `json:"close_price" binding:"required,gt=0"`
+	ExecutedAt *time.Time `json:"executed_at"` // fill time, defaults to now
+	Reason     *string    `json:"reason"`
 }
 
 func (h *ExecutionHandler) CloseTrade(c *gin.Context) {
@@ -85,7 +93,7 @@ func (h *ExecutionHandler) CloseTrade(c *gin.Context) {
 	err = h.executionService. CloseTrade(c.Request.Context(), services.CloseTradeInput{
 		TradeID:    tradeID,
 		ClosePrice: req.ClosePrice,
-		ExecutedAt:  time.Now().UTC(),
+		ExecutedAt: executedAtOrNow(req.ExecutedAt),
 		Reason:     req.Reason,
 	})
 	if err != nil {
@@ -103,6 +111,48 @@ func (h *ExecutionHandler) CloseTrade(c *gin.Context) {
 	})
 }
 
+type PartialCloseRequest struct {
+	ClosePrice   float64    `json:"close_price" binding:"required,gt=0"`
+	PositionSize float64    `json:"position_size" binding:"required,gt=0"` // lots, less than the remaining position
+	ExecutedAt   *time.Time `json:"executed_at"`                           // fill time, defaults to now
+	Reason       *string    `json:"reason"`
+}
+
+func (h *ExecutionHandler) PartialCloseTrade(c *gin.Context) {
+	tradeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	var req PartialCloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.executionService.PartialClose(c.Request.Context(), services.PartialCloseInput{
+		TradeID:      tradeID,
+		ClosePrice:   req.ClosePrice,
+		PositionSize: req.PositionSize,
+		ExecutedAt:   executedAtOrNow(req.ExecutedAt),
+		Reason:       req.Reason,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, _ := h.executionService.GetTradeState(c.Request.Context(), tradeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"trade_id": tradeID,
+		"state":    state,
+		"message":  "trade partially closed",
+	})
+}
+
 type CancelTradeRequest struct {
 	Reason string `json:"reason" binding:"required,min=5"`
 }
@@ -173,10 +223,35 @@ func (h *ExecutionHandler) GetTradeExecutions(c *gin.Context) {
 		return
 	}
 
+	if len(executions) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"trade_id":   tradeID,
+			"executions": executions,
+			"count":      0,
+		})
+		return
+	}
+
+	realized, err := h.executionService.GetRealizedPnL(c.Request.Context(), tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute realized pnl"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":     "success",
-		"trade_id":   tradeID,
-		"executions": executions,
-		"count":      len(executions),
+		"status":       "success",
+		"trade_id":     tradeID,
+		"executions":   executions,
+		"count":        len(executions),
+		"realized_pnl": realized,
 	})
 }
+
+// executedAtOrNow is the client-supplied fill time, or now when journaling live
+func executedAtOrNow(executedAt *time.Time) time.Time {
+	if executedAt == nil {
+		return time.Now().UTC()
+	}
+	return executedAt.UTC()
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/services"
+)
+
+type FeedbackHandler struct {
+	feedbackService *services.FeedbackService
+}
+
+func NewFeedbackHandler(feedbackService *services.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{feedbackService: feedbackService}
+}
+
+type FeedbackRequest struct {
+	FollowedPlan   *bool   `json:"followed_plan" binding:"required"`
+	EmotionBefore  string  `json:"emotion_before" binding:"required"`
+	EmotionDuring  string  `json:"emotion_during" binding:"required"`
+	EmotionAfter   string  `json:"emotion_after" binding:"required"`
+	BiggestMistake *string `json:"biggest_mistake"`
+	ScreenshotURL  *string `json:"screenshot_url" binding:"omitempty,url"`
+}
+
+// CreateFeedback records the journal entry of a finished trade
+func (h *FeedbackHandler) CreateFeedback(c *gin.Context) {
+	input, ok := feedbackInput(c)
+	if !ok {
+		return
+	}
+
+	fb, err := h.feedbackService.CreateFeedback(c.Request.Context(), input)
+	if err != nil {
+		respondFeedbackError(c, input.TradeID, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": fb})
+}
+
+// GetFeedback returns the journal entry of a trade
+func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
+	tradeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	fb, err := h.feedbackService.GetFeedback(c.Request.Context(), tradeID)
+	if err != nil {
+		respondFeedbackError(c, tradeID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": fb})
+}
+
+// UpdateFeedback replaces the journal entry of a finished trade
+func (h *FeedbackHandler) UpdateFeedback(c *gin.Context) {
+	input, ok := feedbackInput(c)
+	if !ok {
+		return
+	}
+
+	fb, err := h.feedbackService.UpdateFeedback(c.Request.Context(), input)
+	if err != nil {
+		respondFeedbackError(c, input.TradeID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": fb})
+}
+
+func feedbackInput(c *gin.Context) (services.FeedbackInput, bool) {
+	tradeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return services.FeedbackInput{}, false
+	}
+
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("invalid feedback request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return services.FeedbackInput{}, false
+	}
+
+	input := services.FeedbackInput{
+		TradeID:        tradeID,
+		FollowedPlan:   *req.FollowedPlan,
+		EmotionBefore:  domain.Emotion(req.EmotionBefore),
+		EmotionDuring:  domain.Emotion(req.EmotionDuring),
+		EmotionAfter:   domain.Emotion(req.EmotionAfter),
+		BiggestMistake: req.BiggestMistake,
+		ScreenshotURL:  req.ScreenshotURL,
+	}
+	if err := services.ValidateFeedback(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return services.FeedbackInput{}, false
+	}
+
+	return input, true
+}
+
+func respondFeedbackError(c *gin.Context, tradeID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "trade not found"})
+	case errors.Is(err, services.ErrFeedbackNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTradeNotFinished), errors.Is(err, services.ErrFeedbackExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Str("trade_id", tradeID.String()).Msg("trade feedback failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
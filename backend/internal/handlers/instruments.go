@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/repositories"
+)
+
+type InstrumentHandler struct {
+	instrumentRepo *repositories.InstrumentRepository
+}
+
+func NewInstrumentHandler(instrumentRepo *repositories.InstrumentRepository) *InstrumentHandler {
+	return &InstrumentHandler{instrumentRepo: instrumentRepo}
+}
+
+// ListInstruments returns the instrument catalogue
+func (h *InstrumentHandler) ListInstruments(c *gin.Context) {
+	instruments, err := h.instrumentRepo.ListInstruments(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("list instruments failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": instruments})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/services"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{ledgerService: ledgerService}
+}
+
+type LedgerEntryRequest struct {
+	EntryType   string  `json:"entry_type" binding:"required,oneof=deposit withdrawal fee adjustment"`
+	Amount      string  `json:"amount" binding:"required"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+// PostLedgerEntry appends a deposit, withdrawal, fee or adjustment
+func (h *LedgerHandler) PostLedgerEntry(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+
+	var req LedgerEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("invalid ledger request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		return
+	}
+
+	entryType := domain.LedgerEntryType(req.EntryType)
+	if _, err := services.SignedLedgerAmount(entryType, amount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.ledgerService.PostEntry(c.Request.Context(), services.LedgerEntryInput{
+		AccountID:   accountID,
+		EntryType:   entryType,
+		Amount:      amount,
+		Description: req.Description,
+	})
+	if err != nil {
+		respondLedgerError(c, accountID, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": entry})
+}
+
+// GetLedger returns the account's ledger and current balance
+func (h *LedgerHandler) GetLedger(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+
+	entries, balance, err := h.ledgerService.GetLedger(c.Request.Context(), accountID)
+	if err != nil {
+		respondLedgerError(c, accountID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"balance": balance.StringFixed(2),
+			"entries": entries,
+		},
+	})
+}
+
+func respondLedgerError(c *gin.Context, accountID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+	case errors.Is(err, services.ErrInsufficientBalance):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Str("account_id", accountID.String()).Msg("account ledger failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
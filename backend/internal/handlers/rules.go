@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+type RuleHandler struct {
+	versionService *services.RuleVersionService
+}
+
+func NewRuleHandler(versionService *services.RuleVersionService) *RuleHandler {
+	return &RuleHandler{versionService: versionService}
+}
+
+// ListRuleVersions returns every stored rule version (active and candidates)
+func (h *RuleHandler) ListRuleVersions(c *gin.Context) {
+	versions, err := h.versionService.ListVersions(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("list rule versions failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": versions})
+}
+
+// EvaluateRuleVersion evaluates a rule version over all candles
+func (h *RuleHandler) EvaluateRuleVersion(c *gin.Context) {
+	code, version, ok := ruleVersionParams(c)
+	if !ok {
+		return
+	}
+
+	rv, err := h.versionService.EvaluateVersion(c.Request.Context(), code, version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rv})
+}
+
+// CompareRuleVersions diffs PASS/FAIL results: GET /rules/:code/compare?from=1&to=2
+func (h *RuleHandler) CompareRuleVersions(c *gin.Context) {
+	code := rules.RuleCode(c.Param("code"))
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from version"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to version"})
+		return
+	}
+
+	cmp, err := h.versionService.CompareVersions(c.Request.Context(), code, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": cmp})
+}
+
+// PromoteRuleVersion makes a rule version active
+func (h *RuleHandler) PromoteRuleVersion(c *gin.Context) {
+	code, version, ok := ruleVersionParams(c)
+	if !ok {
+		return
+	}
+
+	rv, err := h.versionService.PromoteVersion(c.Request.Context(), code, version)
+	if errors.Is(err, services.ErrVersionNotEvaluated) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rv})
+}
+
+func ruleVersionParams(c *gin.Context) (rules.RuleCode, int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule version"})
+		return "", 0, false
+	}
+
+	return rules.RuleCode(c.Param("code")), version, true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/repositories"
+)
+
+type SwingHandler struct {
+	swingRepo *repositories.SwingRepository
+}
+
+func NewSwingHandler(swingRepo *repositories.SwingRepository) *SwingHandler {
+	return &SwingHandler{swingRepo: swingRepo}
+}
+
+// GetSwings returns confirmed swings, newest first.
+// Each swing carries both its own timestamp and the timestamp of the candle
+// that confirmed it; ?as_of=RFC3339 hides swings not yet confirmed at that time.
+// ?symbol= selects the series (default EURUSD).
+func (h *SwingHandler) GetSwings(c *gin.Context) {
+	asOf := time.Now().UTC()
+	if raw := c.Query("as_of"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of format, use RFC3339"})
+			return
+		}
+		asOf = parsed
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	symbol := c.DefaultQuery("symbol", constants.SymbolEURUSD)
+
+	swings, err := h.swingRepo.GetConfirmedSwings(c.Request.Context(), symbol, asOf, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("get swings failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": swings})
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/domain"
 	"set-and-trend/backend/internal/services"
 )
 
@@ -26,6 +28,11 @@ type CreateTradeRequest struct {
 	PlannedTP      float64 `json:"planned_tp" binding:"required,gt=0"`
 	PlannedRiskPct float64 `json:"planned_risk_pct" binding:"required,gt=0,lte=100"`
 	ReasonForTrade string  `json:"reason_for_trade" binding:"required,min=10"`
+	// Optional: [{"close_pct": 50, "target_r": 1}, {"close_pct": 50}] (no target_r = at TP)
+	ScaleOutPlan domain.ScaleOutPlan `json:"scale_out_plan"`
+	// Optional: market (default), limit or stop; limit/stop are entered by ingested candles
+	OrderType   string `json:"order_type" binding:"omitempty,oneof=market limit stop"`
+	ExpiryWeeks int    `json:"expiry_weeks" binding:"gte=0"` // limit/stop only, 0 = good till cancelled
 }
 
 func (h *TradeHandler) CreateTrade(c *gin.Context) {
@@ -57,7 +64,16 @@ func (h *TradeHandler) CreateTrade(c *gin.Context) {
 		PlannedTP:      req.PlannedTP,
 		PlannedRiskPct: req.PlannedRiskPct,
 		ReasonForTrade: req.ReasonForTrade,
+		ScaleOutPlan:   req.ScaleOutPlan,
+		OrderType:      domain.OrderType(req.OrderType),
+		ExpiryWeeks:    req.ExpiryWeeks,
 	})
+	var rejected *services.TradeRejectedError
+	if errors.As(err, &rejected) {
+		log.Warn().Str("code", rejected.Code).Msg(rejected.Message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": rejected.Message, "code": rejected.Code})
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("create trade failed")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -0,0 +1,287 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// Built-in indicators (Wilder smoothing for ATR/RSI/ADX, SMA-seeded EMAs for MACD)
+const (
+	ATR14        = "atr14"
+	RSI14        = "rsi14"
+	ADX14        = "adx14"
+	MACD         = "macd_12_26_9"
+	MACDSignal   = "macd_signal_12_26_9"
+	MACDHist     = "macd_hist_12_26_9"
+	BollingerUp  = "bb_upper_20_2"
+	BollingerMid = "bb_middle_20_2"
+	BollingerLow = "bb_lower_20_2"
+)
+
+func init() {
+	mustRegister(Spec{
+		Name:     "atr",
+		Params:   map[string]float64{"period": 14},
+		Lookback: 14 + 1,
+		Outputs:  []string{ATR14},
+		Compute: func(bars []Bar) map[string][]float64 {
+			return map[string][]float64{ATR14: ATR(bars, 14)}
+		},
+	})
+	mustRegister(Spec{
+		Name:     "rsi",
+		Params:   map[string]float64{"period": 14},
+		Lookback: 14 + 1,
+		Outputs:  []string{RSI14},
+		Compute: func(bars []Bar) map[string][]float64 {
+			return map[string][]float64{RSI14: RSI(bars, 14)}
+		},
+	})
+	mustRegister(Spec{
+		Name:     "adx",
+		Params:   map[string]float64{"period": 14},
+		Lookback: 2 * 14,
+		Outputs:  []string{ADX14},
+		Compute: func(bars []Bar) map[string][]float64 {
+			return map[string][]float64{ADX14: ADX(bars, 14)}
+		},
+	})
+	mustRegister(Spec{
+		Name:     "macd",
+		Params:   map[string]float64{"fast": 12, "slow": 26, "signal": 9},
+		Lookback: 26 + 9 - 1,
+		Outputs:  []string{MACD, MACDSignal, MACDHist},
+		Compute: func(bars []Bar) map[string][]float64 {
+			line, signal, hist := MACDSeries(bars, 12, 26, 9)
+			return map[string][]float64{MACD: line, MACDSignal: signal, MACDHist: hist}
+		},
+	})
+	mustRegister(Spec{
+		Name:     "bollinger",
+		Params:   map[string]float64{"period": 20, "stddev": 2},
+		Lookback: 20,
+		Outputs:  []string{BollingerUp, BollingerMid, BollingerLow},
+		Compute: func(bars []Bar) map[string][]float64 {
+			upper, middle, lower := Bollinger(bars, 20, 2)
+			return map[string][]float64{BollingerUp: upper, BollingerMid: middle, BollingerLow: lower}
+		},
+	})
+}
+
+func nanSeries(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = math.NaN()
+	}
+	return s
+}
+
+// trueRange of bar i (needs the previous close; bar 0 has none)
+func trueRange(bars []Bar, i int) float64 {
+	prevClose := bars[i-1].Close
+	return math.Max(bars[i].High-bars[i].Low,
+		math.Max(math.Abs(bars[i].High-prevClose), math.Abs(bars[i].Low-prevClose)))
+}
+
+// ATR is Wilder's Average True Range.
+// First value at bar `period` (mean of the first `period` true ranges).
+func ATR(bars []Bar, period int) []float64 {
+	out := nanSeries(len(bars))
+	if len(bars) <= period {
+		return out
+	}
+
+	sum := 0.0
+	for i := 1; i <= period; i++ {
+		sum += trueRange(bars, i)
+	}
+	atr := sum / float64(period)
+	out[period] = atr
+
+	for i := period + 1; i < len(bars); i++ {
+		atr = (atr*float64(period-1) + trueRange(bars, i)) / float64(period)
+		out[i] = atr
+	}
+	return out
+}
+
+// RSI is Wilder's Relative Strength Index (0-100).
+// First value at bar `period`; 100 when there were no losses.
+func RSI(bars []Bar, period int) []float64 {
+	out := nanSeries(len(bars))
+	if len(bars) <= period {
+		return out
+	}
+
+	rsi := func(gain, loss float64) float64 {
+		if loss == 0 {
+			return 100
+		}
+		return 100 - 100/(1+gain/loss)
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := bars[i].Close - bars[i-1].Close
+		gain += math.Max(change, 0)
+		loss += math.Max(-change, 0)
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	out[period] = rsi(gain, loss)
+
+	for i := period + 1; i < len(bars); i++ {
+		change := bars[i].Close - bars[i-1].Close
+		gain = (gain*float64(period-1) + math.Max(change, 0)) / float64(period)
+		loss = (loss*float64(period-1) + math.Max(-change, 0)) / float64(period)
+		out[i] = rsi(gain, loss)
+	}
+	return out
+}
+
+// ADX is Wilder's Average Directional Index (0-100).
+// DX is available from bar `period`, the first ADX (mean of `period` DX
+// values) at bar 2×period-1.
+func ADX(bars []Bar, period int) []float64 {
+	out := nanSeries(len(bars))
+	if len(bars) < 2*period {
+		return out
+	}
+
+	p := float64(period)
+	var trS, plusS, minusS float64
+	dx := func() float64 {
+		if trS == 0 {
+			return 0
+		}
+		plusDI := 100 * plusS / trS
+		minusDI := 100 * minusS / trS
+		if plusDI+minusDI == 0 {
+			return 0
+		}
+		return 100 * math.Abs(plusDI-minusDI) / (plusDI + minusDI)
+	}
+
+	var adxSum, adx float64
+	for i := 1; i < len(bars); i++ {
+		up := bars[i].High - bars[i-1].High
+		down := bars[i-1].Low - bars[i].Low
+		plusDM, minusDM := 0.0, 0.0
+		if up > down && up > 0 {
+			plusDM = up
+		}
+		if down > up && down > 0 {
+			minusDM = down
+		}
+		tr := trueRange(bars, i)
+
+		if i <= period {
+			// Initial sums over the first `period` bars
+			trS += tr
+			plusS += plusDM
+			minusS += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			trS = trS - trS/p + tr
+			plusS = plusS - plusS/p + plusDM
+			minusS = minusS - minusS/p + minusDM
+		}
+
+		switch {
+		case i < 2*period-1:
+			adxSum += dx()
+		case i == 2*period-1:
+			adx = (adxSum + dx()) / p
+			out[i] = adx
+		default:
+			adx = (adx*(p-1) + dx()) / p
+			out[i] = adx
+		}
+	}
+	return out
+}
+
+// ema of a series seeded with the SMA of its first `period` valid values.
+// NaN inputs before the first valid value are skipped.
+func ema(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < period {
+		return out
+	}
+
+	sum := 0.0
+	for i := start; i < start+period; i++ {
+		sum += values[i]
+	}
+	seedAt := start + period - 1
+	out[seedAt] = sum / float64(period)
+
+	k := 2.0 / float64(period+1)
+	for i := seedAt + 1; i < len(values); i++ {
+		out[i] = values[i]*k + out[i-1]*(1-k)
+	}
+	return out
+}
+
+// MACDSeries returns the MACD line (EMA fast - EMA slow), its signal line
+// (EMA of the line) and the histogram (line - signal)
+func MACDSeries(bars []Bar, fast, slow, signal int) (line, sig, hist []float64) {
+	if fast >= slow {
+		panic(fmt.Sprintf("macd: fast period %d must be < slow period %d", fast, slow))
+	}
+
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	emaFast := ema(closes, fast)
+	emaSlow := ema(closes, slow)
+
+	line = nanSeries(len(bars))
+	for i := range bars {
+		if !math.IsNaN(emaSlow[i]) {
+			line[i] = emaFast[i] - emaSlow[i]
+		}
+	}
+
+	sig = ema(line, signal)
+	hist = nanSeries(len(bars))
+	for i := range bars {
+		if !math.IsNaN(sig[i]) {
+			hist[i] = line[i] - sig[i]
+		}
+	}
+	return line, sig, hist
+}
+
+// Bollinger returns SMA(period) ± k × population standard deviation
+func Bollinger(bars []Bar, period int, k float64) (upper, middle, lower []float64) {
+	upper, middle, lower = nanSeries(len(bars)), nanSeries(len(bars)), nanSeries(len(bars))
+
+	for i := period - 1; i < len(bars); i++ {
+		sum := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			sum += bars[j].Close
+		}
+		mean := sum / float64(period)
+
+		variance := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			d := bars[j].Close - mean
+			variance += d * d
+		}
+		sd := math.Sqrt(variance / float64(period))
+
+		middle[i] = mean
+		upper[i] = mean + k*sd
+		lower[i] = mean - k*sd
+	}
+	return upper, middle, lower
+}
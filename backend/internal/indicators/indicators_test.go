@@ -0,0 +1,188 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+func closesToBars(closes ...float64) []Bar {
+	bars := make([]Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = Bar{Open: c, High: c, Low: c, Close: c}
+	}
+	return bars
+}
+
+// wave is a deterministic non-trivial series (trend + oscillation)
+func wave(n int) []Bar {
+	bars := make([]Bar, n)
+	for i := range bars {
+		mid := 1.10 + 0.0005*float64(i) + 0.01*math.Sin(float64(i)/3)
+		bars[i] = Bar{Open: mid - 0.002, High: mid + 0.006, Low: mid - 0.005, Close: mid + 0.001}
+	}
+	return bars
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRSI(t *testing.T) {
+	// changes +1, -1, +1: first RSI = 50, then gain 0.75 / loss 0.25 -> 75
+	got := RSI(closesToBars(1, 2, 1, 2), 2)
+	if !math.IsNaN(got[1]) {
+		t.Errorf("expected NaN before warm-up, got %v", got[1])
+	}
+	if !near(got[2], 50) || !near(got[3], 75) {
+		t.Errorf("expected [50 75], got %v", got[2:])
+	}
+
+	rising := RSI(closesToBars(1, 2, 3, 4, 5), 2)
+	if rising[4] != 100 {
+		t.Errorf("expected 100 without losses, got %v", rising[4])
+	}
+}
+
+func TestATR(t *testing.T) {
+	bars := []Bar{
+		{High: 2, Low: 1, Close: 1.5},
+		{High: 3, Low: 2, Close: 2.5},   // TR 1.5 (high - prev close)
+		{High: 2.5, Low: 2, Close: 2.2}, // TR 0.5
+		{High: 4, Low: 3, Close: 3.5},   // TR 1.8
+	}
+
+	got := ATR(bars, 2)
+	if !math.IsNaN(got[1]) {
+		t.Errorf("expected NaN before warm-up, got %v", got[1])
+	}
+	if !near(got[2], 1.0) || !near(got[3], 1.4) {
+		t.Errorf("expected [1.0 1.4], got %v", got[2:])
+	}
+}
+
+func TestADX_StrongTrend(t *testing.T) {
+	bars := make([]Bar, 40)
+	for i := range bars {
+		p := 1 + 0.01*float64(i)
+		bars[i] = Bar{Open: p, High: p + 0.005, Low: p - 0.005, Close: p}
+	}
+
+	got := ADX(bars, 14)
+	if !near(got[len(got)-1], 100) {
+		t.Errorf("expected ADX 100 in a one-way trend, got %v", got[len(got)-1])
+	}
+
+	for i, v := range ADX(wave(120), 14) {
+		if !math.IsNaN(v) && (v < 0 || v > 100) {
+			t.Fatalf("bar %d: ADX %v out of range", i, v)
+		}
+	}
+}
+
+func TestMACDSeries_Flat(t *testing.T) {
+	closes := make([]float64, 50)
+	for i := range closes {
+		closes[i] = 1.1
+	}
+	bars := closesToBars(closes...)
+
+	line, signal, hist := MACDSeries(bars, 12, 26, 9)
+	last := len(bars) - 1
+	if !near(line[last], 0) || !near(signal[last], 0) || !near(hist[last], 0) {
+		t.Errorf("expected zero MACD on a flat series, got %v %v %v", line[last], signal[last], hist[last])
+	}
+	if !math.IsNaN(line[24]) || math.IsNaN(line[25]) {
+		t.Errorf("expected MACD line from bar 25, got %v %v", line[24], line[25])
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	upper, middle, lower := Bollinger(closesToBars(1, 2, 3), 3, 2)
+
+	sd := math.Sqrt(2.0 / 3.0) // population σ of 1, 2, 3
+	if !near(middle[2], 2) || !near(upper[2], 2+2*sd) || !near(lower[2], 2-2*sd) {
+		t.Errorf("unexpected bands %v %v %v", upper[2], middle[2], lower[2])
+	}
+	if !math.IsNaN(middle[1]) {
+		t.Errorf("expected NaN before warm-up, got %v", middle[1])
+	}
+}
+
+// Every registered indicator is fully valid exactly from its lookback on
+func TestRegistry_Lookback(t *testing.T) {
+	bars := wave(100)
+
+	for name, spec := range Registry {
+		series := spec.Compute(bars)
+		if len(series) != len(spec.Outputs) {
+			t.Errorf("%s: expected %d outputs, got %d", name, len(spec.Outputs), len(series))
+		}
+
+		lastWarm := -1
+		for _, out := range spec.Outputs {
+			values, ok := series[out]
+			if !ok {
+				t.Errorf("%s: output %s not computed", name, out)
+				continue
+			}
+			first := len(values)
+			for i, v := range values {
+				if !math.IsNaN(v) {
+					first = i
+					break
+				}
+			}
+			for i := first; i < len(values); i++ {
+				if math.IsNaN(values[i]) {
+					t.Errorf("%s: NaN at bar %d after warm-up", out, i)
+					break
+				}
+			}
+			if first > lastWarm {
+				lastWarm = first
+			}
+		}
+		if lastWarm != spec.Lookback-1 {
+			t.Errorf("%s: fully valid from bar %d, expected %d", name, lastWarm, spec.Lookback-1)
+		}
+	}
+}
+
+func TestComputeLatest(t *testing.T) {
+	short := ComputeLatest(wave(20))
+	if _, ok := short[BollingerMid]; !ok {
+		t.Errorf("expected %s after 20 bars, got %v", BollingerMid, short)
+	}
+	if _, ok := short[ADX14]; ok {
+		t.Errorf("expected %s omitted before warm-up", ADX14)
+	}
+
+	full := ComputeLatest(wave(MaxLookback()))
+	for _, out := range Outputs() {
+		if _, ok := full[out]; !ok {
+			t.Errorf("expected %s after %d bars", out, MaxLookback())
+		}
+	}
+}
+
+func TestRegister_Rejects(t *testing.T) {
+	compute := func([]Bar) map[string][]float64 { return nil }
+
+	tests := []struct {
+		name string
+		spec Spec
+	}{
+		{"duplicate name", Spec{Name: "rsi", Lookback: 1, Outputs: []string{"x_new"}, Compute: compute}},
+		{"duplicate output", Spec{Name: "rsi_fast", Lookback: 1, Outputs: []string{RSI14}, Compute: compute}},
+		{"no outputs", Spec{Name: "empty", Lookback: 1, Compute: compute}},
+		{"no lookback", Spec{Name: "nolookback", Outputs: []string{"nolookback"}, Compute: compute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Register(tt.spec); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
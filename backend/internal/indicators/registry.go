@@ -0,0 +1,109 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Bar is the OHLC input of an indicator
+type Bar struct {
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// Spec describes a registered indicator.
+// Output names encode the parameters (e.g. "rsi14", "macd_12_26_9"): changing
+// a parameter means a new name, so rules referencing an output keep their meaning.
+type Spec struct {
+	Name     string
+	Params   map[string]float64
+	Lookback int      // bars needed before every output is valid
+	Outputs  []string // field names conditions can reference
+	// Compute returns one value per bar for every output, NaN until warmed up.
+	// Bars are oldest first. PURE: no state between calls.
+	Compute func(bars []Bar) map[string][]float64
+}
+
+// Registry holds every registered indicator by name
+var Registry = map[string]Spec{}
+
+// outputOwners maps an output field name to the indicator that produces it
+var outputOwners = map[string]string{}
+
+// Register adds an indicator. Names and output names must be unique.
+func Register(spec Spec) error {
+	if spec.Name == "" || spec.Compute == nil || len(spec.Outputs) == 0 {
+		return fmt.Errorf("indicator %q: name, outputs and compute are required", spec.Name)
+	}
+	if spec.Lookback < 1 {
+		return fmt.Errorf("indicator %s: lookback must be >= 1", spec.Name)
+	}
+	if _, exists := Registry[spec.Name]; exists {
+		return fmt.Errorf("indicator %s already registered", spec.Name)
+	}
+	for _, out := range spec.Outputs {
+		if owner, exists := outputOwners[out]; exists {
+			return fmt.Errorf("indicator %s: output %s already produced by %s", spec.Name, out, owner)
+		}
+	}
+
+	Registry[spec.Name] = spec
+	for _, out := range spec.Outputs {
+		outputOwners[out] = spec.Name
+	}
+	return nil
+}
+
+func mustRegister(spec Spec) {
+	if err := Register(spec); err != nil {
+		panic(err)
+	}
+}
+
+// IsOutput reports whether a field name is produced by a registered indicator
+func IsOutput(field string) bool {
+	_, ok := outputOwners[field]
+	return ok
+}
+
+// Outputs returns every registered output field name, sorted
+func Outputs() []string {
+	names := make([]string, 0, len(outputOwners))
+	for name := range outputOwners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MaxLookback is the longest lookback of any registered indicator
+func MaxLookback() int {
+	max := 0
+	for _, spec := range Registry {
+		if spec.Lookback > max {
+			max = spec.Lookback
+		}
+	}
+	return max
+}
+
+// ComputeLatest computes every registered indicator over bars and returns the
+// valid values of the last bar. Outputs that are not warmed up are omitted.
+func ComputeLatest(bars []Bar) map[string]float64 {
+	values := make(map[string]float64)
+	if len(bars) == 0 {
+		return values
+	}
+
+	for _, spec := range Registry {
+		for name, series := range spec.Compute(bars) {
+			if v := series[len(series)-1]; !math.IsNaN(v) {
+				values[name] = v
+			}
+		}
+	}
+	return values
+}
@@ -23,7 +23,8 @@ type Account struct {
 	Type               string    `json:"type"`
 	BrokerName         string    `json:"broker_name"`
 	Currency           string    `json:"currency"`
-	Balance            string    `json:"balance"`
+	Balance            string    `json:"balance"`         // current: sum of the ledger
+	OpeningBalance     string    `json:"opening_balance"` // accounts.balance
 	Leverage           int       `json:"leverage"`
 	MaxRiskPerTradePct float64   `json:"max_risk_per_trade_pct"`
 	MaxDailyRiskPct    float64   `json:"max_daily_risk_pct"`
@@ -82,7 +83,8 @@ func (r *AccountRepository) CreateAccount(ctx context.Context, params AccountCre
 		Type:               string(account.Type),
 		BrokerName:         account.BrokerName,
 		Currency:           account.Currency,
-		Balance:            account.Balance.String(),
+		Balance:            account.Balance.String(), // opening deposit is the only ledger entry
+		OpeningBalance:     account.Balance.String(),
 		Leverage:           int(account.Leverage),
 		MaxRiskPerTradePct: account.MaxRiskPerTradePct.InexactFloat64(),
 		MaxDailyRiskPct:    account.MaxDailyRiskPct.InexactFloat64(),
@@ -99,13 +101,20 @@ func (r *AccountRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*
 		return nil, err
 	}
 
+	// Current balance is derived from the ledger, never stored
+	balance, err := r.q.GetAccountLedgerBalance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Account{
 		ID:                 acc.ID,
 		UserID:             acc.UserID,
 		Type:               string(acc.Type),
 		BrokerName:         acc.BrokerName,
 		Currency:           acc.Currency,
-		Balance:            acc.Balance.String(),
+		Balance:            balance.String(),
+		OpeningBalance:     acc.Balance.String(),
 		Leverage:           int(acc.Leverage),
 		MaxRiskPerTradePct: acc.MaxRiskPerTradePct.InexactFloat64(),
 		MaxDailyRiskPct:    acc.MaxDailyRiskPct.InexactFloat64(),
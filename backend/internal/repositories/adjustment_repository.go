@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type AdjustmentRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAdjustmentRepository(pool *pgxpool.Pool) *AdjustmentRepository {
+	return &AdjustmentRepository{pool: pool}
+}
+
+// TradeAdjustment is one append-only move of a trade's SL or TP
+type TradeAdjustment struct {
+	ID             uuid.UUID  `json:"id"`
+	TradeID        uuid.UUID  `json:"trade_id"`
+	AdjustmentType string     `json:"adjustment_type"`
+	PreviousPrice  string     `json:"previous_price"`
+	NewPrice       string     `json:"new_price"`
+	MarketPrice    string     `json:"market_price"`
+	SwingPointID   *uuid.UUID `json:"swing_point_id,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	AdjustedAt     time.Time  `json:"adjusted_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateAdjustmentParams contains parameters for recording an adjustment
+type CreateAdjustmentParams struct {
+	TradeID        uuid.UUID
+	AdjustmentType string
+	PreviousPrice  float64
+	NewPrice       float64
+	MarketPrice    float64
+	SwingPointID   *uuid.UUID // trail_to_swing only
+	Reason         *string
+	AdjustedAt     time.Time
+}
+
+const adjustmentColumns = `id, trade_id, adjustment_type::text, previous_price::text, new_price::text,
+	market_price::text, swing_point_id, reason, adjusted_at, created_at`
+
+// CreateAdjustmentTx records an adjustment within a transaction
+func (r *AdjustmentRepository) CreateAdjustmentTx(ctx context.Context, tx pgx.Tx, params CreateAdjustmentParams) (*TradeAdjustment, error) {
+	row := tx.QueryRow(ctx, `
+		INSERT INTO trade_adjustments (
+			trade_id, adjustment_type, previous_price, new_price, market_price,
+			swing_point_id, reason, adjusted_at, created_at
+		)
+		VALUES ($1, $2::trade_adjustment_type, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING `+adjustmentColumns,
+		params.TradeID, params.AdjustmentType,
+		decimal.NewFromFloat(params.PreviousPrice).String(),
+		decimal.NewFromFloat(params.NewPrice).String(),
+		decimal.NewFromFloat(params.MarketPrice).String(),
+		params.SwingPointID, params.Reason, params.AdjustedAt)
+
+	adjustment, err := scanAdjustment(row)
+	if err != nil {
+		return nil, fmt.Errorf("create adjustment (tx): %w", err)
+	}
+
+	return adjustment, nil
+}
+
+// UpdateTradeLevelsTx writes the SL and TP in force to trades.actual_sl/actual_tp
+func (r *AdjustmentRepository) UpdateTradeLevelsTx(ctx context.Context, tx pgx.Tx, tradeID uuid.UUID, sl, tp float64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE trades SET actual_sl = $2, actual_tp = $3 WHERE id = $1
+	`, tradeID, decimal.NewFromFloat(sl).String(), decimal.NewFromFloat(tp).String())
+	if err != nil {
+		return fmt.Errorf("update trade levels (tx): %w", err)
+	}
+
+	return nil
+}
+
+// GetAdjustmentsByTradeID retrieves a trade's adjustments in the order they were made
+func (r *AdjustmentRepository) GetAdjustmentsByTradeID(ctx context.Context, tradeID uuid.UUID) ([]TradeAdjustment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+adjustmentColumns+`
+		FROM trade_adjustments
+		WHERE trade_id = $1
+		ORDER BY adjusted_at ASC, created_at ASC
+	`, tradeID)
+	if err != nil {
+		return nil, fmt.Errorf("query adjustments: %w", err)
+	}
+
+	return collectAdjustments(rows)
+}
+
+// GetAdjustmentsByTradeIDTx retrieves a trade's adjustments within a transaction
+func (r *AdjustmentRepository) GetAdjustmentsByTradeIDTx(ctx context.Context, tx pgx.Tx, tradeID uuid.UUID) ([]TradeAdjustment, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+adjustmentColumns+`
+		FROM trade_adjustments
+		WHERE trade_id = $1
+		ORDER BY adjusted_at ASC, created_at ASC
+	`, tradeID)
+	if err != nil {
+		return nil, fmt.Errorf("query adjustments (tx): %w", err)
+	}
+
+	return collectAdjustments(rows)
+}
+
+func collectAdjustments(rows pgx.Rows) ([]TradeAdjustment, error) {
+	defer rows.Close()
+
+	adjustments := []TradeAdjustment{}
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan adjustment: %w", err)
+		}
+		adjustments = append(adjustments, *adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+func scanAdjustment(row pgx.Row) (*TradeAdjustment, error) {
+	var a TradeAdjustment
+	err := row.Scan(
+		&a.ID,
+		&a.TradeID,
+		&a.AdjustmentType,
+		&a.PreviousPrice,
+		&a.NewPrice,
+		&a.MarketPrice,
+		&a.SwingPointID,
+		&a.Reason,
+		&a.AdjustedAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+type AnalyticsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAnalyticsRepository(pool *pgxpool.Pool) *AnalyticsRepository {
+	return &AnalyticsRepository{pool: pool}
+}
+
+// ClosedTrade is a closed trade with its full execution history
+type ClosedTrade struct {
+	TradeID      uuid.UUID
+	CandleID     uuid.UUID
+	Symbol       string
+	Bias         string
+	Rate         string // quote → account currency, snapshotted at setup
+	PlannedEntry string
+	PlannedSL    string
+	PlannedTP    string
+	Feedback     *TradeFeedback   // nil until the journal entry is written
+	Executions   []TradeExecution // executed_at order
+}
+
+// GetClosedTrades returns the account's trades closed in [from, to)
+func (r *AnalyticsRepository) GetClosedTrades(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]ClosedTrade, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.id, t.candle_id, t.symbol, t.bias::text, t.quote_to_account_rate_at_setup,
+			t.planned_entry, t.planned_sl, t.planned_tp,
+			f.followed_plan, f.emotion_before::text, f.emotion_during::text, f.emotion_after::text,
+			e.id, e.event_type::text, e.price, e.position_size, e.executed_at, e.session::text
+		FROM trades t
+		JOIN trade_executions e ON e.trade_id = t.id
+		LEFT JOIN trade_feedback f ON f.trade_id = t.id
+		WHERE t.account_id = $1
+		  AND EXISTS (
+			SELECT 1 FROM trade_executions c
+			WHERE c.trade_id = t.id
+			  AND c.event_type IN ('tp_hit', 'sl_hit', 'manual_close')
+			  AND c.executed_at >= $2
+			  AND c.executed_at < $3
+		  )
+		ORDER BY t.id, e.executed_at ASC
+	`, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query closed trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []ClosedTrade
+	for rows.Next() {
+		var t ClosedTrade
+		var exec TradeExecution
+		var followedPlan *bool
+		var emotionBefore, emotionDuring, emotionAfter *string
+		err := rows.Scan(
+			&t.TradeID,
+			&t.CandleID,
+			&t.Symbol,
+			&t.Bias,
+			&t.Rate,
+			&t.PlannedEntry,
+			&t.PlannedSL,
+			&t.PlannedTP,
+			&followedPlan,
+			&emotionBefore,
+			&emotionDuring,
+			&emotionAfter,
+			&exec.ID,
+			&exec.EventType,
+			&exec.Price,
+			&exec.PositionSize,
+			&exec.ExecutedAt,
+			&exec.Session,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan closed trade: %w", err)
+		}
+		exec.TradeID = t.TradeID
+		if followedPlan != nil {
+			t.Feedback = &TradeFeedback{
+				TradeID:       t.TradeID,
+				FollowedPlan:  *followedPlan,
+				EmotionBefore: *emotionBefore,
+				EmotionDuring: *emotionDuring,
+				EmotionAfter:  *emotionAfter,
+			}
+		}
+
+		if n := len(trades); n > 0 && trades[n-1].TradeID == t.TradeID {
+			trades[n-1].Executions = append(trades[n-1].Executions, exec)
+			continue
+		}
+		t.Executions = []TradeExecution{exec}
+		trades = append(trades, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return trades, nil
+}
+
+// GetActiveRuleResults returns the active-version rule results of the given
+// candles: candle_id -> rule code -> PASS / FAIL / INSUFFICIENT_DATA
+func (r *AnalyticsRepository) GetActiveRuleResults(ctx context.Context, candleIDs []uuid.UUID) (map[uuid.UUID]map[string]string, error) {
+	results := make(map[uuid.UUID]map[string]string)
+	if len(candleIDs) == 0 {
+		return results, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT rr.candle_id, r.code, rr.result::text
+		FROM rule_results rr
+		JOIN rules r ON r.id = rr.rule_id
+		JOIN rule_versions rv ON rv.rule_id = rr.rule_id
+			AND rv.version = rr.rule_version
+			AND rv.is_active
+		WHERE rr.candle_id = ANY($1)
+	`, candleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query rule results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var candleID uuid.UUID
+		var code, result string
+		if err := rows.Scan(&candleID, &code, &result); err != nil {
+			return nil, fmt.Errorf("scan rule result: %w", err)
+		}
+		if results[candleID] == nil {
+			results[candleID] = make(map[string]string)
+		}
+		results[candleID][code] = result
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetScaleOutPlans returns the scale-out plans of the given trades, legs in
+// order. Trades without a plan are absent.
+func (r *AnalyticsRepository) GetScaleOutPlans(ctx context.Context, tradeIDs []uuid.UUID) (map[uuid.UUID]domain.ScaleOutPlan, error) {
+	plans := make(map[uuid.UUID]domain.ScaleOutPlan)
+	if len(tradeIDs) == 0 {
+		return plans, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT trade_id, close_pct::float8, target_r::float8
+		FROM trade_scale_out_legs
+		WHERE trade_id = ANY($1)
+		ORDER BY trade_id, leg ASC
+	`, tradeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query scale-out plans: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tradeID uuid.UUID
+		var leg domain.ScaleOutLeg
+		if err := rows.Scan(&tradeID, &leg.ClosePct, &leg.TargetR); err != nil {
+			return nil, fmt.Errorf("scan scale-out leg: %w", err)
+		}
+		plans[tradeID] = append(plans[tradeID], leg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return plans, nil
+}
+
+// AccountExecution is an execution with the symbol, bias and conversion
+// rate of its trade
+type AccountExecution struct {
+	TradeExecution
+	Symbol string
+	Bias   string
+	Rate   string // quote → account currency, snapshotted at setup
+}
+
+// GetAccountExecutions returns every execution of the account's trades,
+// oldest first
+func (r *AnalyticsRepository) GetAccountExecutions(ctx context.Context, accountID uuid.UUID) ([]AccountExecution, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT e.id, e.trade_id, e.event_type::text, e.price, e.position_size, e.executed_at,
+			t.symbol, t.bias::text, t.quote_to_account_rate_at_setup
+		FROM trade_executions e
+		JOIN trades t ON t.id = e.trade_id
+		WHERE t.account_id = $1
+		ORDER BY e.executed_at ASC, e.created_at ASC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("query account executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []AccountExecution
+	for rows.Next() {
+		var exec AccountExecution
+		err := rows.Scan(
+			&exec.ID,
+			&exec.TradeID,
+			&exec.EventType,
+			&exec.Price,
+			&exec.PositionSize,
+			&exec.ExecutedAt,
+			&exec.Symbol,
+			&exec.Bias,
+			&exec.Rate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan account execution: %w", err)
+		}
+		executions = append(executions, exec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return executions, nil
+}
@@ -20,6 +20,7 @@ func NewCandleRepository(q *db.Queries) *CandleRepository {
 
 type Candle struct {
 	ID           uuid.UUID `json:"id"`
+	Symbol       string    `json:"symbol"`
 	TimestampUTC time.Time `json:"timestamp_utc"`
 	Open         string    `json:"open"`
 	High         string    `json:"high"`
@@ -31,6 +32,7 @@ type Candle struct {
 
 type CandleCreateParams struct {
 	ID           uuid.UUID
+	Symbol       string
 	TimestampUTC time.Time
 	Open         string
 	High         string
@@ -70,6 +72,7 @@ func (r *CandleRepository) CreateCandle(ctx context.Context, params CandleCreate
 
 	candle, err := r.q.CreateCandle(ctx, db.CreateCandleParams{
 		ID:           params.ID,
+		Symbol:       params.Symbol,
 		TimestampUtc: timestampPg,
 		Open:         openDec,
 		High:         highDec,
@@ -90,6 +93,7 @@ func (r *CandleRepository) CreateCandle(ctx context.Context, params CandleCreate
 
 	return &Candle{
 		ID:           candle.ID,
+		Symbol:       candle.Symbol,
 		TimestampUTC: candle.TimestampUtc.Time,
 		Open:         candle.Open.String(),
 		High:         candle.High.String(),
@@ -100,8 +104,11 @@ func (r *CandleRepository) CreateCandle(ctx context.Context, params CandleCreate
 	}, nil
 }
 
-func (r *CandleRepository) GetLatestCandles(ctx context.Context, limit int) ([]Candle, error) {
-	dbCandles, err := r.q.GetLatestCandles(ctx, int32(limit))
+func (r *CandleRepository) GetLatestCandles(ctx context.Context, symbol string, limit int) ([]Candle, error) {
+	dbCandles, err := r.q.GetLatestCandles(ctx, db.GetLatestCandlesParams{
+		Symbol: symbol,
+		Limit:  int32(limit),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +123,7 @@ func (r *CandleRepository) GetLatestCandles(ctx context.Context, limit int) ([]C
 
 		candles[i] = Candle{
 			ID:           c.ID,
+			Symbol:       c.Symbol,
 			TimestampUTC: c.TimestampUtc.Time,
 			Open:         c.Open.String(),
 			High:         c.High.String(),
@@ -142,6 +150,7 @@ func (r *CandleRepository) GetCandleByID(ctx context.Context, id uuid.UUID) (*Ca
 
 	return &Candle{
 		ID:           dbCandle.ID,
+		Symbol:       dbCandle.Symbol,
 		TimestampUTC: dbCandle.TimestampUtc.Time,
 		Open:         dbCandle.Open.String(),
 		High:         dbCandle.High.String(),
@@ -151,3 +160,99 @@ func (r *CandleRepository) GetCandleByID(ctx context.Context, id uuid.UUID) (*Ca
 		CreatedAt:    dbCandle.CreatedAt.Time,
 	}, nil
 }
+
+// GetAllCandlesOrdered returns every candle, one symbol after the other, oldest first
+func (r *CandleRepository) GetAllCandlesOrdered(ctx context.Context) ([]Candle, error) {
+	dbCandles, err := r.q.GetAllCandlesOrdered(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, len(dbCandles))
+	for i, c := range dbCandles {
+		var volume *int64
+		if c.Volume.Valid {
+			v := c.Volume.Int64
+			volume = &v
+		}
+
+		candles[i] = Candle{
+			ID:           c.ID,
+			Symbol:       c.Symbol,
+			TimestampUTC: c.TimestampUtc.Time,
+			Open:         c.Open.String(),
+			High:         c.High.String(),
+			Low:          c.Low.String(),
+			Close:        c.Close.String(),
+			Volume:       volume,
+			CreatedAt:    c.CreatedAt.Time,
+		}
+	}
+	return candles, nil
+}
+
+// GetCandlesBySymbol returns every candle of a symbol, oldest first
+func (r *CandleRepository) GetCandlesBySymbol(ctx context.Context, symbol string) ([]Candle, error) {
+	dbCandles, err := r.q.GetCandlesBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, len(dbCandles))
+	for i, c := range dbCandles {
+		var volume *int64
+		if c.Volume.Valid {
+			v := c.Volume.Int64
+			volume = &v
+		}
+
+		candles[i] = Candle{
+			ID:           c.ID,
+			Symbol:       c.Symbol,
+			TimestampUTC: c.TimestampUtc.Time,
+			Open:         c.Open.String(),
+			High:         c.High.String(),
+			Low:          c.Low.String(),
+			Close:        c.Close.String(),
+			Volume:       volume,
+			CreatedAt:    c.CreatedAt.Time,
+		}
+	}
+	return candles, nil
+}
+
+// GetCandlesUpTo returns the last `limit` candles of a symbol at or before
+// timestamp, oldest first
+func (r *CandleRepository) GetCandlesUpTo(ctx context.Context, symbol string, timestamp time.Time, limit int) ([]Candle, error) {
+	dbCandles, err := r.q.GetCandlesUpTo(ctx, db.GetCandlesUpToParams{
+		Symbol:       symbol,
+		TimestampUtc: pgtype.Timestamptz{Time: timestamp, Valid: true},
+		Limit:        int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, len(dbCandles))
+	for i, c := range dbCandles {
+		var volume *int64
+		if c.Volume.Valid {
+			v := c.Volume.Int64
+			volume = &v
+		}
+
+		// Query is newest first: fill from the end
+		candles[len(dbCandles)-1-i] = Candle{
+			ID:           c.ID,
+			Symbol:       c.Symbol,
+			TimestampUTC: c.TimestampUtc.Time,
+			Open:         c.Open.String(),
+			High:         c.High.String(),
+			Low:          c.Low.String(),
+			Close:        c.Close.String(),
+			Volume:       volume,
+			CreatedAt:    c.CreatedAt.Time,
+		}
+	}
+	return candles, nil
+}
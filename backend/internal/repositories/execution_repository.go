@@ -32,6 +32,7 @@ type TradeExecution struct {
 	Session      *string    `json:"session,omitempty"`
 	Reason       *string    `json:"reason,omitempty"`
 	SlippagePips *string    `json:"slippage_pips,omitempty"`
+	Source       string     `json:"source"` // user, or system (monitor)
 	CreatedAt    time.Time  `json:"created_at"`
 }
 
@@ -47,6 +48,7 @@ type CreateExecutionParams struct {
 	Session      *string
 	Reason       *string
 	SlippagePips *float64
+	Source       string // user or system
 }
 
 // CreateExecution inserts a new execution event
@@ -80,14 +82,14 @@ func (r *ExecutionRepository) CreateExecution(ctx context.Context, params Create
 	err := r.pool.QueryRow(ctx, `
 		INSERT INTO trade_executions (
 			id, trade_id, event_type, price, position_size, 
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
 		RETURNING id, trade_id, event_type, price, position_size, 
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 	`, uuid.New(), params.TradeID, params. EventType, 
 		priceStr, sizeStr, params.ExecutedAt, params.Session, params.Reason, 
-		slippageStr, pnlStr, pnlPipsStr).Scan(
+		slippageStr, pnlStr, pnlPipsStr, params.Source).Scan(
 		&exec.ID,
 		&exec.TradeID,
 		&exec.EventType,
@@ -99,6 +101,7 @@ func (r *ExecutionRepository) CreateExecution(ctx context.Context, params Create
 		&exec.SlippagePips,
 		&exec.PnL,
 		&exec.PnLPips,
+		&exec.Source,
 		&exec.CreatedAt,
 	)
 	
@@ -113,7 +116,7 @@ func (r *ExecutionRepository) CreateExecution(ctx context.Context, params Create
 func (r *ExecutionRepository) GetExecutionsByTradeID(ctx context.Context, tradeID uuid.UUID) ([]TradeExecution, error) {
 	rows, err := r.pool.Query(ctx, `
 		SELECT id, trade_id, event_type, price, position_size,
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 		FROM trade_executions
 		WHERE trade_id = $1
 		ORDER BY executed_at ASC
@@ -138,6 +141,7 @@ func (r *ExecutionRepository) GetExecutionsByTradeID(ctx context.Context, tradeI
 			&exec.SlippagePips,
 			&exec. PnL,
 			&exec.PnLPips,
+			&exec.Source,
 			&exec.CreatedAt,
 		)
 		if err != nil {
@@ -183,14 +187,14 @@ func (r *ExecutionRepository) CreateExecutionTx(ctx context.Context, tx pgx.Tx,
 	err := tx.QueryRow(ctx, `
 		INSERT INTO trade_executions (
 			id, trade_id, event_type, price, position_size, 
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
 		RETURNING id, trade_id, event_type, price, position_size, 
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 	`, uuid.New(), params.TradeID, params. EventType, 
 		priceStr, sizeStr, params. ExecutedAt, params.Session, params.Reason, 
-		slippageStr, pnlStr, pnlPipsStr).Scan(
+		slippageStr, pnlStr, pnlPipsStr, params.Source).Scan(
 		&exec.ID,
 		&exec. TradeID,
 		&exec.EventType,
@@ -202,6 +206,7 @@ func (r *ExecutionRepository) CreateExecutionTx(ctx context.Context, tx pgx.Tx,
 		&exec.SlippagePips,
 		&exec.PnL,
 		&exec.PnLPips,
+		&exec.Source,
 		&exec.CreatedAt,
 	)
 	
@@ -216,7 +221,7 @@ func (r *ExecutionRepository) CreateExecutionTx(ctx context.Context, tx pgx.Tx,
 func (r *ExecutionRepository) GetExecutionsByTradeIDTx(ctx context. Context, tx pgx.Tx, tradeID uuid.UUID) ([]TradeExecution, error) {
 	rows, err := tx.Query(ctx, `
 		SELECT id, trade_id, event_type, price, position_size,
-			executed_at, session, reason, slippage_pips, pnl, pnl_pips, created_at
+			executed_at, session, reason, slippage_pips, pnl, pnl_pips, source, created_at
 		FROM trade_executions
 		WHERE trade_id = $1
 		ORDER BY executed_at ASC
@@ -241,6 +246,7 @@ func (r *ExecutionRepository) GetExecutionsByTradeIDTx(ctx context. Context, tx
 			&exec.SlippagePips,
 			&exec.PnL,
 			&exec.PnLPips,
+			&exec.Source,
 			&exec.CreatedAt,
 		)
 		if err != nil {
@@ -255,3 +261,98 @@ func (r *ExecutionRepository) GetExecutionsByTradeIDTx(ctx context. Context, tx
 	
 	return executions, nil
 }
+
+// OpenTrade is an entered trade that is not closed yet, with its levels in force
+type OpenTrade struct {
+	TradeID        uuid.UUID
+	Bias           string
+	SL             string // actual_sl, or planned_sl when never moved
+	TP             string // actual_tp, or planned_tp when never moved
+	LastExecutedAt time.Time
+}
+
+// GetOpenTradesBySymbol returns the open and partial trades on a symbol whose
+// executions and SL/TP adjustments all happened at or before asOf
+func (r *ExecutionRepository) GetOpenTradesBySymbol(ctx context.Context, symbol string, asOf time.Time) ([]OpenTrade, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.id, t.bias::text,
+			COALESCE(t.actual_sl, t.planned_sl), COALESCE(t.actual_tp, t.planned_tp),
+			MAX(e.executed_at)
+		FROM trades t
+		JOIN trade_executions e ON e.trade_id = t.id
+		WHERE t.symbol = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM trade_adjustments a
+			WHERE a.trade_id = t.id AND a.adjusted_at > $2
+		  )
+		GROUP BY t.id
+		HAVING bool_or(e.event_type = 'entry')
+		   AND NOT bool_or(e.event_type IN ('tp_hit', 'sl_hit', 'manual_close'))
+		   AND MAX(e.executed_at) <= $2
+		ORDER BY t.id
+	`, symbol, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("query open trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []OpenTrade
+	for rows.Next() {
+		var t OpenTrade
+		if err := rows.Scan(&t.TradeID, &t.Bias, &t.SL, &t.TP, &t.LastExecutedAt); err != nil {
+			return nil, fmt.Errorf("scan open trade: %w", err)
+		}
+		trades = append(trades, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return trades, nil
+}
+
+// PendingOrder is a planned limit or stop order not yet entered, cancelled or
+// invalidated
+type PendingOrder struct {
+	TradeID      uuid.UUID
+	Bias         string
+	OrderType    string
+	PlannedEntry string
+	PlannedSL    string
+	ExpiresAt    *time.Time // nil = good till cancelled
+}
+
+// GetPendingOrdersBySymbol returns the pending orders on a symbol placed at or
+// before asOf, oldest first
+func (r *ExecutionRepository) GetPendingOrdersBySymbol(ctx context.Context, symbol string, asOf time.Time) ([]PendingOrder, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.id, t.bias::text, t.order_type::text, t.planned_entry, t.planned_sl, t.expires_at
+		FROM trades t
+		WHERE t.symbol = $1
+		  AND t.order_type <> 'market'
+		  AND t.setup_timestamp_utc <= $2
+		  AND NOT EXISTS (SELECT 1 FROM trade_executions e WHERE e.trade_id = t.id)
+		  AND NOT EXISTS (SELECT 1 FROM trade_intents i WHERE i.trade_id = t.id)
+		ORDER BY t.setup_timestamp_utc, t.id
+	`, symbol, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("query pending orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []PendingOrder
+	for rows.Next() {
+		var o PendingOrder
+		if err := rows.Scan(&o.TradeID, &o.Bias, &o.OrderType, &o.PlannedEntry, &o.PlannedSL, &o.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan pending order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FeedbackRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewFeedbackRepository(pool *pgxpool.Pool) *FeedbackRepository {
+	return &FeedbackRepository{pool: pool}
+}
+
+// TradeFeedback is the behavioural journal entry of a finished trade
+type TradeFeedback struct {
+	ID             uuid.UUID `json:"id"`
+	TradeID        uuid.UUID `json:"trade_id"`
+	FollowedPlan   bool      `json:"followed_plan"`
+	EmotionBefore  string    `json:"emotion_before"`
+	EmotionDuring  string    `json:"emotion_during"`
+	EmotionAfter   string    `json:"emotion_after"`
+	BiggestMistake *string   `json:"biggest_mistake,omitempty"`
+	ScreenshotURL  *string   `json:"screenshot_url,omitempty"`
+	FeedbackAt     time.Time `json:"feedback_at"`
+}
+
+// FeedbackParams contains the journal fields (create and full update)
+type FeedbackParams struct {
+	TradeID        uuid.UUID
+	FollowedPlan   bool
+	EmotionBefore  string
+	EmotionDuring  string
+	EmotionAfter   string
+	BiggestMistake *string
+	ScreenshotURL  *string
+}
+
+const feedbackColumns = `id, trade_id, followed_plan,
+		emotion_before::text, emotion_during::text, emotion_after::text,
+		biggest_mistake, screenshot_url, feedback_at`
+
+func scanFeedback(row pgx.Row) (*TradeFeedback, error) {
+	var fb TradeFeedback
+	err := row.Scan(
+		&fb.ID,
+		&fb.TradeID,
+		&fb.FollowedPlan,
+		&fb.EmotionBefore,
+		&fb.EmotionDuring,
+		&fb.EmotionAfter,
+		&fb.BiggestMistake,
+		&fb.ScreenshotURL,
+		&fb.FeedbackAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &fb, nil
+}
+
+// CreateFeedback inserts the feedback of a trade.
+// Returns nil (no error) if the trade already has feedback.
+func (r *FeedbackRepository) CreateFeedback(ctx context.Context, params FeedbackParams) (*TradeFeedback, error) {
+	fb, err := scanFeedback(r.pool.QueryRow(ctx, `
+		INSERT INTO trade_feedback (
+			trade_id, followed_plan, emotion_before, emotion_during, emotion_after,
+			biggest_mistake, screenshot_url, feedback_at
+		)
+		VALUES ($1, $2, $3::emotion_type, $4::emotion_type, $5::emotion_type, $6, $7, NOW())
+		ON CONFLICT (trade_id) DO NOTHING
+		RETURNING `+feedbackColumns,
+		params.TradeID, params.FollowedPlan,
+		params.EmotionBefore, params.EmotionDuring, params.EmotionAfter,
+		params.BiggestMistake, params.ScreenshotURL,
+	))
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Feedback already recorded
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("create feedback: %w", err)
+	}
+
+	return fb, nil
+}
+
+// GetFeedbackByTradeID retrieves the feedback of a trade (if exists)
+func (r *FeedbackRepository) GetFeedbackByTradeID(ctx context.Context, tradeID uuid.UUID) (*TradeFeedback, error) {
+	fb, err := scanFeedback(r.pool.QueryRow(ctx, `
+		SELECT `+feedbackColumns+`
+		FROM trade_feedback
+		WHERE trade_id = $1
+	`, tradeID))
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // No feedback yet
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("query feedback: %w", err)
+	}
+
+	return fb, nil
+}
+
+// UpdateFeedback replaces the feedback of a trade.
+// Returns nil (no error) if the trade has no feedback yet.
+func (r *FeedbackRepository) UpdateFeedback(ctx context.Context, params FeedbackParams) (*TradeFeedback, error) {
+	fb, err := scanFeedback(r.pool.QueryRow(ctx, `
+		UPDATE trade_feedback
+		SET followed_plan = $2,
+			emotion_before = $3::emotion_type,
+			emotion_during = $4::emotion_type,
+			emotion_after = $5::emotion_type,
+			biggest_mistake = $6,
+			screenshot_url = $7,
+			feedback_at = NOW()
+		WHERE trade_id = $1
+		RETURNING `+feedbackColumns,
+		params.TradeID, params.FollowedPlan,
+		params.EmotionBefore, params.EmotionDuring, params.EmotionAfter,
+		params.BiggestMistake, params.ScreenshotURL,
+	))
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Nothing to update
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("update feedback: %w", err)
+	}
+
+	return fb, nil
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type FXRateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewFXRateRepository(pool *pgxpool.Pool) *FXRateRepository {
+	return &FXRateRepository{pool: pool}
+}
+
+// FXRate is a daily conversion rate: 1 BaseCurrency = Rate QuoteCurrency
+type FXRate struct {
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	RateDate      time.Time       `json:"rate_date"`
+	Rate          decimal.Decimal `json:"rate"`
+}
+
+// UpsertRate stores a rate, replacing the one of the same pair and day
+func (r *FXRateRepository) UpsertRate(ctx context.Context, rate FXRate) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO fx_rates (base_currency, quote_currency, rate_date, rate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+	`, rate.BaseCurrency, rate.QuoteCurrency, rate.RateDate, rate.Rate.String())
+	if err != nil {
+		return fmt.Errorf("upsert fx rate %s%s %s: %w",
+			rate.BaseCurrency, rate.QuoteCurrency, rate.RateDate.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// GetLatestRate retrieves the most recent rate of base/quote on or before asOf.
+// Returns an error wrapping pgx.ErrNoRows if there is none.
+func (r *FXRateRepository) GetLatestRate(ctx context.Context, base, quote string, asOf time.Time) (*FXRate, error) {
+	var rate FXRate
+	var value string
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT base_currency, quote_currency, rate_date, rate::text
+		FROM fx_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND rate_date <= $3::date
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, base, quote, asOf.UTC()).Scan(
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.RateDate,
+		&value,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get fx rate %s%s: %w", base, quote, err)
+	}
+
+	rate.Rate, err = decimal.NewFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse fx rate %s%s: %w", base, quote, err)
+	}
+	return &rate, nil
+}
@@ -2,11 +2,13 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"set-and-trend/backend/internal/db"
+	"set-and-trend/backend/internal/indicators"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -21,9 +23,9 @@ func NewIndicatorRepository(q *db.Queries) *IndicatorRepository {
 type Indicator struct {
 	ID                 uuid.UUID `json:"id"`
 	CandleID           uuid.UUID `json:"candle_id"`
-	EMA20              string    `json:"ema20"`
-	EMA50              string    `json:"ema50"`
-	EMA200             string    `json:"ema200"`
+	EMA20              *string   `json:"ema20"` // nil until warmed up
+	EMA50              *string   `json:"ema50"`
+	EMA200             *string   `json:"ema200"`
 	RangeSize          string    `json:"range_size"`
 	BodySize           string    `json:"body_size"`
 	UpperWick          string    `json:"upper_wick"`
@@ -32,14 +34,17 @@ type Indicator struct {
 	LastSwingHighPrice *string   `json:"last_swing_high_price,omitempty"`
 	LastSwingLowPrice  *string   `json:"last_swing_low_price,omitempty"`
 	ComputedAt         time.Time `json:"computed_at"`
+
+	// Registry indicator outputs (rsi14, atr14, ...); missing = not warmed up
+	Values map[string]float64 `json:"values,omitempty"`
 }
 
 type IndicatorCreateParams struct {
 	ID                 uuid.UUID
 	CandleID           uuid.UUID
-	EMA20              float64
-	EMA50              float64
-	EMA200             float64
+	EMA20              *float64 // nil = not warmed up (stored as NULL)
+	EMA50              *float64
+	EMA200             *float64
 	RangeSize          float64
 	BodySize           float64
 	UpperWick          float64
@@ -50,68 +55,92 @@ type IndicatorCreateParams struct {
 }
 
 func (r *IndicatorRepository) CreateIndicator(ctx context.Context, params IndicatorCreateParams) (*Indicator, error) {
-	// Convert float64 to decimal.Decimal
-	ema20Dec := decimal.NewFromFloat(params.EMA20)
-	ema50Dec := decimal.NewFromFloat(params.EMA50)
-	ema200Dec := decimal.NewFromFloat(params.EMA200)
-	rangeDec := decimal.NewFromFloat(params.RangeSize)
-	bodyDec := decimal.NewFromFloat(params.BodySize)
-	upperDec := decimal.NewFromFloat(params.UpperWick)
-	lowerDec := decimal.NewFromFloat(params.LowerWick)
-	midDec := decimal.NewFromFloat(params.MidPrice)
-
-	var swingHighDec, swingLowDec decimal.Decimal
-	if params.LastSwingHighPrice != nil {
-		swingHighDec = decimal.NewFromFloat(*params.LastSwingHighPrice)
+	indicator, err := r.q.CreateIndicator(ctx, createIndicatorArgs(params))
+	if err != nil {
+		return nil, err
 	}
-	if params.LastSwingLowPrice != nil {
-		swingLowDec = decimal.NewFromFloat(*params.LastSwingLowPrice)
+
+	return indicatorFromRow(indicator), nil
+}
+
+// UpsertIndicator writes the indicators of a candle, replacing an existing row.
+// Indicators are derived data: recomputing a candle must converge to one row.
+func (r *IndicatorRepository) UpsertIndicator(ctx context.Context, params IndicatorCreateParams) (*Indicator, error) {
+	indicator, err := r.q.UpsertIndicator(ctx, db.UpsertIndicatorParams(createIndicatorArgs(params)))
+	if err != nil {
+		return nil, err
 	}
 
-	indicator, err := r.q.CreateIndicator(ctx, db.CreateIndicatorParams{
+	return indicatorFromRow(indicator), nil
+}
+
+// createIndicatorArgs converts float64 params to decimal.Decimal
+// nil indicators (not warmed up, no swing confirmed yet) are stored as NULL
+func createIndicatorArgs(params IndicatorCreateParams) db.CreateIndicatorParams {
+	return db.CreateIndicatorParams{
 		ID:                 params.ID,
 		CandleID:           params.CandleID,
-		Ema20:              ema20Dec,
-		Ema50:              ema50Dec,
-		Ema200:             ema200Dec,
-		RangeSize:          rangeDec,
-		BodySize:           bodyDec,
-		UpperWick:          upperDec,
-		LowerWick:          lowerDec,
-		MidPrice:           midDec,
-		LastSwingHighPrice: swingHighDec,
-		LastSwingLowPrice:  swingLowDec,
-	})
+		Ema20:              nullDecimalFromFloat(params.EMA20),
+		Ema50:              nullDecimalFromFloat(params.EMA50),
+		Ema200:             nullDecimalFromFloat(params.EMA200),
+		RangeSize:          decimal.NewFromFloat(params.RangeSize),
+		BodySize:           decimal.NewFromFloat(params.BodySize),
+		UpperWick:          decimal.NewFromFloat(params.UpperWick),
+		LowerWick:          decimal.NewFromFloat(params.LowerWick),
+		MidPrice:           decimal.NewFromFloat(params.MidPrice),
+		LastSwingHighPrice: nullDecimalFromFloat(params.LastSwingHighPrice),
+		LastSwingLowPrice:  nullDecimalFromFloat(params.LastSwingLowPrice),
+	}
+}
+
+func nullDecimalFromFloat(v *float64) db.NullDecimal {
+	if v == nil {
+		return db.NullDecimal{}
+	}
+	return db.NullDecimal{Decimal: decimal.NewFromFloat(*v), Valid: true}
+}
+
+func nullDecimalString(nd db.NullDecimal) *string {
+	if !nd.Valid {
+		return nil
+	}
+	s := nd.Decimal.String()
+	return &s
+}
+
+// UpsertIndicatorValues writes the registry indicator outputs of a candle
+func (r *IndicatorRepository) UpsertIndicatorValues(ctx context.Context, candleID uuid.UUID, values map[string]float64) error {
+	for name, value := range values {
+		err := r.q.UpsertIndicatorValue(ctx, db.UpsertIndicatorValueParams{
+			CandleID: candleID,
+			Name:     name,
+			Value:    decimal.NewFromFloat(value),
+		})
+		if err != nil {
+			return fmt.Errorf("upsert indicator value %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetIndicatorValues returns the registry indicator outputs of a candle by name.
+// Rows of indicators that are no longer registered are skipped.
+func (r *IndicatorRepository) GetIndicatorValues(ctx context.Context, candleID uuid.UUID) (map[string]float64, error) {
+	rows, err := r.q.GetIndicatorValuesByCandleID(ctx, candleID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert back to strings
-	var swingHighStr, swingLowStr *string
-	if params.LastSwingHighPrice != nil {
-		s := indicator.LastSwingHighPrice.String()
-		swingHighStr = &s
-	}
-	if params.LastSwingLowPrice != nil {
-		s := indicator.LastSwingLowPrice.String()
-		swingLowStr = &s
+	values := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if !indicators.IsOutput(row.Name) {
+			continue
+		}
+		values[row.Name] = row.Value.InexactFloat64()
 	}
 
-	return &Indicator{
-		ID:                 indicator.ID,
-		CandleID:           indicator.CandleID,
-		EMA20:              indicator.Ema20.String(),
-		EMA50:              indicator.Ema50.String(),
-		EMA200:             indicator.Ema200.String(),
-		RangeSize:          indicator.RangeSize.String(),
-		BodySize:           indicator.BodySize.String(),
-		UpperWick:          indicator.UpperWick.String(),
-		LowerWick:          indicator.LowerWick.String(),
-		MidPrice:           indicator.MidPrice.String(),
-		LastSwingHighPrice: swingHighStr,
-		LastSwingLowPrice:  swingLowStr,
-		ComputedAt:         indicator.ComputedAt.Time,
-	}, nil
+	return values, nil
 }
 
 func (r *IndicatorRepository) GetIndicatorByCandleID(ctx context.Context, candleID uuid.UUID) (*Indicator, error) {
@@ -120,69 +149,100 @@ func (r *IndicatorRepository) GetIndicatorByCandleID(ctx context.Context, candle
 		return nil, err
 	}
 
-	var swingHighStr, swingLowStr *string
-	if indicator.LastSwingHighPrice.String() != "0" {
-		s := indicator.LastSwingHighPrice.String()
-		swingHighStr = &s
-	}
-	if indicator.LastSwingLowPrice.String() != "0" {
-		s := indicator.LastSwingLowPrice.String()
-		swingLowStr = &s
-	}
+	return indicatorFromRow(indicator), nil
+}
 
+func indicatorFromRow(indicator db.IndicatorsWeekly) *Indicator {
 	return &Indicator{
 		ID:                 indicator.ID,
 		CandleID:           indicator.CandleID,
-		EMA20:              indicator.Ema20.String(),
-		EMA50:              indicator.Ema50.String(),
-		EMA200:             indicator.Ema200.String(),
+		EMA20:              nullDecimalString(indicator.Ema20),
+		EMA50:              nullDecimalString(indicator.Ema50),
+		EMA200:             nullDecimalString(indicator.Ema200),
 		RangeSize:          indicator.RangeSize.String(),
 		BodySize:           indicator.BodySize.String(),
 		UpperWick:          indicator.UpperWick.String(),
 		LowerWick:          indicator.LowerWick.String(),
 		MidPrice:           indicator.MidPrice.String(),
-		LastSwingHighPrice: swingHighStr,
-		LastSwingLowPrice:  swingLowStr,
+		LastSwingHighPrice: nullDecimalString(indicator.LastSwingHighPrice),
+		LastSwingLowPrice:  nullDecimalString(indicator.LastSwingLowPrice),
 		ComputedAt:         indicator.ComputedAt.Time,
-	}, nil
+	}
+}
+
+// GetPreviousBarByTimestamp returns the indicator and candle of the bar of
+// the same symbol before timestamp
+func (r *IndicatorRepository) GetPreviousBarByTimestamp(
+	ctx context.Context,
+	symbol string,
+	timestamp time.Time,
+) (*Indicator, *Candle, error) {
+	var timestampPg pgtype.Timestamptz
+	timestampPg.Scan(timestamp)
+
+	row, err := r.q.GetPreviousIndicatorByTimestamp(ctx, db.GetPreviousIndicatorByTimestampParams{
+		Symbol:       symbol,
+		TimestampUtc: timestampPg,
+	})
+	if err != nil {
+		return nil, nil, err // No previous bar (first candle)
+	}
+
+	var volume *int64
+	if row.Volume.Valid {
+		v := row.Volume.Int64
+		volume = &v
+	}
+
+	candle := &Candle{
+		ID:           row.ID_2,
+		Symbol:       row.Symbol,
+		TimestampUTC: row.TimestampUtc.Time,
+		Open:         row.Open.String(),
+		High:         row.High.String(),
+		Low:          row.Low.String(),
+		Close:        row.Close.String(),
+		Volume:       volume,
+		CreatedAt:    row.CreatedAt.Time,
+	}
+
+	return previousRowToIndicator(row), candle, nil
 }
 
 func (r *IndicatorRepository) GetPreviousIndicatorByTimestamp(
 	ctx context.Context,
+	symbol string,
 	timestamp time.Time,
 ) (*Indicator, error) {
 	// Convert timestamp to pgtype.Timestamptz
 	var timestampPg pgtype.Timestamptz
 	timestampPg.Scan(timestamp)
 
-	indicator, err := r.q.GetPreviousIndicatorByTimestamp(ctx, timestampPg)
+	indicator, err := r.q.GetPreviousIndicatorByTimestamp(ctx, db.GetPreviousIndicatorByTimestampParams{
+		Symbol:       symbol,
+		TimestampUtc: timestampPg,
+	})
 	if err != nil {
 		return nil, err // No previous indicator (first candle)
 	}
 
-	var swingHighStr, swingLowStr *string
-	if indicator.LastSwingHighPrice.String() != "0" {
-		s := indicator.LastSwingHighPrice.String()
-		swingHighStr = &s
-	}
-	if indicator.LastSwingLowPrice.String() != "0" {
-		s := indicator.LastSwingLowPrice.String()
-		swingLowStr = &s
-	}
+	return previousRowToIndicator(indicator), nil
+}
 
-	return &Indicator{
-		ID:                 indicator.ID,
-		CandleID:           indicator.CandleID,
-		EMA20:              indicator.Ema20.String(),
-		EMA50:              indicator.Ema50.String(),
-		EMA200:             indicator.Ema200.String(),
-		RangeSize:          indicator.RangeSize.String(),
-		BodySize:           indicator.BodySize.String(),
-		UpperWick:          indicator.UpperWick.String(),
-		LowerWick:          indicator.LowerWick.String(),
-		MidPrice:           indicator.MidPrice.String(),
-		LastSwingHighPrice: swingHighStr,
-		LastSwingLowPrice:  swingLowStr,
-		ComputedAt:         indicator.ComputedAt.Time,
-	}, nil
+func previousRowToIndicator(row db.GetPreviousIndicatorByTimestampRow) *Indicator {
+	return indicatorFromRow(db.IndicatorsWeekly{
+		ID:                 row.ID,
+		CandleID:           row.CandleID,
+		Ema20:              row.Ema20,
+		Ema50:              row.Ema50,
+		Ema200:             row.Ema200,
+		RangeSize:          row.RangeSize,
+		BodySize:           row.BodySize,
+		UpperWick:          row.UpperWick,
+		LowerWick:          row.LowerWick,
+		MidPrice:           row.MidPrice,
+		LastSwingHighPrice: row.LastSwingHighPrice,
+		LastSwingLowPrice:  row.LastSwingLowPrice,
+		ComputedAt:         row.ComputedAt,
+	})
 }
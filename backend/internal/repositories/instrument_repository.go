@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+type InstrumentRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewInstrumentRepository(pool *pgxpool.Pool) *InstrumentRepository {
+	return &InstrumentRepository{pool: pool}
+}
+
+const instrumentColumns = `symbol, description, pip_size, price_precision, contract_size,
+	quote_currency, min_lot, lot_step`
+
+// GetInstrument retrieves the specification of a symbol.
+// Returns an error wrapping pgx.ErrNoRows if the symbol is not catalogued.
+func (r *InstrumentRepository) GetInstrument(ctx context.Context, symbol string) (*domain.Instrument, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+instrumentColumns+`
+		FROM instruments
+		WHERE symbol = $1
+	`, symbol)
+
+	inst, err := scanInstrument(row)
+	if err != nil {
+		return nil, fmt.Errorf("get instrument %s: %w", symbol, err)
+	}
+
+	return inst, nil
+}
+
+// ListInstruments retrieves the whole catalogue, by symbol
+func (r *InstrumentRepository) ListInstruments(ctx context.Context) ([]domain.Instrument, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+instrumentColumns+`
+		FROM instruments
+		ORDER BY symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query instruments: %w", err)
+	}
+	defer rows.Close()
+
+	instruments := []domain.Instrument{}
+	for rows.Next() {
+		inst, err := scanInstrument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan instrument: %w", err)
+		}
+		instruments = append(instruments, *inst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return instruments, nil
+}
+
+func scanInstrument(row pgx.Row) (*domain.Instrument, error) {
+	var inst domain.Instrument
+	err := row.Scan(
+		&inst.Symbol,
+		&inst.Description,
+		&inst.PipSize,
+		&inst.PricePrecision,
+		&inst.ContractSize,
+		&inst.QuoteCurrency,
+		&inst.MinLot,
+		&inst.LotStep,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
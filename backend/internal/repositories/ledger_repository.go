@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type LedgerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLedgerRepository(pool *pgxpool.Pool) *LedgerRepository {
+	return &LedgerRepository{pool: pool}
+}
+
+// LedgerEntry is one append-only money movement of an account
+type LedgerEntry struct {
+	ID          uuid.UUID  `json:"id"`
+	AccountID   uuid.UUID  `json:"account_id"`
+	EntryType   string     `json:"entry_type"`
+	Amount      string     `json:"amount"` // signed
+	TradeID     *uuid.UUID `json:"trade_id,omitempty"`
+	ExecutionID *uuid.UUID `json:"execution_id,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreateLedgerEntryParams contains parameters for posting a ledger entry
+type CreateLedgerEntryParams struct {
+	AccountID   uuid.UUID
+	EntryType   string
+	Amount      decimal.Decimal // signed
+	TradeID     *uuid.UUID
+	ExecutionID *uuid.UUID
+	Description *string
+}
+
+// CreateLedgerEntryTx posts an entry within a transaction
+func (r *LedgerRepository) CreateLedgerEntryTx(ctx context.Context, tx pgx.Tx, params CreateLedgerEntryParams) (*LedgerEntry, error) {
+	var entry LedgerEntry
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO account_ledger (
+			account_id, entry_type, amount, trade_id, execution_id, description, created_at
+		)
+		VALUES ($1, $2::ledger_entry_type, $3, $4, $5, $6, NOW())
+		RETURNING id, account_id, entry_type::text, amount::text, trade_id, execution_id, description, created_at
+	`, params.AccountID, params.EntryType, params.Amount.String(),
+		params.TradeID, params.ExecutionID, params.Description).Scan(
+		&entry.ID,
+		&entry.AccountID,
+		&entry.EntryType,
+		&entry.Amount,
+		&entry.TradeID,
+		&entry.ExecutionID,
+		&entry.Description,
+		&entry.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("create ledger entry (tx): %w", err)
+	}
+
+	return &entry, nil
+}
+
+// GetBalanceTx sums the account's ledger within a transaction
+func (r *LedgerRepository) GetBalanceTx(ctx context.Context, tx pgx.Tx, accountID uuid.UUID) (decimal.Decimal, error) {
+	var balance string
+
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)::text
+		FROM account_ledger
+		WHERE account_id = $1
+	`, accountID).Scan(&balance)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("query balance (tx): %w", err)
+	}
+
+	return decimal.NewFromString(balance)
+}
+
+// GetEntriesByAccountID retrieves the account's ledger, oldest first
+func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID uuid.UUID) ([]LedgerEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, account_id, entry_type::text, amount::text, trade_id, execution_id, description, created_at
+		FROM account_ledger
+		WHERE account_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("query ledger: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []LedgerEntry{}
+	for rows.Next() {
+		var entry LedgerEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.AccountID,
+			&entry.EntryType,
+			&entry.Amount,
+			&entry.TradeID,
+			&entry.ExecutionID,
+			&entry.Description,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan ledger entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"set-and-trend/backend/internal/rules"
+)
+
+type RuleConditionResultRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRuleConditionResultRepository(pool *pgxpool.Pool) *RuleConditionResultRepository {
+	return &RuleConditionResultRepository{pool: pool}
+}
+
+// insertConditionResults persists every condition outcome of a rule result,
+// inside the transaction that created it
+func insertConditionResults(
+	ctx context.Context,
+	tx pgx.Tx,
+	ruleResultID uuid.UUID,
+	conditions []rules.ConditionOutcome,
+) error {
+	for i, cond := range conditions {
+		inputs, err := json.Marshal(cond.Inputs)
+		if err != nil {
+			return fmt.Errorf("marshal inputs %s: %w", cond.Code, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO rule_condition_results (
+				id, rule_result_id, condition_code, position, passed, insufficient_data, inputs, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, NOW())
+		`, uuid.New(), ruleResultID, string(cond.Code), i, cond.Passed, cond.InsufficientData, string(inputs))
+		if err != nil {
+			return fmt.Errorf("create condition result %s: %w", cond.Code, err)
+		}
+	}
+
+	return nil
+}
+
+// ConditionBreakdown is a stored condition outcome
+type ConditionBreakdown struct {
+	Code             string             `json:"code"`
+	Passed           bool               `json:"passed"`
+	InsufficientData bool               `json:"insufficient_data"`
+	Inputs           map[string]float64 `json:"inputs"`
+}
+
+// RuleBreakdown is a stored rule result with every condition outcome
+type RuleBreakdown struct {
+	RuleCode       string               `json:"rule_code"`
+	RuleName       string               `json:"rule_name"`
+	RuleVersion    int                  `json:"rule_version"`
+	DefinitionHash *string              `json:"definition_hash,omitempty"`
+	IsActive       bool                 `json:"is_active"`
+	Result         string               `json:"result"`
+	Confidence     *string              `json:"confidence"`
+	EvaluatedAt    *time.Time           `json:"evaluated_at,omitempty"`
+	Conditions     []ConditionBreakdown `json:"conditions"`
+}
+
+// GetRuleBreakdownByCandleID returns every rule result of a candle (all
+// versions) with its condition outcomes in evaluation order
+func (r *RuleConditionResultRepository) GetRuleBreakdownByCandleID(
+	ctx context.Context,
+	candleID uuid.UUID,
+) ([]RuleBreakdown, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT r.code, r.name, rr.rule_version, rr.definition_hash,
+			COALESCE(rv.is_active, FALSE), rr.result::text, rr.confidence_score, rr.evaluated_at,
+			rcr.condition_code, rcr.passed, rcr.insufficient_data, rcr.inputs
+		FROM rule_results rr
+		JOIN rules r ON r.id = rr.rule_id
+		LEFT JOIN rule_versions rv ON rv.rule_id = rr.rule_id AND rv.version = rr.rule_version
+		LEFT JOIN rule_condition_results rcr ON rcr.rule_result_id = rr.id
+		WHERE rr.candle_id = $1
+		ORDER BY r.code, rr.rule_version, rcr.position
+	`, candleID)
+	if err != nil {
+		return nil, fmt.Errorf("query rule breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	breakdowns := []RuleBreakdown{}
+	for rows.Next() {
+		var (
+			b             RuleBreakdown
+			conditionCode *string
+			passed        *bool
+			insufficient  *bool
+			inputs        []byte
+		)
+		if err := rows.Scan(
+			&b.RuleCode,
+			&b.RuleName,
+			&b.RuleVersion,
+			&b.DefinitionHash,
+			&b.IsActive,
+			&b.Result,
+			&b.Confidence,
+			&b.EvaluatedAt,
+			&conditionCode,
+			&passed,
+			&insufficient,
+			&inputs,
+		); err != nil {
+			return nil, fmt.Errorf("scan rule breakdown: %w", err)
+		}
+
+		// Rows are ordered by rule, version: start a new breakdown on change
+		n := len(breakdowns)
+		if n == 0 || breakdowns[n-1].RuleCode != b.RuleCode || breakdowns[n-1].RuleVersion != b.RuleVersion {
+			b.Conditions = []ConditionBreakdown{}
+			breakdowns = append(breakdowns, b)
+			n++
+		}
+
+		if conditionCode == nil {
+			continue // evaluated before condition results were recorded
+		}
+
+		cond := ConditionBreakdown{Code: *conditionCode, Passed: *passed, InsufficientData: *insufficient}
+		if err := json.Unmarshal(inputs, &cond.Inputs); err != nil {
+			return nil, fmt.Errorf("decode inputs %s: %w", *conditionCode, err)
+		}
+		breakdowns[n-1].Conditions = append(breakdowns[n-1].Conditions, cond)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return breakdowns, nil
+}
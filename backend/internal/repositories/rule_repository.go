@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"set-and-trend/backend/internal/rules"
+)
+
+type RuleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRuleRepository(pool *pgxpool.Pool) *RuleRepository {
+	return &RuleRepository{pool: pool}
+}
+
+// UpsertRule keeps the rules metadata row in sync with the registry
+// rule_results reference rules by id, so every registered rule needs a row
+func (r *RuleRepository) UpsertRule(ctx context.Context, spec rules.RuleSpec) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO rules (code, name, timeframe, description, created_at)
+		VALUES ($1, $2, $3::rule_timeframe, $4, NOW())
+		ON CONFLICT (code) DO UPDATE
+		SET name = EXCLUDED.name,
+			description = EXCLUDED.description
+	`, string(spec.Code), spec.Name, string(spec.Timeframe), spec.Description)
+	if err != nil {
+		return fmt.Errorf("upsert rule %s: %w", spec.Code, err)
+	}
+
+	return nil
+}
+
+// RuleVersion is a registered, frozen rule definition
+type RuleVersion struct {
+	RuleCode       string     `json:"rule_code"`
+	Version        int        `json:"version"`
+	DefinitionHash string     `json:"definition_hash"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	PromotedAt     *time.Time `json:"promoted_at,omitempty"`
+	ResultCount    int        `json:"result_count"`
+}
+
+// SyncRuleVersionParams describes a version known to the rules engine
+type SyncRuleVersionParams struct {
+	RuleCode       string
+	Version        int
+	DefinitionHash string
+	Definition     []byte // canonical JSON
+}
+
+// SyncRuleVersion records a rule version if it is new and returns the stored
+// row. The caller compares hashes: a mismatch means a frozen version changed.
+func (r *RuleRepository) SyncRuleVersion(ctx context.Context, params SyncRuleVersionParams) (*RuleVersion, error) {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO rule_versions (rule_id, version, definition_hash, definition, created_at)
+		SELECT r.id, $2, $3, $4::jsonb, NOW()
+		FROM rules r
+		WHERE r.code = $1
+		ON CONFLICT (rule_id, version) DO NOTHING
+	`, params.RuleCode, params.Version, params.DefinitionHash, string(params.Definition))
+	if err != nil {
+		return nil, fmt.Errorf("sync rule version %s v%d: %w", params.RuleCode, params.Version, err)
+	}
+
+	return r.GetRuleVersion(ctx, params.RuleCode, params.Version)
+}
+
+// GetRuleVersion returns a stored rule version with its result count
+func (r *RuleRepository) GetRuleVersion(ctx context.Context, code string, version int) (*RuleVersion, error) {
+	var rv RuleVersion
+	err := r.pool.QueryRow(ctx, `
+		SELECT r.code, rv.version, rv.definition_hash, rv.is_active, rv.created_at, rv.promoted_at,
+			(SELECT COUNT(*) FROM rule_results rr
+			 WHERE rr.rule_id = rv.rule_id AND rr.rule_version = rv.version)
+		FROM rule_versions rv
+		JOIN rules r ON r.id = rv.rule_id
+		WHERE r.code = $1 AND rv.version = $2
+	`, code, version).Scan(
+		&rv.RuleCode,
+		&rv.Version,
+		&rv.DefinitionHash,
+		&rv.IsActive,
+		&rv.CreatedAt,
+		&rv.PromotedAt,
+		&rv.ResultCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get rule version %s v%d: %w", code, version, err)
+	}
+
+	return &rv, nil
+}
+
+// ListRuleVersions returns every stored version of every rule
+func (r *RuleRepository) ListRuleVersions(ctx context.Context) ([]RuleVersion, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT r.code, rv.version, rv.definition_hash, rv.is_active, rv.created_at, rv.promoted_at,
+			(SELECT COUNT(*) FROM rule_results rr
+			 WHERE rr.rule_id = rv.rule_id AND rr.rule_version = rv.version)
+		FROM rule_versions rv
+		JOIN rules r ON r.id = rv.rule_id
+		ORDER BY r.code, rv.version
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query rule versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []RuleVersion
+	for rows.Next() {
+		var rv RuleVersion
+		if err := rows.Scan(
+			&rv.RuleCode,
+			&rv.Version,
+			&rv.DefinitionHash,
+			&rv.IsActive,
+			&rv.CreatedAt,
+			&rv.PromotedAt,
+			&rv.ResultCount,
+		); err != nil {
+			return nil, fmt.Errorf("scan rule version: %w", err)
+		}
+		versions = append(versions, rv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return versions, nil
+}
+
+// ActivateRuleVersion marks a version active if the rule has no active version
+// yet (first deployment). Existing promotions are never overridden.
+func (r *RuleRepository) ActivateRuleVersion(ctx context.Context, code string, version int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE rule_versions rv
+		SET is_active = TRUE, promoted_at = NOW()
+		FROM rules r
+		WHERE r.id = rv.rule_id
+		  AND r.code = $1
+		  AND rv.version = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM rule_versions a
+			WHERE a.rule_id = rv.rule_id AND a.is_active
+		  )
+	`, code, version)
+	if err != nil {
+		return fmt.Errorf("activate rule version %s v%d: %w", code, version, err)
+	}
+
+	return nil
+}
+
+// PromoteRuleVersion atomically makes version the only active version of a rule
+func (r *RuleRepository) PromoteRuleVersion(ctx context.Context, code string, version int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var ruleID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		SELECT rv.rule_id
+		FROM rule_versions rv
+		JOIN rules r ON r.id = rv.rule_id
+		WHERE r.code = $1 AND rv.version = $2
+		FOR UPDATE
+	`, code, version).Scan(&ruleID)
+	if err != nil {
+		return fmt.Errorf("find rule version %s v%d: %w", code, version, err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE rule_versions SET is_active = FALSE
+		WHERE rule_id = $1 AND is_active
+	`, ruleID); err != nil {
+		return fmt.Errorf("deactivate rule versions: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE rule_versions SET is_active = TRUE, promoted_at = NOW()
+		WHERE rule_id = $1 AND version = $2
+	`, ruleID, version); err != nil {
+		return fmt.Errorf("promote rule version: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RuleResultDiff is one candle where two versions of a rule disagree
+// (or where only one of them has been evaluated)
+type RuleResultDiff struct {
+	CandleID     uuid.UUID `json:"candle_id"`
+	TimestampUTC time.Time `json:"timestamp_utc"`
+	FromResult   *string   `json:"from_result"`
+	ToResult     *string   `json:"to_result"`
+}
+
+// RuleResultComparison summarises PASS/FAIL agreement between two versions
+type RuleResultComparison struct {
+	RuleCode    string           `json:"rule_code"`
+	FromVersion int              `json:"from_version"`
+	ToVersion   int              `json:"to_version"`
+	Compared    int              `json:"compared"`
+	Unchanged   int              `json:"unchanged"`
+	PassToFail  int              `json:"pass_to_fail"`
+	FailToPass  int              `json:"fail_to_pass"`
+	DataChanged int              `json:"data_changed"` // to/from INSUFFICIENT_DATA
+	OnlyFrom    int              `json:"only_from"`
+	OnlyTo      int              `json:"only_to"`
+	Diffs       []RuleResultDiff `json:"diffs"`
+}
+
+// CompareRuleVersions diffs stored results of two versions over the same candles
+func (r *RuleRepository) CompareRuleVersions(
+	ctx context.Context,
+	code string,
+	fromVersion, toVersion int,
+) (*RuleResultComparison, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH a AS (
+			SELECT rr.candle_id, rr.result::text AS result
+			FROM rule_results rr JOIN rules r ON r.id = rr.rule_id
+			WHERE r.code = $1 AND rr.rule_version = $2
+		), b AS (
+			SELECT rr.candle_id, rr.result::text AS result
+			FROM rule_results rr JOIN rules r ON r.id = rr.rule_id
+			WHERE r.code = $1 AND rr.rule_version = $3
+		)
+		SELECT c.id, c.timestamp_utc, a.result, b.result
+		FROM a
+		FULL OUTER JOIN b ON a.candle_id = b.candle_id
+		JOIN candles_weekly c ON c.id = COALESCE(a.candle_id, b.candle_id)
+		ORDER BY c.timestamp_utc ASC
+	`, code, fromVersion, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("compare rule versions: %w", err)
+	}
+	defer rows.Close()
+
+	cmp := &RuleResultComparison{
+		RuleCode:    code,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Diffs:       []RuleResultDiff{},
+	}
+
+	for rows.Next() {
+		var d RuleResultDiff
+		if err := rows.Scan(&d.CandleID, &d.TimestampUTC, &d.FromResult, &d.ToResult); err != nil {
+			return nil, fmt.Errorf("scan rule result diff: %w", err)
+		}
+
+		switch {
+		case d.FromResult == nil:
+			cmp.OnlyTo++
+		case d.ToResult == nil:
+			cmp.OnlyFrom++
+		case *d.FromResult == *d.ToResult:
+			cmp.Compared++
+			cmp.Unchanged++
+			continue
+		case *d.FromResult == "PASS" && *d.ToResult == "FAIL":
+			cmp.Compared++
+			cmp.PassToFail++
+		case *d.FromResult == "FAIL" && *d.ToResult == "PASS":
+			cmp.Compared++
+			cmp.FailToPass++
+		default:
+			cmp.Compared++
+			cmp.DataChanged++
+		}
+		cmp.Diffs = append(cmp.Diffs, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return cmp, nil
+}
@@ -2,44 +2,79 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 	"set-and-trend/backend/internal/db"
 	"set-and-trend/backend/internal/rules"
 )
 
 type RuleResultRepository struct {
-	q *db.Queries
+	q    *db.Queries
+	pool *pgxpool.Pool
 }
 
-func NewRuleResultRepository(q *db.Queries) *RuleResultRepository {
-	return &RuleResultRepository{q: q}
+func NewRuleResultRepository(q *db.Queries, pool *pgxpool.Pool) *RuleResultRepository {
+	return &RuleResultRepository{q: q, pool: pool}
 }
 
 type RuleResultCreateParams struct {
-	RuleCode    rules.RuleCode
-	CandleID    uuid.UUID
-	Result      string  // "PASS" or "FAIL"
-	Confidence  float64
+	RuleCode       rules.RuleCode
+	RuleVersion    int
+	DefinitionHash string
+	CandleID       uuid.UUID
+	Result         string  // "PASS", "FAIL" or "INSUFFICIENT_DATA"
+	Confidence     float64
+	Conditions     []rules.ConditionOutcome // every condition in spec order
 }
 
-// CreateRuleResult persists a rule evaluation result
-// Uses ON CONFLICT DO NOTHING for idempotency
+// CreateRuleResult persists a rule evaluation result and the outcome of every
+// condition in one transaction.
+// Uses ON CONFLICT DO NOTHING for idempotency (one row per rule version per candle):
+// when the result already exists nothing is written and created is false.
 func (r *RuleResultRepository) CreateRuleResult(
 	ctx context.Context,
 	params RuleResultCreateParams,
-) error {
+) (created bool, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// Convert types to match SQLC expectations
 	resultType := db.RuleResultType(params.Result)
 	confidenceDec := decimal.NewFromFloat(params.Confidence)
-	
-	return r.q.CreateRuleResult(ctx, db.CreateRuleResultParams{
-		CandleID:   params.CandleID,
-		Result:     resultType,
-		Confidence: confidenceDec,
-		RuleCode:   string(params.RuleCode),
+
+	ruleResultID, err := r.q.WithTx(tx).CreateRuleResult(ctx, db.CreateRuleResultParams{
+		CandleID:       params.CandleID,
+		Result:         resultType,
+		Confidence:     confidenceDec,
+		RuleVersion:    int32(params.RuleVersion),
+		DefinitionHash: params.DefinitionHash,
+		RuleCode:       string(params.RuleCode),
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already evaluated: the stored result and its conditions are immutable
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("create rule result %s v%d: %w", params.RuleCode, params.RuleVersion, err)
+	}
+
+	if err := insertConditionResults(ctx, tx, ruleResultID, params.Conditions); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return true, nil
 }
 
 // GetRuleResultsByCandleID retrieves all rule results for a candle
@@ -50,7 +85,7 @@ func (r *RuleResultRepository) GetRuleResultsByCandleID(
 	return r.q.GetRuleResultsByCandleID(ctx, candleID)
 }
 
-// TruncateRuleResults deletes all rule results (for backfill)
+// TruncateRuleResults deletes all rule results and their condition results (for backfill)
 func (r *RuleResultRepository) TruncateRuleResults(ctx context.Context) error {
 	return r.q.TruncateRuleResults(ctx)
 }
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type SwingRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSwingRepository(pool *pgxpool.Pool) *SwingRepository {
+	return &SwingRepository{pool: pool}
+}
+
+// SwingPoint is a confirmed swing high/low
+type SwingPoint struct {
+	ID                uuid.UUID `json:"id"`
+	CandleID          uuid.UUID `json:"candle_id"`
+	ConfirmedCandleID uuid.UUID `json:"confirmed_candle_id"`
+	SwingType         string    `json:"swing_type"`
+	Price             string    `json:"price"`
+	SwingTimestampUTC time.Time `json:"swing_timestamp_utc"`
+	ConfirmedAtUTC    time.Time `json:"confirmed_at_utc"`
+}
+
+// SwingPointCreateParams contains parameters for recording a confirmed swing
+type SwingPointCreateParams struct {
+	CandleID          uuid.UUID
+	ConfirmedCandleID uuid.UUID
+	SwingType         string // "high" or "low"
+	Price             float64
+	SwingTimestampUTC time.Time
+	ConfirmedAtUTC    time.Time
+}
+
+// CreateSwingPoint records a confirmed swing
+// Uses ON CONFLICT DO NOTHING for idempotency (backfill can be re-run)
+func (r *SwingRepository) CreateSwingPoint(ctx context.Context, params SwingPointCreateParams) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO swing_points (
+			id, candle_id, confirmed_candle_id, swing_type, price,
+			swing_timestamp_utc, confirmed_at_utc, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (candle_id, swing_type) DO NOTHING
+	`, uuid.New(), params.CandleID, params.ConfirmedCandleID, params.SwingType,
+		decimal.NewFromFloat(params.Price).String(), params.SwingTimestampUTC, params.ConfirmedAtUTC)
+	if err != nil {
+		return fmt.Errorf("create swing point: %w", err)
+	}
+
+	return nil
+}
+
+// GetConfirmedSwings returns swings of a symbol confirmed at or before asOf,
+// newest first. Only confirmed swings are visible: a swing is never returned
+// before its confirming candle closed.
+func (r *SwingRepository) GetConfirmedSwings(ctx context.Context, symbol string, asOf time.Time, limit int) ([]SwingPoint, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.id, s.candle_id, s.confirmed_candle_id, s.swing_type, s.price,
+			s.swing_timestamp_utc, s.confirmed_at_utc
+		FROM swing_points s
+		JOIN candles_weekly c ON c.id = s.candle_id
+		WHERE c.symbol = $1 AND s.confirmed_at_utc <= $2
+		ORDER BY s.swing_timestamp_utc DESC, s.swing_type
+		LIMIT $3
+	`, symbol, asOf, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query swing points: %w", err)
+	}
+	defer rows.Close()
+
+	swings := []SwingPoint{}
+	for rows.Next() {
+		var sp SwingPoint
+		if err := rows.Scan(
+			&sp.ID,
+			&sp.CandleID,
+			&sp.ConfirmedCandleID,
+			&sp.SwingType,
+			&sp.Price,
+			&sp.SwingTimestampUTC,
+			&sp.ConfirmedAtUTC,
+		); err != nil {
+			return nil, fmt.Errorf("scan swing point: %w", err)
+		}
+		swings = append(swings, sp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return swings, nil
+}
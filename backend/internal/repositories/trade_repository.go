@@ -8,16 +8,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 	"set-and-trend/backend/internal/db"
+	"set-and-trend/backend/internal/domain"
 )
 
 type TradeRepository struct {
-	q *db.Queries
+	q    *db.Queries
+	pool *pgxpool.Pool
 }
 
-func NewTradeRepository(q *db.Queries) *TradeRepository {
-	return &TradeRepository{q: q}
+func NewTradeRepository(q *db.Queries, pool *pgxpool.Pool) *TradeRepository {
+	return &TradeRepository{q: q, pool: pool}
 }
 
 // Trade represents a trade (for API responses)
@@ -33,6 +36,8 @@ type Trade struct {
 	LeverageAtSetup           int32      `json:"leverage_at_setup"`
 	MaxRiskPerTradePctAtSetup string     `json:"max_risk_per_trade_pct_at_setup"`
 	TimezoneAtSetup           string     `json:"timezone_at_setup"`
+	AccountCurrencyAtSetup    string     `json:"account_currency_at_setup"`
+	QuoteToAccountRateAtSetup string     `json:"quote_to_account_rate_at_setup"` // quote → account currency
 	Bias                      string     `json:"bias"`
 	PlannedEntry              string     `json:"planned_entry"`
 	PlannedSL                 string     `json:"planned_sl"`
@@ -42,6 +47,9 @@ type Trade struct {
 	PlannedRiskAmount         string     `json:"planned_risk_amount"`
 	PlannedPositionSize       string     `json:"planned_position_size"`
 	ReasonForTrade            string     `json:"reason_for_trade"`
+	ScaleOutPlan              domain.ScaleOutPlan `json:"scale_out_plan,omitempty"` // loaded by GetTradeByID
+	OrderType                 string     `json:"order_type"`           // set by CreateTrade and GetTradeByID
+	ExpiresAt                 *time.Time `json:"expires_at,omitempty"` // pending orders only, nil = good till cancelled
 	CreatedAt                 time.Time  `json:"created_at"`
 }
 
@@ -58,6 +66,8 @@ type TradeCreateParams struct {
 	LeverageAtSetup           int32
 	MaxRiskPerTradePctAtSetup string
 	TimezoneAtSetup           string
+	AccountCurrencyAtSetup    string
+	QuoteToAccountRateAtSetup string
 	Bias                      string
 	PlannedEntry              string
 	PlannedSL                 string
@@ -67,6 +77,9 @@ type TradeCreateParams struct {
 	PlannedRiskAmount         string
 	PlannedPositionSize       string
 	ReasonForTrade            string
+	ScaleOutPlan              domain.ScaleOutPlan
+	OrderType                 string
+	ExpiresAt                 *time.Time
 }
 
 // CreateTrade inserts a new planned trade
@@ -81,11 +94,17 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, params TradeCreatePar
 	plannedRiskPctDec, _ := decimal.NewFromString(params.PlannedRiskPct)
 	plannedRiskAmtDec, _ := decimal.NewFromString(params.PlannedRiskAmount)
 	plannedPosSizeDec, _ := decimal.NewFromString(params.PlannedPositionSize)
+	rateDec, _ := decimal.NewFromString(params.QuoteToAccountRateAtSetup)
 
 	// Convert timestamp
 	var timestampPg pgtype.Timestamptz
 	timestampPg.Scan(params.SetupTimestampUTC)
 
+	var expiresAtPg pgtype.Timestamptz
+	if params.ExpiresAt != nil {
+		expiresAtPg.Scan(*params.ExpiresAt)
+	}
+
 	trade, err := r.q.CreateTrade(ctx, db.CreateTradeParams{
 		ID:                        params.ID,
 		UserID:                    params.UserID,
@@ -107,11 +126,31 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, params TradeCreatePar
 		PlannedRiskAmount:         plannedRiskAmtDec,
 		PlannedPositionSize:       plannedPosSizeDec,
 		ReasonForTrade:            params.ReasonForTrade,
+		AccountCurrencyAtSetup:    params.AccountCurrencyAtSetup,
+		QuoteToAccountRateAtSetup: rateDec,
+		OrderType:                 db.OrderType(params.OrderType),
+		ExpiresAt:                 expiresAtPg,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	for i, leg := range params.ScaleOutPlan {
+		targetR := db.NullDecimal{}
+		if leg.TargetR != nil {
+			targetR = db.NullDecimal{Decimal: decimal.NewFromFloat(*leg.TargetR), Valid: true}
+		}
+		err := r.q.CreateTradeScaleOutLeg(ctx, db.CreateTradeScaleOutLegParams{
+			TradeID:  trade.ID,
+			Leg:      int16(i + 1),
+			ClosePct: decimal.NewFromFloat(leg.ClosePct),
+			TargetR:  targetR,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create scale-out leg %d: %w", i+1, err)
+		}
+	}
+
 	return &Trade{
 		ID:                        trade.ID,
 		UserID:                    trade.UserID,
@@ -124,6 +163,8 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, params TradeCreatePar
 		LeverageAtSetup:           trade.LeverageAtSetup,
 		MaxRiskPerTradePctAtSetup: trade.MaxRiskPerTradePctAtSetup.String(),
 		TimezoneAtSetup:           trade.TimezoneAtSetup,
+		AccountCurrencyAtSetup:    trade.AccountCurrencyAtSetup,
+		QuoteToAccountRateAtSetup: trade.QuoteToAccountRateAtSetup.String(),
 		Bias:                      string(trade.Bias),
 		PlannedEntry:              trade.PlannedEntry.String(),
 		PlannedSL:                 trade.PlannedSl.String(),
@@ -133,17 +174,71 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, params TradeCreatePar
 		PlannedRiskAmount:         trade.PlannedRiskAmount.String(),
 		PlannedPositionSize:       trade.PlannedPositionSize.String(),
 		ReasonForTrade:            trade.ReasonForTrade,
+		ScaleOutPlan:              params.ScaleOutPlan,
+		OrderType:                 string(trade.OrderType),
+		ExpiresAt:                 timestamptzPtr(trade.ExpiresAt),
 		CreatedAt:                 trade.CreatedAt.Time,
 	}, nil
 }
 
-// GetTradeByID retrieves a trade by ID
+// CreateTradeWithinRisk creates a trade once check accepts the account's risk
+// trades (see GetRiskTradesSince), in one transaction. The account row is
+// locked FOR UPDATE, so concurrent creations on an account are checked one at
+// a time and cannot together exceed the cap. check's error is returned as is.
+func (r *TradeRepository) CreateTradeWithinRisk(
+	ctx context.Context,
+	params TradeCreateParams,
+	since time.Time,
+	check func([]RiskTrade) error,
+) (*Trade, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT id FROM accounts WHERE id = $1 FOR UPDATE`, params.AccountID); err != nil {
+		return nil, fmt.Errorf("lock account: %w", err)
+	}
+
+	txRepo := &TradeRepository{q: r.q.WithTx(tx)}
+
+	riskTrades, err := txRepo.GetRiskTradesSince(ctx, params.AccountID, since)
+	if err != nil {
+		return nil, fmt.Errorf("daily risk check failed: %w", err)
+	}
+	if err := check(riskTrades); err != nil {
+		return nil, err
+	}
+
+	trade, err := txRepo.CreateTrade(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return trade, nil
+}
+
+// GetTradeByID retrieves a trade by ID, with its scale-out plan
 func (r *TradeRepository) GetTradeByID(ctx context.Context, id uuid.UUID) (*Trade, error) {
 	trade, err := r.q.GetTradeByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	legs, err := r.q.GetTradeScaleOutLegs(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get scale-out plan: %w", err)
+	}
+	plan := make(domain.ScaleOutPlan, len(legs))
+	for i, leg := range legs {
+		plan[i] = scaleOutLegFromRow(leg.ClosePct, leg.TargetR)
+	}
+
 	return &Trade{
 		ID:                        trade.ID,
 		UserID:                    trade.UserID,
@@ -156,6 +251,8 @@ func (r *TradeRepository) GetTradeByID(ctx context.Context, id uuid.UUID) (*Trad
 		LeverageAtSetup:           trade.LeverageAtSetup,
 		MaxRiskPerTradePctAtSetup: trade.MaxRiskPerTradePctAtSetup.String(),
 		TimezoneAtSetup:           trade.TimezoneAtSetup,
+		AccountCurrencyAtSetup:    trade.AccountCurrencyAtSetup,
+		QuoteToAccountRateAtSetup: trade.QuoteToAccountRateAtSetup.String(),
 		Bias:                      string(trade.Bias),
 		PlannedEntry:              trade.PlannedEntry.String(),
 		PlannedSL:                 trade.PlannedSl.String(),
@@ -165,10 +262,32 @@ func (r *TradeRepository) GetTradeByID(ctx context.Context, id uuid.UUID) (*Trad
 		PlannedRiskAmount:         trade.PlannedRiskAmount.String(),
 		PlannedPositionSize:       trade.PlannedPositionSize.String(),
 		ReasonForTrade:            trade.ReasonForTrade,
+		ScaleOutPlan:              plan,
+		OrderType:                 string(trade.OrderType),
+		ExpiresAt:                 timestamptzPtr(trade.ExpiresAt),
 		CreatedAt:                 trade.CreatedAt.Time,
 	}, nil
 }
 
+// timestamptzPtr converts a nullable timestamp (NULL = nil)
+func timestamptzPtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// scaleOutLegFromRow converts a stored leg (NULL target_r = at TP)
+func scaleOutLegFromRow(closePct decimal.Decimal, targetR db.NullDecimal) domain.ScaleOutLeg {
+	leg := domain.ScaleOutLeg{ClosePct: closePct.InexactFloat64()}
+	if targetR.Valid {
+		r := targetR.Decimal.InexactFloat64()
+		leg.TargetR = &r
+	}
+	return leg
+}
+
 // GetTradesByAccountAndCandle retrieves all trades for a specific account and candle
 func (r *TradeRepository) GetTradesByAccountAndCandle(
 	ctx context.Context,
@@ -197,6 +316,8 @@ func (r *TradeRepository) GetTradesByAccountAndCandle(
 			LeverageAtSetup:           t.LeverageAtSetup,
 			MaxRiskPerTradePctAtSetup: t.MaxRiskPerTradePctAtSetup.String(),
 			TimezoneAtSetup:           t.TimezoneAtSetup,
+			AccountCurrencyAtSetup:    t.AccountCurrencyAtSetup,
+			QuoteToAccountRateAtSetup: t.QuoteToAccountRateAtSetup.String(),
 			Bias:                      string(t.Bias),
 			PlannedEntry:              t.PlannedEntry.String(),
 			PlannedSL:                 t.PlannedSl.String(),
@@ -213,6 +334,47 @@ func (r *TradeRepository) GetTradesByAccountAndCandle(
 }
 
 
+// RiskTrade is the risk-relevant view of a trade for the daily risk cap
+type RiskTrade struct {
+	ID                uuid.UUID
+	SetupTimestampUTC time.Time
+	PlannedRiskPct    float64
+	PendingOrder      bool // limit or stop order, entered by candles
+	Entered           bool // has an entry execution
+	Closed            bool // fully closed by tp_hit, sl_hit or manual_close
+	Withdrawn         bool // has a cancel or invalidate intent
+}
+
+// GetRiskTradesSince retrieves the account's trades set up at or after since,
+// plus older trades that were entered (the caller decides which are still open)
+// and older pending orders not withdrawn
+func (r *TradeRepository) GetRiskTradesSince(ctx context.Context, accountID uuid.UUID, since time.Time) ([]RiskTrade, error) {
+	var sincePg pgtype.Timestamptz
+	sincePg.Scan(since)
+
+	rows, err := r.q.GetAccountRiskTrades(ctx, db.GetAccountRiskTradesParams{
+		AccountID:         accountID,
+		SetupTimestampUtc: sincePg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]RiskTrade, len(rows))
+	for i, t := range rows {
+		trades[i] = RiskTrade{
+			ID:                t.ID,
+			SetupTimestampUTC: t.SetupTimestampUtc.Time,
+			PlannedRiskPct:    t.PlannedRiskPct.InexactFloat64(),
+			PendingOrder:      t.PendingOrder,
+			Entered:           t.Entered,
+			Closed:            t.Closed,
+			Withdrawn:         t.Withdrawn,
+		}
+	}
+	return trades, nil
+}
+
 func (r *TradeRepository) GetTradeTx(ctx context.Context, tx pgx.Tx, tradeID uuid.UUID) (*Trade, error) {
 	var trade Trade
 	
@@ -222,7 +384,8 @@ func (r *TradeRepository) GetTradeTx(ctx context.Context, tx pgx.Tx, tradeID uui
 			max_risk_per_trade_pct_at_setup, timezone_at_setup, bias,
 			planned_entry, planned_sl, planned_tp, planned_rr,
 			planned_risk_pct, planned_risk_amount, planned_position_size,
-			reason_for_trade, created_at
+			reason_for_trade, account_currency_at_setup, quote_to_account_rate_at_setup,
+			created_at
 		FROM trades
 		WHERE id = $1
 	    `, tradeID).Scan(
@@ -246,6 +409,8 @@ func (r *TradeRepository) GetTradeTx(ctx context.Context, tx pgx.Tx, tradeID uui
 		&trade.PlannedRiskAmount,
 		&trade.PlannedPositionSize,
 		&trade.ReasonForTrade,
+		&trade.AccountCurrencyAtSetup,
+		&trade.QuoteToAccountRateAtSetup,
 		&trade.CreatedAt,
 	)
 	
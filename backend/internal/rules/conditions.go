@@ -1,5 +1,7 @@
 package rules
 
+import "set-and-trend/backend/internal/constants"
+
 // ConditionFunc is a pure boolean function that evaluates a condition
 type ConditionFunc func(c Candle, ind Indicators) bool
 
@@ -8,6 +10,17 @@ var ConditionRegistry = map[ConditionCode]ConditionFunc{
 	EMA50GtEMA200:      conditionEMA50GtEMA200,
 	CloseGtEMA50:       conditionCloseGtEMA50,
 	EMA50SlopePositive: conditionEMA50SlopePositive,
+	EMA50LtEMA200:      conditionEMA50LtEMA200,
+	CloseLtEMA50:       conditionCloseLtEMA50,
+	EMA50SlopeNegative: conditionEMA50SlopeNegative,
+	TouchEMA20:         conditionTouchEMA20,
+	PinBarBullish:      conditionPinBarBullish,
+	PinBarBearish:      conditionPinBarBearish,
+	EngulfingBullish:   conditionEngulfingBullish,
+	EngulfingBearish:   conditionEngulfingBearish,
+	InsideBar:          conditionInsideBar,
+	OutsideBar:         conditionOutsideBar,
+	Doji:               conditionDoji,
 }
 
 // conditionEMA50GtEMA200 checks if EMA50 is above EMA200 (bullish structure)
@@ -29,6 +42,43 @@ func conditionEMA50SlopePositive(c Candle, ind Indicators) bool {
 	return ind.EMA50 > *ind.EMA50Prev
 }
 
+// conditionEMA50LtEMA200 checks if EMA50 is below EMA200 (bearish structure)
+func conditionEMA50LtEMA200(c Candle, ind Indicators) bool {
+	return ind.EMA50 < ind.EMA200
+}
+
+// conditionCloseLtEMA50 checks if close is below EMA50 (price in downtrend)
+func conditionCloseLtEMA50(c Candle, ind Indicators) bool {
+	return c.Close < ind.EMA50
+}
+
+// conditionEMA50SlopeNegative checks if EMA50 is falling (trend momentum)
+func conditionEMA50SlopeNegative(c Candle, ind Indicators) bool {
+	if ind.EMA50Prev == nil {
+		return false
+	}
+	return ind.EMA50 < *ind.EMA50Prev
+}
+
+// conditionTouchEMA20 checks if the candle reached EMA20 with proximity filter
+// θ = 0.3 × Range_t (FIXED): EMA20 must lie within [Low - θ, High + θ]
+func conditionTouchEMA20(c Candle, ind Indicators) bool {
+	return touchesEMA20(c, ind, constants.EMATouchProximityFactor)
+}
+
+// TouchEMA20Within is touch_ema20 with another proximity factor.
+// For research only (parameter sweeps): the registered condition stays at 0.3.
+func TouchEMA20Within(proximityFactor float64) ConditionFunc {
+	return func(c Candle, ind Indicators) bool {
+		return touchesEMA20(c, ind, proximityFactor)
+	}
+}
+
+func touchesEMA20(c Candle, ind Indicators, proximityFactor float64) bool {
+	theta := proximityFactor * ind.RangeSize
+	return ind.EMA20 >= c.Low-theta && ind.EMA20 <= c.High+theta
+}
+
 // EvaluateCondition evaluates a single condition
 func EvaluateCondition(code ConditionCode, c Candle, ind Indicators) bool {
 	fn, exists := ConditionRegistry[code]
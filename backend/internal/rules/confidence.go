@@ -20,6 +20,16 @@ func ComputeConfidence(met int, total int, passed bool) float64 {
 	return float64(met) / float64(total)
 }
 
+// ConfidenceSemanticsVersion identifies the semantics of ComputeConfidence,
+// ShouldPass and the evaluator. Every rule version records the semantics it was
+// released under (RuleSpec.Semantics), which is part of its DefinitionHash:
+// bump this if any of them changes and ship new rule versions under it.
+//
+//	1: every condition is evaluated; an input that is not warmed up reads as 0
+//	2: a condition with an input that is not warmed up is not evaluated and
+//	   the result is INSUFFICIENT_DATA
+const ConfidenceSemanticsVersion = 2
+
 // ConfidenceThreshold defines when a rule passes
 const ConfidenceThreshold = 1.0 // All conditions must be met for PASS
 
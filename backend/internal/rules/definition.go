@@ -0,0 +1,415 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// RuleDefinition is the declarative (YAML/JSON) form of a RuleSpec.
+// Definitions compile into ConditionFuncs and are registered at startup.
+type RuleDefinition struct {
+	Code        RuleCode              `json:"code"`
+	Version     int                   `json:"version"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Timeframe   Timeframe             `json:"timeframe"`
+	Semantics   int                   `json:"semantics,omitempty"` // ConfidenceSemanticsVersion, 0 = current
+	Conditions  []ConditionDefinition `json:"conditions"`
+}
+
+// ConditionDefinition compares two operands: left <op> right.
+// A condition with only a code references an already registered condition.
+type ConditionDefinition struct {
+	Code  ConditionCode `json:"code"`
+	Left  *Operand      `json:"left,omitempty"`
+	Op    CompareOp     `json:"op,omitempty"`
+	Right *Operand      `json:"right,omitempty"`
+}
+
+// CompareOp is a comparison operator between two operands
+type CompareOp string
+
+const (
+	OpGt  CompareOp = "gt"
+	OpGte CompareOp = "gte"
+	OpLt  CompareOp = "lt"
+	OpLte CompareOp = "lte"
+)
+
+// Operand resolves to (field | value) × multiplier + sum(plus)
+// Example: low - 0.3 × range → {field: low, plus: [{field: range_size, multiplier: -0.3}]}
+type Operand struct {
+	Field      string    `json:"field,omitempty"`
+	Prev       bool      `json:"prev,omitempty"` // read field from the previous bar
+	Value      *float64  `json:"value,omitempty"`
+	Multiplier *float64  `json:"multiplier,omitempty"`
+	Plus       []Operand `json:"plus,omitempty"`
+}
+
+var (
+	ruleCodePattern      = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	conditionCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// declaredConditions tracks conditions registered from definitions
+// so a code can never be re-registered with different semantics
+var declaredConditions = map[ConditionCode]ConditionDefinition{}
+
+// ParseDefinition decodes a single rule definition (format: "yaml" or "json")
+func ParseDefinition(data []byte, format string) (RuleDefinition, error) {
+	var def RuleDefinition
+
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&def); err != nil {
+			return RuleDefinition{}, fmt.Errorf("decode json: %w", err)
+		}
+	case "yaml":
+		if err := yaml.UnmarshalWithOptions(data, &def, yaml.DisallowUnknownField()); err != nil {
+			return RuleDefinition{}, fmt.Errorf("decode yaml: %w", err)
+		}
+	default:
+		return RuleDefinition{}, fmt.Errorf("unsupported definition format: %s", format)
+	}
+
+	return def, nil
+}
+
+// LoadDefinitions reads every *.yaml, *.yml and *.json file in dir.
+// A missing directory means no declarative rules.
+func LoadDefinitions(dir string) ([]RuleDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read rules dir: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var defs []RuleDefinition
+	for _, name := range files {
+		var format string
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".json":
+			format = "json"
+		default:
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		def, err := ParseDefinition(data, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+// Validate checks a rule definition without registering it
+func (d RuleDefinition) Validate() error {
+	if !ruleCodePattern.MatchString(string(d.Code)) {
+		return fmt.Errorf("invalid rule code %q (expected UPPER_SNAKE_CASE)", d.Code)
+	}
+	if d.Version < 1 {
+		return fmt.Errorf("rule %s: version must be >= 1", d.Code)
+	}
+	if d.Name == "" {
+		return fmt.Errorf("rule %s: name is required", d.Code)
+	}
+	if d.Semantics < 0 || d.Semantics > ConfidenceSemanticsVersion {
+		return fmt.Errorf("rule %s: unknown semantics %d", d.Code, d.Semantics)
+	}
+	if d.Timeframe != W1 {
+		return fmt.Errorf("rule %s: unsupported timeframe %q", d.Code, d.Timeframe)
+	}
+	if len(d.Conditions) == 0 {
+		return fmt.Errorf("rule %s: at least one condition is required", d.Code)
+	}
+
+	seen := make(map[ConditionCode]bool)
+	for _, cond := range d.Conditions {
+		if seen[cond.Code] {
+			return fmt.Errorf("rule %s: duplicate condition %s", d.Code, cond.Code)
+		}
+		seen[cond.Code] = true
+
+		if err := cond.Validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", d.Code, err)
+		}
+	}
+
+	return nil
+}
+
+// IsReference reports whether the condition only references a registered condition
+func (d ConditionDefinition) IsReference() bool {
+	return d.Left == nil && d.Op == "" && d.Right == nil
+}
+
+// Validate checks the condition shape and every operand
+func (d ConditionDefinition) Validate() error {
+	if !conditionCodePattern.MatchString(string(d.Code)) {
+		return fmt.Errorf("invalid condition code %q (expected lower_snake_case)", d.Code)
+	}
+	if d.IsReference() {
+		return nil
+	}
+	if d.Left == nil || d.Right == nil {
+		return fmt.Errorf("condition %s: left and right operands are required", d.Code)
+	}
+
+	switch d.Op {
+	case OpGt, OpGte, OpLt, OpLte:
+	default:
+		return fmt.Errorf("condition %s: unknown operator %q", d.Code, d.Op)
+	}
+
+	if err := d.Left.validate(); err != nil {
+		return fmt.Errorf("condition %s left: %w", d.Code, err)
+	}
+	if err := d.Right.validate(); err != nil {
+		return fmt.Errorf("condition %s right: %w", d.Code, err)
+	}
+	return nil
+}
+
+func (o Operand) validate() error {
+	hasField := o.Field != ""
+	hasValue := o.Value != nil
+
+	if hasField == hasValue {
+		return errors.New("operand needs exactly one of field or value")
+	}
+	if hasField && !IsKnownField(o.Field) {
+		return fmt.Errorf("unknown field %q", o.Field)
+	}
+	if o.Prev && !hasField {
+		return errors.New("prev only applies to fields")
+	}
+
+	for _, term := range o.Plus {
+		if err := term.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compile turns a validated comparison into a ConditionFunc.
+// Unavailable inputs (e.g. previous bar on the first candle) evaluate to false.
+func (d ConditionDefinition) Compile() (ConditionFunc, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	if d.IsReference() {
+		return nil, fmt.Errorf("condition %s is a reference and cannot be compiled", d.Code)
+	}
+
+	left, right, op := *d.Left, *d.Right, d.Op
+
+	return func(c Candle, ind Indicators) bool {
+		l, ok := left.eval(c, ind)
+		if !ok {
+			return false
+		}
+		r, ok := right.eval(c, ind)
+		if !ok {
+			return false
+		}
+
+		switch op {
+		case OpGt:
+			return l > r
+		case OpGte:
+			return l >= r
+		case OpLt:
+			return l < r
+		case OpLte:
+			return l <= r
+		}
+		return false
+	}, nil
+}
+
+func (o Operand) eval(c Candle, ind Indicators) (float64, bool) {
+	var v float64
+	if o.Value != nil {
+		v = *o.Value
+	} else {
+		fv, ok := FieldValue(o.Field, o.Prev, c, ind)
+		if !ok {
+			return 0, false
+		}
+		v = fv
+	}
+
+	if o.Multiplier != nil {
+		v *= *o.Multiplier
+	}
+
+	for _, term := range o.Plus {
+		tv, ok := term.eval(c, ind)
+		if !ok {
+			return 0, false
+		}
+		v += tv
+	}
+	return v, true
+}
+
+// ToSpec converts the definition into the RuleSpec stored in RuleRegistry
+func (d RuleDefinition) ToSpec() RuleSpec {
+	conditions := make([]ConditionCode, len(d.Conditions))
+	for i, cond := range d.Conditions {
+		conditions[i] = cond.Code
+	}
+
+	semantics := d.Semantics
+	if semantics == 0 {
+		semantics = ConfidenceSemanticsVersion
+	}
+
+	return RuleSpec{
+		Code:        d.Code,
+		Version:     d.Version,
+		Name:        d.Name,
+		Description: d.Description,
+		Timeframe:   d.Timeframe,
+		Semantics:   semantics,
+		Conditions:  conditions,
+	}
+}
+
+// RegisterDefinitions validates and compiles all definitions, then registers
+// them. Nothing is registered if any definition is invalid.
+//
+// FROZEN SEMANTICS: a registered (code, version) can never be redefined and a
+// condition code can never change meaning. Changes require a new version.
+func RegisterDefinitions(defs []RuleDefinition) error {
+	sorted := make([]RuleDefinition, len(defs))
+	copy(sorted, defs)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Code != sorted[j].Code {
+			return sorted[i].Code < sorted[j].Code
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	newConditions := make(map[ConditionCode]ConditionFunc)
+	newDeclared := make(map[ConditionCode]ConditionDefinition)
+	newRules := make([]RuleSpec, 0, len(sorted))
+
+	for i, def := range sorted {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+
+		if i > 0 && sorted[i-1].Code == def.Code && sorted[i-1].Version == def.Version {
+			return fmt.Errorf("rule %s v%d is defined twice", def.Code, def.Version)
+		}
+
+		if _, ok := GetRuleVersion(def.Code, def.Version); ok {
+			return fmt.Errorf(
+				"rule %s v%d is frozen: changes require a new version",
+				def.Code, def.Version,
+			)
+		}
+
+		for _, cond := range def.Conditions {
+			if err := compileInto(cond, newConditions, newDeclared); err != nil {
+				return fmt.Errorf("rule %s v%d: %w", def.Code, def.Version, err)
+			}
+		}
+
+		newRules = append(newRules, def.ToSpec())
+	}
+
+	for code, fn := range newConditions {
+		ConditionRegistry[code] = fn
+	}
+	for code, cond := range newDeclared {
+		declaredConditions[code] = cond
+	}
+	// Highest version becomes active (sorted ascending). Persisted promotions
+	// override this via ActivateVersion once the database is synced.
+	for _, spec := range newRules {
+		registerVersion(spec)
+		if active, ok := RuleRegistry[spec.Code]; !ok || spec.Version > active.Version {
+			RuleRegistry[spec.Code] = spec
+		}
+	}
+
+	return nil
+}
+
+// compileInto compiles a condition into the pending maps, rejecting any
+// redefinition of an existing condition code with different semantics
+func compileInto(
+	cond ConditionDefinition,
+	pending map[ConditionCode]ConditionFunc,
+	pendingDeclared map[ConditionCode]ConditionDefinition,
+) error {
+	_, registered := ConditionRegistry[cond.Code]
+	_, queued := pending[cond.Code]
+
+	if cond.IsReference() {
+		if !registered && !queued {
+			return fmt.Errorf("condition %s is not registered", cond.Code)
+		}
+		return nil
+	}
+
+	if previous, ok := pendingDeclared[cond.Code]; ok {
+		return sameSemantics(cond, previous)
+	}
+	if previous, ok := declaredConditions[cond.Code]; ok {
+		return sameSemantics(cond, previous)
+	}
+	if registered {
+		return fmt.Errorf("condition %s is built-in and cannot be redefined", cond.Code)
+	}
+
+	fn, err := cond.Compile()
+	if err != nil {
+		return err
+	}
+	pending[cond.Code] = fn
+	pendingDeclared[cond.Code] = cond
+	return nil
+}
+
+func sameSemantics(a, b ConditionDefinition) error {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	if !bytes.Equal(aj, bj) {
+		return fmt.Errorf("condition %s is already defined with different semantics", a.Code)
+	}
+	return nil
+}
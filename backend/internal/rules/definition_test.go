@@ -0,0 +1,440 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// withRegistries snapshots the global registries and restores them after the test
+func withRegistries(t *testing.T) {
+	t.Helper()
+
+	rules := make(map[RuleCode]RuleSpec, len(RuleRegistry))
+	for k, v := range RuleRegistry {
+		rules[k] = v
+	}
+	conditions := make(map[ConditionCode]ConditionFunc, len(ConditionRegistry))
+	for k, v := range ConditionRegistry {
+		conditions[k] = v
+	}
+	declared := make(map[ConditionCode]ConditionDefinition, len(declaredConditions))
+	for k, v := range declaredConditions {
+		declared[k] = v
+	}
+	versions := make(map[RuleCode]map[int]RuleSpec, len(RuleVersions))
+	for code, byVersion := range RuleVersions {
+		versions[code] = make(map[int]RuleSpec, len(byVersion))
+		for v, spec := range byVersion {
+			versions[code][v] = spec
+		}
+	}
+
+	t.Cleanup(func() {
+		RuleRegistry = rules
+		ConditionRegistry = conditions
+		declaredConditions = declared
+		RuleVersions = versions
+	})
+}
+
+const pullbackYAML = `
+code: TEST_PULLBACK
+version: 1
+name: Test Pullback
+timeframe: W1
+conditions:
+  - code: ema50_gt_ema200
+  - code: test_low_reaches_ema20
+    left:
+      field: low
+      plus:
+        - field: range_size
+          multiplier: -0.3
+    op: lte
+    right:
+      field: ema20
+`
+
+func TestParseDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		wantErr bool
+	}{
+		{"valid yaml", pullbackYAML, "yaml", false},
+		{
+			"valid json",
+			`{"code":"TEST_JSON","version":1,"name":"Test","timeframe":"W1",
+			  "conditions":[{"code":"test_close_gt_ema20","left":{"field":"close"},"op":"gt","right":{"field":"ema20"}}]}`,
+			"json",
+			false,
+		},
+		{"unknown yaml field", pullbackYAML + "extra: true\n", "yaml", true},
+		{"unknown json field", `{"code":"X","extra":1}`, "json", true},
+		{"unsupported format", pullbackYAML, "toml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDefinition([]byte(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+		})
+	}
+
+	def, err := ParseDefinition([]byte(pullbackYAML), "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if def.Code != "TEST_PULLBACK" || def.Version != 1 || len(def.Conditions) != 2 {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+	if def.Conditions[1].Left.Plus[0].Multiplier == nil || *def.Conditions[1].Left.Plus[0].Multiplier != -0.3 {
+		t.Errorf("multiplier not decoded: %+v", def.Conditions[1].Left)
+	}
+}
+
+func TestRuleDefinition_Validate(t *testing.T) {
+	valid := func() RuleDefinition {
+		def, err := ParseDefinition([]byte(pullbackYAML), "yaml")
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return def
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(d *RuleDefinition)
+		errMsg string
+	}{
+		{"valid", func(d *RuleDefinition) {}, ""},
+		{"lowercase code", func(d *RuleDefinition) { d.Code = "test" }, "invalid rule code"},
+		{"zero version", func(d *RuleDefinition) { d.Version = 0 }, "version"},
+		{"missing name", func(d *RuleDefinition) { d.Name = "" }, "name"},
+		{"unsupported timeframe", func(d *RuleDefinition) { d.Timeframe = "D1" }, "timeframe"},
+		{"no conditions", func(d *RuleDefinition) { d.Conditions = nil }, "at least one condition"},
+		{"duplicate condition", func(d *RuleDefinition) {
+			d.Conditions = append(d.Conditions, d.Conditions[0])
+		}, "duplicate condition"},
+		{"unknown field", func(d *RuleDefinition) { d.Conditions[1].Left.Field = "volume" }, "unknown field"},
+		{"unknown operator", func(d *RuleDefinition) { d.Conditions[1].Op = "eq" }, "unknown operator"},
+		{"missing right operand", func(d *RuleDefinition) { d.Conditions[1].Right = nil }, "operands are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := valid()
+			tt.mutate(&def)
+
+			err := def.Validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("expected valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestConditionDefinition_Compile(t *testing.T) {
+	candle := Candle{TimestampUTC: time.Now(), Open: 1.08, High: 1.09, Low: 1.07, Close: 1.085}
+	ind := Indicators{EMA20: 1.072, EMA50: 1.06, RangeSize: 0.02}
+
+	prevCandle := Candle{Open: 1.07, High: 1.08, Low: 1.06, Close: 1.075}
+	withPrev := ind
+	withPrev.Prev = &Indicators{EMA20: 1.071, EMA50: 1.059}
+	withPrev.PrevCandle = &prevCandle
+
+	neg := -0.3
+	one := 1.08
+
+	tests := []struct {
+		name     string
+		cond     ConditionDefinition
+		ind      Indicators
+		expected bool
+	}{
+		{
+			"low - 0.3 × range <= ema20",
+			ConditionDefinition{
+				Code:  "t_touch",
+				Left:  &Operand{Field: FieldLow, Plus: []Operand{{Field: FieldRangeSize, Multiplier: &neg}}},
+				Op:    OpLte,
+				Right: &Operand{Field: FieldEMA20},
+			},
+			ind,
+			true, // 1.07 - 0.006 = 1.064 <= 1.072
+		},
+		{
+			"close > constant",
+			ConditionDefinition{Code: "t_const", Left: &Operand{Field: FieldClose}, Op: OpGt, Right: &Operand{Value: &one}},
+			ind,
+			true,
+		},
+		{
+			"close > previous high",
+			ConditionDefinition{Code: "t_prev", Left: &Operand{Field: FieldClose}, Op: OpGt, Right: &Operand{Field: FieldHigh, Prev: true}},
+			withPrev,
+			true,
+		},
+		{
+			"previous bar unavailable",
+			ConditionDefinition{Code: "t_prev", Left: &Operand{Field: FieldClose}, Op: OpGt, Right: &Operand{Field: FieldHigh, Prev: true}},
+			ind,
+			false,
+		},
+		{
+			"ema50 slope falls back to EMA50Prev",
+			ConditionDefinition{Code: "t_slope", Left: &Operand{Field: FieldEMA50}, Op: OpGt, Right: &Operand{Field: FieldEMA50, Prev: true}},
+			Indicators{EMA50: 1.06, EMA50Prev: floatPtr(1.05)},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := tt.cond.Compile()
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if got := fn(candle, tt.ind); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRegisterDefinitions(t *testing.T) {
+	withRegistries(t)
+
+	def, err := ParseDefinition([]byte(pullbackYAML), "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if err := RegisterDefinitions([]RuleDefinition{def}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if spec, ok := RuleRegistry["TEST_PULLBACK"]; !ok || spec.Version != 1 {
+		t.Fatalf("rule not registered: %+v", spec)
+	}
+	if _, ok := ConditionRegistry["test_low_reaches_ema20"]; !ok {
+		t.Fatal("declared condition not registered")
+	}
+
+	t.Run("same version is frozen", func(t *testing.T) {
+		err := RegisterDefinitions([]RuleDefinition{def})
+		if err == nil || !strings.Contains(err.Error(), "frozen") {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+	})
+
+	t.Run("built-in rule version is frozen", func(t *testing.T) {
+		builtin := def
+		builtin.Code = W1TrendBullish
+		builtin.Version = 1
+		if err := RegisterDefinitions([]RuleDefinition{builtin}); err == nil {
+			t.Error("expected error redefining built-in v1")
+		}
+	})
+
+	t.Run("condition semantics cannot change", func(t *testing.T) {
+		v2 := def
+		v2.Version = 2
+		v2.Conditions = []ConditionDefinition{def.Conditions[0], def.Conditions[1]}
+		changed := *def.Conditions[1].Left
+		changed.Field = FieldClose
+		v2.Conditions[1].Left = &changed
+
+		err := RegisterDefinitions([]RuleDefinition{v2})
+		if err == nil || !strings.Contains(err.Error(), "different semantics") {
+			t.Errorf("expected semantics error, got %v", err)
+		}
+	})
+
+	t.Run("built-in condition cannot be redefined", func(t *testing.T) {
+		v2 := def
+		v2.Version = 2
+		v2.Conditions = []ConditionDefinition{{
+			Code: EMA50GtEMA200, Left: &Operand{Field: FieldEMA50}, Op: OpLt, Right: &Operand{Field: FieldEMA200},
+		}}
+		if err := RegisterDefinitions([]RuleDefinition{v2}); err == nil {
+			t.Error("expected error redefining built-in condition")
+		}
+	})
+
+	t.Run("unknown reference rejects the whole batch", func(t *testing.T) {
+		good := def
+		good.Code = "TEST_OTHER"
+
+		bad := def
+		bad.Code = "TEST_BAD"
+		bad.Conditions = []ConditionDefinition{{Code: "does_not_exist"}}
+
+		if err := RegisterDefinitions([]RuleDefinition{good, bad}); err == nil {
+			t.Fatal("expected error for unknown reference")
+		}
+		if _, ok := RuleRegistry["TEST_OTHER"]; ok {
+			t.Error("valid definition registered despite batch failure")
+		}
+	})
+
+	t.Run("new version replaces old", func(t *testing.T) {
+		v2 := def
+		v2.Version = 2
+		v2.Name = "Test Pullback v2"
+		if err := RegisterDefinitions([]RuleDefinition{v2}); err != nil {
+			t.Fatalf("register v2: %v", err)
+		}
+		if RuleRegistry["TEST_PULLBACK"].Version != 2 {
+			t.Errorf("expected v2, got %+v", RuleRegistry["TEST_PULLBACK"])
+		}
+	})
+}
+
+func TestLoadDefinitions_Shipped(t *testing.T) {
+	withRegistries(t)
+
+	defs, err := LoadDefinitions("../../rules")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(defs) == 0 {
+		t.Fatal("expected shipped rule definitions")
+	}
+	if err := RegisterDefinitions(defs); err != nil {
+		t.Fatalf("register shipped definitions: %v", err)
+	}
+
+	missing, err := LoadDefinitions("does-not-exist")
+	if err != nil || missing != nil {
+		t.Errorf("missing dir should load nothing, got %v, %v", missing, err)
+	}
+}
+
+func TestConditionDefinition_Inputs(t *testing.T) {
+	def, err := ParseDefinition([]byte(pullbackYAML), "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	got := def.Conditions[1].Inputs()
+	want := []FieldRef{{Field: FieldLow}, {Field: FieldRangeSize}, {Field: FieldEMA20}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("input %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+const oversoldYAML = `
+code: TEST_OVERSOLD
+version: 1
+name: Test Oversold
+timeframe: W1
+conditions:
+  - code: test_rsi14_lt_30
+    left:
+      field: rsi14
+    op: lt
+    right:
+      value: 30
+  - code: test_macd_hist_rising
+    left:
+      field: macd_hist_12_26_9
+    op: gt
+    right:
+      field: macd_hist_12_26_9
+      prev: true
+`
+
+func TestRegistryIndicatorFields(t *testing.T) {
+	withRegistries(t)
+
+	def, err := ParseDefinition([]byte(oversoldYAML), "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := RegisterDefinitions([]RuleDefinition{def}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	candle := Candle{Open: 1.08, High: 1.09, Low: 1.07, Close: 1.085}
+	prevCandle := Candle{Open: 1.09, High: 1.10, Low: 1.07, Close: 1.08}
+
+	tests := []struct {
+		name     string
+		ind      Indicators
+		expected string
+	}{
+		{
+			"oversold and rising",
+			Indicators{
+				Values:     map[string]float64{"rsi14": 25, "macd_hist_12_26_9": -0.001},
+				Prev:       &Indicators{Values: map[string]float64{"rsi14": 22, "macd_hist_12_26_9": -0.002}},
+				PrevCandle: &prevCandle,
+			},
+			ResultPass,
+		},
+		{
+			"not oversold",
+			Indicators{
+				Values:     map[string]float64{"rsi14": 45, "macd_hist_12_26_9": -0.003},
+				Prev:       &Indicators{Values: map[string]float64{"rsi14": 40, "macd_hist_12_26_9": -0.002}},
+				PrevCandle: &prevCandle,
+			},
+			ResultFail,
+		},
+		{
+			"macd not warmed up",
+			Indicators{
+				Values:     map[string]float64{"rsi14": 25},
+				Prev:       &Indicators{Values: map[string]float64{"rsi14": 22}},
+				PrevCandle: &prevCandle,
+			},
+			ResultInsufficientData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EvaluateRule("TEST_OVERSOLD", candle, tt.ind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Fatalf("expected %s, got %s (%+v)", tt.expected, result.Result, result.Conditions)
+			}
+		})
+	}
+
+	t.Run("inputs are recorded", func(t *testing.T) {
+		values := ConditionInputValues("test_macd_hist_rising", candle, tests[0].ind)
+		if values["macd_hist_12_26_9"] != -0.001 || values["prev_macd_hist_12_26_9"] != -0.002 {
+			t.Errorf("unexpected inputs %v", values)
+		}
+	})
+
+	t.Run("unregistered indicator is rejected", func(t *testing.T) {
+		bad := strings.Replace(oversoldYAML, "field: rsi14", "field: rsi15", 1)
+		bad = strings.Replace(bad, "TEST_OVERSOLD", "TEST_BAD", 1)
+		def, err := ParseDefinition([]byte(bad), "yaml")
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if err := def.Validate(); err == nil {
+			t.Error("expected unknown field error")
+		}
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"log"
 )
 
-// EvaluateRule is a pure function that evaluates a rule
+// EvaluateRule is a pure function that evaluates the active version of a rule
 // NO DATABASE. NO SIDE EFFECTS. DETERMINISTIC.
 func EvaluateRule(ruleCode RuleCode, c Candle, ind Indicators) (RuleResult, error) {
 	// Get rule spec
@@ -14,19 +14,82 @@ func EvaluateRule(ruleCode RuleCode, c Candle, ind Indicators) (RuleResult, erro
 		return RuleResult{}, fmt.Errorf("rule not found: %s", ruleCode)
 	}
 
+	return EvaluateRuleSpec(spec, c, ind)
+}
+
+// EvaluateRuleVersion evaluates a specific registered version of a rule,
+// e.g. a candidate version side by side with the active one
+func EvaluateRuleVersion(ruleCode RuleCode, version int, c Candle, ind Indicators) (RuleResult, error) {
+	spec, exists := GetRuleVersion(ruleCode, version)
+	if !exists {
+		return RuleResult{}, fmt.Errorf("rule not found: %s v%d", ruleCode, version)
+	}
+
+	return EvaluateRuleSpec(spec, c, ind)
+}
+
+// EvaluateRuleSpec evaluates a rule spec and stamps the result with its lineage
+func EvaluateRuleSpec(spec RuleSpec, c Candle, ind Indicators) (RuleResult, error) {
+	return EvaluateRuleSpecWith(spec, c, ind, nil)
+}
+
+// EvaluateRuleSpecWith evaluates a rule spec with some conditions replaced
+// (e.g. TouchEMA20Within for a parameter sweep).
+// RESEARCH ONLY: with overrides the result no longer matches the definition
+// hash and must never be persisted.
+func EvaluateRuleSpecWith(spec RuleSpec, c Candle, ind Indicators, overrides map[ConditionCode]ConditionFunc) (RuleResult, error) {
+	hash, err := DefinitionHash(spec)
+	if err != nil {
+		return RuleResult{}, err
+	}
+
 	result := RuleResult{
-		RuleCode:       ruleCode,
+		RuleCode:       spec.Code,
+		Version:        spec.Version,
+		DefinitionHash: hash,
 		ConditionsMet:  []ConditionCode{},
 		ConditionsFail: []ConditionCode{},
 	}
 
-	// Evaluate each condition
+	// Evaluate each condition, recording the inputs it compared.
+	// From semantics 2 on, a condition with an input that is not warmed up is
+	// not evaluated at all: comparing placeholder zeros would produce a bogus
+	// PASS/FAIL. Versions released under semantics 1 keep doing so.
+	insufficient := false
 	for _, condCode := range spec.Conditions {
-		if EvaluateCondition(condCode, c, ind) {
+		if spec.Semantics >= 2 && len(InvalidInputs(condCode, ind)) > 0 {
+			insufficient = true
+			result.Conditions = append(result.Conditions, ConditionOutcome{
+				Code:             condCode,
+				InsufficientData: true,
+				Inputs:           ConditionInputValues(condCode, c, ind),
+			})
+			continue
+		}
+
+		var passed bool
+		if fn, ok := overrides[condCode]; ok {
+			passed = fn(c, ind)
+		} else {
+			passed = EvaluateCondition(condCode, c, ind)
+		}
+		if passed {
 			result.ConditionsMet = append(result.ConditionsMet, condCode)
 		} else {
 			result.ConditionsFail = append(result.ConditionsFail, condCode)
 		}
+
+		result.Conditions = append(result.Conditions, ConditionOutcome{
+			Code:   condCode,
+			Passed: passed,
+			Inputs: ConditionInputValues(condCode, c, ind),
+		})
+	}
+
+	if insufficient {
+		result.Result = ResultInsufficientData
+		result.Confidence = 0
+		return result, nil
 	}
 
 	// Determine pass/fail
@@ -35,9 +98,9 @@ func EvaluateRule(ruleCode RuleCode, c Candle, ind Indicators) (RuleResult, erro
 	passed := ShouldPass(metCount, totalCount)
 
 	if passed {
-		result.Result = "PASS"
+		result.Result = ResultPass
 	} else {
-		result.Result = "FAIL"
+		result.Result = ResultFail
 	}
 
 	// ✅ FIXED: Calculate confidence with pass/fail awareness
@@ -0,0 +1,353 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestEvaluateRule_W1TrendBearish(t *testing.T) {
+	candle := Candle{
+		Open:         1.0850,
+		High:         1.0870,
+		Low:          1.0760,
+		Close:        1.0780,
+		TimestampUTC: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name       string
+		ind        Indicators
+		expected   string
+		confidence float64
+		failed     []ConditionCode
+	}{
+		{
+			name: "all bearish conditions met",
+			ind: Indicators{
+				EMA50:     1.0842,
+				EMA200:    1.0900,
+				EMA50Prev: floatPtr(1.0850),
+			},
+			expected:   "PASS",
+			confidence: 1.0,
+		},
+		{
+			name: "EMA50 above EMA200",
+			ind: Indicators{
+				EMA50:     1.0842,
+				EMA200:    1.0790,
+				EMA50Prev: floatPtr(1.0850),
+			},
+			expected: "FAIL",
+			failed:   []ConditionCode{EMA50LtEMA200},
+		},
+		{
+			name: "close above EMA50",
+			ind: Indicators{
+				EMA50:     1.0770,
+				EMA200:    1.0900,
+				EMA50Prev: floatPtr(1.0780),
+			},
+			expected: "FAIL",
+			failed:   []ConditionCode{CloseLtEMA50},
+		},
+		{
+			name: "EMA50 rising",
+			ind: Indicators{
+				EMA50:     1.0842,
+				EMA200:    1.0900,
+				EMA50Prev: floatPtr(1.0830),
+			},
+			expected: "FAIL",
+			failed:   []ConditionCode{EMA50SlopeNegative},
+		},
+		{
+			name: "no previous EMA50 (first candle)",
+			ind: Indicators{
+				EMA50:  1.0842,
+				EMA200: 1.0900,
+			},
+			expected: "FAIL",
+			failed:   []ConditionCode{EMA50SlopeNegative},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EvaluateRule(W1TrendBearish, candle, tt.ind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result.Result)
+			}
+			if result.Confidence != tt.confidence {
+				t.Errorf("expected confidence %v, got %v", tt.confidence, result.Confidence)
+			}
+			if len(result.ConditionsFail) != len(tt.failed) {
+				t.Fatalf("expected failed %v, got %v", tt.failed, result.ConditionsFail)
+			}
+			for i, code := range tt.failed {
+				if result.ConditionsFail[i] != code {
+					t.Errorf("expected failed %v, got %v", tt.failed, result.ConditionsFail)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRule_W1TouchEMA20(t *testing.T) {
+	// Range = 0.0100 → θ = 0.0030, touch window = [1.0770, 1.0900]
+	candle := Candle{
+		Open:  1.0820,
+		High:  1.0870,
+		Low:   1.0800,
+		Close: 1.0850,
+	}
+	ind := Indicators{RangeSize: 0.0100}
+
+	tests := []struct {
+		name     string
+		ema20    float64
+		expected string
+	}{
+		{"EMA20 inside candle", 1.0830, "PASS"},
+		{"EMA20 below low within θ", 1.0775, "PASS"},
+		{"EMA20 above high within θ", 1.0895, "PASS"},
+		{"EMA20 just inside lower bound", 1.0771, "PASS"},
+		{"EMA20 below low beyond θ", 1.0760, "FAIL"},
+		{"EMA20 above high beyond θ", 1.0910, "FAIL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ind.EMA20 = tt.ema20
+			result, err := EvaluateRule(W1TouchEMA20, candle, ind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result.Result)
+			}
+		})
+	}
+}
+
+func TestEvaluateAllRules_CoversRegistry(t *testing.T) {
+	candle := Candle{Open: 1.08, High: 1.09, Low: 1.07, Close: 1.085}
+	ind := Indicators{EMA20: 1.08, EMA50: 1.07, EMA200: 1.06, RangeSize: 0.02}
+
+	results := EvaluateAllRules(candle, ind)
+	for _, code := range []RuleCode{W1TrendBullish, W1TrendBearish, W1TouchEMA20} {
+		if _, ok := results[code]; !ok {
+			t.Errorf("expected result for %s", code)
+		}
+	}
+
+	// Bullish and bearish trend can never pass on the same candle
+	if results[W1TrendBullish].Result == "PASS" && results[W1TrendBearish].Result == "PASS" {
+		t.Error("bullish and bearish trend both passed")
+	}
+}
+
+func TestConditionInputs_CoverBuiltins(t *testing.T) {
+	for code := range ConditionRegistry {
+		if _, declared := declaredConditions[code]; declared {
+			continue
+		}
+		if len(ConditionInputs[code]) == 0 {
+			t.Errorf("built-in condition %s has no recorded inputs", code)
+		}
+	}
+}
+
+func TestEvaluateRule_RecordsConditionInputs(t *testing.T) {
+	candle := Candle{Open: 1.0800, High: 1.0900, Low: 1.0780, Close: 1.0880}
+	ind := Indicators{EMA50: 1.0842, EMA200: 1.0790, EMA50Prev: floatPtr(1.0830)}
+
+	result, err := EvaluateRule(W1TrendBullish, candle, ind)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Conditions) != 3 {
+		t.Fatalf("expected 3 condition outcomes, got %d", len(result.Conditions))
+	}
+
+	first := result.Conditions[0]
+	if first.Code != EMA50GtEMA200 || !first.Passed {
+		t.Errorf("unexpected first outcome: %+v", first)
+	}
+	if first.Inputs["ema50"] != 1.0842 || first.Inputs["ema200"] != 1.0790 {
+		t.Errorf("unexpected inputs: %v", first.Inputs)
+	}
+
+	slope := result.Conditions[2]
+	if slope.Inputs["prev_ema50"] != 1.0830 {
+		t.Errorf("expected prev_ema50 input, got %v", slope.Inputs)
+	}
+
+	// Previous bar unavailable: input omitted, condition fails
+	ind.EMA50Prev = nil
+	result, _ = EvaluateRule(W1TrendBullish, candle, ind)
+	if _, ok := result.Conditions[2].Inputs["prev_ema50"]; ok || result.Conditions[2].Passed {
+		t.Errorf("expected missing prev_ema50 and failed slope, got %+v", result.Conditions[2])
+	}
+}
+
+func TestEvaluateRule_InsufficientData(t *testing.T) {
+	candle := Candle{Open: 1.0800, High: 1.0900, Low: 1.0780, Close: 1.0880}
+
+	tests := []struct {
+		name     string
+		ind      Indicators
+		expected string
+		invalid  []ConditionCode
+	}{
+		{
+			name: "EMA200 not warmed up",
+			ind: Indicators{
+				EMA50:     1.0842,
+				EMA50Prev: floatPtr(1.0830),
+				Invalid:   map[string]bool{FieldEMA200: true},
+			},
+			expected: ResultInsufficientData,
+			invalid:  []ConditionCode{EMA50GtEMA200},
+		},
+		{
+			name: "previous EMA50 not warmed up",
+			ind: Indicators{
+				EMA50:  1.0842,
+				EMA200: 1.0790,
+				Prev:   &Indicators{Invalid: map[string]bool{FieldEMA50: true}},
+			},
+			expected: ResultInsufficientData,
+			invalid:  []ConditionCode{EMA50SlopePositive},
+		},
+		{
+			name: "unrelated field not warmed up",
+			ind: Indicators{
+				EMA50:     1.0842,
+				EMA200:    1.0790,
+				EMA50Prev: floatPtr(1.0830),
+				Invalid:   map[string]bool{FieldEMA20: true},
+			},
+			expected: ResultPass,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EvaluateRule(W1TrendBullish, candle, tt.ind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, result.Result)
+			}
+
+			var invalid []ConditionCode
+			for _, cond := range result.Conditions {
+				if cond.InsufficientData {
+					invalid = append(invalid, cond.Code)
+					if cond.Passed {
+						t.Errorf("%s: insufficient condition marked passed", cond.Code)
+					}
+				}
+			}
+			if len(invalid) != len(tt.invalid) {
+				t.Fatalf("expected insufficient %v, got %v", tt.invalid, invalid)
+			}
+			for i := range invalid {
+				if invalid[i] != tt.invalid[i] {
+					t.Errorf("expected insufficient %v, got %v", tt.invalid, invalid)
+				}
+			}
+
+			if tt.expected == ResultInsufficientData {
+				if result.Confidence != 0 {
+					t.Errorf("expected confidence 0, got %v", result.Confidence)
+				}
+				for _, code := range result.ConditionsFail {
+					for _, bad := range tt.invalid {
+						if code == bad {
+							t.Errorf("insufficient condition %s counted as failed", code)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleVersion_InsufficientDataBySemantics(t *testing.T) {
+	// EMA200 not warmed up reads as 0, so EMA50 > EMA200 holds under semantics 1
+	candle := Candle{Open: 1.0800, High: 1.0900, Low: 1.0780, Close: 1.0880}
+	ind := Indicators{
+		EMA50:     1.0842,
+		EMA50Prev: floatPtr(1.0830),
+		Invalid:   map[string]bool{FieldEMA200: true},
+	}
+
+	tests := []struct {
+		version  int
+		expected string
+	}{
+		{1, ResultPass},
+		{2, ResultInsufficientData},
+	}
+
+	for _, tt := range tests {
+		result, err := EvaluateRuleVersion(W1TrendBullish, tt.version, candle, ind)
+		if err != nil {
+			t.Fatalf("v%d: unexpected error: %v", tt.version, err)
+		}
+		if result.Result != tt.expected {
+			t.Errorf("v%d: expected %s, got %s", tt.version, tt.expected, result.Result)
+		}
+	}
+}
+
+func TestConditionInputValues_OmitsInvalid(t *testing.T) {
+	candle := Candle{Close: 1.0880}
+	ind := Indicators{EMA50: 1.0842, Invalid: map[string]bool{FieldEMA200: true}}
+
+	values := ConditionInputValues(EMA50GtEMA200, candle, ind)
+	if _, ok := values["ema200"]; ok {
+		t.Errorf("expected invalid ema200 omitted, got %v", values)
+	}
+	if values["ema50"] != 1.0842 {
+		t.Errorf("expected ema50 input, got %v", values)
+	}
+}
+
+func TestEvaluateRuleSpecWith_Overrides(t *testing.T) {
+	// EMA20 is 0.4 × range below the low: outside θ = 0.3, inside θ = 0.5
+	candle := Candle{Open: 1.1050, High: 1.1100, Low: 1.1000, Close: 1.1080}
+	ind := Indicators{EMA20: 1.0960, RangeSize: 0.0100}
+	spec := RuleRegistry[W1TouchEMA20]
+
+	registered, err := EvaluateRuleSpec(spec, candle, ind)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if registered.Result != ResultFail {
+		t.Errorf("expected FAIL at θ = 0.3, got %s", registered.Result)
+	}
+	if TouchEMA20Within(0.3)(candle, ind) != EvaluateCondition(TouchEMA20, candle, ind) {
+		t.Error("TouchEMA20Within(0.3) differs from touch_ema20")
+	}
+
+	wider, err := EvaluateRuleSpecWith(spec, candle, ind, map[ConditionCode]ConditionFunc{
+		TouchEMA20: TouchEMA20Within(0.5),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wider.Result != ResultPass {
+		t.Errorf("expected PASS at θ = 0.5, got %s", wider.Result)
+	}
+}
@@ -0,0 +1,95 @@
+package rules
+
+import "set-and-trend/backend/internal/indicators"
+
+// Field names that declarative conditions can reference.
+// Price fields come from Candle, the rest from Indicators. Every output of a
+// registered indicator (indicators.Registry, e.g. "rsi14") is a field too,
+// resolved from Indicators.Values.
+const (
+	FieldOpen      = "open"
+	FieldHigh      = "high"
+	FieldLow       = "low"
+	FieldClose     = "close"
+	FieldEMA20     = "ema20"
+	FieldEMA50     = "ema50"
+	FieldEMA200    = "ema200"
+	FieldRangeSize = "range_size"
+	FieldBodySize  = "body_size"
+	FieldUpperWick = "upper_wick"
+	FieldLowerWick = "lower_wick"
+	FieldMidPrice  = "mid_price"
+)
+
+// fieldAccessors maps field names to their value on a single bar
+var fieldAccessors = map[string]func(c Candle, ind Indicators) float64{
+	FieldOpen:      func(c Candle, ind Indicators) float64 { return c.Open },
+	FieldHigh:      func(c Candle, ind Indicators) float64 { return c.High },
+	FieldLow:       func(c Candle, ind Indicators) float64 { return c.Low },
+	FieldClose:     func(c Candle, ind Indicators) float64 { return c.Close },
+	FieldEMA20:     func(c Candle, ind Indicators) float64 { return ind.EMA20 },
+	FieldEMA50:     func(c Candle, ind Indicators) float64 { return ind.EMA50 },
+	FieldEMA200:    func(c Candle, ind Indicators) float64 { return ind.EMA200 },
+	FieldRangeSize: func(c Candle, ind Indicators) float64 { return ind.RangeSize },
+	FieldBodySize:  func(c Candle, ind Indicators) float64 { return ind.BodySize },
+	FieldUpperWick: func(c Candle, ind Indicators) float64 { return ind.UpperWick },
+	FieldLowerWick: func(c Candle, ind Indicators) float64 { return ind.LowerWick },
+	FieldMidPrice:  func(c Candle, ind Indicators) float64 { return ind.MidPrice },
+}
+
+// IsKnownField reports whether a field name can be resolved by FieldValue
+func IsKnownField(name string) bool {
+	_, ok := fieldAccessor(name)
+	return ok
+}
+
+// fieldAccessor returns the accessor of a built-in field or registry indicator output
+func fieldAccessor(name string) (func(c Candle, ind Indicators) float64, bool) {
+	if accessor, ok := fieldAccessors[name]; ok {
+		return accessor, true
+	}
+	if indicators.IsOutput(name) {
+		return func(c Candle, ind Indicators) float64 { return ind.Values[name] }, true
+	}
+	return nil, false
+}
+
+// FieldValue resolves a named field on the current bar, or on the previous
+// bar when prev is true. Returns false if the value is not available
+// (e.g. previous bar on the first candle).
+func FieldValue(name string, prev bool, c Candle, ind Indicators) (float64, bool) {
+	accessor, ok := fieldAccessor(name)
+	if !ok {
+		return 0, false
+	}
+
+	if !prev {
+		return accessor(c, ind), true
+	}
+
+	// EMA50Prev predates Prev and is still the only previous value some callers set
+	if name == FieldEMA50 && ind.Prev == nil {
+		if ind.EMA50Prev == nil {
+			return 0, false
+		}
+		return *ind.EMA50Prev, true
+	}
+
+	if ind.Prev == nil || ind.PrevCandle == nil {
+		return 0, false
+	}
+	return accessor(*ind.PrevCandle, *ind.Prev), true
+}
+
+// FieldValid reports whether a field is warmed up on the current bar, or on
+// the previous bar when prev is true. A missing previous bar is not invalid:
+// FieldValue reports it as unavailable and the condition simply fails.
+func FieldValid(name string, prev bool, ind Indicators) bool {
+	if !prev {
+		return ind.IsValid(name)
+	}
+	if ind.Prev == nil {
+		return true
+	}
+	return ind.Prev.IsValid(name)
+}
@@ -0,0 +1,108 @@
+package rules
+
+// FieldRef names a field on the current bar, or on the previous bar when Prev is set
+type FieldRef struct {
+	Field string
+	Prev  bool
+}
+
+// Name is the key used when the input value is recorded ("ema50", "prev_ema50")
+func (f FieldRef) Name() string {
+	if f.Prev {
+		return "prev_" + f.Field
+	}
+	return f.Field
+}
+
+// ConditionInputs lists the fields each built-in condition compares.
+// Declared conditions derive their inputs from their operands.
+var ConditionInputs = map[ConditionCode][]FieldRef{
+	EMA50GtEMA200:      {{Field: FieldEMA50}, {Field: FieldEMA200}},
+	CloseGtEMA50:       {{Field: FieldClose}, {Field: FieldEMA50}},
+	EMA50SlopePositive: {{Field: FieldEMA50}, {Field: FieldEMA50, Prev: true}},
+	EMA50LtEMA200:      {{Field: FieldEMA50}, {Field: FieldEMA200}},
+	CloseLtEMA50:       {{Field: FieldClose}, {Field: FieldEMA50}},
+	EMA50SlopeNegative: {{Field: FieldEMA50}, {Field: FieldEMA50, Prev: true}},
+	TouchEMA20:         {{Field: FieldEMA20}, {Field: FieldLow}, {Field: FieldHigh}, {Field: FieldRangeSize}},
+	PinBarBullish:      ohlcRefs(false),
+	PinBarBearish:      ohlcRefs(false),
+	Doji:               ohlcRefs(false),
+	EngulfingBullish:   append(ohlcRefs(false), ohlcRefs(true)...),
+	EngulfingBearish:   append(ohlcRefs(false), ohlcRefs(true)...),
+	InsideBar:          {{Field: FieldHigh}, {Field: FieldLow}, {Field: FieldHigh, Prev: true}, {Field: FieldLow, Prev: true}},
+	OutsideBar:         {{Field: FieldHigh}, {Field: FieldLow}, {Field: FieldHigh, Prev: true}, {Field: FieldLow, Prev: true}},
+}
+
+// ohlcRefs references the candle's open/high/low/close (previous candle when prev)
+func ohlcRefs(prev bool) []FieldRef {
+	return []FieldRef{
+		{Field: FieldOpen, Prev: prev},
+		{Field: FieldHigh, Prev: prev},
+		{Field: FieldLow, Prev: prev},
+		{Field: FieldClose, Prev: prev},
+	}
+}
+
+// conditionRefs returns the fields a built-in or declared condition reads
+func conditionRefs(code ConditionCode) []FieldRef {
+	if def, ok := declaredConditions[code]; ok {
+		return def.Inputs()
+	}
+	return ConditionInputs[code]
+}
+
+// ConditionInputValues resolves the input values a condition compared on a bar.
+// Unavailable inputs (e.g. previous bar on the first candle) and inputs that
+// are not warmed up are omitted.
+func ConditionInputValues(code ConditionCode, c Candle, ind Indicators) map[string]float64 {
+	refs := conditionRefs(code)
+
+	values := make(map[string]float64, len(refs))
+	for _, ref := range refs {
+		if !FieldValid(ref.Field, ref.Prev, ind) {
+			continue
+		}
+		if v, ok := FieldValue(ref.Field, ref.Prev, c, ind); ok {
+			values[ref.Name()] = v
+		}
+	}
+	return values
+}
+
+// InvalidInputs lists the inputs of a condition that are not warmed up yet
+func InvalidInputs(code ConditionCode, ind Indicators) []FieldRef {
+	var invalid []FieldRef
+	for _, ref := range conditionRefs(code) {
+		if !FieldValid(ref.Field, ref.Prev, ind) {
+			invalid = append(invalid, ref)
+		}
+	}
+	return invalid
+}
+
+// Inputs lists every field referenced by the condition's operands, in order
+func (d ConditionDefinition) Inputs() []FieldRef {
+	var refs []FieldRef
+	seen := make(map[FieldRef]bool)
+
+	var walk func(o *Operand)
+	walk = func(o *Operand) {
+		if o == nil {
+			return
+		}
+		if o.Field != "" {
+			ref := FieldRef{Field: o.Field, Prev: o.Prev}
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+		for i := range o.Plus {
+			walk(&o.Plus[i])
+		}
+	}
+
+	walk(d.Left)
+	walk(d.Right)
+	return refs
+}
@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"math"
+
+	"set-and-trend/backend/internal/constants"
+)
+
+// Candlestick pattern conditions (weekly playbook: rejection and engulfing setups)
+const (
+	PinBarBullish    ConditionCode = "pin_bar_bullish"
+	PinBarBearish    ConditionCode = "pin_bar_bearish"
+	EngulfingBullish ConditionCode = "engulfing_bullish"
+	EngulfingBearish ConditionCode = "engulfing_bearish"
+	InsideBar        ConditionCode = "inside_bar"
+	OutsideBar       ConditionCode = "outside_bar"
+	Doji             ConditionCode = "doji"
+)
+
+// PatternConfig holds the ratios the pattern detectors use.
+// Registered conditions always use DefaultPatternConfig (FROZEN semantics);
+// other ratios are for research (e.g. backtests) through the Is* functions.
+type PatternConfig struct {
+	PinBarMinWickRatio    float64 // rejection wick as a fraction of the range
+	PinBarMaxBodyRatio    float64 // body as a fraction of the range
+	DojiMaxBodyRatio      float64 // body as a fraction of the range
+	EngulfingMinBodyRatio float64 // body as a multiple of the previous body
+}
+
+// DefaultPatternConfig is the configuration of the registered pattern conditions
+func DefaultPatternConfig() PatternConfig {
+	return PatternConfig{
+		PinBarMinWickRatio:    constants.PinBarMinWickRatio,
+		PinBarMaxBodyRatio:    constants.PinBarMaxBodyRatio,
+		DojiMaxBodyRatio:      constants.DojiMaxBodyRatio,
+		EngulfingMinBodyRatio: constants.EngulfingMinBodyRatio,
+	}
+}
+
+func candleRange(c Candle) float64 { return c.High - c.Low }
+func candleBody(c Candle) float64  { return math.Abs(c.Close - c.Open) }
+func upperWick(c Candle) float64   { return c.High - math.Max(c.Open, c.Close) }
+func lowerWick(c Candle) float64   { return math.Min(c.Open, c.Close) - c.Low }
+func isBullish(c Candle) bool      { return c.Close > c.Open }
+func isBearish(c Candle) bool      { return c.Close < c.Open }
+
+// IsBullishPinBar: long lower rejection wick, small body
+func IsBullishPinBar(c Candle, cfg PatternConfig) bool {
+	r := candleRange(c)
+	if r <= 0 {
+		return false
+	}
+	return lowerWick(c) >= cfg.PinBarMinWickRatio*r && candleBody(c) <= cfg.PinBarMaxBodyRatio*r
+}
+
+// IsBearishPinBar: long upper rejection wick, small body
+func IsBearishPinBar(c Candle, cfg PatternConfig) bool {
+	r := candleRange(c)
+	if r <= 0 {
+		return false
+	}
+	return upperWick(c) >= cfg.PinBarMinWickRatio*r && candleBody(c) <= cfg.PinBarMaxBodyRatio*r
+}
+
+// IsDoji: body negligible compared to the range
+func IsDoji(c Candle, cfg PatternConfig) bool {
+	r := candleRange(c)
+	if r <= 0 {
+		return false
+	}
+	return candleBody(c) <= cfg.DojiMaxBodyRatio*r
+}
+
+// IsBullishEngulfing: a bullish body covers the previous bearish body
+func IsBullishEngulfing(prev, c Candle, cfg PatternConfig) bool {
+	if !isBearish(prev) || !isBullish(c) {
+		return false
+	}
+	return c.Open <= prev.Close && c.Close >= prev.Open &&
+		candleBody(c) >= cfg.EngulfingMinBodyRatio*candleBody(prev)
+}
+
+// IsBearishEngulfing: a bearish body covers the previous bullish body
+func IsBearishEngulfing(prev, c Candle, cfg PatternConfig) bool {
+	if !isBullish(prev) || !isBearish(c) {
+		return false
+	}
+	return c.Open >= prev.Close && c.Close <= prev.Open &&
+		candleBody(c) >= cfg.EngulfingMinBodyRatio*candleBody(prev)
+}
+
+// IsInsideBar: range within the previous range (and strictly smaller)
+func IsInsideBar(prev, c Candle) bool {
+	return c.High <= prev.High && c.Low >= prev.Low && candleRange(c) < candleRange(prev)
+}
+
+// IsOutsideBar: range covers the previous range (and is strictly larger)
+func IsOutsideBar(prev, c Candle) bool {
+	return c.High >= prev.High && c.Low <= prev.Low && candleRange(c) > candleRange(prev)
+}
+
+// Registered pattern conditions. Two-bar patterns need the previous candle
+// and are false on the first candle.
+
+func conditionPinBarBullish(c Candle, ind Indicators) bool {
+	return IsBullishPinBar(c, DefaultPatternConfig())
+}
+
+func conditionPinBarBearish(c Candle, ind Indicators) bool {
+	return IsBearishPinBar(c, DefaultPatternConfig())
+}
+
+func conditionDoji(c Candle, ind Indicators) bool {
+	return IsDoji(c, DefaultPatternConfig())
+}
+
+func conditionEngulfingBullish(c Candle, ind Indicators) bool {
+	return ind.PrevCandle != nil && IsBullishEngulfing(*ind.PrevCandle, c, DefaultPatternConfig())
+}
+
+func conditionEngulfingBearish(c Candle, ind Indicators) bool {
+	return ind.PrevCandle != nil && IsBearishEngulfing(*ind.PrevCandle, c, DefaultPatternConfig())
+}
+
+func conditionInsideBar(c Candle, ind Indicators) bool {
+	return ind.PrevCandle != nil && IsInsideBar(*ind.PrevCandle, c)
+}
+
+func conditionOutsideBar(c Candle, ind Indicators) bool {
+	return ind.PrevCandle != nil && IsOutsideBar(*ind.PrevCandle, c)
+}
@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// loadEURUSDWeekly reads the shipped EURUSD weekly candles, oldest first
+func loadEURUSDWeekly(t *testing.T) []Candle {
+	t.Helper()
+
+	f, err := os.Open("../../mt4_ready/EURUSD_weekly_2015_2025.csv")
+	if err != nil {
+		t.Skipf("EURUSD weekly data not available: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	candles := make([]Candle, 0, len(records)-1)
+	for _, r := range records[1:] {
+		ts, err := time.Parse("2006-01-02", r[0])
+		if err != nil {
+			t.Fatalf("parse date %q: %v", r[0], err)
+		}
+		var ohlc [4]float64
+		for i := range ohlc {
+			if ohlc[i], err = strconv.ParseFloat(r[i+1], 64); err != nil {
+				t.Fatalf("parse price %q: %v", r[i+1], err)
+			}
+		}
+		candles = append(candles, Candle{
+			TimestampUTC: ts,
+			Open:         ohlc[0],
+			High:         ohlc[1],
+			Low:          ohlc[2],
+			Close:        ohlc[3],
+		})
+	}
+	return candles
+}
+
+var patternConditions = []ConditionCode{
+	PinBarBullish, PinBarBearish, EngulfingBullish, EngulfingBearish, InsideBar, OutsideBar, Doji,
+}
+
+func TestPatternConditions_EURUSDWeeks(t *testing.T) {
+	candles := loadEURUSDWeekly(t)
+
+	byWeek := make(map[string]int, len(candles))
+	for i, c := range candles {
+		byWeek[c.TimestampUTC.Format("2006-01-02")] = i
+	}
+
+	tests := []struct {
+		week     string
+		expected []ConditionCode
+	}{
+		{"2015-04-26", []ConditionCode{PinBarBullish}},                // long lower wick, close near open
+		{"2015-02-08", []ConditionCode{PinBarBearish, Doji}},          // long upper wick, tiny body
+		{"2015-06-07", []ConditionCode{EngulfingBullish}},             // covers the 2015-05-31 bearish body
+		{"2015-04-12", []ConditionCode{EngulfingBearish, OutsideBar}}, // engulfs body and range of 2015-04-05
+		{"2015-02-01", []ConditionCode{InsideBar}},                    // within the 2015-01-25 range
+		{"2015-12-06", []ConditionCode{OutsideBar}},                   // bullish, but 2015-11-29 body not covered by the open
+		{"2015-01-18", nil}, // plain bearish week
+		{"2022-09-25", nil}, // strong bearish week, no pattern
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.week, func(t *testing.T) {
+			i, ok := byWeek[tt.week]
+			if !ok || i == 0 {
+				t.Fatalf("week %s not in data (or first week)", tt.week)
+			}
+			ind := Indicators{PrevCandle: &candles[i-1]}
+
+			expected := make(map[ConditionCode]bool)
+			for _, code := range tt.expected {
+				expected[code] = true
+			}
+			for _, code := range patternConditions {
+				if got := EvaluateCondition(code, candles[i], ind); got != expected[code] {
+					t.Errorf("%s: expected %v, got %v", code, expected[code], got)
+				}
+			}
+		})
+	}
+}
+
+func TestPatternConditions_FirstCandle(t *testing.T) {
+	// Two-bar patterns need the previous candle
+	c := Candle{Open: 1.10, High: 1.12, Low: 1.08, Close: 1.11}
+	for _, code := range []ConditionCode{EngulfingBullish, EngulfingBearish, InsideBar, OutsideBar} {
+		if EvaluateCondition(code, c, Indicators{}) {
+			t.Errorf("%s: expected false without previous candle", code)
+		}
+	}
+}
+
+func TestPatternConfig_Ratios(t *testing.T) {
+	// Range 100 pips, lower wick 55, body 15
+	c := Candle{Open: 1.1055, High: 1.1100, Low: 1.1000, Close: 1.1070}
+	strict := DefaultPatternConfig()
+	loose := DefaultPatternConfig()
+	loose.PinBarMinWickRatio = 0.5
+
+	if IsBullishPinBar(c, strict) {
+		t.Error("expected no pin bar with a 55% wick at the default 60%")
+	}
+	if !IsBullishPinBar(c, loose) {
+		t.Error("expected pin bar with a 55% wick at 50%")
+	}
+
+	doji := Candle{Open: 1.1050, High: 1.1100, Low: 1.1000, Close: 1.1065} // body 15% of range
+	if IsDoji(doji, strict) {
+		t.Error("expected no doji with a 15% body at the default 10%")
+	}
+	loose.DojiMaxBodyRatio = 0.2
+	if !IsDoji(doji, loose) {
+		t.Error("expected doji with a 15% body at 20%")
+	}
+
+	prev := Candle{Open: 1.1060, High: 1.1070, Low: 1.0990, Close: 1.1000}
+	engulf := Candle{Open: 1.1000, High: 1.1080, Low: 1.0995, Close: 1.1065}
+	if !IsBullishEngulfing(prev, engulf, strict) {
+		t.Error("expected bullish engulfing")
+	}
+	loose.EngulfingMinBodyRatio = 1.5
+	if IsBullishEngulfing(prev, engulf, loose) {
+		t.Error("expected no engulfing when the body must be 1.5× the previous body")
+	}
+
+	flat := Candle{Open: 1.1, High: 1.1, Low: 1.1, Close: 1.1}
+	if IsDoji(flat, strict) || IsBullishPinBar(flat, strict) || IsBearishPinBar(flat, strict) {
+		t.Error("expected no single-bar pattern on a zero-range candle")
+	}
+}
@@ -1,6 +1,10 @@
 package rules
 
-import "time"
+import (
+	"time"
+
+	"set-and-trend/backend/internal/indicators"
+)
 
 // Timeframe represents a trading timeframe
 type Timeframe string
@@ -16,14 +20,19 @@ type RuleCode string
 
 const (
 	W1TrendBullish RuleCode = "W1_TREND_BULLISH"
+	W1TrendBearish RuleCode = "W1_TREND_BEARISH"
+	W1TouchEMA20   RuleCode = "W1_TOUCH_EMA20"
 )
 
 // RuleSpec defines an immutable rule specification
+// A (Code, Version) pair is FROZEN: changing semantics requires a new version
 type RuleSpec struct {
 	Code        RuleCode
+	Version     int
 	Name        string
 	Description string
 	Timeframe   Timeframe // ✅ FIXED: Now typed, not string
+	Semantics   int       // ConfidenceSemanticsVersion the version was released under
 	Conditions  []ConditionCode
 }
 
@@ -34,12 +43,19 @@ const (
 	EMA50GtEMA200      ConditionCode = "ema50_gt_ema200"
 	CloseGtEMA50       ConditionCode = "close_gt_ema50"
 	EMA50SlopePositive ConditionCode = "ema50_slope_positive"
+	EMA50LtEMA200      ConditionCode = "ema50_lt_ema200"
+	CloseLtEMA50       ConditionCode = "close_lt_ema50"
+	EMA50SlopeNegative ConditionCode = "ema50_slope_negative"
+	TouchEMA20         ConditionCode = "touch_ema20"
 )
 
-// RuleRegistry is the immutable registry of all rules
+// RuleRegistry is the immutable registry of all rules.
+// Version 2 of each built-in rule is version 1 under semantics 2.
 var RuleRegistry = map[RuleCode]RuleSpec{
 	W1TrendBullish: {
 		Code:        W1TrendBullish,
+		Version:     2,
+		Semantics:   2,
 		Name:        "Weekly Trend Bullish",
 		Description: "Weekly bullish trend confirmation: EMA50 > EMA200, Close > EMA50, EMA50 rising",
 		Timeframe:   W1, // ✅ FIXED: Using typed constant
@@ -49,18 +65,104 @@ var RuleRegistry = map[RuleCode]RuleSpec{
 			EMA50SlopePositive,
 		},
 	},
+	W1TrendBearish: {
+		Code:        W1TrendBearish,
+		Version:     2,
+		Semantics:   2,
+		Name:        "Weekly Trend Bearish",
+		Description: "Weekly bearish trend confirmation: EMA50 < EMA200, Close < EMA50, EMA50 falling",
+		Timeframe:   W1,
+		Conditions: []ConditionCode{
+			EMA50LtEMA200,
+			CloseLtEMA50,
+			EMA50SlopeNegative,
+		},
+	},
+	W1TouchEMA20: {
+		Code:        W1TouchEMA20,
+		Version:     2,
+		Semantics:   2,
+		Name:        "Weekly EMA20 Touch",
+		Description: "Weekly candle reaches EMA20 within θ = 0.3 × range of its high/low",
+		Timeframe:   W1,
+		Conditions: []ConditionCode{
+			TouchEMA20,
+		},
+	},
+}
+
+// releasedRuleVersions are earlier versions of the built-in rules, kept so
+// their stored results stay traceable. FROZEN: never edit, never remove.
+var releasedRuleVersions = []RuleSpec{
+	{
+		Code:        W1TrendBullish,
+		Version:     1,
+		Semantics:   1,
+		Name:        "Weekly Trend Bullish",
+		Description: "Weekly bullish trend confirmation: EMA50 > EMA200, Close > EMA50, EMA50 rising",
+		Timeframe:   W1,
+		Conditions: []ConditionCode{
+			EMA50GtEMA200,
+			CloseGtEMA50,
+			EMA50SlopePositive,
+		},
+	},
+	{
+		Code:        W1TrendBearish,
+		Version:     1,
+		Semantics:   1,
+		Name:        "Weekly Trend Bearish",
+		Description: "Weekly bearish trend confirmation: EMA50 < EMA200, Close < EMA50, EMA50 falling",
+		Timeframe:   W1,
+		Conditions: []ConditionCode{
+			EMA50LtEMA200,
+			CloseLtEMA50,
+			EMA50SlopeNegative,
+		},
+	},
+	{
+		Code:        W1TouchEMA20,
+		Version:     1,
+		Semantics:   1,
+		Name:        "Weekly EMA20 Touch",
+		Description: "Weekly candle reaches EMA20 within θ = 0.3 × range of its high/low",
+		Timeframe:   W1,
+		Conditions: []ConditionCode{
+			TouchEMA20,
+		},
+	},
 }
 
 // RuleResult represents the outcome of rule evaluation
 type RuleResult struct {
 	RuleCode       RuleCode
-	Result         string  // "PASS" or "FAIL"
+	Version        int     // rule version that produced this result
+	DefinitionHash string  // DefinitionHash of that version (lineage)
+	Result         string  // ResultPass, ResultFail or ResultInsufficientData
 	Confidence     float64 // 0.0 to 1.0
 	ConditionsMet  []ConditionCode
 	ConditionsFail []ConditionCode
+	Conditions     []ConditionOutcome // every condition in spec order, with inputs
 	// ✅ FIXED: Removed Explanation - frontend can build it from structured data
 }
 
+// Rule result values (rule_result_type)
+const (
+	ResultPass = "PASS"
+	ResultFail = "FAIL"
+	// An input of at least one condition is not warmed up yet: the rule
+	// cannot be judged either way on this candle
+	ResultInsufficientData = "INSUFFICIENT_DATA"
+)
+
+// ConditionOutcome is a single condition's boolean and the input values it compared
+type ConditionOutcome struct {
+	Code             ConditionCode
+	Passed           bool
+	InsufficientData bool               // not evaluated: an input is not warmed up
+	Inputs           map[string]float64 // e.g. {"ema50": 1.0842, "ema200": 1.0790}
+}
+
 // Candle represents candle data for rule evaluation
 type Candle struct {
 	Open         float64
@@ -81,4 +183,26 @@ type Indicators struct {
 	UpperWick  float64
 	LowerWick  float64
 	MidPrice   float64
+
+	// Previous bar (nil on the first candle), used for previous-bar comparisons
+	Prev       *Indicators
+	PrevCandle *Candle
+
+	// Fields without a valid value yet (NULL until warmed up, e.g. ema200 for
+	// the first 199 candles), keyed by field name. Their value above is 0.
+	Invalid map[string]bool
+
+	// Registry indicator outputs by name (rsi14, atr14, ...).
+	// An output missing from Values is not warmed up.
+	Values map[string]float64
+}
+
+// IsValid reports whether a field has a warmed-up value on this bar
+func (ind Indicators) IsValid(field string) bool {
+	if indicators.IsOutput(field) {
+		if _, ok := ind.Values[field]; !ok {
+			return false
+		}
+	}
+	return !ind.Invalid[field]
 }
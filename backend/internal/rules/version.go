@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// RuleVersions holds every registered version of every rule.
+// RuleRegistry holds the ACTIVE version per rule and is what EvaluateAllRules runs.
+// Candidate versions live only here until promoted.
+var RuleVersions = map[RuleCode]map[int]RuleSpec{}
+
+func init() {
+	for _, spec := range releasedRuleVersions {
+		registerVersion(spec)
+	}
+	for _, spec := range RuleRegistry {
+		registerVersion(spec)
+	}
+}
+
+func registerVersion(spec RuleSpec) {
+	if RuleVersions[spec.Code] == nil {
+		RuleVersions[spec.Code] = map[int]RuleSpec{}
+	}
+	RuleVersions[spec.Code][spec.Version] = spec
+}
+
+// GetRuleVersion returns a specific registered version of a rule
+func GetRuleVersion(code RuleCode, version int) (RuleSpec, bool) {
+	spec, ok := RuleVersions[code][version]
+	return spec, ok
+}
+
+// ListRuleVersions returns all registered versions of a rule, ascending
+func ListRuleVersions(code RuleCode) []RuleSpec {
+	specs := make([]RuleSpec, 0, len(RuleVersions[code]))
+	for _, spec := range RuleVersions[code] {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Version < specs[j].Version })
+	return specs
+}
+
+// ActivateVersion makes a registered version the one EvaluateAllRules uses
+func ActivateVersion(code RuleCode, version int) error {
+	spec, ok := GetRuleVersion(code, version)
+	if !ok {
+		return fmt.Errorf("rule %s v%d is not registered", code, version)
+	}
+	RuleRegistry[code] = spec
+	return nil
+}
+
+// canonicalCondition is the hashed form of a single condition.
+// Built-in conditions are identified by code only (their Go code is FROZEN);
+// declared conditions include their full definition.
+type canonicalCondition struct {
+	Code       ConditionCode        `json:"code"`
+	Definition *ConditionDefinition `json:"definition,omitempty"`
+}
+
+// canonicalRule is everything that can change the outcome of a rule result
+type canonicalRule struct {
+	Code                RuleCode             `json:"code"`
+	Version             int                  `json:"version"`
+	Timeframe           Timeframe            `json:"timeframe"`
+	Conditions          []canonicalCondition `json:"conditions"`
+	ConfidenceSemantics int                  `json:"confidence_semantics"`
+	ConfidenceThreshold float64              `json:"confidence_threshold"`
+	Sessions            []SessionSpec        `json:"sessions"`
+}
+
+// CanonicalDefinition returns the deterministic JSON document a rule version
+// is hashed from. It is stored alongside the hash for auditability.
+func CanonicalDefinition(spec RuleSpec) ([]byte, error) {
+	conditions := make([]canonicalCondition, len(spec.Conditions))
+	for i, code := range spec.Conditions {
+		conditions[i] = canonicalCondition{Code: code}
+		if def, ok := declaredConditions[code]; ok {
+			def := def
+			conditions[i].Definition = &def
+		}
+	}
+
+	return json.Marshal(canonicalRule{
+		Code:                spec.Code,
+		Version:             spec.Version,
+		Timeframe:           spec.Timeframe,
+		Conditions:          conditions,
+		ConfidenceSemantics: spec.Semantics,
+		ConfidenceThreshold: ConfidenceThreshold,
+		Sessions:            SessionRegistry,
+	})
+}
+
+// DefinitionHash is the sha256 of CanonicalDefinition.
+// Two results with the same hash were produced by identical semantics.
+func DefinitionHash(spec RuleSpec) (string, error) {
+	doc, err := CanonicalDefinition(spec)
+	if err != nil {
+		return "", fmt.Errorf("canonical definition %s v%d: %w", spec.Code, spec.Version, err)
+	}
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:]), nil
+}
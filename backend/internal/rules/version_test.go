@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestDefinitionHash(t *testing.T) {
+	base := RuleRegistry[W1TrendBullish]
+
+	h1, err := DefinitionHash(base)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	h2, _ := DefinitionHash(base)
+	if h1 != h2 {
+		t.Fatal("DefinitionHash is not deterministic")
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(s RuleSpec) RuleSpec
+	}{
+		{"version", func(s RuleSpec) RuleSpec { s.Version++; return s }},
+		{"semantics", func(s RuleSpec) RuleSpec { s.Semantics = 1; return s }},
+		{"condition removed", func(s RuleSpec) RuleSpec { s.Conditions = s.Conditions[:2]; return s }},
+		{"condition order", func(s RuleSpec) RuleSpec {
+			s.Conditions = []ConditionCode{s.Conditions[1], s.Conditions[0], s.Conditions[2]}
+			return s
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := DefinitionHash(tt.mutate(base))
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+			if h == h1 {
+				t.Errorf("expected hash to change when %s changes", tt.name)
+			}
+		})
+	}
+
+	t.Run("name and description are not semantics", func(t *testing.T) {
+		s := base
+		s.Name = "Renamed"
+		s.Description = "Reworded"
+		if h, _ := DefinitionHash(s); h != h1 {
+			t.Error("expected hash to ignore name/description")
+		}
+	})
+
+	t.Run("session registry is part of the hash", func(t *testing.T) {
+		original := SessionRegistry
+		t.Cleanup(func() { SessionRegistry = original })
+
+		SessionRegistry = append([]SessionSpec{}, original...)
+		SessionRegistry[0].EndUTC += 30
+		if h, _ := DefinitionHash(base); h == h1 {
+			t.Error("expected hash to change when sessions change")
+		}
+	})
+}
+
+func TestDefinitionHash_ReleasedVersionsUnchanged(t *testing.T) {
+	withRegistries(t)
+
+	defs, err := LoadDefinitions("../../rules")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := RegisterDefinitions(defs); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// Hashes stored when v1 was released: a change here breaks startup
+	released := map[RuleCode]string{
+		W1TrendBullish:        "1675864d72920e33069d93430f1b6b8d14ea00764d1cbfbc7d04542b7f08a39a",
+		W1TrendBearish:        "1e4e6c57a18403ebf55702f945f4d8da084aa13fdbdb39d2a72216a883fbd7ab",
+		W1TouchEMA20:          "71816337866af4e0650df4d5129f1a0f7cb4d9513cf8258b13be1699cb09eb42",
+		"W1_PULLBACK_BULLISH": "d573d96805b3e67469f515310a4d2fc5d63b4e6fbfd2524e09e78dc4e56a1724",
+	}
+
+	for code, want := range released {
+		v1, ok := GetRuleVersion(code, 1)
+		if !ok {
+			t.Fatalf("%s v1 is not registered", code)
+		}
+		if h, _ := DefinitionHash(v1); h != want {
+			t.Errorf("%s v1 hash changed: %s", code, h)
+		}
+
+		v2, ok := GetRuleVersion(code, 2)
+		if !ok {
+			t.Fatalf("%s v2 is not registered", code)
+		}
+		if v2.Semantics != ConfidenceSemanticsVersion {
+			t.Errorf("%s v2: expected semantics %d, got %d", code, ConfidenceSemanticsVersion, v2.Semantics)
+		}
+		if h, _ := DefinitionHash(v2); h == want {
+			t.Errorf("%s v2 shares the v1 hash", code)
+		}
+	}
+}
+
+func TestRuleVersions_SideBySide(t *testing.T) {
+	withRegistries(t)
+
+	v1, err := ParseDefinition([]byte(pullbackYAML), "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	v2 := v1
+	v2.Version = 2
+	v2.Conditions = v1.Conditions[1:] // drop the trend filter
+
+	if err := RegisterDefinitions([]RuleDefinition{v1, v2}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if got := ListRuleVersions("TEST_PULLBACK"); len(got) != 2 || got[0].Version != 1 || got[1].Version != 2 {
+		t.Fatalf("expected versions [1 2], got %+v", got)
+	}
+	if RuleRegistry["TEST_PULLBACK"].Version != 2 {
+		t.Fatalf("expected highest version active, got v%d", RuleRegistry["TEST_PULLBACK"].Version)
+	}
+
+	// Bearish structure: v1 fails on the trend filter, v2 ignores it
+	c := Candle{Open: 1.08, High: 1.09, Low: 1.07, Close: 1.085}
+	ind := Indicators{EMA20: 1.072, EMA50: 1.06, EMA200: 1.10, RangeSize: 0.02}
+
+	r1, err := EvaluateRuleVersion("TEST_PULLBACK", 1, c, ind)
+	if err != nil {
+		t.Fatalf("evaluate v1: %v", err)
+	}
+	r2, err := EvaluateRuleVersion("TEST_PULLBACK", 2, c, ind)
+	if err != nil {
+		t.Fatalf("evaluate v2: %v", err)
+	}
+
+	if r1.Result != "FAIL" || r2.Result != "PASS" {
+		t.Errorf("expected v1 FAIL / v2 PASS, got %s / %s", r1.Result, r2.Result)
+	}
+	if r1.Version != 1 || r2.Version != 2 {
+		t.Errorf("results not stamped with version: %d, %d", r1.Version, r2.Version)
+	}
+	if r1.DefinitionHash == "" || r1.DefinitionHash == r2.DefinitionHash {
+		t.Errorf("expected distinct definition hashes, got %q and %q", r1.DefinitionHash, r2.DefinitionHash)
+	}
+
+	if err := ActivateVersion("TEST_PULLBACK", 1); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	active, _ := EvaluateRule("TEST_PULLBACK", c, ind)
+	if active.Version != 1 || active.Result != "FAIL" {
+		t.Errorf("expected active v1 FAIL, got v%d %s", active.Version, active.Result)
+	}
+
+	if err := ActivateVersion("TEST_PULLBACK", 3); err == nil {
+		t.Error("expected error activating unregistered version")
+	}
+	if _, err := EvaluateRuleVersion("TEST_PULLBACK", 3, c, ind); err == nil {
+		t.Error("expected error evaluating unregistered version")
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// swingLookback is how many recent confirmed swings are searched for a trail
+const swingLookback = 10
+
+// AdjustmentService moves the SL and TP of open trades. Every move is
+// appended to trade_adjustments; trades.actual_sl/actual_tp mirror the levels
+// in force, planned_sl/planned_tp stay the plan.
+type AdjustmentService struct {
+	pool           *pgxpool.Pool
+	tradeRepo      *repositories.TradeRepository
+	executionRepo  *repositories.ExecutionRepository
+	intentRepo     *repositories.IntentRepository
+	adjustmentRepo *repositories.AdjustmentRepository
+	swingRepo      SwingRepo
+	instrumentRepo *repositories.InstrumentRepository
+}
+
+type AdjustTradeInput struct {
+	TradeID        uuid.UUID
+	AdjustmentType domain.AdjustmentType
+	Price          *float64 // new level, move_sl and move_tp only
+	MarketPrice    float64
+	AdjustedAt     time.Time
+	Reason         *string
+}
+
+func NewAdjustmentService(
+	tradeRepo *repositories.TradeRepository,
+	executionRepo *repositories.ExecutionRepository,
+	intentRepo *repositories.IntentRepository,
+	adjustmentRepo *repositories.AdjustmentRepository,
+	swingRepo SwingRepo,
+	instrumentRepo *repositories.InstrumentRepository,
+	pool *pgxpool.Pool,
+) *AdjustmentService {
+	return &AdjustmentService{
+		tradeRepo:      tradeRepo,
+		executionRepo:  executionRepo,
+		intentRepo:     intentRepo,
+		adjustmentRepo: adjustmentRepo,
+		swingRepo:      swingRepo,
+		instrumentRepo: instrumentRepo,
+		pool:           pool,
+	}
+}
+
+// AdjustTrade moves the SL or TP of an open or partial trade with
+// SERIALIZABLE isolation, like RecordExecution
+func (s *AdjustmentService) AdjustTrade(ctx context.Context, input AdjustTradeInput) (*repositories.TradeAdjustment, error) {
+	if !domain.IsValidAdjustment(string(input.AdjustmentType)) {
+		return nil, fmt.Errorf("invalid adjustment type: %s", input.AdjustmentType)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE"); err != nil {
+		return nil, fmt.Errorf("set isolation level: %w", err)
+	}
+
+	// 1. Load trade, executions, intent and prior adjustments
+	trade, err := s.tradeRepo.GetTradeByID(ctx, input.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("get trade: %w", err)
+	}
+
+	executions, err := s.executionRepo.GetExecutionsByTradeIDTx(ctx, tx, input.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("get executions: %w", err)
+	}
+
+	intent, err := s.intentRepo.GetIntentByTradeIDTx(ctx, tx, input.TradeID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("get intent: %w", err)
+	}
+
+	stored, err := s.adjustmentRepo.GetAdjustmentsByTradeIDTx(ctx, tx, input.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("get adjustments: %w", err)
+	}
+	adjustments, err := mapToTradeAdjustments(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Only a position in the market has levels to move
+	tradeExecs := mapToTradeExecutions(executions)
+	state, err := DeriveTradeState(tradeExecs, mapToTradeIntent(intent))
+	if err != nil {
+		return nil, fmt.Errorf("derive state: %w", err)
+	}
+	if state != StateOpen && state != StatePartial {
+		return nil, fmt.Errorf("cannot adjust: trade is %s", state)
+	}
+
+	if err := ValidateAdjustmentTime(input.AdjustedAt, time.Now(), tradeExecs, adjustments); err != nil {
+		return nil, err
+	}
+
+	// 3. Resolve the new level
+	plannedSL, err := parseDecimal(trade.PlannedSL)
+	if err != nil {
+		return nil, fmt.Errorf("parse planned sl: %w", err)
+	}
+	plannedTP, err := parseDecimal(trade.PlannedTP)
+	if err != nil {
+		return nil, fmt.Errorf("parse planned tp: %w", err)
+	}
+	levels := LevelsInForce(plannedSL, plannedTP, adjustments, input.AdjustedAt)
+
+	newPrice, swingID, err := s.resolveLevel(ctx, trade, tradeExecs, levels.SL, input)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := levels.TP
+	if input.AdjustmentType.MovesSL() {
+		previous = levels.SL
+	}
+	if newPrice == previous {
+		return nil, fmt.Errorf("level is already at %v", newPrice)
+	}
+
+	// 4. A SL never crosses the market price (nor a TP)
+	if err := ValidateAdjustment(trade.Bias, input.AdjustmentType, newPrice, input.MarketPrice); err != nil {
+		return nil, err
+	}
+
+	// 5. Append the adjustment and mirror the levels in force on the trade
+	adjustment, err := s.adjustmentRepo.CreateAdjustmentTx(ctx, tx, repositories.CreateAdjustmentParams{
+		TradeID:        input.TradeID,
+		AdjustmentType: string(input.AdjustmentType),
+		PreviousPrice:  previous,
+		NewPrice:       newPrice,
+		MarketPrice:    input.MarketPrice,
+		SwingPointID:   swingID,
+		Reason:         input.Reason,
+		AdjustedAt:     input.AdjustedAt.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create adjustment: %w", err)
+	}
+
+	if input.AdjustmentType.MovesSL() {
+		levels.SL = newPrice
+	} else {
+		levels.TP = newPrice
+	}
+	if err := s.adjustmentRepo.UpdateTradeLevelsTx(ctx, tx, input.TradeID, levels.SL, levels.TP); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	log.Info().
+		Str("trade_id", input.TradeID.String()).
+		Str("adjustment_type", string(input.AdjustmentType)).
+		Float64("previous", previous).
+		Float64("new", newPrice).
+		Msg("trade adjusted")
+
+	return adjustment, nil
+}
+
+// resolveLevel returns the new level of an adjustment: the requested price,
+// the actual entry (break-even) or the last swing on the stop side (a low for
+// a long, a high for a short) whose confirming candle closed by AdjustedAt.
+// A trail only tightens: swings at or beyond currentSL are skipped.
+func (s *AdjustmentService) resolveLevel(
+	ctx context.Context,
+	trade *repositories.Trade,
+	executions []TradeExecution,
+	currentSL float64,
+	input AdjustTradeInput,
+) (float64, *uuid.UUID, error) {
+	switch input.AdjustmentType {
+	case domain.AdjustBreakEven:
+		entry, err := GetActualEntryPrice(executions)
+		return entry, nil, err
+
+	case domain.AdjustTrailToSwing:
+		swingType := "low"
+		if trade.Bias == "short" {
+			swingType = "high"
+		}
+		swings, err := s.swingRepo.GetConfirmedSwings(ctx, trade.Symbol, input.AdjustedAt, swingLookback)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, swing := range swings {
+			if swing.SwingType != swingType {
+				continue
+			}
+			price, err := parseDecimal(swing.Price)
+			if err != nil {
+				return 0, nil, fmt.Errorf("parse swing price: %w", err)
+			}
+			if (trade.Bias == "short" && price >= currentSL) || (trade.Bias != "short" && price <= currentSL) {
+				continue
+			}
+			return price, &swing.ID, nil
+		}
+		return 0, nil, fmt.Errorf("no confirmed swing %s tightens the SL %v", swingType, currentSL)
+
+	default:
+		if input.Price == nil {
+			return 0, nil, fmt.Errorf("price is required for %s", input.AdjustmentType)
+		}
+		return *input.Price, nil, nil
+	}
+}
+
+// GetTradeRisk returns the levels in force of an entered trade and what its
+// remaining position risks at the stop in force
+func (s *AdjustmentService) GetTradeRisk(ctx context.Context, tradeID uuid.UUID) (TradeRisk, error) {
+	trade, err := s.tradeRepo.GetTradeByID(ctx, tradeID)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("get trade: %w", err)
+	}
+
+	executions, err := s.executionRepo.GetExecutionsByTradeID(ctx, tradeID)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("get executions: %w", err)
+	}
+
+	stored, err := s.adjustmentRepo.GetAdjustmentsByTradeID(ctx, tradeID)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("get adjustments: %w", err)
+	}
+	adjustments, err := mapToTradeAdjustments(stored)
+	if err != nil {
+		return TradeRisk{}, err
+	}
+
+	inst, err := s.instrumentRepo.GetInstrument(ctx, trade.Symbol)
+	if err != nil {
+		return TradeRisk{}, err
+	}
+
+	plannedSL, err := parseDecimal(trade.PlannedSL)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("parse planned sl: %w", err)
+	}
+	plannedTP, err := parseDecimal(trade.PlannedTP)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("parse planned tp: %w", err)
+	}
+	plannedSize, err := parseDecimal(trade.PlannedPositionSize)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("parse planned size: %w", err)
+	}
+	rate, err := parseDecimal(trade.QuoteToAccountRateAtSetup)
+	if err != nil {
+		return TradeRisk{}, fmt.Errorf("parse conversion rate: %w", err)
+	}
+
+	levels := LevelsInForce(plannedSL, plannedTP, adjustments, time.Now())
+	return ComputeTradeRisk(trade.Bias, plannedSize, mapToTradeExecutions(executions), levels, *inst, rate)
+}
+
+func mapToTradeAdjustments(stored []repositories.TradeAdjustment) ([]TradeAdjustment, error) {
+	adjustments := make([]TradeAdjustment, len(stored))
+	for i, a := range stored {
+		price, err := parseDecimal(a.NewPrice)
+		if err != nil {
+			return nil, fmt.Errorf("parse adjustment price: %w", err)
+		}
+		adjustments[i] = TradeAdjustment{
+			AdjustmentType: a.AdjustmentType,
+			Price:          price,
+			AdjustedAt:     a.AdjustedAt,
+		}
+	}
+	return adjustments, nil
+}
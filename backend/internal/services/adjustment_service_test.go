@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// mockSwingRepo applies GetConfirmedSwings' visibility rule to stored swings
+type mockSwingRepo struct {
+	swings []repositories.SwingPoint
+}
+
+func (m *mockSwingRepo) GetConfirmedSwings(ctx context.Context, symbol string, asOf time.Time, limit int) ([]repositories.SwingPoint, error) {
+	visible := []repositories.SwingPoint{}
+	for _, sp := range m.swings {
+		if !sp.ConfirmedAtUTC.After(asOf) {
+			visible = append(visible, sp)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		return visible[i].SwingTimestampUTC.After(visible[j].SwingTimestampUTC)
+	})
+	if len(visible) > limit {
+		visible = visible[:limit]
+	}
+	return visible, nil
+}
+
+func TestResolveLevel_TrailToSwing(t *testing.T) {
+	candles := swingCandles([][2]float64{
+		{1.10, 1.05},
+		{1.11, 1.04},
+		{1.12, 1.02}, // swing low at 2, confirmed by candle 4
+		{1.13, 1.04},
+		{1.14, 1.05},
+		{1.15, 1.06},
+		{1.16, 1.04}, // swing low at 6, confirmed by candle 8
+		{1.17, 1.05},
+		{1.18, 1.07},
+		{1.19, 1.06},
+		{1.20, 1.03}, // swing low at 10, below the one at 6, confirmed by candle 12
+		{1.21, 1.05},
+		{1.22, 1.06},
+	})
+
+	// Stored the way IndicatorService.ProcessCandle records them
+	repo := &mockSwingRepo{}
+	for _, sp := range DetectSwings(candles) {
+		repo.swings = append(repo.swings, repositories.SwingPoint{
+			SwingType:         string(sp.Type),
+			Price:             strconv.FormatFloat(sp.Price, 'f', -1, 64),
+			SwingTimestampUTC: sp.TimestampUTC,
+			ConfirmedAtUTC:    sp.ConfirmedAtUTC,
+		})
+	}
+	service := &AdjustmentService{swingRepo: repo}
+	trade := &repositories.Trade{Symbol: "EURUSD", Bias: "long"}
+
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		adjustedAt time.Time
+		currentSL  float64
+		expected   float64 // 0 = no swing to trail to
+	}{
+		{"inside the confirming week", candles[8].TimestampUTC.Add(3 * day), 1.00, 1.02},
+		{"at the confirming close", candles[8].TimestampUTC.Add(7 * day), 1.00, 1.04},
+		{"newest swing would widen, older one tightens", candles[12].TimestampUTC.Add(7 * day), 1.035, 1.04},
+		{"at the stop does not tighten", candles[12].TimestampUTC.Add(7 * day), 1.04, 0},
+		{"every swing would widen", candles[8].TimestampUTC.Add(3 * day), 1.03, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, _, err := service.resolveLevel(context.Background(), trade, nil, tt.currentSL, AdjustTradeInput{
+				AdjustmentType: domain.AdjustTrailToSwing,
+				AdjustedAt:     tt.adjustedAt,
+			})
+			if tt.expected == 0 {
+				if err == nil {
+					t.Fatalf("expected no swing to trail to, got %v", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if price != tt.expected {
+				t.Errorf("expected trail to %v, got %v", tt.expected, price)
+			}
+		})
+	}
+}
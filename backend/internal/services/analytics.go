@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+// TradeOutcome is a closed trade measured from its executions.
+// Everything is relative to the ACTUAL entry price, never the planned one.
+type TradeOutcome struct {
+	TradeID    uuid.UUID          `json:"trade_id"`
+	Bias       string             `json:"bias"`
+	EntryPrice float64            `json:"entry_price"`
+	ClosedAt   time.Time          `json:"closed_at"`
+	Result     domain.TradeResult `json:"result"`
+	PnLMoney   float64            `json:"pnl_money"`
+	PnLPips    float64            `json:"pnl_pips"`   // size-weighted over every exit
+	RMultiple  float64            `json:"r_multiple"` // pnl pips / initial risk pips
+	PlannedRR  float64            `json:"planned_rr"` // planned TP vs SL, from the actual entry
+}
+
+// AnalyticsSummary is the outcome summary of a set of closed trades
+type AnalyticsSummary struct {
+	Trades               int     `json:"trades"`
+	Wins                 int     `json:"wins"`
+	Losses               int     `json:"losses"`
+	Breakevens           int     `json:"breakevens"`
+	WinRate              float64 `json:"win_rate"` // wins / trades, 0-1
+	NetPnL               float64 `json:"net_pnl"`
+	NetPips              float64 `json:"net_pips"`
+	NetR                 float64 `json:"net_r"`
+	AverageRR            float64 `json:"average_rr"` // planned reward:risk taken
+	AverageR             float64 `json:"average_r"`  // realized R-multiple per trade
+	AverageWinR          float64 `json:"average_win_r"`
+	AverageLossR         float64 `json:"average_loss_r"`
+	Expectancy           float64 `json:"expectancy"`    // money per trade
+	ProfitFactor         float64 `json:"profit_factor"` // gross profit / gross loss, 0 without losses
+	MaxConsecutiveWins   int     `json:"max_consecutive_wins"`
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"`
+}
+
+// ComputeTradeOutcome prices every exit (partial closes included) against the
+// actual entry with ComputeRealizedPnL. R is in units of the original stop
+// (plannedSL), however the stop was moved since. Returns an error if the
+// trade has no closing event.
+func ComputeTradeOutcome(
+	tradeID uuid.UUID,
+	inst domain.Instrument,
+	quoteToAccountRate float64,
+	bias string,
+	plannedSL float64,
+	plannedTP float64,
+	executions []TradeExecution,
+) (TradeOutcome, error) {
+	entryPrice, err := GetActualEntryPrice(executions)
+	if err != nil {
+		return TradeOutcome{}, err
+	}
+
+	riskPips := inst.Pips(math.Abs(entryPrice - plannedSL))
+	if riskPips == 0 {
+		return TradeOutcome{}, errors.New("stop loss equals entry price")
+	}
+
+	outcome := TradeOutcome{
+		TradeID:    tradeID,
+		Bias:       bias,
+		EntryPrice: entryPrice,
+		PlannedRR:  math.Abs(plannedTP-entryPrice) / math.Abs(entryPrice-plannedSL),
+	}
+
+	realized, err := ComputeRealizedPnL(bias, executions, inst, quoteToAccountRate)
+	if err != nil {
+		return TradeOutcome{}, err
+	}
+
+	closed := false
+	for _, exec := range realized.Executions {
+		if domain.IsClosingEvent(domain.ExecutionEventType(exec.EventType)) {
+			closed = true
+			outcome.ClosedAt = exec.ExecutedAt
+		}
+	}
+	if !closed {
+		return TradeOutcome{}, errors.New("trade has no closing execution")
+	}
+	if realized.ClosedSize <= 0 {
+		return TradeOutcome{}, errors.New("closed position size must be positive")
+	}
+
+	outcome.PnLMoney = realized.PnL
+	outcome.PnLPips = realized.PnLPips
+	outcome.RMultiple = outcome.PnLPips / riskPips
+
+	switch {
+	case outcome.PnLMoney > 0:
+		outcome.Result = domain.ResultWin
+	case outcome.PnLMoney < 0:
+		outcome.Result = domain.ResultLoss
+	default:
+		outcome.Result = domain.ResultBreakeven
+	}
+
+	return outcome, nil
+}
+
+// ComputeAnalyticsSummary aggregates closed trades. Streaks follow close
+// time; breakevens neither extend nor break a streak.
+func ComputeAnalyticsSummary(outcomes []TradeOutcome) AnalyticsSummary {
+	sorted := make([]TradeOutcome, len(outcomes))
+	copy(sorted, outcomes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ClosedAt.Before(sorted[j].ClosedAt)
+	})
+
+	summary := AnalyticsSummary{Trades: len(sorted)}
+
+	var grossProfit, grossLoss, winR, lossR, plannedRR float64
+	winStreak, lossStreak := 0, 0
+
+	for _, o := range sorted {
+		summary.NetPnL += o.PnLMoney
+		summary.NetPips += o.PnLPips
+		summary.NetR += o.RMultiple
+		plannedRR += o.PlannedRR
+
+		switch o.Result {
+		case domain.ResultWin:
+			summary.Wins++
+			grossProfit += o.PnLMoney
+			winR += o.RMultiple
+			winStreak++
+			lossStreak = 0
+		case domain.ResultLoss:
+			summary.Losses++
+			grossLoss -= o.PnLMoney
+			lossR += o.RMultiple
+			lossStreak++
+			winStreak = 0
+		default:
+			summary.Breakevens++
+		}
+
+		summary.MaxConsecutiveWins = max(summary.MaxConsecutiveWins, winStreak)
+		summary.MaxConsecutiveLosses = max(summary.MaxConsecutiveLosses, lossStreak)
+	}
+
+	if summary.Trades > 0 {
+		n := float64(summary.Trades)
+		summary.WinRate = float64(summary.Wins) / n
+		summary.AverageR = summary.NetR / n
+		summary.AverageRR = plannedRR / n
+		summary.Expectancy = summary.NetPnL / n
+	}
+	if summary.Wins > 0 {
+		summary.AverageWinR = winR / float64(summary.Wins)
+	}
+	if summary.Losses > 0 {
+		summary.AverageLossR = lossR / float64(summary.Losses)
+	}
+	if grossLoss > 0 {
+		summary.ProfitFactor = grossProfit / grossLoss
+	}
+	return summary
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
+)
+
+// AnalyticsService derives outcome statistics from trade_executions.
+// trades.result / rr_realized / pips_gained are never read: executions are
+// the source of truth.
+type AnalyticsService struct {
+	analyticsRepo  *repositories.AnalyticsRepository
+	accountRepo    AccountRepo
+	instrumentRepo *repositories.InstrumentRepository
+}
+
+func NewAnalyticsService(
+	analyticsRepo *repositories.AnalyticsRepository,
+	accountRepo AccountRepo,
+	instrumentRepo *repositories.InstrumentRepository,
+) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo:  analyticsRepo,
+		accountRepo:    accountRepo,
+		instrumentRepo: instrumentRepo,
+	}
+}
+
+// instruments loads the catalogue by symbol
+func (s *AnalyticsService) instruments(ctx context.Context) (map[string]domain.Instrument, error) {
+	list, err := s.instrumentRepo.ListInstruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instruments := make(map[string]domain.Instrument, len(list))
+	for _, inst := range list {
+		instruments[inst.Symbol] = inst
+	}
+	return instruments, nil
+}
+
+// Summary computes the outcome summary of the account's trades closed in [from, to)
+func (s *AnalyticsService) Summary(ctx context.Context, accountID uuid.UUID, from, to time.Time) (AnalyticsSummary, error) {
+	outcomes, err := s.ClosedTradeOutcomes(ctx, accountID, from, to)
+	if err != nil {
+		return AnalyticsSummary{}, err
+	}
+	return ComputeAnalyticsSummary(outcomes), nil
+}
+
+// Edge computes the statistics of the trades closed in [from, to) matching
+// filter, next to the unfiltered baseline
+func (s *AnalyticsService) Edge(ctx context.Context, accountID uuid.UUID, from, to time.Time, filter EdgeFilter) (EdgeReport, error) {
+	if err := filter.Validate(); err != nil {
+		return EdgeReport{}, err
+	}
+
+	trades, err := s.analyticsRepo.GetClosedTrades(ctx, accountID, from, to)
+	if err != nil {
+		return EdgeReport{}, err
+	}
+
+	candleIDs := make([]uuid.UUID, len(trades))
+	for i, t := range trades {
+		candleIDs[i] = t.CandleID
+	}
+	ruleResults, err := s.analyticsRepo.GetActiveRuleResults(ctx, candleIDs)
+	if err != nil {
+		return EdgeReport{}, err
+	}
+	instruments, err := s.instruments(ctx)
+	if err != nil {
+		return EdgeReport{}, err
+	}
+
+	edgeTrades := make([]EdgeTrade, 0, len(trades))
+	for _, t := range trades {
+		outcome, err := closedTradeOutcome(t, instruments)
+		if err != nil {
+			return EdgeReport{}, err
+		}
+
+		et := EdgeTrade{
+			Outcome:     outcome,
+			CandleID:    t.CandleID,
+			RuleResults: make(map[rules.RuleCode]string),
+		}
+		for code, result := range ruleResults[t.CandleID] {
+			et.RuleResults[rules.RuleCode(code)] = result
+		}
+		for _, e := range t.Executions {
+			if e.EventType == string(domain.EventEntry) && e.Session != nil {
+				session := domain.Session(*e.Session)
+				et.Session = &session
+			}
+		}
+		if t.Feedback != nil {
+			et.Feedback = &EdgeFeedback{
+				FollowedPlan:  t.Feedback.FollowedPlan,
+				EmotionBefore: domain.Emotion(t.Feedback.EmotionBefore),
+				EmotionDuring: domain.Emotion(t.Feedback.EmotionDuring),
+				EmotionAfter:  domain.Emotion(t.Feedback.EmotionAfter),
+			}
+		}
+		edgeTrades = append(edgeTrades, et)
+	}
+
+	return ComputeEdgeReport(edgeTrades, filter), nil
+}
+
+// ScaleOut compares the scale-out plans of the trades closed in [from, to)
+// with their executions. Trades without a plan are left out.
+func (s *AnalyticsService) ScaleOut(ctx context.Context, accountID uuid.UUID, from, to time.Time) (ScaleOutReport, error) {
+	trades, err := s.analyticsRepo.GetClosedTrades(ctx, accountID, from, to)
+	if err != nil {
+		return ScaleOutReport{}, err
+	}
+
+	tradeIDs := make([]uuid.UUID, len(trades))
+	for i, t := range trades {
+		tradeIDs[i] = t.TradeID
+	}
+	plans, err := s.analyticsRepo.GetScaleOutPlans(ctx, tradeIDs)
+	if err != nil {
+		return ScaleOutReport{}, err
+	}
+	instruments, err := s.instruments(ctx)
+	if err != nil {
+		return ScaleOutReport{}, err
+	}
+
+	var comparisons []ScaleOutComparison
+	for _, t := range trades {
+		plan, ok := plans[t.TradeID]
+		if !ok {
+			continue
+		}
+		inst, ok := instruments[t.Symbol]
+		if !ok {
+			return ScaleOutReport{}, fmt.Errorf("trade %s: unknown instrument %s", t.TradeID, t.Symbol)
+		}
+
+		entry, err := parseDecimal(t.PlannedEntry)
+		if err != nil {
+			return ScaleOutReport{}, fmt.Errorf("trade %s: parse planned entry: %w", t.TradeID, err)
+		}
+		sl, err := parseDecimal(t.PlannedSL)
+		if err != nil {
+			return ScaleOutReport{}, fmt.Errorf("trade %s: parse planned sl: %w", t.TradeID, err)
+		}
+		tp, err := parseDecimal(t.PlannedTP)
+		if err != nil {
+			return ScaleOutReport{}, fmt.Errorf("trade %s: parse planned tp: %w", t.TradeID, err)
+		}
+
+		comparison, err := CompareScaleOut(t.TradeID, inst, t.Bias, entry, sl, tp, plan, mapToTradeExecutions(t.Executions))
+		if err != nil {
+			return ScaleOutReport{}, fmt.Errorf("trade %s: %w", t.TradeID, err)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	return ComputeScaleOutReport(comparisons), nil
+}
+
+// Equity builds the realized equity curve of an account, starting from its
+// opening balance, with drawdowns measured until asOf
+func (s *AnalyticsService) Equity(ctx context.Context, accountID uuid.UUID, asOf time.Time) (EquityCurve, error) {
+	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return EquityCurve{}, fmt.Errorf("get account: %w", err)
+	}
+	// The curve tracks trading only, so deposits and withdrawals are left out
+	balance, err := parseDecimal(account.OpeningBalance)
+	if err != nil {
+		return EquityCurve{}, fmt.Errorf("parse balance: %w", err)
+	}
+
+	executions, err := s.analyticsRepo.GetAccountExecutions(ctx, accountID)
+	if err != nil {
+		return EquityCurve{}, err
+	}
+
+	instruments, err := s.instruments(ctx)
+	if err != nil {
+		return EquityCurve{}, err
+	}
+
+	events, err := equityEvents(executions, instruments)
+	if err != nil {
+		return EquityCurve{}, err
+	}
+	return ComputeEquityCurve(balance, events, asOf), nil
+}
+
+// equityEvents prices every exit (executed_at order) against the ACTUAL
+// entry of its trade
+func equityEvents(executions []repositories.AccountExecution, instruments map[string]domain.Instrument) ([]EquityEvent, error) {
+	entries := make(map[uuid.UUID]float64)
+	var events []EquityEvent
+
+	for _, e := range executions {
+		price := parseFloatPtr(e.Price)
+		event := domain.ExecutionEventType(e.EventType)
+
+		if event == domain.EventEntry {
+			entries[e.TradeID] = price
+			continue
+		}
+		if !domain.IsReducingEvent(event) {
+			continue
+		}
+
+		entry, ok := entries[e.TradeID]
+		if !ok {
+			return nil, fmt.Errorf("trade %s: %s before entry", e.TradeID, e.EventType)
+		}
+		inst, ok := instruments[e.Symbol]
+		if !ok {
+			return nil, fmt.Errorf("trade %s: unknown instrument %s", e.TradeID, e.Symbol)
+		}
+		rate, err := parseDecimal(e.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: parse conversion rate: %w", e.TradeID, err)
+		}
+		pnl, _, err := ComputeExecutionPnL(e.Bias, entry, price, parseFloatPtr(e.PositionSize), inst, rate)
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: price %s: %w", e.TradeID, e.EventType, err)
+		}
+		events = append(events, EquityEvent{
+			TradeID:    e.TradeID,
+			EventType:  e.EventType,
+			ExecutedAt: e.ExecutedAt,
+			PnL:        pnl,
+		})
+	}
+	return events, nil
+}
+
+// ClosedTradeOutcomes measures every trade closed in [from, to)
+func (s *AnalyticsService) ClosedTradeOutcomes(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]TradeOutcome, error) {
+	trades, err := s.analyticsRepo.GetClosedTrades(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments, err := s.instruments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]TradeOutcome, 0, len(trades))
+	for _, t := range trades {
+		outcome, err := closedTradeOutcome(t, instruments)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+func closedTradeOutcome(t repositories.ClosedTrade, instruments map[string]domain.Instrument) (TradeOutcome, error) {
+	inst, ok := instruments[t.Symbol]
+	if !ok {
+		return TradeOutcome{}, fmt.Errorf("trade %s: unknown instrument %s", t.TradeID, t.Symbol)
+	}
+
+	sl, err := parseDecimal(t.PlannedSL)
+	if err != nil {
+		return TradeOutcome{}, fmt.Errorf("trade %s: parse planned sl: %w", t.TradeID, err)
+	}
+	tp, err := parseDecimal(t.PlannedTP)
+	if err != nil {
+		return TradeOutcome{}, fmt.Errorf("trade %s: parse planned tp: %w", t.TradeID, err)
+	}
+	rate, err := parseDecimal(t.Rate)
+	if err != nil {
+		return TradeOutcome{}, fmt.Errorf("trade %s: parse conversion rate: %w", t.TradeID, err)
+	}
+
+	outcome, err := ComputeTradeOutcome(t.TradeID, inst, rate, t.Bias, sl, tp, mapToTradeExecutions(t.Executions))
+	if err != nil {
+		return TradeOutcome{}, fmt.Errorf("trade %s: %w", t.TradeID, err)
+	}
+	return outcome, nil
+}
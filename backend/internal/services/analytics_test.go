@@ -0,0 +1,146 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestComputeTradeOutcome(t *testing.T) {
+	t0 := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		inst       domain.Instrument
+		bias       string
+		sl, tp     float64
+		executions []TradeExecution
+		pnlMoney   float64
+		pnlPips    float64
+		r          float64
+		plannedRR  float64
+		result     domain.TradeResult
+	}{
+		{
+			name: "long target from slipped entry",
+			inst: domain.EURUSD,
+			bias: "long", sl: 1.0950, tp: 1.1100,
+			executions: []TradeExecution{
+				{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+				{EventType: "tp_hit", Price: 1.1100, PositionSize: 1, ExecutedAt: t0.Add(72 * time.Hour)},
+			},
+			pnlMoney: 1000, pnlPips: 100, r: 2, plannedRR: 2, result: domain.ResultWin,
+		},
+		{
+			name: "short stop",
+			inst: domain.EURUSD,
+			bias: "short", sl: 1.1050, tp: 1.0900,
+			executions: []TradeExecution{
+				{EventType: "entry", Price: 1.1000, PositionSize: 0.5, ExecutedAt: t0},
+				{EventType: "sl_hit", Price: 1.1050, PositionSize: 0.5, ExecutedAt: t0.Add(24 * time.Hour)},
+			},
+			pnlMoney: -250, pnlPips: -50, r: -1, plannedRR: 2, result: domain.ResultLoss,
+		},
+		{
+			name: "partial close priced too",
+			inst: domain.EURUSD,
+			bias: "long", sl: 1.0950, tp: 1.1100,
+			executions: []TradeExecution{
+				{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+				{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: t0.Add(24 * time.Hour)},
+				{EventType: "manual_close", Price: 1.1000, PositionSize: 0.5, ExecutedAt: t0.Add(48 * time.Hour)},
+			},
+			pnlMoney: 250, pnlPips: 25, r: 0.5, plannedRR: 2, result: domain.ResultWin,
+		},
+		{
+			name: "closed at entry",
+			inst: domain.EURUSD,
+			bias: "long", sl: 1.0950, tp: 1.1100,
+			executions: []TradeExecution{
+				{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+				{EventType: "manual_close", Price: 1.1000, PositionSize: 1, ExecutedAt: t0.Add(time.Hour)},
+			},
+			pnlMoney: 0, pnlPips: 0, r: 0, plannedRR: 2, result: domain.ResultBreakeven,
+		},
+		{
+			name: "yen pair priced in yen pips",
+			inst: testUSDJPY,
+			bias: "long", sl: 149.50, tp: 151.00,
+			executions: []TradeExecution{
+				{EventType: "entry", Price: 150.00, PositionSize: 1, ExecutedAt: t0},
+				{EventType: "tp_hit", Price: 151.00, PositionSize: 1, ExecutedAt: t0.Add(72 * time.Hour)},
+			},
+			pnlMoney: 100000, pnlPips: 100, r: 2, plannedRR: 2, result: domain.ResultWin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := ComputeTradeOutcome(uuid.New(), tt.inst, 1, tt.bias, tt.sl, tt.tp, tt.executions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(o.PnLMoney-tt.pnlMoney) > 1e-6 || math.Abs(o.PnLPips-tt.pnlPips) > 1e-6 {
+				t.Errorf("expected pnl %v (%v pips), got %v (%v pips)", tt.pnlMoney, tt.pnlPips, o.PnLMoney, o.PnLPips)
+			}
+			if math.Abs(o.RMultiple-tt.r) > 1e-6 || math.Abs(o.PlannedRR-tt.plannedRR) > 1e-6 {
+				t.Errorf("expected %vR (planned %v), got %vR (planned %v)", tt.r, tt.plannedRR, o.RMultiple, o.PlannedRR)
+			}
+			if o.Result != tt.result {
+				t.Errorf("expected %s, got %s", tt.result, o.Result)
+			}
+			if !o.ClosedAt.Equal(tt.executions[len(tt.executions)-1].ExecutedAt) {
+				t.Errorf("unexpected close time %s", o.ClosedAt)
+			}
+		})
+	}
+
+	t.Run("open trade is rejected", func(t *testing.T) {
+		_, err := ComputeTradeOutcome(uuid.New(), domain.EURUSD, 1, "long", 1.0950, 1.1100, []TradeExecution{
+			{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+		})
+		if err == nil {
+			t.Error("expected error for trade without closing execution")
+		}
+	})
+}
+
+func TestComputeAnalyticsSummary(t *testing.T) {
+	t0 := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	// Deliberately out of order: streaks follow ClosedAt
+	outcomes := []TradeOutcome{
+		{ClosedAt: t0.Add(3 * week), Result: domain.ResultLoss, PnLMoney: -100, RMultiple: -1, PlannedRR: 2},
+		{ClosedAt: t0, Result: domain.ResultWin, PnLMoney: 200, RMultiple: 2, PlannedRR: 2},
+		{ClosedAt: t0.Add(week), Result: domain.ResultWin, PnLMoney: 300, RMultiple: 3, PlannedRR: 3},
+		{ClosedAt: t0.Add(2 * week), Result: domain.ResultBreakeven, PlannedRR: 2},
+		{ClosedAt: t0.Add(4 * week), Result: domain.ResultLoss, PnLMoney: -100, RMultiple: -1, PlannedRR: 1},
+		{ClosedAt: t0.Add(5 * week), Result: domain.ResultLoss, PnLMoney: -100, RMultiple: -1, PlannedRR: 2},
+	}
+
+	s := ComputeAnalyticsSummary(outcomes)
+	if s.Trades != 6 || s.Wins != 2 || s.Losses != 3 || s.Breakevens != 1 {
+		t.Errorf("unexpected counts %+v", s)
+	}
+	if math.Abs(s.WinRate-2.0/6) > 1e-9 || s.NetPnL != 200 || s.Expectancy != 200.0/6 {
+		t.Errorf("unexpected totals %+v", s)
+	}
+	if s.AverageR != 2.0/6 || s.AverageWinR != 2.5 || s.AverageLossR != -1 || s.AverageRR != 2 {
+		t.Errorf("unexpected R stats %+v", s)
+	}
+	if math.Abs(s.ProfitFactor-500.0/300) > 1e-9 {
+		t.Errorf("expected profit factor %v, got %v", 500.0/300, s.ProfitFactor)
+	}
+	if s.MaxConsecutiveWins != 2 || s.MaxConsecutiveLosses != 3 {
+		t.Errorf("expected streaks 2/3, got %d/%d", s.MaxConsecutiveWins, s.MaxConsecutiveLosses)
+	}
+
+	if empty := ComputeAnalyticsSummary(nil); empty != (AnalyticsSummary{}) {
+		t.Errorf("expected zero summary, got %+v", empty)
+	}
+}
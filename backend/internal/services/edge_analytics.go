@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
+)
+
+// confidenceZ is the normal quantile of the reported 95% intervals
+const confidenceZ = 1.96
+
+// EdgeTrade is a closed trade with every attribute edge analysis can filter on
+type EdgeTrade struct {
+	Outcome     TradeOutcome
+	CandleID    uuid.UUID
+	Session     *domain.Session           // session of the entry execution
+	Feedback    *EdgeFeedback             // nil without journal entry
+	RuleResults map[rules.RuleCode]string // active version, setup candle
+}
+
+// EdgeFeedback is the journal part of a trade used for filtering
+type EdgeFeedback struct {
+	FollowedPlan  bool
+	EmotionBefore domain.Emotion
+	EmotionDuring domain.Emotion
+	EmotionAfter  domain.Emotion
+}
+
+// EdgeFilter selects trades; nil / empty fields match everything.
+// Every set field must match (AND). A trade without feedback never matches
+// a feedback filter, a candle without a result never matches a rule filter.
+type EdgeFilter struct {
+	RuleResults   map[rules.RuleCode]string `json:"rule_results,omitempty"`
+	Bias          *domain.TradeBias         `json:"bias,omitempty"`
+	EmotionBefore *domain.Emotion           `json:"emotion_before,omitempty"`
+	EmotionDuring *domain.Emotion           `json:"emotion_during,omitempty"`
+	EmotionAfter  *domain.Emotion           `json:"emotion_after,omitempty"`
+	FollowedPlan  *bool                     `json:"followed_plan,omitempty"`
+	Session       *domain.Session           `json:"session,omitempty"`
+}
+
+// Validate rejects unknown enum values
+func (f EdgeFilter) Validate() error {
+	for code, result := range f.RuleResults {
+		switch result {
+		case rules.ResultPass, rules.ResultFail, rules.ResultInsufficientData:
+		default:
+			return fmt.Errorf("invalid result %q for rule %s", result, code)
+		}
+	}
+	if f.Bias != nil && !f.Bias.IsValid() {
+		return fmt.Errorf("invalid bias: %q", *f.Bias)
+	}
+	for _, e := range []*domain.Emotion{f.EmotionBefore, f.EmotionDuring, f.EmotionAfter} {
+		if e != nil && !e.IsValid() {
+			return fmt.Errorf("invalid emotion: %q", *e)
+		}
+	}
+	if f.Session != nil && !f.Session.IsValid() {
+		return fmt.Errorf("invalid session: %q", *f.Session)
+	}
+	return nil
+}
+
+// Matches reports whether the trade satisfies every set field
+func (f EdgeFilter) Matches(t EdgeTrade) bool {
+	for code, result := range f.RuleResults {
+		if got, ok := t.RuleResults[code]; !ok || got != result {
+			return false
+		}
+	}
+	if f.Bias != nil && t.Outcome.Bias != string(*f.Bias) {
+		return false
+	}
+	if f.Session != nil && (t.Session == nil || *t.Session != *f.Session) {
+		return false
+	}
+
+	if f.EmotionBefore == nil && f.EmotionDuring == nil && f.EmotionAfter == nil && f.FollowedPlan == nil {
+		return true
+	}
+	if t.Feedback == nil {
+		return false
+	}
+	if f.EmotionBefore != nil && t.Feedback.EmotionBefore != *f.EmotionBefore {
+		return false
+	}
+	if f.EmotionDuring != nil && t.Feedback.EmotionDuring != *f.EmotionDuring {
+		return false
+	}
+	if f.EmotionAfter != nil && t.Feedback.EmotionAfter != *f.EmotionAfter {
+		return false
+	}
+	if f.FollowedPlan != nil && t.Feedback.FollowedPlan != *f.FollowedPlan {
+		return false
+	}
+	return true
+}
+
+// Interval is a two-sided 95% confidence interval
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// EdgeStats is the outcome summary of a sample with its uncertainty
+type EdgeStats struct {
+	SampleSize int              `json:"sample_size"`
+	Summary    AnalyticsSummary `json:"summary"`
+	WinRateCI  *Interval        `json:"win_rate_ci"`  // Wilson score; nil without trades
+	AverageRCI *Interval        `json:"average_r_ci"` // normal approximation; nil below 2 trades
+}
+
+// EdgeReport compares the filtered trades against every closed trade of the
+// range (the baseline): a rule adds edge only if the filtered interval
+// clears the baseline, not just its point estimate
+type EdgeReport struct {
+	Filter   EdgeFilter `json:"filter"`
+	Filtered EdgeStats  `json:"filtered"`
+	Baseline EdgeStats  `json:"baseline"`
+	// Filtered minus baseline expectancy (R per trade)
+	AverageRDelta float64 `json:"average_r_delta"`
+}
+
+// ComputeEdgeReport filters trades and computes both samples' statistics
+func ComputeEdgeReport(trades []EdgeTrade, filter EdgeFilter) EdgeReport {
+	all := make([]TradeOutcome, 0, len(trades))
+	var matched []TradeOutcome
+	for _, t := range trades {
+		all = append(all, t.Outcome)
+		if filter.Matches(t) {
+			matched = append(matched, t.Outcome)
+		}
+	}
+
+	report := EdgeReport{
+		Filter:   filter,
+		Filtered: ComputeEdgeStats(matched),
+		Baseline: ComputeEdgeStats(all),
+	}
+	report.AverageRDelta = report.Filtered.Summary.AverageR - report.Baseline.Summary.AverageR
+	return report
+}
+
+// ComputeEdgeStats summarises a sample with 95% intervals on win rate and
+// average R
+func ComputeEdgeStats(outcomes []TradeOutcome) EdgeStats {
+	stats := EdgeStats{
+		SampleSize: len(outcomes),
+		Summary:    ComputeAnalyticsSummary(outcomes),
+	}
+	if stats.SampleSize == 0 {
+		return stats
+	}
+
+	low, high := WilsonInterval(stats.Summary.Wins, stats.SampleSize)
+	stats.WinRateCI = &Interval{Low: low, High: high}
+
+	if stats.SampleSize >= 2 {
+		rs := make([]float64, len(outcomes))
+		for i, o := range outcomes {
+			rs[i] = o.RMultiple
+		}
+		low, high := MeanInterval(rs)
+		stats.AverageRCI = &Interval{Low: low, High: high}
+	}
+	return stats
+}
+
+// WilsonInterval is the 95% Wilson score interval of a proportion.
+// Unlike the normal approximation it stays inside [0, 1] for small samples.
+func WilsonInterval(successes, n int) (low, high float64) {
+	if n <= 0 {
+		return 0, 0
+	}
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	z2 := confidenceZ * confidenceZ
+
+	center := (p + z2/(2*nf)) / (1 + z2/nf)
+	margin := confidenceZ / (1 + z2/nf) * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// MeanInterval is the 95% normal-approximation interval of a sample mean
+// (sample standard deviation). Too optimistic below ~30 values.
+func MeanInterval(values []float64) (low, high float64) {
+	n := float64(len(values))
+	if n < 2 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / n
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	margin := confidenceZ * math.Sqrt(sq/(n-1)) / math.Sqrt(n)
+	return mean - margin, mean + margin
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		successes, n int
+		low, high    float64
+	}{
+		{5, 10, 0.2366, 0.7634},
+		{0, 10, 0, 0.2775},
+		{10, 10, 0.7225, 1},
+		{60, 100, 0.5020, 0.6906},
+	}
+
+	for _, tt := range tests {
+		low, high := WilsonInterval(tt.successes, tt.n)
+		if math.Abs(low-tt.low) > 1e-4 || math.Abs(high-tt.high) > 1e-4 {
+			t.Errorf("%d/%d: expected [%.4f, %.4f], got [%.4f, %.4f]", tt.successes, tt.n, tt.low, tt.high, low, high)
+		}
+	}
+}
+
+func TestMeanInterval(t *testing.T) {
+	// mean 0.5, sample sd 1.5 (n = 4): margin 1.96 * 1.5 / 2
+	low, high := MeanInterval([]float64{2, -1, 2, -1})
+	margin := 1.96 * math.Sqrt(3) / 2
+	if math.Abs(low-(0.5-margin)) > 1e-9 || math.Abs(high-(0.5+margin)) > 1e-9 {
+		t.Errorf("unexpected interval [%v, %v]", low, high)
+	}
+
+	// Wider with fewer samples
+	low2, high2 := MeanInterval([]float64{2, -1})
+	if high2-low2 <= high-low {
+		t.Errorf("expected a wider interval for a smaller sample")
+	}
+}
+
+func TestEdgeFilter(t *testing.T) {
+	calm := domain.EmotionCalm
+	fomo := domain.EmotionFOMO
+	london := domain.SessionLondon
+	long := domain.BiasLong
+	followed := true
+
+	trade := EdgeTrade{
+		Outcome:     TradeOutcome{Bias: "long"},
+		Session:     &london,
+		Feedback:    &EdgeFeedback{FollowedPlan: true, EmotionBefore: calm, EmotionDuring: calm, EmotionAfter: calm},
+		RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultPass},
+	}
+	noFeedback := trade
+	noFeedback.Feedback = nil
+
+	tests := []struct {
+		name   string
+		filter EdgeFilter
+		trade  EdgeTrade
+		match  bool
+	}{
+		{"empty filter", EdgeFilter{}, trade, true},
+		{"rule pass", EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultPass}}, trade, true},
+		{"rule fail", EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultFail}}, trade, false},
+		{"rule without result", EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBearish: rules.ResultPass}}, trade, false},
+		{"rule and emotion", EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultPass}, EmotionBefore: &calm}, trade, true},
+		{"other emotion", EdgeFilter{EmotionBefore: &fomo}, trade, false},
+		{"bias, session, plan", EdgeFilter{Bias: &long, Session: &london, FollowedPlan: &followed}, trade, true},
+		{"no feedback, feedback filter", EdgeFilter{FollowedPlan: &followed}, noFeedback, false},
+		{"no feedback, rule filter", EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultPass}}, noFeedback, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.trade); got != tt.match {
+				t.Errorf("expected match %v, got %v", tt.match, got)
+			}
+		})
+	}
+
+	invalid := domain.Emotion("happy")
+	if err := (EdgeFilter{EmotionAfter: &invalid}).Validate(); err == nil {
+		t.Error("expected invalid emotion to be rejected")
+	}
+	if err := (EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBullish: "MAYBE"}}).Validate(); err == nil {
+		t.Error("expected invalid rule result to be rejected")
+	}
+}
+
+func TestComputeEdgeReport(t *testing.T) {
+	pass := map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultPass}
+	fail := map[rules.RuleCode]string{rules.W1TrendBullish: rules.ResultFail}
+	win := TradeOutcome{Result: domain.ResultWin, PnLMoney: 200, RMultiple: 2}
+	loss := TradeOutcome{Result: domain.ResultLoss, PnLMoney: -100, RMultiple: -1}
+
+	trades := []EdgeTrade{
+		{Outcome: win, RuleResults: pass},
+		{Outcome: win, RuleResults: pass},
+		{Outcome: loss, RuleResults: pass},
+		{Outcome: loss, RuleResults: fail},
+		{Outcome: loss, RuleResults: fail},
+	}
+
+	report := ComputeEdgeReport(trades, EdgeFilter{RuleResults: pass})
+	if report.Filtered.SampleSize != 3 || report.Baseline.SampleSize != 5 {
+		t.Fatalf("unexpected sample sizes %d/%d", report.Filtered.SampleSize, report.Baseline.SampleSize)
+	}
+	if report.Filtered.Summary.AverageR != 1 || math.Abs(report.AverageRDelta-0.8) > 1e-9 {
+		t.Errorf("unexpected expectancy %v (delta %v)", report.Filtered.Summary.AverageR, report.AverageRDelta)
+	}
+	ci := report.Filtered.WinRateCI
+	if ci == nil || ci.Low > 2.0/3 || ci.High < 2.0/3 || report.Filtered.AverageRCI == nil {
+		t.Errorf("expected intervals around the point estimates, got %+v", report.Filtered)
+	}
+
+	empty := ComputeEdgeReport(trades, EdgeFilter{RuleResults: map[rules.RuleCode]string{rules.W1TrendBearish: rules.ResultPass}})
+	if empty.Filtered.SampleSize != 0 || empty.Filtered.WinRateCI != nil || empty.Filtered.AverageRCI != nil {
+		t.Errorf("expected empty sample without intervals, got %+v", empty.Filtered)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EquityEvent is a realized PnL change: an exit execution (partial or full)
+type EquityEvent struct {
+	TradeID    uuid.UUID
+	EventType  string
+	ExecutedAt time.Time
+	PnL        float64
+}
+
+// EquityPoint is the account equity right after an exit execution
+type EquityPoint struct {
+	ExecutedAt  time.Time `json:"executed_at"`
+	TradeID     uuid.UUID `json:"trade_id"`
+	EventType   string    `json:"event_type"`
+	PnL         float64   `json:"pnl"`
+	Equity      float64   `json:"equity"`
+	Peak        float64   `json:"peak"`
+	Drawdown    float64   `json:"drawdown"`     // money below the peak
+	DrawdownPct float64   `json:"drawdown_pct"` // % of the peak
+}
+
+// EquityCurve is the realized equity series of an account with its
+// drawdown statistics. Durations are in seconds.
+type EquityCurve struct {
+	StartingBalance float64 `json:"starting_balance"`
+	EndingBalance   float64 `json:"ending_balance"`
+	NetPnL          float64 `json:"net_pnl"`
+	MaxDrawdown     float64 `json:"max_drawdown"`     // money, peak to trough
+	MaxDrawdownPct  float64 `json:"max_drawdown_pct"` // % of the peak
+	// Longest stretch below a previous peak until equity got back to it
+	// (or until as_of if it has not recovered yet)
+	MaxDrawdownDuration int64 `json:"max_drawdown_duration_seconds"`
+	// Net PnL / max drawdown, 0 without drawdown
+	RecoveryFactor float64 `json:"recovery_factor"`
+	// Total time spent below a previous peak, and its share of the
+	// first exit -> as_of period
+	TimeUnderWater    int64         `json:"time_under_water_seconds"`
+	TimeUnderWaterPct float64       `json:"time_under_water_pct"`
+	AsOf              time.Time     `json:"as_of"`
+	Points            []EquityPoint `json:"points"`
+}
+
+// ComputeEquityCurve replays the exits in executed_at order on top of the
+// starting balance. A drawdown still open is measured until asOf.
+func ComputeEquityCurve(startingBalance float64, events []EquityEvent, asOf time.Time) EquityCurve {
+	curve := EquityCurve{
+		StartingBalance: startingBalance,
+		EndingBalance:   startingBalance,
+		AsOf:            asOf,
+		Points:          make([]EquityPoint, 0, len(events)),
+	}
+
+	equity := startingBalance
+	peak := startingBalance
+	var underwaterSince *time.Time
+
+	endDrawdown := func(at time.Time) {
+		d := int64(at.Sub(*underwaterSince) / time.Second)
+		curve.TimeUnderWater += d
+		curve.MaxDrawdownDuration = max(curve.MaxDrawdownDuration, d)
+		underwaterSince = nil
+	}
+
+	for _, e := range events {
+		equity += e.PnL
+
+		if equity >= peak {
+			if underwaterSince != nil {
+				endDrawdown(e.ExecutedAt)
+			}
+			peak = equity
+		} else if underwaterSince == nil {
+			at := e.ExecutedAt
+			underwaterSince = &at
+		}
+
+		point := EquityPoint{
+			ExecutedAt: e.ExecutedAt,
+			TradeID:    e.TradeID,
+			EventType:  e.EventType,
+			PnL:        e.PnL,
+			Equity:     equity,
+			Peak:       peak,
+			Drawdown:   peak - equity,
+		}
+		if peak > 0 {
+			point.DrawdownPct = point.Drawdown / peak * 100
+		}
+		if point.Drawdown > curve.MaxDrawdown {
+			curve.MaxDrawdown = point.Drawdown
+			curve.MaxDrawdownPct = point.DrawdownPct
+		}
+		curve.Points = append(curve.Points, point)
+	}
+
+	if underwaterSince != nil && asOf.After(*underwaterSince) {
+		endDrawdown(asOf)
+	}
+
+	curve.EndingBalance = equity
+	curve.NetPnL = equity - startingBalance
+	if curve.MaxDrawdown > 0 {
+		curve.RecoveryFactor = curve.NetPnL / curve.MaxDrawdown
+	}
+	if len(events) > 0 {
+		if period := asOf.Sub(events[0].ExecutedAt); period > 0 {
+			curve.TimeUnderWaterPct = float64(curve.TimeUnderWater) / period.Seconds() * 100
+		}
+	}
+	return curve
+}
+
+// WriteEquityCSV writes the equity points as CSV
+func WriteEquityCSV(w io.Writer, curve EquityCurve) error {
+	cw := csv.NewWriter(w)
+	header := []string{"executed_at", "trade_id", "event_type", "pnl", "equity", "peak", "drawdown", "drawdown_pct"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, p := range curve.Points {
+		row := []string{
+			p.ExecutedAt.UTC().Format(time.RFC3339), p.TradeID.String(), p.EventType,
+			f(p.PnL), f(p.Equity), f(p.Peak), f(p.Drawdown), f(p.DrawdownPct),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestComputeEquityCurve(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(days int) time.Time { return t0.Add(time.Duration(days) * day) }
+
+	events := []EquityEvent{
+		{ExecutedAt: at(0), PnL: 200},   // 10200 peak
+		{ExecutedAt: at(2), PnL: -300},  // 9900 under water since day 2
+		{ExecutedAt: at(5), PnL: -100},  // 9800 trough: DD 400
+		{ExecutedAt: at(9), PnL: 500},   // 10300 recovered after 7 days
+		{ExecutedAt: at(10), PnL: -100}, // 10200 under water until as_of
+	}
+
+	curve := ComputeEquityCurve(10000, events, at(12))
+
+	if curve.EndingBalance != 10200 || curve.NetPnL != 200 || len(curve.Points) != 5 {
+		t.Fatalf("unexpected totals %+v", curve)
+	}
+	if curve.MaxDrawdown != 400 || math.Abs(curve.MaxDrawdownPct-400.0/10200*100) > 1e-9 {
+		t.Errorf("expected max drawdown 400, got %v (%v%%)", curve.MaxDrawdown, curve.MaxDrawdownPct)
+	}
+	if curve.MaxDrawdownDuration != int64(7*day/time.Second) {
+		t.Errorf("expected 7 day drawdown, got %ds", curve.MaxDrawdownDuration)
+	}
+	if curve.TimeUnderWater != int64(9*day/time.Second) {
+		t.Errorf("expected 9 days under water, got %ds", curve.TimeUnderWater)
+	}
+	if math.Abs(curve.TimeUnderWaterPct-75) > 1e-9 {
+		t.Errorf("expected 75%% under water, got %v", curve.TimeUnderWaterPct)
+	}
+	if curve.RecoveryFactor != 0.5 {
+		t.Errorf("expected recovery factor 0.5, got %v", curve.RecoveryFactor)
+	}
+
+	last := curve.Points[4]
+	if last.Equity != 10200 || last.Peak != 10300 || last.Drawdown != 100 {
+		t.Errorf("unexpected last point %+v", last)
+	}
+
+	t.Run("no exits", func(t *testing.T) {
+		empty := ComputeEquityCurve(10000, nil, at(12))
+		if empty.EndingBalance != 10000 || empty.MaxDrawdown != 0 || empty.TimeUnderWater != 0 || len(empty.Points) != 0 {
+			t.Errorf("expected flat curve, got %+v", empty)
+		}
+	})
+}
+
+func TestWriteEquityCSV(t *testing.T) {
+	tradeID := uuid.New()
+	curve := ComputeEquityCurve(1000, []EquityEvent{
+		{TradeID: tradeID, EventType: "tp_hit", ExecutedAt: time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC), PnL: 20},
+	}, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	if err := WriteEquityCSV(&buf, curve); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header + 1 row, got %d", len(records))
+	}
+	want := []string{"2024-01-05T12:00:00Z", tradeID.String(), "tp_hit", "20.00", "1020.00", "1020.00", "0.00", "0.00"}
+	for i, v := range want {
+		if records[1][i] != v {
+			t.Errorf("column %s: expected %q, got %q", records[0][i], v, records[1][i])
+		}
+	}
+}
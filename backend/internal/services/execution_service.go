@@ -10,16 +10,20 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 
+	"set-and-trend/backend/internal/constants"
 	"set-and-trend/backend/internal/domain"
 	"set-and-trend/backend/internal/repositories"
 )
 
 type ExecutionService struct {
-	pool          *pgxpool.Pool
-	tradeRepo     *repositories.TradeRepository
-	executionRepo *repositories.ExecutionRepository
-	intentRepo    *repositories. IntentRepository
+	pool           *pgxpool.Pool
+	tradeRepo      *repositories.TradeRepository
+	executionRepo  *repositories.ExecutionRepository
+	intentRepo     *repositories. IntentRepository
+	ledgerRepo     *repositories.LedgerRepository
+	instrumentRepo *repositories.InstrumentRepository
 }
 
 type ExecuteTradeInput struct {
@@ -36,6 +40,14 @@ type CloseTradeInput struct {
     Reason     *string
 }
 
+type PartialCloseInput struct {
+    TradeID      uuid.UUID
+    ClosePrice   float64
+    PositionSize float64
+    ExecutedAt   time.Time
+    Reason       *string
+}
+
 type CancelTradeInput struct {
     TradeID    uuid.UUID
     ExecutedAt time.Time
@@ -46,24 +58,31 @@ func NewExecutionService(
     tradeRepo *repositories.TradeRepository,
     executionRepo *repositories.ExecutionRepository,
     intentRepo *repositories.IntentRepository,
+    ledgerRepo *repositories.LedgerRepository,
+    instrumentRepo *repositories.InstrumentRepository,
     pool *pgxpool.Pool,
 ) *ExecutionService {
     return &ExecutionService{
-        tradeRepo:     tradeRepo,
-        executionRepo: executionRepo,
-        intentRepo:    intentRepo,
-        pool:          pool,
+        tradeRepo:      tradeRepo,
+        executionRepo:  executionRepo,
+        intentRepo:     intentRepo,
+        ledgerRepo:     ledgerRepo,
+        instrumentRepo: instrumentRepo,
+        pool:           pool,
     }
 }
 
-// RecordExecution records a market execution with SERIALIZABLE isolation
+// RecordExecution records a market execution with SERIALIZABLE isolation,
+// at the time it was filled (executedAt)
 func (s *ExecutionService) RecordExecution(
 	ctx context.Context,
 	tradeID uuid.UUID,
 	eventType string,
 	price float64,
 	positionSize float64,
+	executedAt time.Time,
 	reason string,
+	source domain.ExecutionSource,
 ) (*repositories.TradeExecution, error) {
 	// Validate event type
 	if ! domain.IsValidExecutionEvent(eventType) {
@@ -123,15 +142,59 @@ func (s *ExecutionService) RecordExecution(
 		return nil, err
 	}
 	
-	// 6. Compute PnL if closing
+	// 5b. Validate fill time against setup, now and prior executions
+	if err := ValidateExecutionTime(
+		executedAt,
+		trade.SetupTimestampUTC,
+		time.Now(),
+		tradeExecs,
+	); err != nil {
+		return nil, err
+	}
+	
+	inst, err := s.instrumentRepo.GetInstrument(ctx, trade.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 5c. Entry slippage against the planned entry (positive = adverse).
+	// A wide fill is recorded and only flagged: it already happened (gaps
+	// included). A price that cannot be a fill of this plan is refused.
+	var slippagePips *float64
+	if eventType == string(domain.EventEntry) {
+		plannedEntry, err := parseDecimal(trade.PlannedEntry)
+		if err != nil {
+			return nil, fmt.Errorf("parse planned entry: %w", err)
+		}
+		slippage, err := ValidateEntryPrice(trade.Bias, plannedEntry, price, *inst, constants.MaxEntrySlippagePips)
+		if err != nil && !errors.Is(err, ErrEntrySlippage) {
+			return nil, err
+		}
+		if err != nil {
+			log.Warn().Err(err).
+				Str("trade_id", tradeID.String()).
+				Str("symbol", trade.Symbol).
+				Msg("entry fill far from planned entry")
+		}
+		slippagePips = &slippage
+	}
+	
+	// 6. Compute PnL for every reducing execution (partial closes included),
+	// on its own size
 	var pnl, pnlPips *float64
-	if domain.IsClosingEvent(domain.ExecutionEventType(eventType)) {
-		pnlMoney, pnlPipsVal, err := ComputePnL(
+	if domain.IsReducingEvent(domain.ExecutionEventType(eventType)) {
+		// Converted at the rate snapshotted at setup, like the planned risk
+		rate, err := parseDecimal(trade.QuoteToAccountRateAtSetup)
+		if err != nil {
+			return nil, fmt.Errorf("parse conversion rate: %w", err)
+		}
+		pnlMoney, pnlPipsVal, err := storedExecutionPnL(
 			trade.Bias,
 			tradeExecs,
 			price,
 			positionSize,
-			0.0001, // EURUSD pip value
+			*inst,
+			rate,
 		)
 		if err != nil {
 			return nil, fmt. Errorf("compute pnl:  %w", err)
@@ -146,21 +209,51 @@ func (s *ExecutionService) RecordExecution(
 		reasonPtr = nil
 	}
 	
+	var session *string
+	if sess := ExecutionSession(executedAt); sess != nil {
+		name := string(*sess)
+		session = &name
+	}
+	
 	execution, err := s.executionRepo. CreateExecutionTx(ctx, tx, repositories.CreateExecutionParams{
 		TradeID:      tradeID,
 		EventType:    eventType,
 		Price:        &price,
 		PositionSize: &positionSize,
-		ExecutedAt:   time.Now(),
+		ExecutedAt:   executedAt.UTC(),
+		Session:      session,
 		Reason:       reasonPtr,
+		SlippagePips: slippagePips,
+		Source:       string(source),
 		PnL:          pnl,
 		PnLPips:       pnlPips,
 	})
 	if err != nil {
 		return nil, fmt. Errorf("create execution: %w", err)
 	}
+
+	// 8. Post realised PnL to the account ledger in the same transaction
+	if execution.PnL != nil {
+		amount, err := decimal.NewFromString(*execution.PnL)
+		if err != nil {
+			return nil, fmt.Errorf("parse pnl: %w", err)
+		}
+		if !amount.Round(2).IsZero() {
+			_, err = s.ledgerRepo.CreateLedgerEntryTx(ctx, tx, repositories.CreateLedgerEntryParams{
+				AccountID:   trade.AccountID,
+				EntryType:   string(domain.LedgerTradePnL),
+				Amount:      amount.Round(2),
+				TradeID:     &tradeID,
+				ExecutionID: &execution.ID,
+				Description: &eventType,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("post ledger entry: %w", err)
+			}
+		}
+	}
 	
-	// 8. Commit transaction
+	// 9. Commit transaction
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
@@ -168,6 +261,7 @@ func (s *ExecutionService) RecordExecution(
 	log.Info().
 		Str("trade_id", tradeID. String()).
 		Str("event_type", eventType).
+		Str("source", string(source)).
 		Float64("price", price).
 		Msg("execution recorded")
 	
@@ -252,6 +346,31 @@ func (s *ExecutionService) GetTradeState(ctx context.Context, tradeID uuid.UUID)
 	)
 }
 
+// GetRealizedPnL prices every reducing execution of a trade and the running
+// total, converted at the rate snapshotted at setup
+func (s *ExecutionService) GetRealizedPnL(ctx context.Context, tradeID uuid.UUID) (RealizedPnL, error) {
+	trade, err := s.tradeRepo.GetTradeByID(ctx, tradeID)
+	if err != nil {
+		return RealizedPnL{}, fmt.Errorf("get trade: %w", err)
+	}
+	
+	executions, err := s.executionRepo.GetExecutionsByTradeID(ctx, tradeID)
+	if err != nil {
+		return RealizedPnL{}, fmt.Errorf("get executions: %w", err)
+	}
+	
+	inst, err := s.instrumentRepo.GetInstrument(ctx, trade.Symbol)
+	if err != nil {
+		return RealizedPnL{}, err
+	}
+	rate, err := parseDecimal(trade.QuoteToAccountRateAtSetup)
+	if err != nil {
+		return RealizedPnL{}, fmt.Errorf("parse conversion rate: %w", err)
+	}
+	
+	return ComputeRealizedPnL(trade.Bias, mapToTradeExecutions(executions), *inst, rate)
+}
+
 // Helper functions
 func mapToTradeExecutions(execs []repositories.TradeExecution) []TradeExecution {
 	result := make([]TradeExecution, len(execs))
@@ -296,71 +415,159 @@ func parseDecimal(s string) (float64, error) {
 
 // ExecuteTrade executes a trade entry
 func (s *ExecutionService) ExecuteTrade(ctx context.Context, input ExecuteTradeInput) error {
+	reason := ""
+	if input.Reason != nil {
+		reason = *input.Reason
+	}
+	
+	_, err := s.recordEntry(
+		ctx,
+		input.TradeID,
+		input.ActualEntry,
+		input.ExecutedAt,
+		reason,
+		domain.SourceUser,
+	)
+	return err
+}
+
+// TriggerOrder enters a pending order at its fill price (see OrderFillPrice),
+// as a system-generated execution (see TradeMonitor)
+func (s *ExecutionService) TriggerOrder(
+	ctx context.Context,
+	tradeID uuid.UUID,
+	price float64,
+	executedAt time.Time,
+	reason string,
+) (*repositories.TradeExecution, error) {
+	return s.recordEntry(ctx, tradeID, price, executedAt, reason, domain.SourceSystem)
+}
+
+// recordEntry records the entry of the whole planned position
+func (s *ExecutionService) recordEntry(
+	ctx context.Context,
+	tradeID uuid.UUID,
+	price float64,
+	executedAt time.Time,
+	reason string,
+	source domain.ExecutionSource,
+) (*repositories.TradeExecution, error) {
 	// Load trade to get planned position size
-	trade, err := s.tradeRepo.GetTradeByID(ctx, input.TradeID)
+	trade, err := s.tradeRepo.GetTradeByID(ctx, tradeID)
 	if err != nil {
-		return fmt.Errorf("get trade: %w", err)
+		return nil, fmt.Errorf("get trade: %w", err)
 	}
 	
 	plannedSize, err := parseDecimal(trade.PlannedPositionSize)
 	if err != nil {
-		return fmt.Errorf("parse planned size: %w", err)
+		return nil, fmt.Errorf("parse planned size: %w", err)
 	}
 	
+	return s.RecordExecution(
+		ctx,
+		tradeID,
+		string(domain.EventEntry),
+		price,
+		plannedSize,
+		executedAt,
+		reason,
+		source,
+	)
+}
+
+// CloseTrade closes a trade position
+func (s *ExecutionService) CloseTrade(ctx context.Context, input CloseTradeInput) error {
 	reason := ""
 	if input.Reason != nil {
 		reason = *input.Reason
 	}
 	
-	_, err = s.RecordExecution(
+	_, err := s.closeRemaining(
 		ctx,
 		input.TradeID,
-		string(domain.EventEntry),
-		input.ActualEntry,
-		plannedSize,
+		domain.EventManualClose,
+		input.ClosePrice,
+		input.ExecutedAt,
 		reason,
+		domain.SourceUser,
 	)
 	return err
 }
 
-// CloseTrade closes a trade position
-func (s *ExecutionService) CloseTrade(ctx context.Context, input CloseTradeInput) error {
+// RecordLevelHit closes what remains of a trade at its SL or TP, as a
+// system-generated execution (see TradeMonitor)
+func (s *ExecutionService) RecordLevelHit(
+	ctx context.Context,
+	tradeID uuid.UUID,
+	hit LevelHit,
+	executedAt time.Time,
+	reason string,
+) (*repositories.TradeExecution, error) {
+	return s.closeRemaining(ctx, tradeID, hit.EventType, hit.Price, executedAt, reason, domain.SourceSystem)
+}
+
+// closeRemaining records a closing event for the whole remaining position
+func (s *ExecutionService) closeRemaining(
+	ctx context.Context,
+	tradeID uuid.UUID,
+	eventType domain.ExecutionEventType,
+	price float64,
+	executedAt time.Time,
+	reason string,
+	source domain.ExecutionSource,
+) (*repositories.TradeExecution, error) {
 	// Load executions to compute remaining position
-	executions, err := s.executionRepo.GetExecutionsByTradeID(ctx, input.TradeID)
+	executions, err := s.executionRepo.GetExecutionsByTradeID(ctx, tradeID)
 	if err != nil {
-		return fmt.Errorf("get executions: %w", err)
+		return nil, fmt.Errorf("get executions: %w", err)
 	}
 	
 	// Load trade to get planned size
-	trade, err := s.tradeRepo.GetTradeByID(ctx, input.TradeID)
+	trade, err := s.tradeRepo.GetTradeByID(ctx, tradeID)
 	if err != nil {
-		return fmt.Errorf("get trade: %w", err)
+		return nil, fmt.Errorf("get trade: %w", err)
 	}
 	
 	plannedSize, err := parseDecimal(trade.PlannedPositionSize)
 	if err != nil {
-		return fmt.Errorf("parse planned size: %w", err)
+		return nil, fmt.Errorf("parse planned size: %w", err)
 	}
 	
 	// Compute remaining position
 	tradeExecs := mapToTradeExecutions(executions)
 	remainingSize, err := ComputeRemainingPosition(plannedSize, tradeExecs)
 	if err != nil {
-		return fmt.Errorf("compute remaining: %w", err)
+		return nil, fmt.Errorf("compute remaining: %w", err)
 	}
 	
+	return s.RecordExecution(
+		ctx,
+		tradeID,
+		string(eventType),
+		price,
+		remainingSize,
+		executedAt,
+		reason,
+		source,
+	)
+}
+
+// PartialClose closes part of an open position (less than what remains)
+func (s *ExecutionService) PartialClose(ctx context.Context, input PartialCloseInput) error {
 	reason := ""
 	if input.Reason != nil {
 		reason = *input.Reason
 	}
 	
-	_, err = s.RecordExecution(
+	_, err := s.RecordExecution(
 		ctx,
 		input.TradeID,
-		string(domain.EventManualClose),
+		string(domain.EventPartialClose),
 		input.ClosePrice,
-		remainingSize,
+		input.PositionSize,
+		input.ExecutedAt,
 		reason,
+		domain.SourceUser,
 	)
 	return err
 }
@@ -375,3 +582,34 @@ func (s *ExecutionService) CancelTrade(ctx context.Context, input CancelTradeInp
 	)
 	return err
 }
+
+// InvalidateTrade invalidates a planned trade whose setup no longer holds
+// (pending order expired, or stop hit before entry)
+func (s *ExecutionService) InvalidateTrade(ctx context.Context, tradeID uuid.UUID, reason string) (*repositories.TradeIntent, error) {
+	return s.RecordIntent(ctx, tradeID, string(domain.IntentInvalidate), reason)
+}
+
+// storedExecutionPnL is the PnL RecordExecution stores on a reducing
+// execution and posts to the ledger: priced on its own size against the
+// ACTUAL entry, rounded to the stored precision (pnl and pnl_pips are
+// numeric(12,2))
+func storedExecutionPnL(
+	bias string,
+	executions []TradeExecution,
+	closePrice float64,
+	positionSize float64,
+	inst domain.Instrument,
+	quoteToAccountRate float64,
+) (pnlMoney float64, pnlPips float64, err error) {
+	entryPrice, err := GetActualEntryPrice(executions)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get entry price: %w", err)
+	}
+
+	money, pips, err := ComputeExecutionPnL(bias, entryPrice, closePrice, positionSize, inst, quoteToAccountRate)
+	if err != nil {
+		return 0, 0, err
+	}
+	return decimal.NewFromFloat(money).Round(2).InexactFloat64(),
+		decimal.NewFromFloat(pips).Round(2).InexactFloat64(), nil
+}
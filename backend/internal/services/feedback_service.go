@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+var (
+	ErrTradeNotFinished = errors.New("feedback requires a closed, cancelled or invalidated trade")
+	ErrFeedbackExists   = errors.New("trade already has feedback")
+	ErrFeedbackNotFound = errors.New("trade has no feedback")
+)
+
+// FeedbackService records the behavioural journal of finished trades
+type FeedbackService struct {
+	tradeRepo        *repositories.TradeRepository
+	feedbackRepo     *repositories.FeedbackRepository
+	executionService *ExecutionService
+}
+
+// FeedbackInput is the full journal entry (POST and PUT)
+type FeedbackInput struct {
+	TradeID        uuid.UUID
+	FollowedPlan   bool
+	EmotionBefore  domain.Emotion
+	EmotionDuring  domain.Emotion
+	EmotionAfter   domain.Emotion
+	BiggestMistake *string
+	ScreenshotURL  *string
+}
+
+func NewFeedbackService(
+	tradeRepo *repositories.TradeRepository,
+	feedbackRepo *repositories.FeedbackRepository,
+	executionService *ExecutionService,
+) *FeedbackService {
+	return &FeedbackService{
+		tradeRepo:        tradeRepo,
+		feedbackRepo:     feedbackRepo,
+		executionService: executionService,
+	}
+}
+
+// ValidateFeedback checks every emotion against domain.Emotion
+func ValidateFeedback(input FeedbackInput) error {
+	emotions := []struct {
+		field string
+		value domain.Emotion
+	}{
+		{"emotion_before", input.EmotionBefore},
+		{"emotion_during", input.EmotionDuring},
+		{"emotion_after", input.EmotionAfter},
+	}
+	for _, e := range emotions {
+		if !e.value.IsValid() {
+			return fmt.Errorf("invalid %s: %q", e.field, e.value)
+		}
+	}
+	return nil
+}
+
+// CanRecordFeedback allows feedback only once the trade is over
+func CanRecordFeedback(state TradeState) error {
+	switch state {
+	case StateClosed, StateCancelled, StateInvalidated:
+		return nil
+	default:
+		return fmt.Errorf("%w (state: %s)", ErrTradeNotFinished, state)
+	}
+}
+
+// CreateFeedback records the journal entry of a finished trade
+func (s *FeedbackService) CreateFeedback(ctx context.Context, input FeedbackInput) (*repositories.TradeFeedback, error) {
+	if err := s.checkFeedbackAllowed(ctx, input); err != nil {
+		return nil, err
+	}
+
+	fb, err := s.feedbackRepo.CreateFeedback(ctx, toFeedbackParams(input))
+	if err != nil {
+		return nil, err
+	}
+	if fb == nil {
+		return nil, ErrFeedbackExists
+	}
+	return fb, nil
+}
+
+// UpdateFeedback replaces the journal entry of a finished trade
+func (s *FeedbackService) UpdateFeedback(ctx context.Context, input FeedbackInput) (*repositories.TradeFeedback, error) {
+	if err := s.checkFeedbackAllowed(ctx, input); err != nil {
+		return nil, err
+	}
+
+	fb, err := s.feedbackRepo.UpdateFeedback(ctx, toFeedbackParams(input))
+	if err != nil {
+		return nil, err
+	}
+	if fb == nil {
+		return nil, ErrFeedbackNotFound
+	}
+	return fb, nil
+}
+
+// GetFeedback returns the journal entry of a trade
+func (s *FeedbackService) GetFeedback(ctx context.Context, tradeID uuid.UUID) (*repositories.TradeFeedback, error) {
+	if _, err := s.tradeRepo.GetTradeByID(ctx, tradeID); err != nil {
+		return nil, fmt.Errorf("get trade: %w", err)
+	}
+
+	fb, err := s.feedbackRepo.GetFeedbackByTradeID(ctx, tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if fb == nil {
+		return nil, ErrFeedbackNotFound
+	}
+	return fb, nil
+}
+
+func (s *FeedbackService) checkFeedbackAllowed(ctx context.Context, input FeedbackInput) error {
+	if err := ValidateFeedback(input); err != nil {
+		return err
+	}
+
+	if _, err := s.tradeRepo.GetTradeByID(ctx, input.TradeID); err != nil {
+		return fmt.Errorf("get trade: %w", err)
+	}
+
+	// State is derived from executions + intents (never stored)
+	state, err := s.executionService.GetTradeState(ctx, input.TradeID)
+	if err != nil {
+		return fmt.Errorf("derive state: %w", err)
+	}
+	return CanRecordFeedback(state)
+}
+
+func toFeedbackParams(input FeedbackInput) repositories.FeedbackParams {
+	return repositories.FeedbackParams{
+		TradeID:        input.TradeID,
+		FollowedPlan:   input.FollowedPlan,
+		EmotionBefore:  string(input.EmotionBefore),
+		EmotionDuring:  string(input.EmotionDuring),
+		EmotionAfter:   string(input.EmotionAfter),
+		BiggestMistake: input.BiggestMistake,
+		ScreenshotURL:  input.ScreenshotURL,
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestCanRecordFeedback(t *testing.T) {
+	tests := []struct {
+		state   TradeState
+		allowed bool
+	}{
+		{StatePlanned, false},
+		{StateOpen, false},
+		{StatePartial, false},
+		{StateClosed, true},
+		{StateCancelled, true},
+		{StateInvalidated, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			err := CanRecordFeedback(tt.state)
+			if tt.allowed && err != nil {
+				t.Errorf("expected feedback allowed, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrTradeNotFinished) {
+				t.Errorf("expected ErrTradeNotFinished, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateFeedback(t *testing.T) {
+	valid := FeedbackInput{
+		EmotionBefore: domain.EmotionCalm,
+		EmotionDuring: domain.EmotionAnxious,
+		EmotionAfter:  domain.EmotionOther,
+	}
+	if err := ValidateFeedback(valid); err != nil {
+		t.Errorf("expected valid feedback, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*FeedbackInput)
+	}{
+		{"unknown before", func(in *FeedbackInput) { in.EmotionBefore = "happy" }},
+		{"empty during", func(in *FeedbackInput) { in.EmotionDuring = "" }},
+		{"wrong case after", func(in *FeedbackInput) { in.EmotionAfter = "FOMO" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid
+			tt.mutate(&input)
+			if err := ValidateFeedback(input); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
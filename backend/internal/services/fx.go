@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// ErrNoFXRate means no stored rate converts between two currencies
+var ErrNoFXRate = errors.New("no fx rate")
+
+// QuoteToAccountRate returns the value of one unit of the quote currency in
+// the account currency as of asOf. Either direction of the pair may be
+// stored (USD→EUR or EUR→USD); the most recent one wins and is inverted when
+// needed. Same currency is always 1.
+func QuoteToAccountRate(ctx context.Context, repo FXRateRepo, quote, account string, asOf time.Time) (float64, error) {
+	if quote == account {
+		return 1, nil
+	}
+
+	direct, err := repo.GetLatestRate(ctx, quote, account, asOf)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	inverse, err := repo.GetLatestRate(ctx, account, quote, asOf)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	switch {
+	case direct == nil && inverse == nil:
+		return 0, fmt.Errorf("%w: %s→%s as of %s", ErrNoFXRate, quote, account, asOf.Format("2006-01-02"))
+	case inverse == nil || (direct != nil && !direct.RateDate.Before(inverse.RateDate)):
+		return fxRateValue(direct)
+	default:
+		rate, err := fxRateValue(inverse)
+		if err != nil {
+			return 0, err
+		}
+		return 1 / rate, nil
+	}
+}
+
+func fxRateValue(rate *repositories.FXRate) (float64, error) {
+	value := rate.Rate.InexactFloat64()
+	if value <= 0 {
+		return 0, fmt.Errorf("fx rate %s%s must be positive", rate.BaseCurrency, rate.QuoteCurrency)
+	}
+	return value, nil
+}
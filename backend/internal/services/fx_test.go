@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQuoteToAccountRate(t *testing.T) {
+	repo := &mockFXRateRepo{rates: map[string]float64{
+		"EURUSD": 1.25,
+		"USDJPY": 150,
+	}}
+	asOf := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		quote    string
+		account  string
+		expected float64
+		wantErr  bool
+	}{
+		{"same currency", "USD", "USD", 1, false},
+		{"stored direction", "EUR", "USD", 1.25, false},
+		{"inverted", "USD", "EUR", 0.8, false},
+		{"yen to dollars", "JPY", "USD", 1.0 / 150, false},
+		{"no rate", "USD", "GBP", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := QuoteToAccountRate(context.Background(), repo, tt.quote, tt.account, asOf)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoFXRate) {
+					t.Fatalf("expected ErrNoFXRate, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(rate-tt.expected) > 1e-12 {
+				t.Errorf("expected %v, got %v", tt.expected, rate)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// ErrPreviousIndicatorsMissing means the candle before the one being processed
+// has no indicators yet: candles must be processed in order
+var ErrPreviousIndicatorsMissing = errors.New("previous candle has no indicators")
+
+// IndicatorService is the single indicator pipeline: the candle API, the
+// compute endpoint and the batch scripts all go through ProcessCandle, so
+// they produce identical rows.
+type IndicatorService struct {
+	candleRepo    *repositories.CandleRepository
+	indicatorRepo *repositories.IndicatorRepository
+	swingRepo     *repositories.SwingRepository
+	ruleService   *RuleEvaluationService
+}
+
+func NewIndicatorService(
+	candleRepo *repositories.CandleRepository,
+	indicatorRepo *repositories.IndicatorRepository,
+	swingRepo *repositories.SwingRepository,
+	ruleService *RuleEvaluationService,
+) *IndicatorService {
+	return &IndicatorService{
+		candleRepo:    candleRepo,
+		indicatorRepo: indicatorRepo,
+		swingRepo:     swingRepo,
+		ruleService:   ruleService,
+	}
+}
+
+// ProcessCandle computes the indicators of a candle from the previous
+// candle's stored indicators, records any swing it confirms, writes one
+// indicator row and evaluates the rules on the candle.
+// The previous candle must already be processed (candles are processed in order).
+func (s *IndicatorService) ProcessCandle(ctx context.Context, candleID uuid.UUID) (*repositories.Indicator, error) {
+	candle, err := s.candleRepo.GetCandleByID(ctx, candleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candle: %w", err)
+	}
+
+	stored, err := s.candleRepo.GetCandlesUpTo(ctx, candle.Symbol, candle.TimestampUTC, IndicatorWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candle window: %w", err)
+	}
+
+	window, err := ToComputeCandles(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev *WeeklyIndicators
+	if len(stored) > 1 {
+		prevCandle := stored[len(stored)-2]
+		prevIndicator, err := s.indicatorRepo.GetIndicatorByCandleID(ctx, prevCandle.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: candle %s: %v", ErrPreviousIndicatorsMissing, prevCandle.ID, err)
+		}
+		prev, err = toWeeklyIndicators(prevIndicator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse previous indicators: %w", err)
+		}
+	}
+
+	computed, swings := ComputeIndicators(prev, window)
+
+	for _, sp := range swings {
+		err := s.swingRepo.CreateSwingPoint(ctx, repositories.SwingPointCreateParams{
+			CandleID:          stored[sp.Index].ID,
+			ConfirmedCandleID: stored[sp.ConfirmedIndex].ID,
+			SwingType:         string(sp.Type),
+			Price:             sp.Price,
+			SwingTimestampUTC: sp.TimestampUTC,
+			ConfirmedAtUTC:    sp.ConfirmedAtUTC,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	indicator, err := s.indicatorRepo.UpsertIndicator(ctx, repositories.IndicatorCreateParams{
+		ID:                 uuid.New(),
+		CandleID:           candleID,
+		EMA20:              computed.EMA20,
+		EMA50:              computed.EMA50,
+		EMA200:             computed.EMA200,
+		RangeSize:          computed.RangeSize,
+		BodySize:           computed.BodySize,
+		UpperWick:          computed.UpperWick,
+		LowerWick:          computed.LowerWick,
+		MidPrice:           computed.MidPrice,
+		LastSwingHighPrice: computed.LastSwingHighPrice,
+		LastSwingLowPrice:  computed.LastSwingLowPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store indicators: %w", err)
+	}
+
+	if err := s.indicatorRepo.UpsertIndicatorValues(ctx, candleID, computed.Values); err != nil {
+		return nil, fmt.Errorf("failed to store indicator values: %w", err)
+	}
+	indicator.Values = computed.Values
+
+	if err := s.ruleService.EvaluateCandle(ctx, candleID); err != nil {
+		return indicator, fmt.Errorf("failed to evaluate rules: %w", err)
+	}
+
+	return indicator, nil
+}
+
+// ProcessAll runs ProcessCandle over every candle, each symbol oldest first.
+// Stops at the first failure: every later candle depends on its state.
+func (s *IndicatorService) ProcessAll(ctx context.Context) (processed int, err error) {
+	candles, err := s.candleRepo.GetAllCandlesOrdered(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load candles: %w", err)
+	}
+
+	for _, candle := range candles {
+		if _, err := s.ProcessCandle(ctx, candle.ID); err != nil {
+			return processed, fmt.Errorf("candle %s: %w", candle.ID, err)
+		}
+		processed++
+
+		if processed%50 == 0 {
+			log.Info().
+				Int("processed", processed).
+				Int("total", len(candles)).
+				Msg("Indicator computation progress")
+		}
+	}
+
+	return processed, nil
+}
+
+// ToComputeCandles converts stored candles (string decimals) for computation
+func ToComputeCandles(stored []repositories.Candle) ([]Candle, error) {
+	candles := make([]Candle, len(stored))
+	for i, c := range stored {
+		open, err := strconv.ParseFloat(c.Open, 64)
+		if err != nil {
+			return nil, fmt.Errorf("candle %s open: %w", c.ID, err)
+		}
+		high, err := strconv.ParseFloat(c.High, 64)
+		if err != nil {
+			return nil, fmt.Errorf("candle %s high: %w", c.ID, err)
+		}
+		low, err := strconv.ParseFloat(c.Low, 64)
+		if err != nil {
+			return nil, fmt.Errorf("candle %s low: %w", c.ID, err)
+		}
+		close, err := strconv.ParseFloat(c.Close, 64)
+		if err != nil {
+			return nil, fmt.Errorf("candle %s close: %w", c.ID, err)
+		}
+
+		candles[i] = Candle{
+			TimestampUTC: c.TimestampUTC,
+			Open:         open,
+			High:         high,
+			Low:          low,
+			Close:        close,
+			Volume:       c.Volume,
+		}
+	}
+	return candles, nil
+}
+
+// toWeeklyIndicators converts a stored indicator row back to the state the
+// next candle is computed from (EMAs and last confirmed swings)
+func toWeeklyIndicators(i *repositories.Indicator) (*WeeklyIndicators, error) {
+	var ind WeeklyIndicators
+	var err error
+
+	if ind.EMA20, err = parseOptionalFloat(i.EMA20); err != nil {
+		return nil, fmt.Errorf("ema20: %w", err)
+	}
+	if ind.EMA50, err = parseOptionalFloat(i.EMA50); err != nil {
+		return nil, fmt.Errorf("ema50: %w", err)
+	}
+	if ind.EMA200, err = parseOptionalFloat(i.EMA200); err != nil {
+		return nil, fmt.Errorf("ema200: %w", err)
+	}
+	if ind.LastSwingHighPrice, err = parseOptionalFloat(i.LastSwingHighPrice); err != nil {
+		return nil, fmt.Errorf("last_swing_high_price: %w", err)
+	}
+	if ind.LastSwingLowPrice, err = parseOptionalFloat(i.LastSwingLowPrice); err != nil {
+		return nil, fmt.Errorf("last_swing_low_price: %w", err)
+	}
+
+	return &ind, nil
+}
+
+// parseOptionalFloat parses a nullable stored decimal (nil = not available)
+func parseOptionalFloat(raw *string) (*float64, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(*raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"time"
+
 	"github.com/google/uuid"
+	"set-and-trend/backend/internal/domain"
 	"set-and-trend/backend/internal/repositories"
 )
 
@@ -16,7 +19,22 @@ type AccountRepo interface {
 type CandleRepo interface {
 	GetCandleByID(ctx context.Context, id uuid.UUID) (*repositories.Candle, error)
 	CreateCandle(ctx context.Context, params repositories.CandleCreateParams) (*repositories.Candle, error)
-	GetLatestCandles(ctx context.Context, limit int) ([]repositories.Candle, error)
+	GetLatestCandles(ctx context.Context, symbol string, limit int) ([]repositories.Candle, error)
+}
+
+// FXRateRepo defines the interface for conversion rate lookups
+type FXRateRepo interface {
+	GetLatestRate(ctx context.Context, base, quote string, asOf time.Time) (*repositories.FXRate, error)
+}
+
+// InstrumentRepo defines the interface for instrument lookups
+type InstrumentRepo interface {
+	GetInstrument(ctx context.Context, symbol string) (*domain.Instrument, error)
+}
+
+// SwingRepo defines the interface for confirmed swing lookups
+type SwingRepo interface {
+	GetConfirmedSwings(ctx context.Context, symbol string, asOf time.Time, limit int) ([]repositories.SwingPoint, error)
 }
 
 // TradeRepo defines the interface for trade operations
@@ -24,4 +42,10 @@ type TradeRepo interface {
 	CreateTrade(ctx context.Context, params repositories.TradeCreateParams) (*repositories.Trade, error)
 	GetTradeByID(ctx context.Context, id uuid.UUID) (*repositories.Trade, error)
 	GetTradesByAccountAndCandle(ctx context.Context, accountID, candleID uuid.UUID) ([]*repositories.Trade, error)
+	CreateTradeWithinRisk(
+		ctx context.Context,
+		params repositories.TradeCreateParams,
+		since time.Time,
+		check func([]repositories.RiskTrade) error,
+	) (*repositories.Trade, error)
 }
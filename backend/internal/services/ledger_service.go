@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+var ErrInsufficientBalance = errors.New("entry would make the account balance negative")
+
+// LedgerService posts manual money movements (deposits, withdrawals, fees,
+// adjustments). Trade PnL is posted by ExecutionService with the execution.
+type LedgerService struct {
+	pool        *pgxpool.Pool
+	ledgerRepo  *repositories.LedgerRepository
+	accountRepo AccountRepo
+}
+
+// LedgerEntryInput is a manual entry. Amount is positive for deposits,
+// withdrawals and fees (the sign follows the type) and signed for adjustments.
+type LedgerEntryInput struct {
+	AccountID   uuid.UUID
+	EntryType   domain.LedgerEntryType
+	Amount      decimal.Decimal
+	Description *string
+}
+
+func NewLedgerService(
+	pool *pgxpool.Pool,
+	ledgerRepo *repositories.LedgerRepository,
+	accountRepo AccountRepo,
+) *LedgerService {
+	return &LedgerService{
+		pool:        pool,
+		ledgerRepo:  ledgerRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// SignedLedgerAmount validates a manual entry and returns its signed amount
+func SignedLedgerAmount(entryType domain.LedgerEntryType, amount decimal.Decimal) (decimal.Decimal, error) {
+	amount = amount.Round(2)
+
+	switch entryType {
+	case domain.LedgerDeposit:
+		if !amount.IsPositive() {
+			return decimal.Zero, errors.New("deposit amount must be positive")
+		}
+		return amount, nil
+	case domain.LedgerWithdrawal, domain.LedgerFee:
+		if !amount.IsPositive() {
+			return decimal.Zero, fmt.Errorf("%s amount must be positive", entryType)
+		}
+		return amount.Neg(), nil
+	case domain.LedgerAdjustment:
+		if amount.IsZero() {
+			return decimal.Zero, errors.New("adjustment amount must not be zero")
+		}
+		return amount, nil
+	case domain.LedgerTradePnL:
+		return decimal.Zero, errors.New("trade_pnl entries are posted by closing executions")
+	default:
+		return decimal.Zero, fmt.Errorf("invalid ledger entry type: %s", entryType)
+	}
+}
+
+// PostEntry appends a manual entry with SERIALIZABLE isolation, so two
+// concurrent withdrawals cannot both pass the balance check
+func (s *LedgerService) PostEntry(ctx context.Context, input LedgerEntryInput) (*repositories.LedgerEntry, error) {
+	amount, err := SignedLedgerAmount(input.EntryType, input.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.accountRepo.GetAccountByID(ctx, input.AccountID); err != nil {
+		return nil, fmt.Errorf("get account: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE"); err != nil {
+		return nil, fmt.Errorf("set isolation level: %w", err)
+	}
+
+	if amount.IsNegative() {
+		balance, err := s.ledgerRepo.GetBalanceTx(ctx, tx, input.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Add(amount).IsNegative() {
+			return nil, fmt.Errorf("%w (balance %s, %s %s)", ErrInsufficientBalance, balance, input.EntryType, amount.Neg())
+		}
+	}
+
+	entry, err := s.ledgerRepo.CreateLedgerEntryTx(ctx, tx, repositories.CreateLedgerEntryParams{
+		AccountID:   input.AccountID,
+		EntryType:   string(input.EntryType),
+		Amount:      amount,
+		Description: input.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	log.Info().
+		Str("account_id", input.AccountID.String()).
+		Str("entry_type", string(input.EntryType)).
+		Str("amount", amount.String()).
+		Msg("ledger entry posted")
+
+	return entry, nil
+}
+
+// GetLedger returns the account's entries and the balance derived from them
+func (s *LedgerService) GetLedger(ctx context.Context, accountID uuid.UUID) ([]repositories.LedgerEntry, decimal.Decimal, error) {
+	if _, err := s.accountRepo.GetAccountByID(ctx, accountID); err != nil {
+		return nil, decimal.Zero, fmt.Errorf("get account: %w", err)
+	}
+
+	entries, err := s.ledgerRepo.GetEntriesByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	balance := decimal.Zero
+	for _, e := range entries {
+		amount, err := decimal.NewFromString(e.Amount)
+		if err != nil {
+			return nil, decimal.Zero, fmt.Errorf("parse ledger amount: %w", err)
+		}
+		balance = balance.Add(amount)
+	}
+	return entries, balance, nil
+}
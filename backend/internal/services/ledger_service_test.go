@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestSignedLedgerAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		entryType domain.LedgerEntryType
+		amount    string
+		want      string
+		wantErr   bool
+	}{
+		{"deposit stays positive", domain.LedgerDeposit, "500", "500", false},
+		{"withdrawal is negated", domain.LedgerWithdrawal, "250.50", "-250.5", false},
+		{"fee is negated", domain.LedgerFee, "3.456", "-3.46", false},
+		{"negative adjustment", domain.LedgerAdjustment, "-12.10", "-12.1", false},
+		{"positive adjustment", domain.LedgerAdjustment, "7", "7", false},
+		{"negative deposit", domain.LedgerDeposit, "-5", "", true},
+		{"zero withdrawal", domain.LedgerWithdrawal, "0", "", true},
+		{"rounds to zero", domain.LedgerFee, "0.004", "", true},
+		{"zero adjustment", domain.LedgerAdjustment, "0", "", true},
+		{"trade pnl is not manual", domain.LedgerTradePnL, "10", "", true},
+		{"unknown type", domain.LedgerEntryType("bonus"), "10", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SignedLedgerAmount(tt.entryType, decimal.RequireFromString(tt.amount))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
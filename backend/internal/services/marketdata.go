@@ -2,12 +2,17 @@ package services
 
 import (
 	"math"
+	"time"
+
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/indicators"
 )
 
 type WeeklyIndicators struct {
-	EMA20  float64
-	EMA50  float64
-	EMA200 float64
+	// nil until warmed up (not enough candles for the period)
+	EMA20  *float64
+	EMA50  *float64
+	EMA200 *float64
 
 	RangeSize float64
 	BodySize  float64
@@ -17,15 +22,19 @@ type WeeklyIndicators struct {
 
 	LastSwingHighPrice *float64
 	LastSwingLowPrice  *float64
+
+	// Registry indicator outputs by name; an output that is not warmed up is absent
+	Values map[string]float64
 }
 
 // Candle represents a single OHLCV candle for computation
 type Candle struct {
-	Open   float64
-	High   float64
-	Low    float64
-	Close  float64
-	Volume *int64
+	TimestampUTC time.Time
+	Open         float64
+	High         float64
+	Low          float64
+	Close        float64
+	Volume       *int64
 }
 
 // ComputeBasicIndicators computes deterministic weekly indicators
@@ -51,13 +60,261 @@ func ComputeBasicIndicators(candle Candle) WeeklyIndicators {
 		LowerWick: lowerWick,
 		MidPrice:  midPrice,
 
-		// EMA values placeholder (need historical candles)
-		EMA20:  0,
-		EMA50:  0,
-		EMA200: 0,
+		// EMAs need historical candles (see ComputeIndicators)
+		EMA20:  nil,
+		EMA50:  nil,
+		EMA200: nil,
 
-		// Swing points placeholder (need previous candles)
+		// Swing points need neighbouring candles (see LastConfirmedSwings)
 		LastSwingHighPrice: nil,
 		LastSwingLowPrice:  nil,
 	}
 }
+
+// EMA periods; the longest one is how far back an incremental step needs to see
+const (
+	EMA20Period  = 20
+	EMA50Period  = 50
+	EMA200Period = 200
+
+	IndicatorWindow = EMA200Period
+
+	// Decimal places of stored registry indicator values
+	IndicatorValuePrecision = 8
+)
+
+// EMAPeriods are the periods behind the ema20/ema50/ema200 fields.
+// Stored indicators always use DefaultEMAPeriods; other periods are for
+// research only (parameter sweeps) and are never persisted.
+type EMAPeriods struct {
+	Fast  int `json:"fast"`  // ema20 field
+	Trend int `json:"trend"` // ema50 field
+	Slow  int `json:"slow"`  // ema200 field
+}
+
+var DefaultEMAPeriods = EMAPeriods{Fast: EMA20Period, Trend: EMA50Period, Slow: EMA200Period}
+
+// Window is how many candles an incremental step needs to see
+func (p EMAPeriods) Window() int {
+	return max(IndicatorWindow, p.Fast, p.Trend, p.Slow)
+}
+
+// NextEMA advances an EMA by one candle.
+// prev is the EMA of the previous candle (nil = not warmed up yet), closes are
+// the closes up to and including the new candle, oldest first. The first EMA is
+// seeded with the SMA of the first `period` closes; before that it is nil.
+func NextEMA(prev *float64, closes []float64, period int) *float64 {
+	if prev != nil {
+		multiplier := 2.0 / float64(period+1)
+		ema := (closes[len(closes)-1] * multiplier) + (*prev * (1 - multiplier))
+		return &ema
+	}
+	if len(closes) < period {
+		return nil // Insufficient data
+	}
+
+	sum := 0.0
+	for _, c := range closes[len(closes)-period:] {
+		sum += c
+	}
+	sma := sum / float64(period)
+	return &sma
+}
+
+// ComputeIndicators computes the indicators of the newest candle in window
+// incrementally from prev, the stored indicators of the candle before it (nil
+// for the very first candle).
+//
+// window holds the most recent candles, oldest first, ending with the new one.
+// It must reach back IndicatorWindow candles (or to the first candle) so the
+// EMAs can be seeded. The returned swings are the ones the new candle confirms,
+// with Index/ConfirmedIndex relative to window.
+//
+// EMAs are rounded to the stored precision so the next step computes from the
+// same state whether prev comes from memory or from the database.
+//
+// Registry indicators (indicators.Registry) are computed over window alone, so
+// every registered lookback must fit in IndicatorWindow.
+func ComputeIndicators(prev *WeeklyIndicators, window []Candle) (WeeklyIndicators, []SwingPoint) {
+	return ComputeIndicatorsWith(prev, window, DefaultEMAPeriods)
+}
+
+// ComputeIndicatorsWith is ComputeIndicators with other EMA periods (window
+// must reach back periods.Window() candles)
+func ComputeIndicatorsWith(prev *WeeklyIndicators, window []Candle, periods EMAPeriods) (WeeklyIndicators, []SwingPoint) {
+	current := window[len(window)-1]
+	ind := ComputeBasicIndicators(current)
+
+	var prevState WeeklyIndicators
+	if prev != nil {
+		prevState = *prev
+	}
+
+	closes := make([]float64, len(window))
+	for i, c := range window {
+		closes[i] = c.Close
+	}
+	ind.EMA20 = roundPrice(NextEMA(prevState.EMA20, closes, periods.Fast))
+	ind.EMA50 = roundPrice(NextEMA(prevState.EMA50, closes, periods.Trend))
+	ind.EMA200 = roundPrice(NextEMA(prevState.EMA200, closes, periods.Slow))
+
+	// Swings: carry the last confirmed ones, replace with what this candle confirms
+	ind.LastSwingHighPrice = prevState.LastSwingHighPrice
+	ind.LastSwingLowPrice = prevState.LastSwingLowPrice
+
+	var confirmed []SwingPoint
+	t := len(window) - 1 - constants.SwingLookbackBars
+	isHigh, isLow := DetectSwingAt(window, t)
+	if isHigh {
+		sp := newSwingPoint(window, SwingHigh, t)
+		ind.LastSwingHighPrice = &sp.Price
+		confirmed = append(confirmed, sp)
+	}
+	if isLow {
+		sp := newSwingPoint(window, SwingLow, t)
+		ind.LastSwingLowPrice = &sp.Price
+		confirmed = append(confirmed, sp)
+	}
+
+	ind.Values = indicators.ComputeLatest(toBars(window))
+	for name, v := range ind.Values {
+		ind.Values[name] = roundValue(v)
+	}
+
+	return ind, confirmed
+}
+
+func toBars(candles []Candle) []indicators.Bar {
+	bars := make([]indicators.Bar, len(candles))
+	for i, c := range candles {
+		bars[i] = indicators.Bar{Open: c.Open, High: c.High, Low: c.Low, Close: c.Close}
+	}
+	return bars
+}
+
+// roundValue rounds a registry indicator value to its stored precision (numeric(18,8))
+func roundValue(v float64) float64 {
+	scale := math.Pow10(IndicatorValuePrecision)
+	return math.Round(v*scale) / scale
+}
+
+func roundPrice(p *float64) *float64 {
+	if p == nil {
+		return nil
+	}
+	scale := math.Pow10(constants.PriceStoragePrecision)
+	rounded := math.Round(*p*scale) / scale
+	return &rounded
+}
+
+type SwingType string
+
+const (
+	SwingHigh SwingType = "high"
+	SwingLow  SwingType = "low"
+)
+
+// SwingPoint is a confirmed swing high or low.
+// The swing is at Index but only KNOWN from ConfirmedIndex (Index + 2):
+// anything evaluated before ConfirmedIndex must not see it (no lookahead).
+// ConfirmedAtUTC is the close of the confirming candle: W1 timestamps are the
+// week's open, and the confirming low/high is only formed once the week closes.
+type SwingPoint struct {
+	Type           SwingType
+	Index          int
+	Price          float64
+	TimestampUTC   time.Time
+	ConfirmedIndex int
+	ConfirmedAtUTC time.Time
+}
+
+// SwingState is the last confirmed swing high/low as of a candle
+type SwingState struct {
+	High *SwingPoint
+	Low  *SwingPoint
+}
+
+// DetectSwingAt checks whether candle t is a swing using the MVP 2-bar definition:
+//
+//	swing high: H_t > max(H_{t-1}, H_{t-2}) ∧ H_t > max(H_{t+1}, H_{t+2})
+//	swing low:  L_t < min(L_{t-1}, L_{t-2}) ∧ L_t < min(L_{t+1}, L_{t+2})
+//
+// Equal highs/lows are not swings. Returns false if t lacks 2 bars on either side.
+func DetectSwingAt(candles []Candle, t int) (isHigh bool, isLow bool) {
+	n := constants.SwingLookbackBars
+	if t-n < 0 || t+n >= len(candles) {
+		return false, false
+	}
+
+	isHigh, isLow = true, true
+	for k := 1; k <= n; k++ {
+		for _, j := range []int{t - k, t + k} {
+			if candles[t].High <= candles[j].High {
+				isHigh = false
+			}
+			if candles[t].Low >= candles[j].Low {
+				isLow = false
+			}
+		}
+	}
+	return isHigh, isLow
+}
+
+// DetectSwings returns every swing in candles (oldest first), ordered by
+// confirmation. Candles must be sorted by time ascending.
+func DetectSwings(candles []Candle) []SwingPoint {
+	n := constants.SwingLookbackBars
+	var swings []SwingPoint
+
+	for t := n; t+n < len(candles); t++ {
+		isHigh, isLow := DetectSwingAt(candles, t)
+		if isHigh {
+			swings = append(swings, newSwingPoint(candles, SwingHigh, t))
+		}
+		if isLow {
+			swings = append(swings, newSwingPoint(candles, SwingLow, t))
+		}
+	}
+	return swings
+}
+
+// LastConfirmedSwings returns, for every candle, the most recent swing high and
+// low that was confirmed at or before that candle. This is what
+// indicators_weekly.last_swing_*_price stores.
+func LastConfirmedSwings(candles []Candle) []SwingState {
+	states := make([]SwingState, len(candles))
+	swings := DetectSwings(candles)
+
+	var current SwingState
+	next := 0
+	for i := range candles {
+		for next < len(swings) && swings[next].ConfirmedIndex <= i {
+			sp := swings[next]
+			if sp.Type == SwingHigh {
+				current.High = &sp
+			} else {
+				current.Low = &sp
+			}
+			next++
+		}
+		states[i] = current
+	}
+	return states
+}
+
+func newSwingPoint(candles []Candle, swingType SwingType, t int) SwingPoint {
+	price := candles[t].High
+	if swingType == SwingLow {
+		price = candles[t].Low
+	}
+
+	confirmed := t + constants.SwingLookbackBars
+	return SwingPoint{
+		Type:           swingType,
+		Index:          t,
+		Price:          price,
+		TimestampUTC:   candles[t].TimestampUTC,
+		ConfirmedIndex: confirmed,
+		ConfirmedAtUTC: candles[confirmed].TimestampUTC.Add(7 * 24 * time.Hour),
+	}
+}
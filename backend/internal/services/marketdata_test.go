@@ -0,0 +1,300 @@
+package services
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"set-and-trend/backend/internal/indicators"
+)
+
+// swingCandles builds weekly candles from (high, low) pairs
+func swingCandles(hl [][2]float64) []Candle {
+	start := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	candles := make([]Candle, len(hl))
+	for i, p := range hl {
+		candles[i] = Candle{
+			TimestampUTC: start.AddDate(0, 0, 7*i),
+			Open:         (p[0] + p[1]) / 2,
+			High:         p[0],
+			Low:          p[1],
+			Close:        (p[0] + p[1]) / 2,
+		}
+	}
+	return candles
+}
+
+func TestDetectSwingAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		hl       [][2]float64
+		t        int
+		wantHigh bool
+		wantLow  bool
+	}{
+		{
+			"swing high",
+			[][2]float64{{1.10, 1.05}, {1.11, 1.06}, {1.15, 1.07}, {1.12, 1.06}, {1.11, 1.05}},
+			2, true, false,
+		},
+		{
+			"swing low",
+			[][2]float64{{1.10, 1.05}, {1.09, 1.04}, {1.08, 1.00}, {1.09, 1.03}, {1.10, 1.04}},
+			2, false, true,
+		},
+		{
+			"equal high on the right is not a swing",
+			[][2]float64{{1.10, 1.05}, {1.11, 1.06}, {1.15, 1.07}, {1.12, 1.06}, {1.15, 1.05}},
+			2, false, false,
+		},
+		{
+			"equal low on the left is not a swing",
+			[][2]float64{{1.10, 1.00}, {1.09, 1.04}, {1.08, 1.00}, {1.09, 1.03}, {1.10, 1.04}},
+			2, false, false,
+		},
+		{
+			"outside bar is both",
+			[][2]float64{{1.10, 1.05}, {1.11, 1.06}, {1.20, 1.00}, {1.12, 1.06}, {1.11, 1.05}},
+			2, true, true,
+		},
+		{
+			"needs two bars on the left",
+			[][2]float64{{1.11, 1.06}, {1.15, 1.07}, {1.12, 1.06}, {1.11, 1.05}},
+			1, false, false,
+		},
+		{
+			"needs two bars on the right",
+			[][2]float64{{1.10, 1.05}, {1.11, 1.06}, {1.15, 1.07}, {1.12, 1.06}},
+			2, false, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isHigh, isLow := DetectSwingAt(swingCandles(tt.hl), tt.t)
+			if isHigh != tt.wantHigh || isLow != tt.wantLow {
+				t.Errorf("expected high=%v low=%v, got high=%v low=%v", tt.wantHigh, tt.wantLow, isHigh, isLow)
+			}
+		})
+	}
+}
+
+func TestLastConfirmedSwings_NoLookahead(t *testing.T) {
+	candles := swingCandles([][2]float64{
+		{1.10, 1.05},
+		{1.11, 1.06},
+		{1.15, 1.07}, // swing high at 2, confirmed at 4
+		{1.12, 1.06},
+		{1.11, 1.02}, // swing low at 4, confirmed at 6
+		{1.12, 1.04},
+		{1.13, 1.05},
+	})
+
+	swings := DetectSwings(candles)
+	if len(swings) != 2 {
+		t.Fatalf("expected 2 swings, got %+v", swings)
+	}
+	high := swings[0]
+	if high.Type != SwingHigh || high.Index != 2 || high.ConfirmedIndex != 4 || high.Price != 1.15 {
+		t.Errorf("unexpected swing high: %+v", high)
+	}
+	week := 7 * 24 * time.Hour
+	if !high.ConfirmedAtUTC.Equal(candles[4].TimestampUTC.Add(week)) || !high.TimestampUTC.Equal(candles[2].TimestampUTC) {
+		t.Errorf("swing high timestamps wrong: %+v", high)
+	}
+
+	// GetConfirmedSwings returns swings with confirmed_at_utc <= as_of: not
+	// while the confirming candle is still forming
+	midWeek := candles[4].TimestampUTC.Add(3 * 24 * time.Hour)
+	if !high.ConfirmedAtUTC.After(midWeek) {
+		t.Errorf("swing high visible at %s, inside its confirming week", midWeek)
+	}
+
+	states := LastConfirmedSwings(candles)
+	for i, s := range states {
+		wantHigh := i >= 4
+		wantLow := i >= 6
+		if (s.High != nil) != wantHigh {
+			t.Errorf("candle %d: expected swing high known=%v", i, wantHigh)
+		}
+		if (s.Low != nil) != wantLow {
+			t.Errorf("candle %d: expected swing low known=%v", i, wantLow)
+		}
+	}
+	if states[6].Low.Price != 1.02 {
+		t.Errorf("expected last swing low 1.02, got %v", states[6].Low.Price)
+	}
+}
+
+func TestLastConfirmedSwings_PrefixStable(t *testing.T) {
+	// Adding future candles must never change the state of an earlier candle
+	full := swingCandles([][2]float64{
+		{1.10, 1.05}, {1.11, 1.06}, {1.15, 1.07}, {1.12, 1.06}, {1.11, 1.02},
+		{1.12, 1.04}, {1.16, 1.05}, {1.14, 1.03}, {1.13, 1.04}, {1.12, 1.01},
+	})
+	all := LastConfirmedSwings(full)
+
+	for n := 1; n <= len(full); n++ {
+		prefix := LastConfirmedSwings(full[:n])
+		for i := range prefix {
+			if !sameSwing(prefix[i].High, all[i].High) || !sameSwing(prefix[i].Low, all[i].Low) {
+				t.Fatalf("candle %d differs with %d candles known", i, n)
+			}
+		}
+	}
+}
+
+func sameSwing(a, b *SwingPoint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Index == b.Index && a.Type == b.Type
+}
+
+func TestNextEMA(t *testing.T) {
+	closes := []float64{1, 2, 3, 4, 5}
+
+	if got := NextEMA(nil, closes[:2], 3); got != nil {
+		t.Errorf("expected nil before warm-up, got %v", *got)
+	}
+	seed := NextEMA(nil, closes[:3], 3)
+	if seed == nil || *seed != 2 {
+		t.Fatalf("expected SMA seed 2, got %v", seed)
+	}
+	// k = 2/(3+1) = 0.5 → 4×0.5 + 2×0.5 = 3
+	if got := NextEMA(seed, closes[:4], 3); got == nil || *got != 3 {
+		t.Errorf("expected 3, got %v", got)
+	}
+}
+
+// loadEURUSDWeekly reads the shipped MT4 export (DateTime,Open,High,Low,Close,Volume,...)
+func loadEURUSDWeekly(t *testing.T) []Candle {
+	t.Helper()
+
+	f, err := os.Open("../../mt4_ready/EURUSD_weekly_2015_2025.csv")
+	if err != nil {
+		t.Skipf("EURUSD weekly data not available: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	candles := make([]Candle, 0, len(records)-1)
+	for _, r := range records[1:] {
+		ts, err := time.Parse("2006-01-02", r[0])
+		if err != nil {
+			t.Fatalf("parse date %q: %v", r[0], err)
+		}
+		var ohlc [4]float64
+		for i := range ohlc {
+			if ohlc[i], err = strconv.ParseFloat(r[i+1], 64); err != nil {
+				t.Fatalf("parse price %q: %v", r[i+1], err)
+			}
+		}
+		candles = append(candles, Candle{
+			TimestampUTC: ts,
+			Open:         ohlc[0],
+			High:         ohlc[1],
+			Low:          ohlc[2],
+			Close:        ohlc[3],
+		})
+	}
+	return candles
+}
+
+func TestComputeIndicators_IncrementalMatchesHistory(t *testing.T) {
+	candles := loadEURUSDWeekly(t)
+	if len(candles) <= IndicatorWindow {
+		t.Fatalf("expected more than %d candles, got %d", IndicatorWindow, len(candles))
+	}
+	history := LastConfirmedSwings(candles)
+
+	var prev *WeeklyIndicators
+	var confirmed int
+	for i := range candles {
+		start := i + 1 - IndicatorWindow
+		if start < 0 {
+			start = 0
+		}
+		ind, swings := ComputeIndicators(prev, candles[start:i+1])
+		confirmed += len(swings)
+
+		// Same state as if computed from the full history
+		full, _ := ComputeIndicators(prev, candles[:i+1])
+		if !sameValue(ind.EMA20, full.EMA20) || !sameValue(ind.EMA50, full.EMA50) || !sameValue(ind.EMA200, full.EMA200) {
+			t.Fatalf("candle %d: windowed EMAs differ from full history", i)
+		}
+
+		// NULL until warmed up, valid from the period-th candle on
+		if (i < EMA20Period-1) != (ind.EMA20 == nil) ||
+			(i < EMA50Period-1) != (ind.EMA50 == nil) ||
+			(i < EMA200Period-1) != (ind.EMA200 == nil) {
+			t.Fatalf("candle %d: unexpected warm-up state ema20=%v ema50=%v ema200=%v", i, ind.EMA20, ind.EMA50, ind.EMA200)
+		}
+
+		if !samePrice(ind.LastSwingHighPrice, history[i].High) || !samePrice(ind.LastSwingLowPrice, history[i].Low) {
+			t.Fatalf("candle %d: swings differ from LastConfirmedSwings", i)
+		}
+
+		prev = &ind
+	}
+
+	if confirmed != len(DetectSwings(candles)) {
+		t.Errorf("expected %d confirmed swings, got %d", len(DetectSwings(candles)), confirmed)
+	}
+}
+
+// Registry indicators are computed from the window alone: they must fit in it
+func TestComputeIndicators_RegistryValues(t *testing.T) {
+	if indicators.MaxLookback() > IndicatorWindow {
+		t.Fatalf("indicator lookback %d exceeds IndicatorWindow %d", indicators.MaxLookback(), IndicatorWindow)
+	}
+
+	candles := loadEURUSDWeekly(t)
+	var prev *WeeklyIndicators
+	for i := range candles {
+		start := i + 1 - IndicatorWindow
+		if start < 0 {
+			start = 0
+		}
+		ind, _ := ComputeIndicators(prev, candles[start:i+1])
+		prev = &ind
+
+		for _, spec := range indicators.Registry {
+			for _, out := range spec.Outputs {
+				_, ok := ind.Values[out]
+				if i >= spec.Lookback-1 && !ok {
+					t.Fatalf("candle %d: %s missing after warm-up", i, out)
+				}
+			}
+		}
+		if rsi, ok := ind.Values[indicators.RSI14]; ok && (rsi < 0 || rsi > 100) {
+			t.Fatalf("candle %d: rsi14 %v out of range", i, rsi)
+		}
+		if atr, ok := ind.Values[indicators.ATR14]; ok && atr <= 0 {
+			t.Fatalf("candle %d: atr14 %v not positive", i, atr)
+		}
+		if up, ok := ind.Values[indicators.BollingerUp]; ok && up < ind.Values[indicators.BollingerLow] {
+			t.Fatalf("candle %d: bollinger bands inverted", i)
+		}
+	}
+}
+
+func sameValue(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func samePrice(p *float64, sp *SwingPoint) bool {
+	if p == nil || sp == nil {
+		return p == nil && sp == nil
+	}
+	return *p == sp.Price
+}
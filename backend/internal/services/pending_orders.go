@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// OrderOutcome is what a candle does to a pending order
+type OrderOutcome string
+
+const (
+	OrderUntouched  OrderOutcome = "untouched"
+	OrderTriggered  OrderOutcome = "triggered"   // entry reached
+	OrderStoppedOut OrderOutcome = "stopped_out" // entry reached, then the SL
+	OrderStopped    OrderOutcome = "stopped"     // SL reached before the entry
+)
+
+// ValidateOrder checks an order against the market price it is placed at. A
+// limit enters at a better price than the market (below it for a long), a
+// stop at a worse one (above it for a long). Only limit and stop orders can
+// expire; expiryWeeks 0 = good till cancelled.
+func ValidateOrder(bias string, orderType domain.OrderType, entry, marketPrice float64, expiryWeeks int) error {
+	if !domain.IsValidOrderType(string(orderType)) {
+		return fmt.Errorf("invalid order type: %s", orderType)
+	}
+	if expiryWeeks < 0 {
+		return errors.New("expiry must be zero or more weeks")
+	}
+	if !orderType.IsPending() {
+		if expiryWeeks != 0 {
+			return errors.New("expiry only applies to limit and stop orders")
+		}
+		return nil
+	}
+
+	// A long limit and a short stop sit below the market
+	below := orderBelowMarket(bias, orderType)
+	if below && entry >= marketPrice {
+		return fmt.Errorf("%s %s entry %v must be below market %v", bias, orderType, entry, marketPrice)
+	}
+	if !below && entry <= marketPrice {
+		return fmt.Errorf("%s %s entry %v must be above market %v", bias, orderType, entry, marketPrice)
+	}
+
+	return nil
+}
+
+// DetectOrderTrigger checks a pending order's entry and SL against a candle's
+// range. A candle opening past the SL stops the order, one opening past the
+// entry (but not the SL) fills it at the open. Otherwise, when the range
+// contains both, the level nearer the open is taken as reached first, the SL
+// on a tie. An entry followed by the SL within the range is a stop-out: for a
+// limit order the SL lies beyond the entry, so the sequence is certain.
+func DetectOrderTrigger(bias string, orderType domain.OrderType, entry, sl float64, candle Candle) OrderOutcome {
+	var entryHit, slHit bool
+	if orderBelowMarket(bias, orderType) {
+		entryHit = candle.Low <= entry
+	} else {
+		entryHit = candle.High >= entry
+	}
+	if bias == "short" {
+		slHit = candle.High >= sl
+	} else {
+		slHit = candle.Low <= sl
+	}
+
+	slGapped := (bias == "short" && candle.Open >= sl) || (bias != "short" && candle.Open <= sl)
+
+	switch {
+	case slGapped:
+		return OrderStopped
+	case orderGapped(bias, orderType, entry, candle):
+		if slHit {
+			return OrderStoppedOut
+		}
+		return OrderTriggered
+	case entryHit && slHit:
+		if math.Abs(candle.Open-entry) < math.Abs(candle.Open-sl) {
+			return OrderStoppedOut
+		}
+		return OrderStopped
+	case slHit:
+		return OrderStopped
+	case entryHit:
+		return OrderTriggered
+	default:
+		return OrderUntouched
+	}
+}
+
+// OrderFillPrice is the price a triggered order fills at: its entry, or the
+// candle's open when the candle opened past the entry
+func OrderFillPrice(bias string, orderType domain.OrderType, entry float64, candle Candle) float64 {
+	if orderGapped(bias, orderType, entry, candle) {
+		return candle.Open
+	}
+	return entry
+}
+
+// orderBelowMarket returns true for orders filled on the way down: a long
+// limit and a short stop
+func orderBelowMarket(bias string, orderType domain.OrderType) bool {
+	return (bias == "long") == (orderType == domain.OrderLimit)
+}
+
+// orderGapped returns true if the candle opened at or past the order's entry
+func orderGapped(bias string, orderType domain.OrderType, entry float64, candle Candle) bool {
+	if orderBelowMarket(bias, orderType) {
+		return candle.Open <= entry
+	}
+	return candle.Open >= entry
+}
+
+// CheckPendingOrders checks the limit and stop orders on the candle's symbol
+// against the candle: a reached entry is filled at the order price (at the
+// open when the candle gapped through it) at the candle's close (capped at
+// now), followed by an sl_hit when the SL is reached after it in the same
+// candle; an order whose SL is reached first, or whose expiry passes, is
+// invalidated. Only orders placed before the candle opened are checked. An
+// order that fails is logged and skipped.
+func (m *TradeMonitor) CheckPendingOrders(
+	ctx context.Context,
+	candleID uuid.UUID,
+) ([]repositories.TradeExecution, []repositories.TradeIntent, error) {
+	stored, err := m.candleRepo.GetCandleByID(ctx, candleID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load candle: %w", err)
+	}
+
+	candles, err := ToComputeCandles([]repositories.Candle{*stored})
+	if err != nil {
+		return nil, nil, err
+	}
+	candle := candles[0]
+
+	orders, err := m.executionRepo.GetPendingOrdersBySymbol(ctx, stored.Symbol, candle.TimestampUTC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeTime := candle.TimestampUTC.Add(7 * 24 * time.Hour)
+	executedAt := closeTime
+	if now := time.Now().UTC(); executedAt.After(now) {
+		executedAt = now
+	}
+
+	executions := []repositories.TradeExecution{}
+	invalidations := []repositories.TradeIntent{}
+	for _, order := range orders {
+		recorded, intent, err := m.checkOrder(ctx, order, candle, closeTime, executedAt)
+		if err != nil {
+			log.Error().Err(err).
+				Str("trade_id", order.TradeID.String()).
+				Str("candle_id", candleID.String()).
+				Msg("pending order check failed")
+			continue
+		}
+		executions = append(executions, recorded...)
+		if intent != nil {
+			invalidations = append(invalidations, *intent)
+		}
+	}
+
+	return executions, invalidations, nil
+}
+
+func (m *TradeMonitor) checkOrder(
+	ctx context.Context,
+	order repositories.PendingOrder,
+	candle Candle,
+	closeTime time.Time,
+	executedAt time.Time,
+) ([]repositories.TradeExecution, *repositories.TradeIntent, error) {
+	day := candle.TimestampUTC.Format("2006-01-02")
+
+	// Expired before the candle opened: the candle says nothing about it
+	if order.ExpiresAt != nil && !candle.TimestampUTC.Before(*order.ExpiresAt) {
+		return m.expireOrder(ctx, order)
+	}
+
+	entry, err := parseDecimal(order.PlannedEntry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse planned entry: %w", err)
+	}
+	sl, err := parseDecimal(order.PlannedSL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse planned sl: %w", err)
+	}
+
+	orderType := domain.OrderType(order.OrderType)
+	switch outcome := DetectOrderTrigger(order.Bias, orderType, entry, sl, candle); outcome {
+	case OrderTriggered, OrderStoppedOut:
+		price := OrderFillPrice(order.Bias, orderType, entry, candle)
+		reason := fmt.Sprintf("%s entry %s reached by W1 candle %s",
+			orderType, strconv.FormatFloat(entry, 'f', -1, 64), day)
+		if price != entry {
+			reason = fmt.Sprintf("%s entry %s gapped through by W1 candle %s, filled at open %s",
+				orderType, strconv.FormatFloat(entry, 'f', -1, 64), day, strconv.FormatFloat(price, 'f', -1, 64))
+		}
+		if outcome == OrderTriggered {
+			execution, err := m.executionService.TriggerOrder(ctx, order.TradeID, price, executedAt, reason)
+			if err != nil {
+				return nil, nil, err
+			}
+			return []repositories.TradeExecution{*execution}, nil, nil
+		}
+
+		// Executions are strictly ordered: the entry goes a second before the stop
+		execution, err := m.executionService.TriggerOrder(ctx, order.TradeID, price, executedAt.Add(-time.Second), reason)
+		if err != nil {
+			return nil, nil, err
+		}
+		stopReason := fmt.Sprintf("SL %s hit by W1 candle %s after %s entry",
+			strconv.FormatFloat(sl, 'f', -1, 64), day, orderType)
+		stop, err := m.executionService.RecordLevelHit(ctx, order.TradeID,
+			LevelHit{EventType: domain.EventSLHit, Price: sl}, executedAt, stopReason)
+		if err != nil {
+			return []repositories.TradeExecution{*execution}, nil, fmt.Errorf("record sl hit after entry: %w", err)
+		}
+		return []repositories.TradeExecution{*execution, *stop}, nil, nil
+
+	case OrderStopped:
+		reason := fmt.Sprintf("SL %s hit by W1 candle %s before %s entry",
+			strconv.FormatFloat(sl, 'f', -1, 64), day, orderType)
+		intent, err := m.executionService.InvalidateTrade(ctx, order.TradeID, reason)
+		return nil, intent, err
+	}
+
+	// Untouched through the week its expiry falls in
+	if order.ExpiresAt != nil && !closeTime.Before(*order.ExpiresAt) {
+		return m.expireOrder(ctx, order)
+	}
+
+	return nil, nil, nil
+}
+
+func (m *TradeMonitor) expireOrder(
+	ctx context.Context,
+	order repositories.PendingOrder,
+) ([]repositories.TradeExecution, *repositories.TradeIntent, error) {
+	reason := fmt.Sprintf("%s order expired %s without entry",
+		order.OrderType, order.ExpiresAt.UTC().Format("2006-01-02"))
+	intent, err := m.executionService.InvalidateTrade(ctx, order.TradeID, reason)
+	return nil, intent, err
+}
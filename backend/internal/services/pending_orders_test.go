@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+func TestValidateOrder(t *testing.T) {
+	// Market at 1.1000
+	tests := []struct {
+		name        string
+		bias        string
+		orderType   domain.OrderType
+		entry       float64
+		expiryWeeks int
+		wantErr     bool
+	}{
+		{"market", "long", domain.OrderMarket, 1.1000, 0, false},
+		{"market with expiry", "long", domain.OrderMarket, 1.1000, 2, true},
+		{"unknown type", "long", domain.OrderType("iceberg"), 1.0950, 0, true},
+		{"negative expiry", "long", domain.OrderLimit, 1.0950, -1, true},
+		{"long limit below", "long", domain.OrderLimit, 1.0950, 2, false},
+		{"long limit above", "long", domain.OrderLimit, 1.1050, 0, true},
+		{"long limit at market", "long", domain.OrderLimit, 1.1000, 0, true},
+		{"long stop above", "long", domain.OrderStop, 1.1050, 0, false},
+		{"long stop below", "long", domain.OrderStop, 1.0950, 0, true},
+		{"short limit above", "short", domain.OrderLimit, 1.1050, 1, false},
+		{"short limit below", "short", domain.OrderLimit, 1.0950, 0, true},
+		{"short stop below", "short", domain.OrderStop, 1.0950, 0, false},
+		{"short stop above", "short", domain.OrderStop, 1.1050, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOrder(tt.bias, tt.orderType, tt.entry, 1.1000, tt.expiryWeeks)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDetectOrderTrigger(t *testing.T) {
+	// Long limit 1.0950 / long stop 1.1050, SL 1.0900.
+	// Short limit 1.1050 / short stop 1.0950, SL 1.1100.
+	tests := []struct {
+		name      string
+		bias      string
+		orderType domain.OrderType
+		candle    Candle
+		expected  OrderOutcome
+	}{
+		{"long limit untouched", "long", domain.OrderLimit, Candle{Open: 1.1000, High: 1.1080, Low: 1.0960}, OrderUntouched},
+		{"long limit filled", "long", domain.OrderLimit, Candle{Open: 1.1000, High: 1.1020, Low: 1.0940}, OrderTriggered},
+		{"long limit touch counts", "long", domain.OrderLimit, Candle{Open: 1.1000, High: 1.1020, Low: 1.0950}, OrderTriggered},
+		{"long limit stopped", "long", domain.OrderLimit, Candle{Open: 1.0890, High: 1.0940, Low: 1.0850}, OrderStopped},
+		{"long limit filled then stopped", "long", domain.OrderLimit, Candle{Open: 1.0990, High: 1.1000, Low: 1.0880}, OrderStoppedOut},
+		{"long stop untouched", "long", domain.OrderStop, Candle{Open: 1.1000, High: 1.1040, Low: 1.0960}, OrderUntouched},
+		{"long stop filled", "long", domain.OrderStop, Candle{Open: 1.1000, High: 1.1060, Low: 1.0960}, OrderTriggered},
+		{"long stop both, sl nearer open", "long", domain.OrderStop, Candle{Open: 1.0950, High: 1.1060, Low: 1.0880}, OrderStopped},
+		{"long stop both, tie goes to sl", "long", domain.OrderStop, Candle{Open: 1.0975, High: 1.1060, Low: 1.0880}, OrderStopped},
+		{"short limit untouched", "short", domain.OrderLimit, Candle{Open: 1.1000, High: 1.1040, Low: 1.0920}, OrderUntouched},
+		{"short limit filled", "short", domain.OrderLimit, Candle{Open: 1.1000, High: 1.1060, Low: 1.0980}, OrderTriggered},
+		{"short limit stopped", "short", domain.OrderLimit, Candle{Open: 1.1120, High: 1.1150, Low: 1.1090}, OrderStopped},
+		{"short stop filled", "short", domain.OrderStop, Candle{Open: 1.1000, High: 1.1040, Low: 1.0940}, OrderTriggered},
+		{"short stop both, entry nearer open", "short", domain.OrderStop, Candle{Open: 1.0990, High: 1.1110, Low: 1.0940}, OrderStoppedOut},
+		{"long limit gapped through, open nearer sl", "long", domain.OrderLimit, Candle{Open: 1.0910, High: 1.0930, Low: 1.0905}, OrderTriggered},
+		{"long limit gapped past sl", "long", domain.OrderLimit, Candle{Open: 1.0890, High: 1.0960, Low: 1.0850}, OrderStopped},
+		{"long stop gapped through", "long", domain.OrderStop, Candle{Open: 1.1070, High: 1.1090, Low: 1.1040}, OrderTriggered},
+		{"long limit gapped through then stopped", "long", domain.OrderLimit, Candle{Open: 1.0910, High: 1.0930, Low: 1.0880}, OrderStoppedOut},
+		{"short limit gapped through, open nearer sl", "short", domain.OrderLimit, Candle{Open: 1.1090, High: 1.1095, Low: 1.1060}, OrderTriggered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, sl := 1.0950, 1.0900
+			if tt.bias == "long" && tt.orderType == domain.OrderStop {
+				entry = 1.1050
+			}
+			if tt.bias == "short" {
+				entry, sl = 1.1050, 1.1100
+				if tt.orderType == domain.OrderStop {
+					entry = 1.0950
+				}
+			}
+
+			got := DetectOrderTrigger(tt.bias, tt.orderType, entry, sl, tt.candle)
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOrderFillPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		bias      string
+		orderType domain.OrderType
+		entry     float64
+		candle    Candle
+		expected  float64
+	}{
+		{"long limit reached", "long", domain.OrderLimit, 1.0950, Candle{Open: 1.1000, Low: 1.0940}, 1.0950},
+		{"long limit gapped through", "long", domain.OrderLimit, 1.0950, Candle{Open: 1.0910, Low: 1.0880}, 1.0910},
+		{"long stop gapped through", "long", domain.OrderStop, 1.1050, Candle{Open: 1.1070, High: 1.1090}, 1.1070},
+		{"short limit reached", "short", domain.OrderLimit, 1.1050, Candle{Open: 1.1000, High: 1.1060}, 1.1050},
+		{"short stop gapped through", "short", domain.OrderStop, 1.0950, Candle{Open: 1.0930, Low: 1.0900}, 1.0930},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderFillPrice(tt.bias, tt.orderType, tt.entry, tt.candle); got != tt.expected {
+				t.Errorf("expected fill at %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// mockTradeRecorder records executions in memory, in call order
+type mockTradeRecorder struct {
+	executions []repositories.TradeExecution
+	intents    []repositories.TradeIntent
+}
+
+func (m *mockTradeRecorder) record(tradeID uuid.UUID, eventType string, price float64, executedAt time.Time) *repositories.TradeExecution {
+	p := strconv.FormatFloat(price, 'f', -1, 64)
+	m.executions = append(m.executions, repositories.TradeExecution{
+		TradeID: tradeID, EventType: eventType, Price: &p, ExecutedAt: executedAt, Source: string(domain.SourceSystem),
+	})
+	return &m.executions[len(m.executions)-1]
+}
+
+func (m *mockTradeRecorder) RecordLevelHit(ctx context.Context, tradeID uuid.UUID, hit LevelHit, executedAt time.Time, reason string) (*repositories.TradeExecution, error) {
+	return m.record(tradeID, string(hit.EventType), hit.Price, executedAt), nil
+}
+
+func (m *mockTradeRecorder) TriggerOrder(ctx context.Context, tradeID uuid.UUID, price float64, executedAt time.Time, reason string) (*repositories.TradeExecution, error) {
+	return m.record(tradeID, string(domain.EventEntry), price, executedAt), nil
+}
+
+func (m *mockTradeRecorder) InvalidateTrade(ctx context.Context, tradeID uuid.UUID, reason string) (*repositories.TradeIntent, error) {
+	m.intents = append(m.intents, repositories.TradeIntent{TradeID: tradeID, IntentType: string(domain.IntentInvalidate), Reason: reason})
+	return &m.intents[len(m.intents)-1], nil
+}
+
+func TestCheckOrder_FilledThenStopped(t *testing.T) {
+	week := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	closeTime := week.Add(7 * 24 * time.Hour)
+	order := repositories.PendingOrder{
+		TradeID:      uuid.New(),
+		Bias:         "long",
+		OrderType:    string(domain.OrderLimit),
+		PlannedEntry: "1.09500",
+		PlannedSL:    "1.09000",
+	}
+
+	tests := []struct {
+		name     string
+		candle   Candle
+		expected []string // event_type@price
+	}{
+		{"filled, stop untouched", Candle{TimestampUTC: week, Open: 1.1000, High: 1.1020, Low: 1.0940}, []string{"entry@1.095"}},
+		{"filled then stopped", Candle{TimestampUTC: week, Open: 1.1000, High: 1.1020, Low: 1.0880}, []string{"entry@1.095", "sl_hit@1.09"}},
+		{"gapped through then stopped", Candle{TimestampUTC: week, Open: 1.0920, High: 1.0930, Low: 1.0880}, []string{"entry@1.092", "sl_hit@1.09"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &mockTradeRecorder{}
+			monitor := &TradeMonitor{executionService: recorder}
+
+			recorded, intent, err := monitor.checkOrder(context.Background(), order, tt.candle, closeTime, closeTime)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if intent != nil {
+				t.Fatalf("expected no invalidation, got %+v", *intent)
+			}
+			if len(recorded) != len(tt.expected) || len(recorder.executions) != len(tt.expected) {
+				t.Fatalf("expected %v, got %d recorded / %d returned", tt.expected, len(recorder.executions), len(recorded))
+			}
+			for i, e := range recorded {
+				if got := e.EventType + "@" + *e.Price; got != tt.expected[i] {
+					t.Errorf("execution %d: expected %s, got %s", i, tt.expected[i], got)
+				}
+				if i > 0 && !e.ExecutedAt.After(recorded[i-1].ExecutedAt) {
+					t.Errorf("execution %d at %s is not after %s", i, e.ExecutedAt, recorded[i-1].ExecutedAt)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"time"
+
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
 )
 
 // ComputeRiskAmount calculates the dollar amount risked based on balance and percentage
@@ -37,29 +42,41 @@ func ComputeStopDistance(entry, sl float64) (float64, error) {
 	return distance, nil
 }
 
-// ComputeStopDistancePips converts price distance to pips
-// NOTE: pipValue is the price increment (e.g., 0.0001 for EURUSD)
-// This is NOT pip value per lot ($10) - that's used in ComputePositionSize
-// For EURUSD: 0.0050 / 0.0001 = 50 pips
-func ComputeStopDistancePips(stopDistance, pipValue float64) (float64, error) {
-	if pipValue <= 0 {
-		return 0, errors.New("pip value must be positive")
+// ComputeStopDistancePips converts price distance to pips of the instrument
+// For EURUSD: 0.0050 / 0.0001 = 50 pips; for USDJPY: 0.50 / 0.01 = 50 pips
+func ComputeStopDistancePips(stopDistance float64, inst domain.Instrument) (float64, error) {
+	if inst.PipSize <= 0 {
+		return 0, errors.New("pip size must be positive")
 	}
 	if stopDistance <= 0 {
 		return 0, errors.New("stop distance must be positive")
 	}
 	
-	return stopDistance / pipValue, nil
+	return inst.Pips(stopDistance), nil
+}
+
+// PipValuePerLotInAccount converts the instrument's pip value per lot from its
+// quote currency to the account currency.
+// quoteToAccountRate: value of 1 quote currency unit in the account currency
+// Example: EURUSD on a EUR account at EURUSD 1.25: $10 × 0.8 = €8
+func PipValuePerLotInAccount(inst domain.Instrument, quoteToAccountRate float64) (float64, error) {
+	if quoteToAccountRate <= 0 {
+		return 0, errors.New("conversion rate must be positive")
+	}
+	return inst.PipValuePerLot() * quoteToAccountRate, nil
 }
 
-// ComputePositionSize calculates lot size based on risk and stop distance
-// NOTE: pipValuePerLot is the dollar value of one pip for one lot (e.g., $10 for EURUSD)
+// ComputePositionSize calculates lot size based on risk and stop distance,
+// floored to the instrument's lot step so the trade never risks more than planned
+// riskAmount is in the account currency; quoteToAccountRate converts the pip value
 // Formula: position_size = risk_amount / (stop_distance_pips * pip_value_per_lot)
-// Example: $100 risk / (50 pips * $10/pip) = 0.2 lots
+// Example (EURUSD, USD account, $10/pip/lot): $100 risk / (50 pips * $10/pip) = 0.2 lots
+// Returns an error if the floored size is below the instrument's minimum lot.
 func ComputePositionSize(
 	riskAmount float64,
 	stopDistancePips float64,
-	pipValuePerLot float64,
+	inst domain.Instrument,
+	quoteToAccountRate float64,
 ) (float64, error) {
 	if riskAmount <= 0 {
 		return 0, errors.New("risk amount must be positive")
@@ -67,11 +84,18 @@ func ComputePositionSize(
 	if stopDistancePips <= 0 {
 		return 0, errors.New("stop distance must be positive")
 	}
-	if pipValuePerLot <= 0 {
-		return 0, errors.New("pip value per lot must be positive")
+	if err := inst.Validate(); err != nil {
+		return 0, err
+	}
+	pipValuePerLot, err := PipValuePerLotInAccount(inst, quoteToAccountRate)
+	if err != nil {
+		return 0, err
 	}
 	
-	positionSize := riskAmount / (stopDistancePips * pipValuePerLot)
+	positionSize := inst.FloorLots(riskAmount / (stopDistancePips * pipValuePerLot))
+	if positionSize < inst.MinLot {
+		return 0, fmt.Errorf("position size below %s minimum of %.2f lots", inst.Symbol, inst.MinLot)
+	}
 	
 	return positionSize, nil
 }
@@ -139,54 +163,90 @@ func ValidateTradeGeometry(entry, sl, tp float64, bias string) error {
 	return nil
 }
 
-// ComputeMaxPositionSize calculates maximum position size based on leverage
-// contractSize: units per lot (e.g., 100,000 for EURUSD standard lot)
-func ComputeMaxPositionSize(balance float64, leverage int, contractSize float64) (float64, error) {
+// ComputeMaxPositionSize calculates maximum position size based on leverage.
+// The margin of one lot is its notional: contract size × price, in the quote
+// currency (EURUSD at 1.10: 110,000; XAUUSD at 2,000: 200,000), converted to
+// the account currency of balance with quoteToAccountRate
+func ComputeMaxPositionSize(balance float64, leverage int, inst domain.Instrument, price float64, quoteToAccountRate float64) (float64, error) {
 	if balance <= 0 {
 		return 0, errors.New("balance must be positive")
 	}
 	if leverage <= 0 {
 		return 0, errors.New("leverage must be positive")
 	}
-	if contractSize <= 0 {
+	if inst.ContractSize <= 0 {
 		return 0, errors.New("contract size must be positive")
 	}
+	if price <= 0 {
+		return 0, errors.New("price must be positive")
+	}
+	if quoteToAccountRate <= 0 {
+		return 0, errors.New("conversion rate must be positive")
+	}
 
-	maxPositionSize := balance * float64(leverage) / contractSize
+	maxPositionSize := balance * float64(leverage) / (inst.ContractSize * price * quoteToAccountRate)
 	return maxPositionSize, nil
 }
 
+// ErrEntrySlippage marks an entry fill beyond the slippage tolerance
+var ErrEntrySlippage = errors.New("entry slippage exceeds tolerance")
+
 // ValidateEntryPrice checks if actual entry is within tolerance of planned
+// and returns the slippage in pips, signed: positive when the fill is worse
+// than planned (above it for a long), negative when it is better.
+// A fill beyond maxSlippagePips returns its slippage and an error wrapping
+// ErrEntrySlippage. A price no fill of the plan can have (non-positive, or
+// more than MaxEntryDeviationPct away, e.g. 11000 for 1.1000) is rejected.
 func ValidateEntryPrice(
+	bias string,
 	plannedEntry float64,
 	actualEntry float64,
-	pipValue float64,
+	inst domain.Instrument,
 	maxSlippagePips float64,
-) error {
+) (float64, error) {
 	if plannedEntry <= 0 || actualEntry <= 0 {
-		return errors.New("prices must be positive")
+		return 0, &TradeRejectedError{
+			Code:    RejectInvalidEntryPrice,
+			Message: "prices must be positive",
+		}
+	}
+	
+	deviationPct := math.Abs(actualEntry-plannedEntry) / plannedEntry * 100
+	if deviationPct > constants.MaxEntryDeviationPct {
+		return 0, &TradeRejectedError{
+			Code: RejectInvalidEntryPrice,
+			Message: fmt.Sprintf("entry %v is %.0f%% away from planned entry %v (max %.0f%%)",
+				actualEntry, deviationPct, plannedEntry, constants.MaxEntryDeviationPct),
+		}
 	}
 	
-	slippagePips := math.Abs(actualEntry-plannedEntry) / pipValue
+	priceMove := actualEntry - plannedEntry
+	if bias == "short" {
+		priceMove = -priceMove
+	}
+	slippagePips := inst.Pips(priceMove)
 	
-	if slippagePips > maxSlippagePips {
-		return fmt.Errorf(
-			"entry slippage %.2f pips exceeds max %.2f pips",
+	if math.Abs(slippagePips) > maxSlippagePips {
+		return slippagePips, fmt.Errorf(
+			"%w: %.2f pips, max %.2f pips",
+			ErrEntrySlippage,
 			slippagePips,
 			maxSlippagePips,
 		)
 	}
 	
-	return nil
+	return slippagePips, nil
 }
 
-// ComputeExecutionPnL calculates PnL for an execution event
+// ComputeExecutionPnL calculates PnL for an execution event, in the account
+// currency (quoteToAccountRate = 1 when it is the instrument's quote currency)
 func ComputeExecutionPnL(
 	bias string,
 	entryPrice float64,
 	exitPrice float64,
 	positionSize float64,
-	pipValue float64,
+	inst domain.Instrument,
+	quoteToAccountRate float64,
 ) (pnlMoney float64, pnlPips float64, err error) {
 	
 	if entryPrice <= 0 || exitPrice <= 0 {
@@ -203,11 +263,47 @@ func ComputeExecutionPnL(
 	}
 	
 	// Pips gained/lost
-	pnlPips = priceMove / pipValue
+	pnlPips = inst.Pips(priceMove)
 	
-	// Money gained/lost (for standard lot: 1 pip = $10)
-	const pipValuePerLot = 10.0
+	// Money gained/lost (EURUSD standard lot, USD account: 1 pip = $10)
+	pipValuePerLot, err := PipValuePerLotInAccount(inst, quoteToAccountRate)
+	if err != nil {
+		return 0, 0, err
+	}
 	pnlMoney = pnlPips * positionSize * pipValuePerLot
 	
 	return pnlMoney, pnlPips, nil
 }
+
+// TradingDayStart returns midnight of now's calendar day in the account timezone
+func TradingDayStart(now time.Time, timezone string) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid account timezone %q: %w", timezone, err)
+	}
+
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// ComputeDailyRiskPct sums the risk already committed for the trading day:
+// every trade set up since dayStart (even if closed since, the risk was taken)
+// plus positions from earlier days that are still open, and limit/stop orders
+// from earlier days still waiting for their entry (a candle can trigger them
+// any time). Plans withdrawn before entry never carried risk.
+func ComputeDailyRiskPct(trades []repositories.RiskTrade, dayStart time.Time) float64 {
+	total := 0.0
+	for _, t := range trades {
+		if t.Withdrawn && !t.Entered {
+			continue
+		}
+
+		setUpToday := !t.SetupTimestampUTC.Before(dayStart)
+		stillOpen := t.Entered && !t.Closed
+		waiting := t.PendingOrder && !t.Entered
+		if setUpToday || stillOpen || waiting {
+			total += t.PlannedRiskPct
+		}
+	}
+	return total
+}
@@ -1,12 +1,28 @@
 package services
 
 import (
+	"errors"
 	"math"
 	"testing"
+	"time"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
 )
 
 const floatTolerance = 0.00001
 
+var (
+	testUSDJPY = domain.Instrument{
+		Symbol: "USDJPY", PipSize: 0.01, PricePrecision: 3, ContractSize: 100000,
+		QuoteCurrency: "JPY", MinLot: 0.01, LotStep: 0.01,
+	}
+	testXAUUSD = domain.Instrument{
+		Symbol: "XAUUSD", PipSize: 0.1, PricePrecision: 2, ContractSize: 100,
+		QuoteCurrency: "USD", MinLot: 0.01, LotStep: 0.01,
+	}
+)
+
 func almostEqualRisk(a, b float64) bool {
 	return math.Abs(a-b) < floatTolerance
 }
@@ -70,22 +86,27 @@ func TestComputeStopDistance(t *testing.T) {
 }
 
 func TestComputeStopDistancePips(t *testing.T) {
+	noPip := domain.EURUSD
+	noPip.PipSize = 0
+
 	tests := []struct {
 		name         string
 		stopDistance float64
-		pipValue     float64
+		inst         domain.Instrument
 		expected     float64
 		wantErr      bool
 	}{
-		{"EURUSD 50 pips", 0.0050, 0.0001, 50.0, false},
-		{"EURUSD 100 pips", 0.0100, 0.0001, 100.0, false},
-		{"zero pip value", 0.0050, 0, 0, true},
-		{"zero distance", 0, 0.0001, 0, true},
+		{"EURUSD 50 pips", 0.0050, domain.EURUSD, 50.0, false},
+		{"EURUSD 100 pips", 0.0100, domain.EURUSD, 100.0, false},
+		{"USDJPY 50 pips", 0.50, testUSDJPY, 50.0, false},
+		{"XAUUSD 50 pips", 5.0, testXAUUSD, 50.0, false},
+		{"zero pip value", 0.0050, noPip, 0, true},
+		{"zero distance", 0, domain.EURUSD, 0, true},
 	}
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ComputeStopDistancePips(tt.stopDistance, tt.pipValue)
+			result, err := ComputeStopDistancePips(tt.stopDistance, tt.inst)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
 			}
@@ -97,24 +118,35 @@ func TestComputeStopDistancePips(t *testing.T) {
 }
 
 func TestComputePositionSize(t *testing.T) {
+	noPip := domain.EURUSD
+	noPip.PipSize = 0
+
 	tests := []struct {
 		name             string
 		riskAmount       float64
 		stopDistancePips float64
-		pipValuePerLot   float64
+		inst             domain.Instrument
+		rate             float64
 		expected         float64
 		wantErr          bool
 	}{
-		{"$100 risk, 50 pips, $10/pip", 100.0, 50.0, 10.0, 0.2, false},
-		{"$200 risk, 100 pips, $10/pip", 200.0, 100.0, 10.0, 0.2, false},
-		{"zero risk", 0, 50.0, 10.0, 0, true},
-		{"zero stop distance", 100.0, 0, 10.0, 0, true},
-		{"zero pip value", 100.0, 50.0, 0, 0, true},
+		{"$100 risk, 50 pips, $10/pip", 100.0, 50.0, domain.EURUSD, 1, 0.2, false},
+		{"$200 risk, 100 pips, $10/pip", 200.0, 100.0, domain.EURUSD, 1, 0.2, false},
+		{"floored to lot step", 100.0, 105.0, domain.EURUSD, 1, 0.09, false},
+		{"¥15,000 risk, 50 pips, ¥1,000/pip", 15000.0, 50.0, testUSDJPY, 1, 0.3, false},
+		{"$100 risk, 50 pips, $10/pip gold", 100.0, 50.0, testXAUUSD, 1, 0.2, false},
+		{"€100 risk, 50 pips, €8/pip", 100.0, 50.0, domain.EURUSD, 0.8, 0.25, false},
+		{"$100 risk, 50 pips, yen pair", 100.0, 50.0, testUSDJPY, 1.0 / 150, 0.3, false},
+		{"below min lot", 5.0, 100.0, domain.EURUSD, 1, 0, true},
+		{"zero risk", 0, 50.0, domain.EURUSD, 1, 0, true},
+		{"zero stop distance", 100.0, 0, domain.EURUSD, 1, 0, true},
+		{"zero pip value", 100.0, 50.0, noPip, 1, 0, true},
+		{"zero conversion rate", 100.0, 50.0, domain.EURUSD, 0, 0, true},
 	}
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ComputePositionSize(tt.riskAmount, tt.stopDistancePips, tt.pipValuePerLot)
+			result, err := ComputePositionSize(tt.riskAmount, tt.stopDistancePips, tt.inst, tt.rate)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
 			}
@@ -125,6 +157,112 @@ func TestComputePositionSize(t *testing.T) {
 	}
 }
 
+func TestComputeExecutionPnL(t *testing.T) {
+	tests := []struct {
+		name       string
+		inst       domain.Instrument
+		bias       string
+		entry      float64
+		exit       float64
+		size       float64
+		rate       float64
+		expectPnL  float64
+		expectPips float64
+	}{
+		{"EURUSD long win", domain.EURUSD, "long", 1.1000, 1.1050, 1, 1, 500, 50},
+		{"EURUSD short loss", domain.EURUSD, "short", 1.1000, 1.1050, 0.5, 1, -250, -50},
+		{"USDJPY long win (yen)", testUSDJPY, "long", 150.00, 150.50, 1, 1, 50000, 50},
+		{"XAUUSD short win", testXAUUSD, "short", 2000.0, 1990.0, 0.5, 1, 500, 100},
+		{"EURUSD on EUR account", domain.EURUSD, "long", 1.2500, 1.2550, 1, 0.8, 400, 50},
+		{"USDJPY on USD account", testUSDJPY, "long", 150.00, 150.50, 1, 1.0 / 150, 333.333333, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pnl, pips, err := ComputeExecutionPnL(tt.bias, tt.entry, tt.exit, tt.size, tt.inst, tt.rate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(pnl-tt.expectPnL) > 1e-6 || math.Abs(pips-tt.expectPips) > 1e-6 {
+				t.Errorf("expected %v (%v pips), got %v (%v pips)", tt.expectPnL, tt.expectPips, pnl, pips)
+			}
+		})
+	}
+}
+
+func TestValidateEntryPrice(t *testing.T) {
+	tests := []struct {
+		name           string
+		bias           string
+		inst           domain.Instrument
+		planned        float64
+		actual         float64
+		expectSlippage float64
+		wantErr        error
+	}{
+		{"EURUSD exact fill", "long", domain.EURUSD, 1.1000, 1.1000, 0, nil},
+		{"EURUSD long worse fill", "long", domain.EURUSD, 1.1000, 1.1012, 12, nil},
+		{"EURUSD long better fill", "long", domain.EURUSD, 1.1000, 1.0995, -5, nil},
+		{"EURUSD short worse fill", "short", domain.EURUSD, 1.1000, 1.0988, 12, nil},
+		{"EURUSD short better fill", "short", domain.EURUSD, 1.1000, 1.1005, -5, nil},
+		{"USDJPY fill (yen)", "long", testUSDJPY, 150.00, 150.15, 15, nil},
+		{"EURUSD beyond max", "long", domain.EURUSD, 1.1000, 1.1030, 30, ErrEntrySlippage},
+		{"EURUSD better beyond max", "long", domain.EURUSD, 1.1000, 1.0970, -30, ErrEntrySlippage},
+		{"non-positive price", "long", domain.EURUSD, 1.1000, 0, 0, &TradeRejectedError{}},
+		{"mistyped price", "long", domain.EURUSD, 1.1000, 11000, 0, &TradeRejectedError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slippage, err := ValidateEntryPrice(tt.bias, tt.planned, tt.actual, tt.inst, 20)
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			case *TradeRejectedError:
+				var rejected *TradeRejectedError
+				if !errors.As(err, &rejected) || rejected.Code != RejectInvalidEntryPrice {
+					t.Fatalf("expected %s rejection, got %v", RejectInvalidEntryPrice, err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("expected %v, got %v", want, err)
+				}
+			}
+			if !almostEqualRisk(slippage, tt.expectSlippage) {
+				t.Errorf("expected %v pips, got %v", tt.expectSlippage, slippage)
+			}
+		})
+	}
+}
+
+func TestComputeMaxPositionSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		inst     domain.Instrument
+		price    float64
+		rate     float64
+		expected float64
+	}{
+		{"EURUSD at 1.25", domain.EURUSD, 1.25, 1, 8},
+		{"XAUUSD at 2000", testXAUUSD, 2000, 1, 5},
+		{"EURUSD at 1.25 on EUR account", domain.EURUSD, 1.25, 0.8, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ComputeMaxPositionSize(10000, 100, tt.inst, tt.price, tt.rate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !almostEqualRisk(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestComputeRR(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -240,3 +378,45 @@ func TestValidateTradeGeometry_Deterministic(t *testing.T) {
 		t.Error("ValidateTradeGeometry is not deterministic")
 	}
 }
+
+func TestTradingDayStart(t *testing.T) {
+	// 23:30 UTC on Jan 1 is already Jan 2 in Berlin
+	now := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+
+	got, err := TradingDayStart(now, "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got.UTC())
+	}
+
+	if _, err := TradingDayStart(now, "Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
+
+func TestComputeDailyRiskPct(t *testing.T) {
+	dayStart := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	today := dayStart.Add(9 * time.Hour)
+	lastWeek := dayStart.AddDate(0, 0, -7)
+
+	trades := []repositories.RiskTrade{
+		{SetupTimestampUTC: today, PlannedRiskPct: 1.0},                                                     // planned today
+		{SetupTimestampUTC: today, PlannedRiskPct: 0.5, Entered: true, Closed: true},                        // taken and closed today
+		{SetupTimestampUTC: today, PlannedRiskPct: 2.0, Withdrawn: true},                                    // cancelled before entry
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 1.5, Entered: true},                                   // still open
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 1.0, Entered: true, Closed: true},                     // closed before today
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 0.8},                                                  // stale plan
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 0.7, PendingOrder: true},                              // order still waiting
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 0.4, PendingOrder: true, Withdrawn: true},             // order expired
+		{SetupTimestampUTC: lastWeek, PlannedRiskPct: 0.3, PendingOrder: true, Entered: true, Closed: true}, // order filled and closed
+	}
+
+	if got := ComputeDailyRiskPct(trades, dayStart); math.Abs(got-3.7) > 1e-9 {
+		t.Errorf("expected 3.7%%, got %v", got)
+	}
+	if got := ComputeDailyRiskPct(nil, dayStart); got != 0 {
+		t.Errorf("expected 0 with no trades, got %v", got)
+	}
+}
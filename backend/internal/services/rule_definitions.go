@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
+)
+
+// LoadRuleDefinitions loads declarative rules from dir, registers them in the
+// rules engine and syncs rules and rule versions to the database.
+// Must run at startup, before any evaluation. Any invalid definition is fatal.
+//
+// FROZEN: a stored (rule, version) whose definition hash no longer matches is
+// fatal too - changed semantics must ship as a new version.
+func LoadRuleDefinitions(
+	ctx context.Context,
+	dir string,
+	ruleRepo *repositories.RuleRepository,
+) error {
+	defs, err := rules.LoadDefinitions(dir)
+	if err != nil {
+		return fmt.Errorf("load rule definitions: %w", err)
+	}
+
+	if err := rules.RegisterDefinitions(defs); err != nil {
+		return fmt.Errorf("register rule definitions: %w", err)
+	}
+
+	for code, spec := range rules.RuleRegistry {
+		if err := ruleRepo.UpsertRule(ctx, spec); err != nil {
+			return err
+		}
+
+		for _, version := range rules.ListRuleVersions(code) {
+			if err := syncRuleVersion(ctx, ruleRepo, version); err != nil {
+				return err
+			}
+		}
+
+		// First deployment of a rule: the highest registered version goes live.
+		// Later versions stay candidates until promoted.
+		if err := ruleRepo.ActivateRuleVersion(ctx, string(code), spec.Version); err != nil {
+			return err
+		}
+	}
+
+	// Promotions are persisted: the database decides which version is active
+	stored, err := ruleRepo.ListRuleVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rv := range stored {
+		if !rv.IsActive {
+			continue
+		}
+		if err := rules.ActivateVersion(rules.RuleCode(rv.RuleCode), rv.Version); err != nil {
+			return fmt.Errorf("active rule version missing from definitions: %w", err)
+		}
+	}
+
+	log.Info().
+		Str("dir", dir).
+		Int("definitions", len(defs)).
+		Int("rules", len(rules.RuleRegistry)).
+		Msg("rule definitions loaded")
+
+	return nil
+}
+
+func syncRuleVersion(ctx context.Context, ruleRepo *repositories.RuleRepository, spec rules.RuleSpec) error {
+	doc, err := rules.CanonicalDefinition(spec)
+	if err != nil {
+		return err
+	}
+	hash, err := rules.DefinitionHash(spec)
+	if err != nil {
+		return err
+	}
+
+	stored, err := ruleRepo.SyncRuleVersion(ctx, repositories.SyncRuleVersionParams{
+		RuleCode:       string(spec.Code),
+		Version:        spec.Version,
+		DefinitionHash: hash,
+		Definition:     doc,
+	})
+	if err != nil {
+		return err
+	}
+
+	if stored.DefinitionHash != hash {
+		return fmt.Errorf(
+			"rule %s v%d is frozen but its definition changed (stored %s, loaded %s): bump the version",
+			spec.Code, spec.Version, stored.DefinitionHash[:12], hash[:12],
+		)
+	}
+
+	return nil
+}
@@ -13,9 +13,9 @@ import (
 )
 
 type RuleEvaluationService struct {
-	candleRepo      *repositories.CandleRepository
-	indicatorRepo   *repositories.IndicatorRepository
-	ruleResultRepo  *repositories.RuleResultRepository
+	candleRepo     *repositories.CandleRepository
+	indicatorRepo  *repositories.IndicatorRepository
+	ruleResultRepo *repositories.RuleResultRepository
 }
 
 func NewRuleEvaluationService(
@@ -34,40 +34,18 @@ func (s *RuleEvaluationService) EvaluateCandle(
 	ctx context.Context,
 	candleID uuid.UUID,
 ) error {
-	// 1. Load candle data
-	candle, err := s.candleRepo.GetCandleByID(ctx, candleID)
-	if err != nil {
-		return fmt.Errorf("failed to load candle: %w", err)
-	}
-
-	// 2. Load indicators
-	indicator, err := s.indicatorRepo.GetIndicatorByCandleID(ctx, candleID)
-	if err != nil {
-		return fmt.Errorf("failed to load indicators: %w", err)
-	}
-
-	// 3. Convert to rule evaluation types
-	ruleCandle, err := s.convertToRuleCandle(*candle)
-	if err != nil {
-		return fmt.Errorf("failed to convert candle: %w", err)
-	}
-
-	ruleIndicators, err := s.convertToRuleIndicators(ctx, indicator, candle.TimestampUTC)
+	// 1-3. Load candle + indicators and convert to rule evaluation types
+	ruleCandle, ruleIndicators, err := s.loadRuleInputs(ctx, candleID)
 	if err != nil {
-		return fmt.Errorf("failed to convert indicators: %w", err)
+		return err
 	}
 
-	// 4. Evaluate all rules
+	// 4. Evaluate all rules (active versions)
 	results := rules.EvaluateAllRules(ruleCandle, ruleIndicators)
 
 	// 5. Persist results
 	for ruleCode, result := range results {
-		err := s.ruleResultRepo.CreateRuleResult(ctx, repositories.RuleResultCreateParams{
-			RuleCode:   ruleCode,
-			CandleID:   candleID,
-			Result:     result.Result,
-			Confidence: result.Confidence,
-		})
+		err := s.persistResult(ctx, candleID, result)
 		if err != nil {
 			log.Warn().
 				Err(err).
@@ -86,6 +64,116 @@ func (s *RuleEvaluationService) EvaluateCandle(
 	return nil
 }
 
+// EvaluateCandleVersion evaluates one specific rule version on a candle and
+// persists the result next to any other version's result for the same candle
+func (s *RuleEvaluationService) EvaluateCandleVersion(
+	ctx context.Context,
+	candleID uuid.UUID,
+	ruleCode rules.RuleCode,
+	version int,
+) (rules.RuleResult, error) {
+	ruleCandle, ruleIndicators, err := s.loadRuleInputs(ctx, candleID)
+	if err != nil {
+		return rules.RuleResult{}, err
+	}
+
+	result, err := rules.EvaluateRuleVersion(ruleCode, version, ruleCandle, ruleIndicators)
+	if err != nil {
+		return rules.RuleResult{}, err
+	}
+
+	if err := s.persistResult(ctx, candleID, result); err != nil {
+		return rules.RuleResult{}, fmt.Errorf("failed to persist rule result: %w", err)
+	}
+
+	return result, nil
+}
+
+// EvaluateVersionAll evaluates a rule version over every candle (oldest first)
+// so it can be compared with another version before promotion
+func (s *RuleEvaluationService) EvaluateVersionAll(
+	ctx context.Context,
+	ruleCode rules.RuleCode,
+	version int,
+) (evaluated int, failed int, err error) {
+	if _, ok := rules.GetRuleVersion(ruleCode, version); !ok {
+		return 0, 0, fmt.Errorf("rule not found: %s v%d", ruleCode, version)
+	}
+
+	candles, err := s.candleRepo.GetAllCandlesOrdered(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load candles: %w", err)
+	}
+
+	for _, candle := range candles {
+		if _, err := s.EvaluateCandleVersion(ctx, candle.ID, ruleCode, version); err != nil {
+			log.Warn().
+				Err(err).
+				Str("rule_code", string(ruleCode)).
+				Int("rule_version", version).
+				Str("candle_id", candle.ID.String()).
+				Msg("Failed to evaluate rule version")
+			failed++
+			continue
+		}
+		evaluated++
+	}
+
+	return evaluated, failed, nil
+}
+
+// loadRuleInputs loads a candle and its indicators as rule evaluation types
+func (s *RuleEvaluationService) loadRuleInputs(
+	ctx context.Context,
+	candleID uuid.UUID,
+) (rules.Candle, rules.Indicators, error) {
+	candle, err := s.candleRepo.GetCandleByID(ctx, candleID)
+	if err != nil {
+		return rules.Candle{}, rules.Indicators{}, fmt.Errorf("failed to load candle: %w", err)
+	}
+
+	indicator, err := s.indicatorRepo.GetIndicatorByCandleID(ctx, candleID)
+	if err != nil {
+		return rules.Candle{}, rules.Indicators{}, fmt.Errorf("failed to load indicators: %w", err)
+	}
+	indicator.Values, err = s.indicatorRepo.GetIndicatorValues(ctx, candleID)
+	if err != nil {
+		return rules.Candle{}, rules.Indicators{}, fmt.Errorf("failed to load indicator values: %w", err)
+	}
+
+	ruleCandle, err := s.convertToRuleCandle(*candle)
+	if err != nil {
+		return rules.Candle{}, rules.Indicators{}, fmt.Errorf("failed to convert candle: %w", err)
+	}
+
+	ruleIndicators, err := s.convertToRuleIndicators(ctx, indicator, candle.Symbol, candle.TimestampUTC)
+	if err != nil {
+		return rules.Candle{}, rules.Indicators{}, fmt.Errorf("failed to convert indicators: %w", err)
+	}
+
+	return ruleCandle, ruleIndicators, nil
+}
+
+// persistResult stores a result with its lineage (rule version + definition hash)
+// and the outcome of every condition, together. A result that is already
+// stored is left as it is.
+func (s *RuleEvaluationService) persistResult(
+	ctx context.Context,
+	candleID uuid.UUID,
+	result rules.RuleResult,
+) error {
+	_, err := s.ruleResultRepo.CreateRuleResult(ctx, repositories.RuleResultCreateParams{
+		RuleCode:       result.RuleCode,
+		RuleVersion:    result.Version,
+		DefinitionHash: result.DefinitionHash,
+		CandleID:       candleID,
+		Result:         result.Result,
+		Confidence:     result.Confidence,
+		Conditions:     result.Conditions,
+	})
+	return err
+}
+
 // Helper: Convert repository candle to rules candle
 func (s *RuleEvaluationService) convertToRuleCandle(c repositories.Candle) (rules.Candle, error) {
 	open, err := strconv.ParseFloat(c.Open, 64)
@@ -118,48 +206,70 @@ func (s *RuleEvaluationService) convertToRuleCandle(c repositories.Candle) (rule
 func (s *RuleEvaluationService) convertToRuleIndicators(
 	ctx context.Context,
 	i *repositories.Indicator,
+	symbol string,
 	candleTimestamp time.Time,
 ) (rules.Indicators, error) {
-	ema20, err := strconv.ParseFloat(i.EMA20, 64)
+	ind, err := parseRuleIndicators(i)
 	if err != nil {
 		return rules.Indicators{}, err
 	}
-	ema50, err := strconv.ParseFloat(i.EMA50, 64)
-	if err != nil {
-		return rules.Indicators{}, err
-	}
-	ema200, err := strconv.ParseFloat(i.EMA200, 64)
-	if err != nil {
-		return rules.Indicators{}, err
-	}
-
-	rangeSize, _ := strconv.ParseFloat(i.RangeSize, 64)
-	bodySize, _ := strconv.ParseFloat(i.BodySize, 64)
-	upperWick, _ := strconv.ParseFloat(i.UpperWick, 64)
-	lowerWick, _ := strconv.ParseFloat(i.LowerWick, 64)
-	midPrice, _ := strconv.ParseFloat(i.MidPrice, 64)
 
-	// ✅ CRITICAL: Fetch previous EMA50
-	var ema50Prev *float64
-	prevIndicator, err := s.indicatorRepo.GetPreviousIndicatorByTimestamp(ctx, candleTimestamp)
+	// ✅ CRITICAL: Fetch previous bar (indicators + candle)
+	prevIndicator, prevCandle, err := s.indicatorRepo.GetPreviousBarByTimestamp(ctx, symbol, candleTimestamp)
 	if err == nil {
-		// Previous indicator exists
-		prevEma50, parseErr := strconv.ParseFloat(prevIndicator.EMA50, 64)
+		// Previous bar exists
+		prevIndicator.Values, err = s.indicatorRepo.GetIndicatorValues(ctx, prevIndicator.CandleID)
+		if err != nil {
+			return rules.Indicators{}, fmt.Errorf("failed to load previous indicator values: %w", err)
+		}
+		prevInd, parseErr := parseRuleIndicators(prevIndicator)
 		if parseErr == nil {
-			ema50Prev = &prevEma50
+			if prevInd.IsValid(rules.FieldEMA50) {
+				ind.EMA50Prev = &prevInd.EMA50
+			}
+			ind.Prev = &prevInd
+		}
+		if rc, convErr := s.convertToRuleCandle(*prevCandle); convErr == nil {
+			ind.PrevCandle = &rc
 		}
 	}
-	// If err != nil, it's the first candle, ema50Prev stays nil (correct)
-
-	return rules.Indicators{
-		EMA20:      ema20,
-		EMA50:      ema50,
-		EMA200:     ema200,
-		EMA50Prev:  ema50Prev, // ✅ NOW PROPERLY POPULATED
-		RangeSize:  rangeSize,
-		BodySize:   bodySize,
-		UpperWick:  upperWick,
-		LowerWick:  lowerWick,
-		MidPrice:   midPrice,
-	}, nil
+	// If err != nil, it's the first candle, previous values stay nil (correct)
+
+	return ind, nil
+}
+
+// parseRuleIndicators converts a stored indicator row without previous-bar context.
+// NULL (not warmed up) values are 0 and listed in Invalid; registry indicator
+// values are taken from i.Values (absent = not warmed up).
+func parseRuleIndicators(i *repositories.Indicator) (rules.Indicators, error) {
+	ind := rules.Indicators{Invalid: map[string]bool{}, Values: i.Values}
+
+	emas := []struct {
+		field string
+		raw   *string
+		dst   *float64
+	}{
+		{rules.FieldEMA20, i.EMA20, &ind.EMA20},
+		{rules.FieldEMA50, i.EMA50, &ind.EMA50},
+		{rules.FieldEMA200, i.EMA200, &ind.EMA200},
+	}
+	for _, e := range emas {
+		v, err := parseOptionalFloat(e.raw)
+		if err != nil {
+			return rules.Indicators{}, err
+		}
+		if v == nil {
+			ind.Invalid[e.field] = true
+			continue
+		}
+		*e.dst = *v
+	}
+
+	ind.RangeSize, _ = strconv.ParseFloat(i.RangeSize, 64)
+	ind.BodySize, _ = strconv.ParseFloat(i.BodySize, 64)
+	ind.UpperWick, _ = strconv.ParseFloat(i.UpperWick, 64)
+	ind.LowerWick, _ = strconv.ParseFloat(i.LowerWick, 64)
+	ind.MidPrice, _ = strconv.ParseFloat(i.MidPrice, 64)
+
+	return ind, nil
 }
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
+)
+
+// ErrVersionNotEvaluated is returned when promoting a version with no stored results
+var ErrVersionNotEvaluated = errors.New("rule version has not been evaluated")
+
+// RuleVersionService runs candidate rule versions side by side with the
+// active one, compares their results and promotes them.
+type RuleVersionService struct {
+	ruleRepo  *repositories.RuleRepository
+	evaluator *RuleEvaluationService
+}
+
+func NewRuleVersionService(
+	ruleRepo *repositories.RuleRepository,
+	evaluator *RuleEvaluationService,
+) *RuleVersionService {
+	return &RuleVersionService{
+		ruleRepo:  ruleRepo,
+		evaluator: evaluator,
+	}
+}
+
+// ListVersions returns every stored rule version
+func (s *RuleVersionService) ListVersions(ctx context.Context) ([]repositories.RuleVersion, error) {
+	return s.ruleRepo.ListRuleVersions(ctx)
+}
+
+// EvaluateVersion evaluates a version over all candles.
+// Results are stored next to other versions; the active version is untouched.
+func (s *RuleVersionService) EvaluateVersion(
+	ctx context.Context,
+	code rules.RuleCode,
+	version int,
+) (*repositories.RuleVersion, error) {
+	evaluated, failed, err := s.evaluator.EvaluateVersionAll(ctx, code, version)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("rule_code", string(code)).
+		Int("rule_version", version).
+		Int("evaluated", evaluated).
+		Int("failed", failed).
+		Msg("rule version evaluated")
+
+	return s.ruleRepo.GetRuleVersion(ctx, string(code), version)
+}
+
+// CompareVersions diffs PASS/FAIL results of two versions over the same candles
+func (s *RuleVersionService) CompareVersions(
+	ctx context.Context,
+	code rules.RuleCode,
+	fromVersion, toVersion int,
+) (*repositories.RuleResultComparison, error) {
+	for _, v := range []int{fromVersion, toVersion} {
+		if _, ok := rules.GetRuleVersion(code, v); !ok {
+			return nil, fmt.Errorf("rule not found: %s v%d", code, v)
+		}
+	}
+
+	return s.ruleRepo.CompareRuleVersions(ctx, string(code), fromVersion, toVersion)
+}
+
+// PromoteVersion makes a version active for all future evaluations.
+// Historical results keep the version that produced them.
+func (s *RuleVersionService) PromoteVersion(
+	ctx context.Context,
+	code rules.RuleCode,
+	version int,
+) (*repositories.RuleVersion, error) {
+	if _, ok := rules.GetRuleVersion(code, version); !ok {
+		return nil, fmt.Errorf("rule not found: %s v%d", code, version)
+	}
+
+	stored, err := s.ruleRepo.GetRuleVersion(ctx, string(code), version)
+	if err != nil {
+		return nil, err
+	}
+	if stored.ResultCount == 0 {
+		return nil, fmt.Errorf("%w: evaluate %s v%d before promoting it", ErrVersionNotEvaluated, code, version)
+	}
+
+	if err := s.ruleRepo.PromoteRuleVersion(ctx, string(code), version); err != nil {
+		return nil, err
+	}
+	if err := rules.ActivateVersion(code, version); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("rule_code", string(code)).
+		Int("rule_version", version).
+		Msg("rule version promoted")
+
+	return s.ruleRepo.GetRuleVersion(ctx, string(code), version)
+}
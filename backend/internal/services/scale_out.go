@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/domain"
+)
+
+// ScaleOutLegResult is one planned leg next to the exit executed in its place
+// (the n-th reducing execution fills the n-th leg)
+type ScaleOutLegResult struct {
+	Leg          int     `json:"leg"`
+	PlannedPct   float64 `json:"planned_pct"`
+	PlannedR     float64 `json:"planned_r"` // the planned RR for the TP leg
+	PlannedPrice float64 `json:"planned_price"`
+	ActualEvent  string  `json:"actual_event,omitempty"` // empty when never executed
+	ActualPct    float64 `json:"actual_pct"`
+	ActualR      float64 `json:"actual_r"`
+	ActualPrice  float64 `json:"actual_price"`
+}
+
+// ScaleOutComparison is a closed trade's planned exits next to its actual ones
+type ScaleOutComparison struct {
+	TradeID        uuid.UUID           `json:"trade_id"`
+	Legs           []ScaleOutLegResult `json:"legs"`
+	UnplannedExits int                 `json:"unplanned_exits"` // exits beyond the last leg
+	StoppedOut     bool                `json:"stopped_out"`     // the stop closed the legs left
+	PlannedR       float64             `json:"planned_r"`       // R had every leg filled at its target
+	ActualR        float64             `json:"actual_r"`        // realized R-multiple
+	Followed       bool                `json:"followed"`
+}
+
+// ScaleOutReport compares the scale-out plans of closed trades with what was executed
+type ScaleOutReport struct {
+	Trades          int                  `json:"trades"` // closed trades with a plan
+	Followed        int                  `json:"followed"`
+	FollowRate      float64              `json:"follow_rate"` // followed / trades, 0-1
+	AveragePlannedR float64              `json:"average_planned_r"`
+	AverageActualR  float64              `json:"average_actual_r"`
+	Comparisons     []ScaleOutComparison `json:"comparisons"`
+}
+
+// CompareScaleOut matches a trade's plan with its exits. A trade follows its
+// plan when every exit closes its leg's share at its target (within
+// ScaleOutPctTolerance / ScaleOutRTolerance) and no leg is skipped or added;
+// a stop loss may close the legs left. R is measured like TradeOutcome: from
+// the actual entry, in units of the planned risk.
+func CompareScaleOut(
+	tradeID uuid.UUID,
+	inst domain.Instrument,
+	bias string,
+	plannedEntry float64,
+	plannedSL float64,
+	plannedTP float64,
+	plan domain.ScaleOutPlan,
+	executions []TradeExecution,
+) (ScaleOutComparison, error) {
+	if len(plan) == 0 {
+		return ScaleOutComparison{}, errors.New("trade has no scale-out plan")
+	}
+
+	plannedRR, err := ComputeRR(plannedEntry, plannedSL, plannedTP, bias)
+	if err != nil {
+		return ScaleOutComparison{}, err
+	}
+
+	entryPrice, err := GetActualEntryPrice(executions)
+	if err != nil {
+		return ScaleOutComparison{}, err
+	}
+	var entrySize float64
+	for _, exec := range executions {
+		if exec.EventType == string(domain.EventEntry) {
+			entrySize = exec.PositionSize
+		}
+	}
+	riskPips := inst.Pips(math.Abs(entryPrice - plannedSL))
+	if riskPips == 0 || entrySize <= 0 {
+		return ScaleOutComparison{}, errors.New("entry has no risk or no size")
+	}
+
+	// Exits priced by the same engine as PnL (the rate does not change pips)
+	realized, err := ComputeRealizedPnL(bias, executions, inst, 1)
+	if err != nil {
+		return ScaleOutComparison{}, err
+	}
+
+	comparison := ScaleOutComparison{
+		TradeID:  tradeID,
+		Legs:     make([]ScaleOutLegResult, len(plan)),
+		ActualR:  realized.PnLPips / riskPips,
+		Followed: true,
+	}
+	for i, leg := range plan {
+		legR := plannedRR
+		if leg.TargetR != nil {
+			legR = *leg.TargetR
+		}
+		comparison.Legs[i] = ScaleOutLegResult{
+			Leg:          i + 1,
+			PlannedPct:   leg.ClosePct,
+			PlannedR:     legR,
+			PlannedPrice: leg.TargetPrice(bias, plannedEntry, plannedSL, plannedTP),
+		}
+		comparison.PlannedR += leg.ClosePct / 100 * legR
+	}
+
+	for j, exit := range realized.Executions {
+		if j >= len(plan) {
+			comparison.UnplannedExits++
+			comparison.Followed = false
+			continue
+		}
+
+		leg := &comparison.Legs[j]
+		leg.ActualEvent = exit.EventType
+		leg.ActualPct = exit.PositionSize / entrySize * 100
+		leg.ActualR = exit.PnLPips / riskPips
+		leg.ActualPrice = exit.Price
+
+		if exit.EventType == string(domain.EventSLHit) {
+			comparison.StoppedOut = true
+			continue
+		}
+		if math.Abs(leg.ActualPct-leg.PlannedPct) > constants.ScaleOutPctTolerance ||
+			math.Abs(leg.ActualR-leg.PlannedR) > constants.ScaleOutRTolerance {
+			comparison.Followed = false
+		}
+	}
+	if !comparison.StoppedOut && len(realized.Executions) < len(plan) {
+		comparison.Followed = false
+	}
+
+	return comparison, nil
+}
+
+// ComputeScaleOutReport aggregates the comparisons of closed trades
+func ComputeScaleOutReport(comparisons []ScaleOutComparison) ScaleOutReport {
+	report := ScaleOutReport{
+		Trades:      len(comparisons),
+		Comparisons: comparisons,
+	}
+	if report.Comparisons == nil {
+		report.Comparisons = []ScaleOutComparison{}
+	}
+
+	var plannedR, actualR float64
+	for _, c := range comparisons {
+		if c.Followed {
+			report.Followed++
+		}
+		plannedR += c.PlannedR
+		actualR += c.ActualR
+	}
+
+	if report.Trades > 0 {
+		n := float64(report.Trades)
+		report.FollowRate = float64(report.Followed) / n
+		report.AveragePlannedR = plannedR / n
+		report.AverageActualR = actualR / n
+	}
+	return report
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestCompareScaleOut(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return t0.Add(time.Duration(h) * time.Hour) }
+	r := func(v float64) *float64 { return &v }
+
+	// Long 1.1000, SL 1.0950 (50 pips = 1R), TP 1.1150 (3R)
+	plan := domain.ScaleOutPlan{{ClosePct: 50, TargetR: r(1)}, {ClosePct: 50}}
+	entry := TradeExecution{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: at(0)}
+
+	tests := []struct {
+		name       string
+		executions []TradeExecution
+		followed   bool
+		stopped    bool
+		unplanned  int
+		actualR    float64
+	}{
+		{
+			"followed",
+			[]TradeExecution{entry,
+				{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: at(1)},
+				{EventType: "tp_hit", Price: 1.1150, PositionSize: 0.5, ExecutedAt: at(2)},
+			},
+			true, false, 0, 2,
+		},
+		{
+			"closed too much at 1R",
+			[]TradeExecution{entry,
+				{EventType: "partial_close", Price: 1.1050, PositionSize: 0.8, ExecutedAt: at(1)},
+				{EventType: "tp_hit", Price: 1.1150, PositionSize: 0.2, ExecutedAt: at(2)},
+			},
+			false, false, 0, 1.4,
+		},
+		{
+			"cut the runner early",
+			[]TradeExecution{entry,
+				{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: at(1)},
+				{EventType: "manual_close", Price: 1.1075, PositionSize: 0.5, ExecutedAt: at(2)},
+			},
+			false, false, 0, 1.25,
+		},
+		{
+			"stopped before 1R",
+			[]TradeExecution{entry,
+				{EventType: "sl_hit", Price: 1.0950, PositionSize: 1, ExecutedAt: at(1)},
+			},
+			true, true, 0, -1,
+		},
+		{
+			"skipped the 1R leg",
+			[]TradeExecution{entry,
+				{EventType: "tp_hit", Price: 1.1150, PositionSize: 1, ExecutedAt: at(2)},
+			},
+			false, false, 0, 3,
+		},
+		{
+			"extra exit",
+			[]TradeExecution{entry,
+				{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: at(1)},
+				{EventType: "partial_close", Price: 1.1100, PositionSize: 0.25, ExecutedAt: at(2)},
+				{EventType: "tp_hit", Price: 1.1150, PositionSize: 0.25, ExecutedAt: at(3)},
+			},
+			false, false, 1, 1.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := CompareScaleOut(uuid.New(), domain.EURUSD, "long", 1.1000, 1.0950, 1.1150, plan, tt.executions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.Followed != tt.followed || c.StoppedOut != tt.stopped || c.UnplannedExits != tt.unplanned {
+				t.Errorf("expected followed=%v stopped=%v unplanned=%d, got %+v", tt.followed, tt.stopped, tt.unplanned, c)
+			}
+			if math.Abs(c.PlannedR-2) > 1e-9 {
+				t.Errorf("expected planned 2R, got %v", c.PlannedR)
+			}
+			if math.Abs(c.ActualR-tt.actualR) > 1e-6 {
+				t.Errorf("expected actual %vR, got %v", tt.actualR, c.ActualR)
+			}
+		})
+	}
+
+	t.Run("leg targets", func(t *testing.T) {
+		c, _ := CompareScaleOut(uuid.New(), domain.EURUSD, "long", 1.1000, 1.0950, 1.1150, plan, tests[0].executions)
+		if math.Abs(c.Legs[0].PlannedPrice-1.1050) > 1e-9 || math.Abs(c.Legs[1].PlannedPrice-1.1150) > 1e-9 {
+			t.Errorf("unexpected leg prices %+v", c.Legs)
+		}
+		if math.Abs(c.Legs[1].PlannedR-3) > 1e-6 || c.Legs[1].ActualEvent != "tp_hit" || math.Abs(c.Legs[0].ActualPct-50) > 1e-9 {
+			t.Errorf("unexpected legs %+v", c.Legs)
+		}
+	})
+
+	t.Run("no plan", func(t *testing.T) {
+		if _, err := CompareScaleOut(uuid.New(), domain.EURUSD, "long", 1.1000, 1.0950, 1.1150, nil, tests[0].executions); err == nil {
+			t.Error("expected error without plan")
+		}
+	})
+}
+
+func TestComputeScaleOutReport(t *testing.T) {
+	report := ComputeScaleOutReport([]ScaleOutComparison{
+		{PlannedR: 2, ActualR: 2, Followed: true},
+		{PlannedR: 2, ActualR: 1, Followed: false},
+		{PlannedR: 2, ActualR: -1, Followed: true},
+		{PlannedR: 1, ActualR: 0, Followed: true},
+	})
+
+	if report.Trades != 4 || report.Followed != 3 || report.FollowRate != 0.75 {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	if report.AveragePlannedR != 1.75 || report.AverageActualR != 0.5 {
+		t.Errorf("unexpected averages %+v", report)
+	}
+
+	empty := ComputeScaleOutReport(nil)
+	if empty.Trades != 0 || empty.Comparisons == nil {
+		t.Errorf("expected empty report, got %+v", empty)
+	}
+}
@@ -3,10 +3,12 @@ package services
 import (
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 	
-//	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/rules"
 )
 
 // TradeState represents the current state of a trade
@@ -147,35 +149,73 @@ func GetActualEntryPrice(executions []TradeExecution) (float64, error) {
 	return 0, errors.New("no entry execution found")
 }
 
-// ComputePnL calculates profit/loss using ACTUAL entry price (not planned)
-func ComputePnL(
+// RealizedExecution is one reducing execution priced on its own size
+type RealizedExecution struct {
+	EventType     string    `json:"event_type"`
+	ExecutedAt    time.Time `json:"executed_at"`
+	Price         float64   `json:"price"`
+	PositionSize  float64   `json:"position_size"`
+	PnL           float64   `json:"pnl"`
+	PnLPips       float64   `json:"pnl_pips"`
+	CumulativePnL float64   `json:"cumulative_pnl"` // trade total up to and including this execution
+}
+
+// RealizedPnL is the PnL a trade has realised so far, in the account currency
+type RealizedPnL struct {
+	Executions []RealizedExecution `json:"executions"`
+	PnL        float64             `json:"pnl"`
+	PnLPips    float64             `json:"pnl_pips"` // size-weighted over every exit
+	ClosedSize float64             `json:"closed_size"`
+}
+
+// ComputeRealizedPnL prices every reducing execution (partial closes and the
+// final close) on its own size against the ACTUAL entry, in execution order
+func ComputeRealizedPnL(
 	bias string,
 	executions []TradeExecution,
-	closePrice float64,
-	positionSize float64,
-	pipValue float64,
-) (pnlMoney float64, pnlPips float64, err error) {
-	// Get ACTUAL entry price (critical fix)
-	entryPrice, err := GetActualEntryPrice(executions)
-	if err != nil {
-		return 0, 0, fmt.Errorf("get entry price:  %w", err)
-	}
+	inst domain.Instrument,
+	quoteToAccountRate float64,
+) (RealizedPnL, error) {
+	sortedExecs := make([]TradeExecution, len(executions))
+	copy(sortedExecs, executions)
+	sort.SliceStable(sortedExecs, func(i, j int) bool {
+		return sortedExecs[i].ExecutedAt.Before(sortedExecs[j].ExecutedAt)
+	})
 	
-	// Calculate pip difference
-	var pipDiff float64
-	if bias == "long" {
-		pipDiff = (closePrice - entryPrice) / pipValue
-	} else if bias == "short" {
-		pipDiff = (entryPrice - closePrice) / pipValue
-	} else {
-		return 0, 0, fmt.Errorf("invalid bias: %s", bias)
+	realized := RealizedPnL{Executions: []RealizedExecution{}}
+	var weightedPips float64
+	for _, exec := range sortedExecs {
+		if !domain.IsReducingEvent(domain.ExecutionEventType(exec.EventType)) {
+			continue
+		}
+		
+		entryPrice, err := GetActualEntryPrice(sortedExecs)
+		if err != nil {
+			return RealizedPnL{}, fmt.Errorf("price %s: %w", exec.EventType, err)
+		}
+		money, pips, err := ComputeExecutionPnL(bias, entryPrice, exec.Price, exec.PositionSize, inst, quoteToAccountRate)
+		if err != nil {
+			return RealizedPnL{}, fmt.Errorf("price %s: %w", exec.EventType, err)
+		}
+		realized.PnL += money
+		realized.ClosedSize += exec.PositionSize
+		weightedPips += pips * exec.PositionSize
+		
+		realized.Executions = append(realized.Executions, RealizedExecution{
+			EventType:     exec.EventType,
+			ExecutedAt:    exec.ExecutedAt,
+			Price:         exec.Price,
+			PositionSize:  exec.PositionSize,
+			PnL:           money,
+			PnLPips:       pips,
+			CumulativePnL: realized.PnL,
+		})
 	}
 	
-	// Calculate money gained/lost
-	pnlPips = pipDiff
-	pnlMoney = pipDiff * pipValue * positionSize * 100000 // Standard lot conversion
-	
-	return pnlMoney, pnlPips, nil
+	if realized.ClosedSize > 0 {
+		realized.PnLPips = weightedPips / realized.ClosedSize
+	}
+	return realized, nil
 }
 
 // ComputeRemainingPosition calculates how much position is still open
@@ -211,7 +251,7 @@ func ComputeRemainingPosition(
 			}
 			if exec.PositionSize >= remaining {
 				return 0, fmt.Errorf(
-					"partial close %. 4f exceeds remaining %.4f",
+					"partial close %.4f exceeds remaining %.4f",
 					exec.PositionSize,
 					remaining,
 				)
@@ -224,7 +264,7 @@ func ComputeRemainingPosition(
 			}
 			if exec.PositionSize != remaining {
 				return 0, fmt.Errorf(
-					"close size %.4f does not match remaining %. 4f",
+					"close size %.4f does not match remaining %.4f",
 					exec. PositionSize,
 					remaining,
 				)
@@ -252,7 +292,7 @@ func ValidateExecutionSize(
 	case "entry":
 		if executionSize != plannedSize {
 			return fmt. Errorf(
-				"entry size %.4f must match planned %. 4f",
+				"entry size %.4f must match planned %.4f",
 				executionSize,
 				plannedSize,
 			)
@@ -283,6 +323,67 @@ func ValidateExecutionSize(
 	return nil
 }
 
+// ValidateExecutionTime checks a fill time: not before the trade setup, not in
+// the future and after every prior execution (DeriveTradeState replays in
+// executed_at order)
+func ValidateExecutionTime(
+	executedAt time.Time,
+	setupTime time.Time,
+	now time.Time,
+	existingExecutions []TradeExecution,
+) error {
+	if executedAt.IsZero() {
+		return errors.New("executed_at is required")
+	}
+	if executedAt.Before(setupTime) {
+		return fmt.Errorf(
+			"executed_at %s is before trade setup %s",
+			executedAt.Format(time.RFC3339),
+			setupTime.Format(time.RFC3339),
+		)
+	}
+	if executedAt.After(now) {
+		return fmt.Errorf("executed_at %s is in the future", executedAt.Format(time.RFC3339))
+	}
+	
+	for _, exec := range existingExecutions {
+		if !executedAt.After(exec.ExecutedAt) {
+			return fmt.Errorf(
+				"executed_at %s must be after the %s at %s",
+				executedAt.Format(time.RFC3339),
+				exec.EventType,
+				exec.ExecutedAt.Format(time.RFC3339),
+			)
+		}
+	}
+	
+	return nil
+}
+
+// ExecutionSession maps a fill time to the stored session: the active session
+// that opened most recently (New York during the London overlap, London during
+// the Asian overlap). Sydney and Asia are both stored as asian; nil on weekends.
+func ExecutionSession(executedAt time.Time) *domain.Session {
+	// DeriveSessions lists active sessions in registry (opening) order
+	active := rules.DeriveSessions(executedAt.UTC())
+	if len(active) == 0 {
+		return nil
+	}
+	
+	var session domain.Session
+	switch active[len(active)-1] {
+	case rules.SessionSydney, rules.SessionAsia:
+		session = domain.SessionAsian
+	case rules.SessionLondon:
+		session = domain.SessionLondon
+	case rules.SessionNewYork:
+		session = domain.SessionNewYork
+	default:
+		return nil
+	}
+	return &session
+}
+
 // ValidateTradeExecutable checks if trade can accept execution events
 func ValidateTradeExecutable(
 	executions []TradeExecution,
@@ -309,3 +410,175 @@ func ValidateTradeExecutable(
 	
 	return nil
 }
+
+// TradeAdjustment represents a move of the SL or TP
+type TradeAdjustment struct {
+	AdjustmentType string
+	Price          float64 // the new level
+	AdjustedAt     time.Time
+}
+
+// TradeLevels are a trade's SL and TP: as planned and in force
+type TradeLevels struct {
+	PlannedSL float64 `json:"planned_sl"` // original stop, the 1R unit
+	PlannedTP float64 `json:"planned_tp"`
+	SL        float64 `json:"sl"` // in force
+	TP        float64 `json:"tp"` // in force
+}
+
+// LevelsInForce replays the adjustments made at or before at over the
+// planned levels
+func LevelsInForce(
+	plannedSL float64,
+	plannedTP float64,
+	adjustments []TradeAdjustment,
+	at time.Time,
+) TradeLevels {
+	sorted := make([]TradeAdjustment, len(adjustments))
+	copy(sorted, adjustments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].AdjustedAt.Before(sorted[j].AdjustedAt)
+	})
+
+	levels := TradeLevels{PlannedSL: plannedSL, PlannedTP: plannedTP, SL: plannedSL, TP: plannedTP}
+	for _, adj := range sorted {
+		if adj.AdjustedAt.After(at) {
+			break
+		}
+		if domain.AdjustmentType(adj.AdjustmentType).MovesSL() {
+			levels.SL = adj.Price
+		} else {
+			levels.TP = adj.Price
+		}
+	}
+	return levels
+}
+
+// ValidateAdjustment checks a new level against the market price: a SL stays
+// on the losing side of it (below for a long), a TP on the winning side, so a
+// move never sets a level the market has already crossed
+func ValidateAdjustment(
+	bias string,
+	adjustmentType domain.AdjustmentType,
+	newPrice float64,
+	marketPrice float64,
+) error {
+	if newPrice <= 0 || marketPrice <= 0 {
+		return errors.New("prices must be positive")
+	}
+	if bias != "long" && bias != "short" {
+		return errors.New("bias must be long or short")
+	}
+
+	if adjustmentType.MovesSL() {
+		if bias == "long" && newPrice >= marketPrice {
+			return fmt.Errorf("SL %v must be below the market price %v for a long", newPrice, marketPrice)
+		}
+		if bias == "short" && newPrice <= marketPrice {
+			return fmt.Errorf("SL %v must be above the market price %v for a short", newPrice, marketPrice)
+		}
+		return nil
+	}
+
+	if bias == "long" && newPrice <= marketPrice {
+		return fmt.Errorf("TP %v must be above the market price %v for a long", newPrice, marketPrice)
+	}
+	if bias == "short" && newPrice >= marketPrice {
+		return fmt.Errorf("TP %v must be below the market price %v for a short", newPrice, marketPrice)
+	}
+	return nil
+}
+
+// ValidateAdjustmentTime checks an adjustment time: not before the entry, not
+// in the future and after every prior adjustment (LevelsInForce replays in
+// adjusted_at order)
+func ValidateAdjustmentTime(
+	adjustedAt time.Time,
+	now time.Time,
+	executions []TradeExecution,
+	existingAdjustments []TradeAdjustment,
+) error {
+	if adjustedAt.IsZero() {
+		return errors.New("adjusted_at is required")
+	}
+	if adjustedAt.After(now) {
+		return fmt.Errorf("adjusted_at %s is in the future", adjustedAt.Format(time.RFC3339))
+	}
+
+	for _, exec := range executions {
+		if exec.EventType == string(domain.EventEntry) && adjustedAt.Before(exec.ExecutedAt) {
+			return fmt.Errorf(
+				"adjusted_at %s is before the entry at %s",
+				adjustedAt.Format(time.RFC3339),
+				exec.ExecutedAt.Format(time.RFC3339),
+			)
+		}
+	}
+
+	for _, adj := range existingAdjustments {
+		if !adjustedAt.After(adj.AdjustedAt) {
+			return fmt.Errorf(
+				"adjusted_at %s must be after the %s at %s",
+				adjustedAt.Format(time.RFC3339),
+				adj.AdjustmentType,
+				adj.AdjustedAt.Format(time.RFC3339),
+			)
+		}
+	}
+
+	return nil
+}
+
+// TradeRisk is what an open trade has at stake: risk is measured to the stop
+// in force, R-multiples in units of the original stop
+type TradeRisk struct {
+	Levels          TradeLevels `json:"levels"`
+	EntryPrice      float64     `json:"entry_price"`
+	RemainingSize   float64     `json:"remaining_size"`
+	PlannedRiskPips float64     `json:"planned_risk_pips"` // entry → planned SL, 1R
+	OpenRiskPips    float64     `json:"open_risk_pips"`    // entry → SL in force, <= 0 once at or past entry
+	OpenRisk        float64     `json:"open_risk"`         // lost on the remaining position if stopped now
+	OpenRiskR       float64     `json:"open_risk_r"`       // OpenRiskPips in planned R
+}
+
+// ComputeTradeRisk prices a stop-out of the remaining position at the SL in
+// force, from the ACTUAL entry, in the account currency
+func ComputeTradeRisk(
+	bias string,
+	plannedPositionSize float64,
+	executions []TradeExecution,
+	levels TradeLevels,
+	inst domain.Instrument,
+	quoteToAccountRate float64,
+) (TradeRisk, error) {
+	entryPrice, err := GetActualEntryPrice(executions)
+	if err != nil {
+		return TradeRisk{}, err
+	}
+
+	remaining, err := ComputeRemainingPosition(plannedPositionSize, executions)
+	if err != nil {
+		return TradeRisk{}, err
+	}
+
+	plannedRiskPips := inst.Pips(math.Abs(entryPrice - levels.PlannedSL))
+	if plannedRiskPips == 0 {
+		return TradeRisk{}, errors.New("stop loss equals entry price")
+	}
+
+	// A stop-out loses what the move from the stop back to the entry gains
+	openRisk, openRiskPips, err := ComputeExecutionPnL(bias, levels.SL, entryPrice, remaining, inst, quoteToAccountRate)
+	if err != nil {
+		return TradeRisk{}, err
+	}
+
+	return TradeRisk{
+		Levels:          levels,
+		EntryPrice:      entryPrice,
+		RemainingSize:   remaining,
+		PlannedRiskPips: plannedRiskPips,
+		OpenRiskPips:    openRiskPips,
+		OpenRisk:        openRisk,
+		OpenRiskR:       openRiskPips / plannedRiskPips,
+	}, nil
+}
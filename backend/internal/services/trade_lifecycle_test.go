@@ -0,0 +1,389 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestComputeRealizedPnL(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	executions := []TradeExecution{
+		{EventType: "tp_hit", Price: 1.1100, PositionSize: 0.5, ExecutedAt: t0.Add(3 * time.Hour)},
+		{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+		{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: t0.Add(time.Hour)},
+	}
+
+	realized, err := ComputeRealizedPnL("long", executions, domain.EURUSD, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(realized.Executions) != 2 {
+		t.Fatalf("expected 2 reducing executions, got %d", len(realized.Executions))
+	}
+	partial, final := realized.Executions[0], realized.Executions[1]
+	if partial.EventType != "partial_close" || math.Abs(partial.PnL-250) > 1e-6 || math.Abs(partial.CumulativePnL-250) > 1e-6 {
+		t.Errorf("unexpected partial %+v", partial)
+	}
+	if final.EventType != "tp_hit" || math.Abs(final.PnL-500) > 1e-6 || math.Abs(final.CumulativePnL-750) > 1e-6 {
+		t.Errorf("unexpected final %+v", final)
+	}
+	if math.Abs(realized.PnL-750) > 1e-6 || math.Abs(realized.PnLPips-75) > 1e-6 || realized.ClosedSize != 1 {
+		t.Errorf("unexpected totals %+v", realized)
+	}
+
+	t.Run("open trade", func(t *testing.T) {
+		open, err := ComputeRealizedPnL("long", executions[1:2], domain.EURUSD, 1)
+		if err != nil || open.PnL != 0 || len(open.Executions) != 0 {
+			t.Errorf("expected nothing realised, got %+v (%v)", open, err)
+		}
+	})
+
+	t.Run("exit before entry", func(t *testing.T) {
+		if _, err := ComputeRealizedPnL("long", executions[2:], domain.EURUSD, 1); err == nil {
+			t.Error("expected error without entry")
+		}
+	})
+}
+
+// randomTrade builds an entry, 0-3 partial closes and a final close that
+// together reduce the whole position
+func randomTrade(r *rand.Rand, inst domain.Instrument) (string, []TradeExecution) {
+	bias := "long"
+	if r.Intn(2) == 1 {
+		bias = "short"
+	}
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := inst.PipSize * float64(10000+r.Intn(10000))
+	price := func() float64 { return entry + inst.PipSize*float64(r.Intn(400)-200) }
+
+	remaining := float64(1+r.Intn(100)) * inst.LotStep
+	executions := []TradeExecution{{EventType: "entry", Price: entry, PositionSize: remaining, ExecutedAt: t0}}
+	partials := r.Intn(4)
+	for i := 1; i <= partials; i++ {
+		size := math.Floor(remaining*r.Float64()/inst.LotStep) * inst.LotStep
+		if size <= 0 || size >= remaining {
+			break
+		}
+		remaining -= size
+		executions = append(executions, TradeExecution{
+			EventType: "partial_close", Price: price(), PositionSize: size, ExecutedAt: t0.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	closing := []string{"tp_hit", "sl_hit", "manual_close"}[r.Intn(3)]
+	executions = append(executions, TradeExecution{
+		EventType: closing, Price: price(), PositionSize: remaining, ExecutedAt: t0.Add(24 * time.Hour),
+	})
+	return bias, executions
+}
+
+// TestPnLEngines_Agree checks on random trades that what RecordExecution
+// stores on each execution (storedExecutionPnL, rounded to cents) and posts to
+// the ledger agrees with the per-trade paths (ComputeRealizedPnL,
+// ComputeTradeOutcome) up to that rounding
+func TestPnLEngines_Agree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	instruments := []domain.Instrument{domain.EURUSD, testXAUUSD}
+
+	for i := 0; i < 1000; i++ {
+		inst := instruments[r.Intn(len(instruments))]
+		rate := 0.5 + r.Float64()
+		bias, executions := randomTrade(r, inst)
+
+		realized, err := ComputeRealizedPnL(bias, executions, inst, rate)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if len(realized.Executions) != len(executions)-1 {
+			t.Fatalf("case %d: %d realised executions for %d exits", i, len(realized.Executions), len(executions)-1)
+		}
+
+		// Replay the executions the way RecordExecution persists them: each
+		// one is priced against the executions recorded before it
+		stored := decimal.Zero
+		ledger := decimal.Zero
+		for j := 1; j < len(executions); j++ {
+			exec := executions[j]
+			money, pips, err := storedExecutionPnL(bias, executions[:j], exec.Price, exec.PositionSize, inst, rate)
+			if err != nil {
+				t.Fatalf("case %d: %v", i, err)
+			}
+
+			want := realized.Executions[j-1]
+			if math.Abs(money-want.PnL) > 0.005+1e-9 || math.Abs(pips-want.PnLPips) > 0.005+1e-9 {
+				t.Fatalf("case %d: stored %v (%v pips) != realised %v (%v pips)", i, money, pips, want.PnL, want.PnLPips)
+			}
+
+			amount := decimal.NewFromFloat(money)
+			stored = stored.Add(amount)
+			if !amount.Round(2).IsZero() {
+				ledger = ledger.Add(amount.Round(2))
+			}
+		}
+
+		if !ledger.Equal(stored) {
+			t.Fatalf("case %d: ledger %s != sum of stored executions %s", i, ledger, stored)
+		}
+		drift := 0.005*float64(len(realized.Executions)) + 1e-9
+		if math.Abs(realized.PnL-stored.InexactFloat64()) > drift {
+			t.Fatalf("case %d: realised %v != sum of stored executions %s", i, realized.PnL, stored)
+		}
+		if realized.Executions[len(realized.Executions)-1].CumulativePnL != realized.PnL {
+			t.Fatalf("case %d: cumulative %v != total %v", i, realized.Executions[len(realized.Executions)-1].CumulativePnL, realized.PnL)
+		}
+		if math.Abs(realized.ClosedSize-executions[0].PositionSize) > 1e-9 {
+			t.Fatalf("case %d: closed %v of %v", i, realized.ClosedSize, executions[0].PositionSize)
+		}
+
+		sl := executions[0].Price - inst.PipSize*50
+		if bias == "short" {
+			sl = executions[0].Price + inst.PipSize*50
+		}
+		outcome, err := ComputeTradeOutcome(uuid.New(), inst, rate, bias, sl, executions[0].Price, executions)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if outcome.PnLMoney != realized.PnL || outcome.PnLPips != realized.PnLPips {
+			t.Fatalf("case %d: outcome %v != realised %v", i, outcome.PnLMoney, realized.PnL)
+		}
+	}
+}
+
+// TestComputeExecutionPnL_Additive checks that closing a position in slices
+// at one price realises the same PnL as closing it at once
+func TestComputeExecutionPnL_Additive(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 1000; i++ {
+		entry := 1 + r.Float64()
+		exit := 1 + r.Float64()
+		a := float64(1+r.Intn(100)) * 0.01
+		b := float64(1+r.Intn(100)) * 0.01
+
+		whole, _, _ := ComputeExecutionPnL("long", entry, exit, a+b, domain.EURUSD, 1)
+		first, _, _ := ComputeExecutionPnL("long", entry, exit, a, domain.EURUSD, 1)
+		second, _, _ := ComputeExecutionPnL("long", entry, exit, b, domain.EURUSD, 1)
+		if math.Abs(whole-(first+second)) > 1e-6 {
+			t.Fatalf("case %d: %v != %v + %v", i, whole, first, second)
+		}
+
+		short, _, _ := ComputeExecutionPnL("short", entry, exit, a, domain.EURUSD, 1)
+		if math.Abs(short+first) > 1e-6 {
+			t.Fatalf("case %d: short %v is not the mirror of long %v", i, short, first)
+		}
+	}
+}
+
+func TestValidateExecutionTime(t *testing.T) {
+	setup := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := setup.Add(72 * time.Hour)
+	prior := []TradeExecution{{EventType: "entry", ExecutedAt: setup.Add(24 * time.Hour)}}
+
+	tests := []struct {
+		name       string
+		executedAt time.Time
+		executions []TradeExecution
+		wantErr    bool
+	}{
+		{"first fill after setup", setup.Add(time.Hour), nil, false},
+		{"fill at setup", setup, nil, false},
+		{"fill after prior execution", setup.Add(48 * time.Hour), prior, false},
+		{"missing time", time.Time{}, nil, true},
+		{"before setup", setup.Add(-time.Hour), nil, true},
+		{"in the future", now.Add(time.Minute), nil, true},
+		{"before prior execution", setup.Add(12 * time.Hour), prior, true},
+		{"same time as prior execution", setup.Add(24 * time.Hour), prior, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExecutionTime(tt.executedAt, setup, now, tt.executions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecutionSession(t *testing.T) {
+	monday := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return monday.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected domain.Session
+	}{
+		{"sydney only", at(22, 0), domain.SessionAsian},
+		{"sydney/asia overlap", at(2, 0), domain.SessionAsian},
+		{"asia/london overlap", at(8, 30), domain.SessionLondon},
+		{"london only", at(10, 0), domain.SessionLondon},
+		{"london/new york overlap", at(14, 0), domain.SessionNewYork},
+		{"new york only", at(18, 0), domain.SessionNewYork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := ExecutionSession(tt.t)
+			if session == nil || *session != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, session)
+			}
+		})
+	}
+
+	t.Run("weekend", func(t *testing.T) {
+		if session := ExecutionSession(monday.Add(-24 * time.Hour)); session != nil {
+			t.Errorf("expected no session on sunday, got %s", *session)
+		}
+	})
+}
+
+func TestLevelsInForce(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	adjustments := []TradeAdjustment{
+		{AdjustmentType: "move_tp", Price: 1.1200, AdjustedAt: t0.Add(3 * time.Hour)},
+		{AdjustmentType: "break_even", Price: 1.1000, AdjustedAt: t0.Add(2 * time.Hour)},
+		{AdjustmentType: "trail_to_swing", Price: 1.1040, AdjustedAt: t0.Add(4 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		sl, tp float64
+	}{
+		{"before any adjustment", t0.Add(time.Hour), 1.0950, 1.1100},
+		{"at break-even", t0.Add(2 * time.Hour), 1.1000, 1.1100},
+		{"after tp move", t0.Add(3 * time.Hour), 1.1000, 1.1200},
+		{"after trail", t0.Add(5 * time.Hour), 1.1040, 1.1200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels := LevelsInForce(1.0950, 1.1100, adjustments, tt.at)
+			if levels.SL != tt.sl || levels.TP != tt.tp {
+				t.Errorf("expected SL %v TP %v, got %+v", tt.sl, tt.tp, levels)
+			}
+			if levels.PlannedSL != 1.0950 || levels.PlannedTP != 1.1100 {
+				t.Errorf("planned levels changed: %+v", levels)
+			}
+		})
+	}
+}
+
+func TestValidateAdjustment(t *testing.T) {
+	tests := []struct {
+		name           string
+		bias           string
+		adjustmentType domain.AdjustmentType
+		newPrice       float64
+		marketPrice    float64
+		wantErr        bool
+	}{
+		{"long SL below market", "long", domain.AdjustMoveSL, 1.0980, 1.1050, false},
+		{"long SL at market", "long", domain.AdjustMoveSL, 1.1050, 1.1050, true},
+		{"long SL above market", "long", domain.AdjustBreakEven, 1.1000, 1.0990, true},
+		{"long trail below market", "long", domain.AdjustTrailToSwing, 1.1020, 1.1050, false},
+		{"short SL above market", "short", domain.AdjustMoveSL, 1.1020, 1.0950, false},
+		{"short SL below market", "short", domain.AdjustBreakEven, 1.1000, 1.1010, true},
+		{"long TP above market", "long", domain.AdjustMoveTP, 1.1200, 1.1050, false},
+		{"long TP below market", "long", domain.AdjustMoveTP, 1.1000, 1.1050, true},
+		{"short TP below market", "short", domain.AdjustMoveTP, 1.0800, 1.0950, false},
+		{"short TP above market", "short", domain.AdjustMoveTP, 1.1000, 1.0950, true},
+		{"invalid bias", "flat", domain.AdjustMoveSL, 1.0980, 1.1050, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAdjustment(tt.bias, tt.adjustmentType, tt.newPrice, tt.marketPrice)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAdjustmentTime(t *testing.T) {
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := entry.Add(72 * time.Hour)
+	executions := []TradeExecution{{EventType: "entry", ExecutedAt: entry}}
+	prior := []TradeAdjustment{{AdjustmentType: "break_even", AdjustedAt: entry.Add(24 * time.Hour)}}
+
+	tests := []struct {
+		name        string
+		adjustedAt  time.Time
+		adjustments []TradeAdjustment
+		wantErr     bool
+	}{
+		{"after entry", entry.Add(time.Hour), nil, false},
+		{"at entry", entry, nil, false},
+		{"after prior adjustment", entry.Add(48 * time.Hour), prior, false},
+		{"missing time", time.Time{}, nil, true},
+		{"before entry", entry.Add(-time.Hour), nil, true},
+		{"in the future", now.Add(time.Minute), nil, true},
+		{"before prior adjustment", entry.Add(12 * time.Hour), prior, true},
+		{"same time as prior adjustment", entry.Add(24 * time.Hour), prior, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAdjustmentTime(tt.adjustedAt, now, executions, tt.adjustments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestComputeTradeRisk(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	executions := []TradeExecution{
+		{EventType: "entry", Price: 1.1000, PositionSize: 1, ExecutedAt: t0},
+		{EventType: "partial_close", Price: 1.1050, PositionSize: 0.5, ExecutedAt: t0.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		sl        float64
+		openRisk  float64
+		openRiskR float64
+	}{
+		{"original stop", 1.0950, 250, 1},
+		{"stop tightened", 1.0975, 125, 0.5},
+		{"break-even", 1.1000, 0, 0},
+		{"stop in profit", 1.1025, -125, -0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels := TradeLevels{PlannedSL: 1.0950, PlannedTP: 1.1100, SL: tt.sl, TP: 1.1100}
+			risk, err := ComputeTradeRisk("long", 1, executions, levels, domain.EURUSD, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if risk.RemainingSize != 0.5 || math.Abs(risk.PlannedRiskPips-50) > 1e-6 {
+				t.Errorf("unexpected remaining %v / planned risk %v", risk.RemainingSize, risk.PlannedRiskPips)
+			}
+			if math.Abs(risk.OpenRisk-tt.openRisk) > 1e-6 || math.Abs(risk.OpenRiskR-tt.openRiskR) > 1e-6 {
+				t.Errorf("expected risk %v (%vR), got %v (%vR)", tt.openRisk, tt.openRiskR, risk.OpenRisk, risk.OpenRiskR)
+			}
+		})
+	}
+
+	t.Run("short", func(t *testing.T) {
+		levels := TradeLevels{PlannedSL: 1.1050, PlannedTP: 1.0900, SL: 1.1025, TP: 1.0900}
+		risk, err := ComputeTradeRisk("short", 1, executions[:1], levels, domain.EURUSD, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(risk.OpenRisk-250) > 1e-6 || math.Abs(risk.OpenRiskR-0.5) > 1e-6 {
+			t.Errorf("expected 250 (0.5R), got %v (%vR)", risk.OpenRisk, risk.OpenRiskR)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"set-and-trend/backend/internal/domain"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// LevelHit is a SL or TP reached by a candle: the closing event and the
+// level price it fills at
+type LevelHit struct {
+	EventType domain.ExecutionEventType
+	Price     float64
+}
+
+// DetectLevelHit checks a trade's SL and TP against a candle's range. A level
+// is hit when the range touches it; when it contains both, rule decides
+// which one filled first. Nil when neither is hit.
+func DetectLevelHit(bias string, sl, tp float64, candle Candle, rule domain.SameCandleRule) *LevelHit {
+	var slHit, tpHit bool
+	if bias == "short" {
+		slHit = candle.High >= sl
+		tpHit = candle.Low <= tp
+	} else {
+		slHit = candle.Low <= sl
+		tpHit = candle.High >= tp
+	}
+
+	stop := &LevelHit{EventType: domain.EventSLHit, Price: sl}
+	target := &LevelHit{EventType: domain.EventTPHit, Price: tp}
+
+	switch {
+	case slHit && tpHit:
+		switch rule {
+		case domain.SameCandleTPFirst:
+			return target
+		case domain.SameCandleNearestOpen:
+			if math.Abs(candle.Open-tp) < math.Abs(candle.Open-sl) {
+				return target
+			}
+			return stop
+		default:
+			return stop
+		}
+	case slHit:
+		return stop
+	case tpHit:
+		return target
+	default:
+		return nil
+	}
+}
+
+// TradeRecorder records what the TradeMonitor detects (see ExecutionService)
+type TradeRecorder interface {
+	RecordLevelHit(ctx context.Context, tradeID uuid.UUID, hit LevelHit, executedAt time.Time, reason string) (*repositories.TradeExecution, error)
+	TriggerOrder(ctx context.Context, tradeID uuid.UUID, price float64, executedAt time.Time, reason string) (*repositories.TradeExecution, error)
+	InvalidateTrade(ctx context.Context, tradeID uuid.UUID, reason string) (*repositories.TradeIntent, error)
+}
+
+// TradeMonitor closes open trades whose SL or TP is reached by an ingested
+// candle and enters or invalidates pending orders, as system-generated
+// executions and intents
+type TradeMonitor struct {
+	candleRepo       *repositories.CandleRepository
+	executionRepo    *repositories.ExecutionRepository
+	executionService TradeRecorder
+	sameCandleRule   domain.SameCandleRule
+}
+
+func NewTradeMonitor(
+	candleRepo *repositories.CandleRepository,
+	executionRepo *repositories.ExecutionRepository,
+	executionService TradeRecorder,
+	sameCandleRule domain.SameCandleRule,
+) *TradeMonitor {
+	return &TradeMonitor{
+		candleRepo:       candleRepo,
+		executionRepo:    executionRepo,
+		executionService: executionService,
+		sameCandleRule:   sameCandleRule,
+	}
+}
+
+// CheckCandle checks the SL and TP in force of every open or partial trade on
+// the candle's symbol against the candle and records the hits. Only trades
+// whose executions and adjustments all precede the candle are checked: within
+// a candle, OHLC cannot tell whether a level was reached before or after a
+// fill or a move. A hit is filled at the level price at the candle's close
+// (capped at now). A trade that fails is logged and skipped so it does not
+// block the others.
+func (m *TradeMonitor) CheckCandle(ctx context.Context, candleID uuid.UUID) ([]repositories.TradeExecution, error) {
+	stored, err := m.candleRepo.GetCandleByID(ctx, candleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candle: %w", err)
+	}
+
+	candles, err := ToComputeCandles([]repositories.Candle{*stored})
+	if err != nil {
+		return nil, err
+	}
+	candle := candles[0]
+
+	trades, err := m.executionRepo.GetOpenTradesBySymbol(ctx, stored.Symbol, candle.TimestampUTC)
+	if err != nil {
+		return nil, err
+	}
+
+	executedAt := candle.TimestampUTC.Add(7 * 24 * time.Hour)
+	if now := time.Now().UTC(); executedAt.After(now) {
+		executedAt = now
+	}
+
+	hits := []repositories.TradeExecution{}
+	for _, trade := range trades {
+		execution, err := m.checkTrade(ctx, trade, candle, executedAt)
+		if err != nil {
+			log.Error().Err(err).
+				Str("trade_id", trade.TradeID.String()).
+				Str("candle_id", candleID.String()).
+				Msg("level check failed")
+			continue
+		}
+		if execution != nil {
+			hits = append(hits, *execution)
+		}
+	}
+
+	return hits, nil
+}
+
+func (m *TradeMonitor) checkTrade(
+	ctx context.Context,
+	trade repositories.OpenTrade,
+	candle Candle,
+	executedAt time.Time,
+) (*repositories.TradeExecution, error) {
+	sl, err := parseDecimal(trade.SL)
+	if err != nil {
+		return nil, fmt.Errorf("parse sl: %w", err)
+	}
+	tp, err := parseDecimal(trade.TP)
+	if err != nil {
+		return nil, fmt.Errorf("parse tp: %w", err)
+	}
+
+	hit := DetectLevelHit(trade.Bias, sl, tp, candle, m.sameCandleRule)
+	if hit == nil {
+		return nil, nil
+	}
+
+	level := "SL"
+	if hit.EventType == domain.EventTPHit {
+		level = "TP"
+	}
+	reason := fmt.Sprintf("%s %s hit by W1 candle %s",
+		level, strconv.FormatFloat(hit.Price, 'f', -1, 64), candle.TimestampUTC.Format("2006-01-02"))
+
+	return m.executionService.RecordLevelHit(ctx, trade.TradeID, *hit, executedAt, reason)
+}
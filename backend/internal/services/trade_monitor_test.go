@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"set-and-trend/backend/internal/domain"
+)
+
+func TestDetectLevelHit(t *testing.T) {
+	// Long: SL 1.0950, TP 1.1100. Short: SL 1.1050, TP 1.0900.
+	tests := []struct {
+		name     string
+		bias     string
+		candle   Candle
+		rule     domain.SameCandleRule
+		expected *LevelHit
+	}{
+		{"long inside range", "long", Candle{Open: 1.1000, High: 1.1050, Low: 1.0960}, domain.SameCandleSLFirst, nil},
+		{"long stop", "long", Candle{Open: 1.1000, High: 1.1050, Low: 1.0940}, domain.SameCandleSLFirst, &LevelHit{domain.EventSLHit, 1.0950}},
+		{"long target", "long", Candle{Open: 1.1000, High: 1.1120, Low: 1.0960}, domain.SameCandleSLFirst, &LevelHit{domain.EventTPHit, 1.1100}},
+		{"long touch counts", "long", Candle{Open: 1.1000, High: 1.1100, Low: 1.0960}, domain.SameCandleSLFirst, &LevelHit{domain.EventTPHit, 1.1100}},
+		{"long both sl_first", "long", Candle{Open: 1.1080, High: 1.1120, Low: 1.0940}, domain.SameCandleSLFirst, &LevelHit{domain.EventSLHit, 1.0950}},
+		{"long both tp_first", "long", Candle{Open: 1.0970, High: 1.1120, Low: 1.0940}, domain.SameCandleTPFirst, &LevelHit{domain.EventTPHit, 1.1100}},
+		{"long both open near tp", "long", Candle{Open: 1.1080, High: 1.1120, Low: 1.0940}, domain.SameCandleNearestOpen, &LevelHit{domain.EventTPHit, 1.1100}},
+		{"long both open near sl", "long", Candle{Open: 1.0970, High: 1.1120, Low: 1.0940}, domain.SameCandleNearestOpen, &LevelHit{domain.EventSLHit, 1.0950}},
+		{"short inside range", "short", Candle{Open: 1.1000, High: 1.1040, Low: 1.0910}, domain.SameCandleSLFirst, nil},
+		{"short stop", "short", Candle{Open: 1.1000, High: 1.1060, Low: 1.0910}, domain.SameCandleSLFirst, &LevelHit{domain.EventSLHit, 1.1050}},
+		{"short target", "short", Candle{Open: 1.1000, High: 1.1040, Low: 1.0890}, domain.SameCandleSLFirst, &LevelHit{domain.EventTPHit, 1.0900}},
+		{"short both sl_first", "short", Candle{Open: 1.0920, High: 1.1060, Low: 1.0890}, domain.SameCandleSLFirst, &LevelHit{domain.EventSLHit, 1.1050}},
+		{"short both tp_first", "short", Candle{Open: 1.1040, High: 1.1060, Low: 1.0890}, domain.SameCandleTPFirst, &LevelHit{domain.EventTPHit, 1.0900}},
+		{"short both open near tp", "short", Candle{Open: 1.0920, High: 1.1060, Low: 1.0890}, domain.SameCandleNearestOpen, &LevelHit{domain.EventTPHit, 1.0900}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl, tp := 1.0950, 1.1100
+			if tt.bias == "short" {
+				sl, tp = 1.1050, 1.0900
+			}
+
+			hit := DetectLevelHit(tt.bias, sl, tp, tt.candle, tt.rule)
+			if tt.expected == nil {
+				if hit != nil {
+					t.Errorf("expected no hit, got %+v", *hit)
+				}
+				return
+			}
+			if hit == nil || *hit != *tt.expected {
+				t.Errorf("expected %+v, got %v", *tt.expected, hit)
+			}
+		})
+	}
+}
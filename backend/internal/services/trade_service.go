@@ -2,33 +2,57 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/domain"
 	"set-and-trend/backend/internal/repositories"
 )
 
 type TradeService struct {
-	tradeRepo   TradeRepo
-	accountRepo AccountRepo
-	candleRepo  CandleRepo
+	tradeRepo      TradeRepo
+	accountRepo    AccountRepo
+	candleRepo     CandleRepo
+	instrumentRepo InstrumentRepo
+	fxRateRepo     FXRateRepo
 }
 
 func NewTradeService(
 	tradeRepo TradeRepo,
 	accountRepo AccountRepo,
 	candleRepo CandleRepo,
+	instrumentRepo InstrumentRepo,
+	fxRateRepo FXRateRepo,
 ) *TradeService {
 	return &TradeService{
-		tradeRepo:   tradeRepo,
-		accountRepo: accountRepo,
-		candleRepo:  candleRepo,
+		tradeRepo:      tradeRepo,
+		accountRepo:    accountRepo,
+		candleRepo:     candleRepo,
+		instrumentRepo: instrumentRepo,
+		fxRateRepo:     fxRateRepo,
 	}
 }
 
+// Rejection codes let clients react to a refused trade without parsing text
+const (
+	RejectDailyRiskExceeded = "DAILY_RISK_EXCEEDED"
+	RejectInvalidEntryPrice = "INVALID_ENTRY_PRICE"
+)
+
+// TradeRejectedError is a business-rule rejection with a stable code
+type TradeRejectedError struct {
+	Code    string
+	Message string
+}
+
+func (e *TradeRejectedError) Error() string {
+	return e.Message
+}
+
 // CreateTradeInput represents user intent
 type CreateTradeInput struct {
 	AccountID      uuid.UUID
@@ -39,6 +63,9 @@ type CreateTradeInput struct {
 	PlannedTP      float64
 	PlannedRiskPct float64
 	ReasonForTrade string
+	ScaleOutPlan   domain.ScaleOutPlan // optional, empty = single exit
+	OrderType      domain.OrderType    // optional, empty = market
+	ExpiryWeeks    int                 // limit/stop only, 0 = good till cancelled
 }
 
 // CreateTrade orchestrates trade creation with full validation
@@ -50,16 +77,50 @@ func (s *TradeService) CreateTrade(ctx context.Context, input CreateTradeInput)
 	}
 	
 	// 2. Verify candle exists (anchor only, no OHLC validation yet)
-	if _, err := s.candleRepo.GetCandleByID(ctx, input.CandleID); err != nil {
+	candle, err := s.candleRepo.GetCandleByID(ctx, input.CandleID)
+	if err != nil {
 		return nil, fmt.Errorf("candle not found: %w", err)
 	}
 
+	// 2.5. The trade is on the candle's instrument
+	inst, err := s.instrumentRepo.GetInstrument(ctx, candle.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("instrument not found: %w", err)
+	}
+
+	// 2.6. Pip value is in the quote currency; risk is in the account currency
+	setupTime := time.Now().UTC()
+	quoteToAccountRate, err := QuoteToAccountRate(ctx, s.fxRateRepo, inst.QuoteCurrency, account.Currency, setupTime)
+	if err != nil {
+		return nil, fmt.Errorf("currency conversion: %w", err)
+	}
+
 	// 3. Validate trade geometry
 	err = ValidateTradeGeometry(input.PlannedEntry, input.PlannedSL, input.PlannedTP, input.Bias)
 	if err != nil {
 		return nil, fmt.Errorf("invalid geometry: %w", err)
 	}
 
+	// 3.5. Pending orders must sit on the right side of the setup candle's close
+	if input.OrderType == "" {
+		input.OrderType = domain.OrderMarket
+	}
+	var marketPrice float64
+	if input.OrderType.IsPending() {
+		marketPrice, err = parseDecimal(candle.Close)
+		if err != nil {
+			return nil, fmt.Errorf("parse candle close: %w", err)
+		}
+	}
+	if err := ValidateOrder(input.Bias, input.OrderType, input.PlannedEntry, marketPrice, input.ExpiryWeeks); err != nil {
+		return nil, fmt.Errorf("invalid order: %w", err)
+	}
+	var expiresAt *time.Time
+	if input.ExpiryWeeks > 0 {
+		expiry := setupTime.Add(time.Duration(input.ExpiryWeeks) * 7 * 24 * time.Hour)
+		expiresAt = &expiry
+	}
+
 	// 4. Validate risk percentage
 	accountMaxRisk := account.MaxRiskPerTradePct
 	if input.PlannedRiskPct > accountMaxRisk {
@@ -69,6 +130,25 @@ func (s *TradeService) CreateTrade(ctx context.Context, input CreateTradeInput)
 		return nil, fmt.Errorf("planned risk must be positive")
 	}
 
+	// 4.5. Enforce the daily risk cap across today's setups and open positions.
+	// Checked in the same transaction as the insert (step 6), so concurrent
+	// setups on the account cannot both pass.
+	dayStart, err := TradingDayStart(time.Now(), account.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	checkDailyRisk := func(riskTrades []repositories.RiskTrade) error {
+		dailyRisk := ComputeDailyRiskPct(riskTrades, dayStart)
+		if dailyRisk+input.PlannedRiskPct > account.MaxDailyRiskPct {
+			return &TradeRejectedError{
+				Code: RejectDailyRiskExceeded,
+				Message: fmt.Sprintf("planned risk %.2f%% on top of %.2f%% already committed today exceeds daily max %.2f%%",
+					input.PlannedRiskPct, dailyRisk, account.MaxDailyRiskPct),
+			}
+		}
+		return nil
+	}
+
 	// 5. Compute risk math
 	balance, _ := strconv.ParseFloat(account.Balance, 64)
 	
@@ -82,14 +162,12 @@ func (s *TradeService) CreateTrade(ctx context.Context, input CreateTradeInput)
 		return nil, fmt.Errorf("stop distance: %w", err)
 	}
 
-	stopDistancePips, err := ComputeStopDistancePips(stopDistance, constants.PipValueEURUSD)
+	stopDistancePips, err := ComputeStopDistancePips(stopDistance, *inst)
 	if err != nil {
 		return nil, fmt.Errorf("pip conversion: %w", err)
 	}
 
-	// Position sizing (assuming $10 per pip for standard lot)
-	const pipValuePerLot = 10.0
-	positionSize, err := ComputePositionSize(riskAmount, stopDistancePips, pipValuePerLot)
+	positionSize, err := ComputePositionSize(riskAmount, stopDistancePips, *inst, quoteToAccountRate)
 	if err != nil {
 		return nil, fmt.Errorf("position sizing: %w", err)
 	}
@@ -118,7 +196,7 @@ func (s *TradeService) CreateTrade(ctx context.Context, input CreateTradeInput)
 	}
 
 	// 5.7. Validate position size against leverage
-	maxPositionSize, err := ComputeMaxPositionSize(balance, account.Leverage, constants.ContractSizeEURUSD)
+	maxPositionSize, err := ComputeMaxPositionSize(balance, account.Leverage, *inst, input.PlannedEntry, quoteToAccountRate)
 	if err != nil {
 		return nil, fmt.Errorf("leverage check: %w", err)
 	}
@@ -126,29 +204,46 @@ func (s *TradeService) CreateTrade(ctx context.Context, input CreateTradeInput)
 		return nil, fmt.Errorf("position size %.2f lots exceeds max %.2f lots (leverage: %dx)",
 			positionSize, maxPositionSize, account.Leverage)
 	}
-	// 6. Create trade with immutable snapshots
-	trade, err := s.tradeRepo.CreateTrade(ctx, repositories.TradeCreateParams{
+
+	// 5.8. Validate the scale-out plan against the RR and the sized position
+	if err := input.ScaleOutPlan.Validate(rr); err != nil {
+		return nil, fmt.Errorf("invalid scale-out plan: %w", err)
+	}
+	if _, err := input.ScaleOutPlan.LegSizes(positionSize, *inst); err != nil {
+		return nil, fmt.Errorf("invalid scale-out plan: %w", err)
+	}
+	// 6. Create trade with immutable snapshots, within the daily risk cap
+	trade, err := s.tradeRepo.CreateTradeWithinRisk(ctx, repositories.TradeCreateParams{
 		ID:                        uuid.New(),
 		UserID:                    account.UserID,
 		AccountID:                 input.AccountID,
 		CandleID:                  input.CandleID,
-		Symbol:                    constants.SymbolEURUSD,
+		Symbol:                    inst.Symbol,
 		Timeframe:                 constants.TimeframeW1,
-		SetupTimestampUTC:         time.Now().UTC(),
+		SetupTimestampUTC:         setupTime,
 		AccountBalanceAtSetup:     account.Balance,
 		LeverageAtSetup:           int32(account.Leverage),
 		MaxRiskPerTradePctAtSetup: fmt.Sprintf("%.2f", account.MaxRiskPerTradePct),
 		TimezoneAtSetup:           account.Timezone,
+		AccountCurrencyAtSetup:    account.Currency,
+		QuoteToAccountRateAtSetup: strconv.FormatFloat(quoteToAccountRate, 'f', 8, 64),
 		Bias:                      input.Bias,
-		PlannedEntry:              fmt.Sprintf("%.5f", input.PlannedEntry),
-		PlannedSL:                 fmt.Sprintf("%.5f", input.PlannedSL),
-		PlannedTP:                 fmt.Sprintf("%.5f", input.PlannedTP),
+		PlannedEntry:              inst.FormatPrice(input.PlannedEntry),
+		PlannedSL:                 inst.FormatPrice(input.PlannedSL),
+		PlannedTP:                 inst.FormatPrice(input.PlannedTP),
 		PlannedRR:                 fmt.Sprintf("%.2f", rr),
 		PlannedRiskPct:            fmt.Sprintf("%.2f", input.PlannedRiskPct),
 		PlannedRiskAmount:         fmt.Sprintf("%.2f", riskAmount),
 		PlannedPositionSize:       fmt.Sprintf("%.2f", positionSize),
 		ReasonForTrade:            input.ReasonForTrade,
-	})
+		ScaleOutPlan:              input.ScaleOutPlan,
+		OrderType:                 string(input.OrderType),
+		ExpiresAt:                 expiresAt,
+	}, dayStart, checkDailyRisk)
+	var rejected *TradeRejectedError
+	if errors.As(err, &rejected) {
+		return nil, rejected
+	}
 	if err != nil {
 		return nil, fmt.Errorf("persist trade: %w", err)
 	}
@@ -2,9 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"set-and-trend/backend/internal/domain"
 	"set-and-trend/backend/internal/repositories"
 )
 
@@ -35,17 +40,50 @@ func (m *mockCandleRepo) CreateCandle(ctx context.Context, params repositories.C
 	return nil, nil
 }
 
-func (m *mockCandleRepo) GetLatestCandles(ctx context.Context, limit int) ([]repositories.Candle, error) {
+func (m *mockCandleRepo) GetLatestCandles(ctx context.Context, symbol string, limit int) ([]repositories.Candle, error) {
 	return nil, nil
 }
 
+type mockInstrumentRepo struct {
+	instrument domain.Instrument
+	err        error
+}
+
+func (m *mockInstrumentRepo) GetInstrument(ctx context.Context, symbol string) (*domain.Instrument, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	inst := m.instrument
+	return &inst, nil
+}
+
+type mockFXRateRepo struct {
+	rates map[string]float64 // "EURUSD" -> 1 EUR in USD
+}
+
+func (m *mockFXRateRepo) GetLatestRate(ctx context.Context, base, quote string, asOf time.Time) (*repositories.FXRate, error) {
+	rate, ok := m.rates[base+quote]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &repositories.FXRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		RateDate:      asOf,
+		Rate:          decimal.NewFromFloat(rate),
+	}, nil
+}
+
 type mockTradeRepo struct {
-	trade  *repositories.Trade
-	trades []*repositories.Trade
-	err    error
+	trade      *repositories.Trade
+	trades     []*repositories.Trade
+	riskTrades []repositories.RiskTrade
+	created    *repositories.TradeCreateParams
+	err        error
 }
 
 func (m *mockTradeRepo) CreateTrade(ctx context.Context, params repositories.TradeCreateParams) (*repositories.Trade, error) {
+	m.created = &params
 	return m.trade, m.err
 }
 
@@ -57,6 +95,18 @@ func (m *mockTradeRepo) GetTradesByAccountAndCandle(ctx context.Context, account
 	return m.trades, m.err
 }
 
+func (m *mockTradeRepo) CreateTradeWithinRisk(
+	ctx context.Context,
+	params repositories.TradeCreateParams,
+	since time.Time,
+	check func([]repositories.RiskTrade) error,
+) (*repositories.Trade, error) {
+	if err := check(m.riskTrades); err != nil {
+		return nil, err
+	}
+	return m.CreateTrade(ctx, params)
+}
+
 func TestCreateTrade_ValidLongTrade(t *testing.T) {
 	ctx := context.Background()
 
@@ -65,8 +115,10 @@ func TestCreateTrade_ValidLongTrade(t *testing.T) {
 			ID:                 uuid.New(),
 			UserID:             uuid.New(),
 			Balance:            "10000.00",
+			Currency:           "USD",
 			Leverage:           100,
 			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
 			Timezone:           "UTC",
 		},
 	}
@@ -84,7 +136,7 @@ func TestCreateTrade_ValidLongTrade(t *testing.T) {
 		},
 	}
 
-	service := NewTradeService(tradeRepo, accountRepo, candleRepo)
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
 
 	input := CreateTradeInput{
 		AccountID:      accountRepo.account.ID,
@@ -114,8 +166,10 @@ func TestCreateTrade_RejectLowRR(t *testing.T) {
 			ID:                 uuid.New(),
 			UserID:             uuid.New(),
 			Balance:            "10000.00",
+			Currency:           "USD",
 			Leverage:           100,
 			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
 			Timezone:           "UTC",
 		},
 	}
@@ -128,7 +182,7 @@ func TestCreateTrade_RejectLowRR(t *testing.T) {
 		trades: []*repositories.Trade{},
 	}
 
-	service := NewTradeService(tradeRepo, accountRepo, candleRepo)
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
 
 	input := CreateTradeInput{
 		AccountID:      accountRepo.account.ID,
@@ -158,8 +212,10 @@ func TestCreateTrade_RejectDuplicate(t *testing.T) {
 			ID:                 accountID,
 			UserID:             uuid.New(),
 			Balance:            "10000.00",
+			Currency:           "USD",
 			Leverage:           100,
 			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
 			Timezone:           "UTC",
 		},
 	}
@@ -180,7 +236,7 @@ func TestCreateTrade_RejectDuplicate(t *testing.T) {
 		},
 	}
 
-	service := NewTradeService(tradeRepo, accountRepo, candleRepo)
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
 
 	input := CreateTradeInput{
 		AccountID:      accountID,
@@ -207,6 +263,7 @@ func TestCreateTrade_RejectExcessiveRisk(t *testing.T) {
 			ID:                 uuid.New(),
 			UserID:             uuid.New(),
 			Balance:            "10000.00",
+			Currency:           "USD",
 			Leverage:           100,
 			MaxRiskPerTradePct: 2.0, // Max 2%
 			Timezone:           "UTC",
@@ -221,7 +278,7 @@ func TestCreateTrade_RejectExcessiveRisk(t *testing.T) {
 		trades: []*repositories.Trade{},
 	}
 
-	service := NewTradeService(tradeRepo, accountRepo, candleRepo)
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
 
 	input := CreateTradeInput{
 		AccountID:      accountRepo.account.ID,
@@ -248,8 +305,10 @@ func TestCreateTrade_RejectInvalidGeometry(t *testing.T) {
 			ID:                 uuid.New(),
 			UserID:             uuid.New(),
 			Balance:            "10000.00",
+			Currency:           "USD",
 			Leverage:           100,
 			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
 			Timezone:           "UTC",
 		},
 	}
@@ -262,7 +321,7 @@ func TestCreateTrade_RejectInvalidGeometry(t *testing.T) {
 		trades: []*repositories.Trade{},
 	}
 
-	service := NewTradeService(tradeRepo, accountRepo, candleRepo)
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
 
 	input := CreateTradeInput{
 		AccountID:      accountRepo.account.ID,
@@ -280,3 +339,262 @@ func TestCreateTrade_RejectInvalidGeometry(t *testing.T) {
 		t.Fatal("Expected error for invalid geometry, got success")
 	}
 }
+
+func TestCreateTrade_RejectDailyRiskExceeded(t *testing.T) {
+	ctx := context.Background()
+
+	accountRepo := &mockAccountRepo{
+		account: &repositories.Account{
+			ID:                 uuid.New(),
+			UserID:             uuid.New(),
+			Balance:            "10000.00",
+			Currency:           "USD",
+			Leverage:           100,
+			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    3.0,
+			Timezone:           "Europe/Berlin",
+		},
+	}
+
+	candleRepo := &mockCandleRepo{
+		candle: &repositories.Candle{ID: uuid.New()},
+	}
+
+	// 1.5% set up today plus 1% still open from last week
+	tradeRepo := &mockTradeRepo{
+		trades: []*repositories.Trade{},
+		trade:  &repositories.Trade{ID: uuid.New()},
+		riskTrades: []repositories.RiskTrade{
+			{SetupTimestampUTC: time.Now(), PlannedRiskPct: 1.5},
+			{SetupTimestampUTC: time.Now().AddDate(0, 0, -7), PlannedRiskPct: 1.0, Entered: true},
+		},
+	}
+
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
+
+	input := CreateTradeInput{
+		AccountID:      accountRepo.account.ID,
+		CandleID:       candleRepo.candle.ID,
+		Bias:           "long",
+		PlannedEntry:   1.1050,
+		PlannedSL:      1.1000,
+		PlannedTP:      1.1200,
+		PlannedRiskPct: 1.0, // 3.5% total - should fail
+		ReasonForTrade: "Third setup of the day",
+	}
+
+	_, err := service.CreateTrade(ctx, input)
+	var rejected *TradeRejectedError
+	if !errors.As(err, &rejected) || rejected.Code != RejectDailyRiskExceeded {
+		t.Fatalf("Expected %s rejection, got: %v", RejectDailyRiskExceeded, err)
+	}
+	if tradeRepo.created != nil {
+		t.Fatal("Expected no trade persisted after the rejection")
+	}
+
+	// 0.5% fits exactly under the cap
+	input.PlannedRiskPct = 0.5
+	if _, err := service.CreateTrade(ctx, input); err != nil {
+		t.Fatalf("Expected success at the cap, got error: %v", err)
+	}
+}
+
+func TestCreateTrade_ConvertsPipValueToAccountCurrency(t *testing.T) {
+	ctx := context.Background()
+
+	accountRepo := &mockAccountRepo{
+		account: &repositories.Account{
+			ID:                 uuid.New(),
+			UserID:             uuid.New(),
+			Balance:            "10000.00",
+			Currency:           "EUR",
+			Leverage:           100,
+			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
+			Timezone:           "UTC",
+		},
+	}
+
+	candleRepo := &mockCandleRepo{
+		candle: &repositories.Candle{ID: uuid.New(), Symbol: "EURUSD"},
+	}
+
+	tradeRepo := &mockTradeRepo{
+		trades: []*repositories.Trade{},
+		trade:  &repositories.Trade{ID: uuid.New()},
+	}
+
+	// Only EUR→USD is stored: USD→EUR is its inverse (0.8)
+	fxRateRepo := &mockFXRateRepo{rates: map[string]float64{"EURUSD": 1.25}}
+
+	service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, fxRateRepo)
+
+	input := CreateTradeInput{
+		AccountID:      accountRepo.account.ID,
+		CandleID:       candleRepo.candle.ID,
+		Bias:           "long",
+		PlannedEntry:   1.2550,
+		PlannedSL:      1.2500, // 50 pips
+		PlannedTP:      1.2700,
+		PlannedRiskPct: 1.0, // €100
+		ReasonForTrade: "EUR account",
+	}
+
+	if _, err := service.CreateTrade(ctx, input); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	// €100 / (50 pips × €8 per pip per lot) = 0.25 lots
+	created := tradeRepo.created
+	if created.PlannedPositionSize != "0.25" {
+		t.Errorf("Expected 0.25 lots, got %s", created.PlannedPositionSize)
+	}
+	if created.AccountCurrencyAtSetup != "EUR" || created.QuoteToAccountRateAtSetup != "0.80000000" {
+		t.Errorf("Expected EUR at 0.8 snapshotted, got %s at %s",
+			created.AccountCurrencyAtSetup, created.QuoteToAccountRateAtSetup)
+	}
+
+	// Without a stored rate the trade cannot be sized
+	accountRepo.account.Currency = "GBP"
+	_, err := service.CreateTrade(ctx, input)
+	if !errors.Is(err, ErrNoFXRate) {
+		t.Fatalf("Expected ErrNoFXRate, got: %v", err)
+	}
+}
+
+func TestCreateTrade_ScaleOutPlan(t *testing.T) {
+	ctx := context.Background()
+	r := func(v float64) *float64 { return &v }
+
+	accountRepo := &mockAccountRepo{
+		account: &repositories.Account{
+			ID:                 uuid.New(),
+			UserID:             uuid.New(),
+			Balance:            "10000.00",
+			Currency:           "USD",
+			Leverage:           100,
+			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
+			Timezone:           "UTC",
+		},
+	}
+	candleRepo := &mockCandleRepo{candle: &repositories.Candle{ID: uuid.New()}}
+
+	tests := []struct {
+		name    string
+		plan    domain.ScaleOutPlan
+		wantErr bool
+	}{
+		{"no plan", nil, false},
+		{"half at 1R, rest at TP", domain.ScaleOutPlan{{ClosePct: 50, TargetR: r(1)}, {ClosePct: 50}}, false},
+		{"three R legs", domain.ScaleOutPlan{{ClosePct: 25, TargetR: r(1)}, {ClosePct: 25, TargetR: r(2)}, {ClosePct: 50, TargetR: r(2.5)}}, false},
+		{"legs below 100%", domain.ScaleOutPlan{{ClosePct: 50, TargetR: r(1)}, {ClosePct: 40}}, true},
+		{"target at TP", domain.ScaleOutPlan{{ClosePct: 50, TargetR: r(3)}, {ClosePct: 50}}, true},
+		{"targets not increasing", domain.ScaleOutPlan{{ClosePct: 50, TargetR: r(2)}, {ClosePct: 50, TargetR: r(1)}}, true},
+		{"TP leg not last", domain.ScaleOutPlan{{ClosePct: 50}, {ClosePct: 50, TargetR: r(1)}}, true},
+		{"leg below min lot", domain.ScaleOutPlan{{ClosePct: 1, TargetR: r(1)}, {ClosePct: 99}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tradeRepo := &mockTradeRepo{
+				trades: []*repositories.Trade{},
+				trade:  &repositories.Trade{ID: uuid.New()},
+			}
+			service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
+
+			_, err := service.CreateTrade(ctx, CreateTradeInput{
+				AccountID:      accountRepo.account.ID,
+				CandleID:       candleRepo.candle.ID,
+				Bias:           "long",
+				PlannedEntry:   1.1050,
+				PlannedSL:      1.1000,
+				PlannedTP:      1.1200, // 3R, 0.20 lots
+				PlannedRiskPct: 1.0,
+				ReasonForTrade: "Weekly bullish trend confirmed",
+				ScaleOutPlan:   tt.plan,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+			if !tt.wantErr && len(tradeRepo.created.ScaleOutPlan) != len(tt.plan) {
+				t.Errorf("expected %d legs stored, got %d", len(tt.plan), len(tradeRepo.created.ScaleOutPlan))
+			}
+		})
+	}
+}
+
+func TestCreateTrade_OrderType(t *testing.T) {
+	ctx := context.Background()
+
+	accountRepo := &mockAccountRepo{
+		account: &repositories.Account{
+			ID:                 uuid.New(),
+			UserID:             uuid.New(),
+			Balance:            "10000.00",
+			Currency:           "USD",
+			Leverage:           100,
+			MaxRiskPerTradePct: 2.0,
+			MaxDailyRiskPct:    5.0,
+			Timezone:           "UTC",
+		},
+	}
+	// Setup candle closed at 1.1020; long entry 1.1050 sits above it
+	candleRepo := &mockCandleRepo{candle: &repositories.Candle{ID: uuid.New(), Close: "1.10200"}}
+
+	tests := []struct {
+		name        string
+		orderType   domain.OrderType
+		expiryWeeks int
+		wantType    string
+		wantExpiry  bool
+		wantErr     bool
+	}{
+		{"default market", "", 0, "market", false, false},
+		{"stop with expiry", domain.OrderStop, 2, "stop", true, false},
+		{"stop good till cancelled", domain.OrderStop, 0, "stop", false, false},
+		{"limit above market", domain.OrderLimit, 0, "", false, true},
+		{"market with expiry", domain.OrderMarket, 1, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tradeRepo := &mockTradeRepo{
+				trades: []*repositories.Trade{},
+				trade:  &repositories.Trade{ID: uuid.New()},
+			}
+			service := NewTradeService(tradeRepo, accountRepo, candleRepo, &mockInstrumentRepo{instrument: domain.EURUSD}, &mockFXRateRepo{})
+
+			_, err := service.CreateTrade(ctx, CreateTradeInput{
+				AccountID:      accountRepo.account.ID,
+				CandleID:       candleRepo.candle.ID,
+				Bias:           "long",
+				PlannedEntry:   1.1050,
+				PlannedSL:      1.1000,
+				PlannedTP:      1.1200,
+				PlannedRiskPct: 1.0,
+				ReasonForTrade: "Weekly bullish trend confirmed",
+				OrderType:      tt.orderType,
+				ExpiryWeeks:    tt.expiryWeeks,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wantErr=%v, got err=%v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tradeRepo.created.OrderType != tt.wantType {
+				t.Errorf("expected order type %s, got %s", tt.wantType, tradeRepo.created.OrderType)
+			}
+			if (tradeRepo.created.ExpiresAt != nil) != tt.wantExpiry {
+				t.Errorf("expected expiry %v, got %v", tt.wantExpiry, tradeRepo.created.ExpiresAt)
+			}
+			if tt.wantExpiry {
+				weeks := tradeRepo.created.ExpiresAt.Sub(tradeRepo.created.SetupTimestampUTC)
+				if weeks != time.Duration(tt.expiryWeeks)*7*24*time.Hour {
+					t.Errorf("expected expiry %d weeks after setup, got %v", tt.expiryWeeks, weeks)
+				}
+			}
+		})
+	}
+}
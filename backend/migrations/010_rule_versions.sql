@@ -0,0 +1,47 @@
+-- Migration 010: Rule Versions and Rule Result Lineage
+-- Date: 2026-10-17
+-- Description: Every rule_results row records the rule version and definition
+-- hash that produced it. Versions are evaluated side by side and promoted explicitly.
+
+-- Step 1: Registered rule versions (synced from the rules engine at startup)
+CREATE TABLE IF NOT EXISTS rule_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES rules(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version >= 1),
+    definition_hash TEXT NOT NULL,
+    definition JSONB NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    promoted_at TIMESTAMPTZ,
+    UNIQUE (rule_id, version)
+);
+
+-- Exactly one active version per rule
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rule_versions_one_active
+ON rule_versions (rule_id) WHERE is_active;
+
+COMMENT ON TABLE rule_versions IS 'Frozen rule definitions. A (rule, version) hash never changes; new semantics require a new version.';
+
+-- Step 2: Lineage on rule_results
+-- Existing rows were produced by v1 of every rule; their hash is unknown (NULL)
+ALTER TABLE rule_results
+    ADD COLUMN IF NOT EXISTS rule_version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS definition_hash TEXT;
+
+-- One result per rule version per candle (versions coexist side by side)
+ALTER TABLE rule_results DROP CONSTRAINT IF EXISTS rule_results_rule_id_candle_id_key;
+ALTER TABLE rule_results
+    ADD CONSTRAINT rule_results_rule_id_candle_id_version_key UNIQUE (rule_id, candle_id, rule_version);
+
+-- Step 3: rule_results are immutable
+CREATE OR REPLACE FUNCTION prevent_rule_result_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'rule_results are immutable: evaluate a new rule version instead (rule %, candle %)', OLD.rule_id, OLD.candle_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_prevent_rule_result_update ON rule_results;
+CREATE TRIGGER trg_prevent_rule_result_update
+BEFORE UPDATE ON rule_results
+FOR EACH ROW EXECUTE FUNCTION prevent_rule_result_update();
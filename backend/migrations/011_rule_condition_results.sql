@@ -0,0 +1,31 @@
+-- Migration 011: Rule Condition Results
+-- Date: 2026-10-17
+-- Description: Per-condition outcome of every rule evaluation, with the input
+-- values the condition compared. Immutable like rule_results.
+
+CREATE TABLE IF NOT EXISTS rule_condition_results (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_result_id UUID NOT NULL REFERENCES rule_results(id) ON DELETE CASCADE,
+    condition_code TEXT NOT NULL,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    passed BOOLEAN NOT NULL,
+    inputs JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (rule_result_id, condition_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_condition_results_code ON rule_condition_results (condition_code);
+
+COMMENT ON TABLE rule_condition_results IS 'Structured explanation of a rule result: one row per condition with its boolean and input values.';
+
+CREATE OR REPLACE FUNCTION prevent_rule_condition_result_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'rule_condition_results are immutable (rule result %)', OLD.rule_result_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_prevent_rule_condition_result_update ON rule_condition_results;
+CREATE TRIGGER trg_prevent_rule_condition_result_update
+BEFORE UPDATE ON rule_condition_results
+FOR EACH ROW EXECUTE FUNCTION prevent_rule_condition_result_update();
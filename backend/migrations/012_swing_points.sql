@@ -0,0 +1,22 @@
+-- Migration 012: Swing Points
+-- Date: 2026-10-17
+-- Description: Confirmed swing highs/lows (2-bar lookback/lookforward).
+-- A swing at candle t is only confirmed at candle t+2; both timestamps are stored
+-- so structure rules can never see a swing before it was confirmed.
+
+CREATE TABLE IF NOT EXISTS swing_points (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    candle_id UUID NOT NULL REFERENCES candles_weekly(id) ON DELETE CASCADE,
+    confirmed_candle_id UUID NOT NULL REFERENCES candles_weekly(id) ON DELETE CASCADE,
+    swing_type VARCHAR(4) NOT NULL CHECK (swing_type IN ('high', 'low')),
+    price NUMERIC(12,5) NOT NULL CHECK (price > 0),
+    swing_timestamp_utc TIMESTAMPTZ NOT NULL,
+    confirmed_at_utc TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (candle_id, swing_type),
+    CONSTRAINT swing_confirmed_after_pivot CHECK (confirmed_at_utc > swing_timestamp_utc)
+);
+
+CREATE INDEX IF NOT EXISTS idx_swing_points_confirmed_at ON swing_points (confirmed_at_utc);
+
+COMMENT ON TABLE swing_points IS 'Confirmed 2-bar swing highs/lows. swing_timestamp_utc = pivot candle, confirmed_at_utc = candle that confirmed it (pivot + 2).';
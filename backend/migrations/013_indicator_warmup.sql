@@ -0,0 +1,24 @@
+-- Migration 013: Indicator Warm-up Validity
+-- Date: 2026-10-17
+-- Description: Indicators are NULL until warmed up (EMA20/50/200 need 20/50/200
+-- candles, swings need a confirmed swing) instead of a 0 sentinel. Rules whose
+-- inputs are not yet valid record INSUFFICIENT_DATA instead of FAIL.
+
+ALTER TABLE indicators_weekly
+    ALTER COLUMN ema20 DROP NOT NULL,
+    ALTER COLUMN ema50 DROP NOT NULL,
+    ALTER COLUMN ema200 DROP NOT NULL;
+
+UPDATE indicators_weekly SET ema20 = NULL WHERE ema20 = 0;
+UPDATE indicators_weekly SET ema50 = NULL WHERE ema50 = 0;
+UPDATE indicators_weekly SET ema200 = NULL WHERE ema200 = 0;
+UPDATE indicators_weekly SET last_swing_high_price = NULL WHERE last_swing_high_price = 0;
+UPDATE indicators_weekly SET last_swing_low_price = NULL WHERE last_swing_low_price = 0;
+
+COMMENT ON COLUMN indicators_weekly.ema200 IS 'NULL until 200 candles are available (warm-up).';
+
+ALTER TYPE rule_result_type ADD VALUE IF NOT EXISTS 'INSUFFICIENT_DATA';
+
+-- Condition not evaluated because an input was not warmed up
+ALTER TABLE rule_condition_results
+    ADD COLUMN IF NOT EXISTS insufficient_data BOOLEAN NOT NULL DEFAULT FALSE;
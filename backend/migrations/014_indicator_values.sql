@@ -0,0 +1,15 @@
+-- Migration 014: Indicator Values
+-- Date: 2026-10-17
+-- Description: Values of registry indicators (ATR, RSI, ADX, MACD, Bollinger, ...)
+-- stored per candle, one row per output name (e.g. rsi14, macd_12_26_9).
+-- Outputs that are not warmed up have no row.
+
+CREATE TABLE IF NOT EXISTS indicator_values (
+    candle_id UUID NOT NULL REFERENCES candles_weekly(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    value NUMERIC(18,8) NOT NULL,
+    computed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (candle_id, name)
+);
+
+COMMENT ON TABLE indicator_values IS 'Registry indicator outputs per candle. Output names encode their parameters; no row = not warmed up.';
@@ -0,0 +1,85 @@
+-- Migration 015: Account Ledger
+-- Date: 2026-10-17
+-- Description: Append-only money movements of an account. The current
+-- balance is SUM(amount); accounts.balance stays the opening balance.
+-- Closing executions post their PnL in the same transaction.
+
+CREATE TYPE ledger_entry_type AS ENUM (
+    'trade_pnl',
+    'deposit',
+    'withdrawal',
+    'fee',
+    'adjustment'
+);
+
+CREATE TABLE IF NOT EXISTS account_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    entry_type ledger_entry_type NOT NULL,
+
+    -- Signed: deposits and profits > 0, withdrawals, fees and losses < 0
+    amount DECIMAL(15,2) NOT NULL,
+
+    -- Set for trade_pnl only: one entry per closing execution
+    trade_id UUID REFERENCES trades(id) ON DELETE CASCADE,
+    execution_id UUID UNIQUE REFERENCES trade_executions(id) ON DELETE CASCADE,
+
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT account_ledger_amount_sign CHECK (
+        (entry_type = 'deposit' AND amount > 0) OR
+        (entry_type IN ('withdrawal', 'fee') AND amount < 0) OR
+        (entry_type IN ('trade_pnl', 'adjustment') AND amount <> 0)
+    ),
+    CONSTRAINT account_ledger_trade_pnl_execution CHECK (
+        (entry_type = 'trade_pnl') = (execution_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_ledger_account_id ON account_ledger (account_id, created_at);
+
+COMMENT ON TABLE account_ledger IS 'Append-only account money movements; the current balance is the sum of amount.';
+COMMENT ON COLUMN accounts.balance IS 'Opening balance. Current balance = SUM(account_ledger.amount).';
+
+-- DB-LEVEL INVARIANT: the ledger is append-only (correct with an adjustment)
+CREATE OR REPLACE FUNCTION prevent_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'account_ledger is append-only (entry %): post an adjustment instead', OLD.id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_prevent_ledger_change ON account_ledger;
+CREATE TRIGGER trg_prevent_ledger_change
+BEFORE UPDATE OR DELETE ON account_ledger
+FOR EACH ROW EXECUTE FUNCTION prevent_ledger_change();
+
+-- Every account starts with its opening balance as a deposit
+CREATE OR REPLACE FUNCTION post_opening_balance()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.balance > 0 THEN
+        INSERT INTO account_ledger (account_id, entry_type, amount, description)
+        VALUES (NEW.id, 'deposit', NEW.balance, 'Opening balance');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_post_opening_balance ON accounts;
+CREATE TRIGGER trg_post_opening_balance
+AFTER INSERT ON accounts
+FOR EACH ROW EXECUTE FUNCTION post_opening_balance();
+
+-- Backfill: opening balances and the PnL of already closed trades
+INSERT INTO account_ledger (account_id, entry_type, amount, description, created_at)
+SELECT a.id, 'deposit', a.balance, 'Opening balance', a.updated_at
+FROM accounts a
+WHERE a.balance > 0;
+
+INSERT INTO account_ledger (account_id, entry_type, amount, trade_id, execution_id, description, created_at)
+SELECT t.account_id, 'trade_pnl', e.pnl, t.id, e.id, e.event_type::text, e.executed_at
+FROM trade_executions e
+JOIN trades t ON t.id = e.trade_id
+WHERE e.pnl IS NOT NULL AND e.pnl <> 0;
@@ -0,0 +1,51 @@
+-- Migration 016: Instrument Catalogue
+-- Date: 2026-10-17
+-- Description: Trading specification per symbol (pip size, precision, contract
+-- size, quote currency, lot limits). Candles are keyed by (symbol, timestamp);
+-- indicators, swings and rule results follow their candle. Trades may use any
+-- catalogued symbol.
+
+CREATE TABLE IF NOT EXISTS instruments (
+    symbol TEXT PRIMARY KEY,
+    description TEXT NOT NULL,
+
+    -- Price increment of one pip (0.0001 EURUSD, 0.01 USDJPY, 0.1 XAUUSD)
+    pip_size NUMERIC(10,5) NOT NULL CHECK (pip_size > 0),
+    -- Decimals quoted by the broker
+    price_precision SMALLINT NOT NULL CHECK (price_precision BETWEEN 0 AND 5),
+    -- Units per standard lot (100,000 for FX, 100 oz for gold)
+    contract_size NUMERIC(15,2) NOT NULL CHECK (contract_size > 0),
+    -- Currency PnL is realised in (pip value per lot = pip_size × contract_size)
+    quote_currency CHAR(3) NOT NULL,
+
+    min_lot NUMERIC(10,5) NOT NULL CHECK (min_lot > 0),
+    lot_step NUMERIC(10,5) NOT NULL CHECK (lot_step > 0),
+
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+COMMENT ON TABLE instruments IS 'Trading specification per symbol. Risk and PnL math is parameterised by these rows.';
+
+INSERT INTO instruments (symbol, description, pip_size, price_precision, contract_size, quote_currency, min_lot, lot_step)
+VALUES
+    ('EURUSD', 'Euro vs US Dollar', 0.0001, 5, 100000, 'USD', 0.01, 0.01),
+    ('GBPUSD', 'British Pound vs US Dollar', 0.0001, 5, 100000, 'USD', 0.01, 0.01),
+    ('USDJPY', 'US Dollar vs Japanese Yen', 0.01, 3, 100000, 'JPY', 0.01, 0.01),
+    ('XAUUSD', 'Gold vs US Dollar', 0.1, 2, 100, 'USD', 0.01, 0.01)
+ON CONFLICT (symbol) DO NOTHING;
+
+-- Candles: one series per symbol (existing rows are EURUSD)
+ALTER TABLE candles_weekly
+    ADD COLUMN IF NOT EXISTS symbol TEXT NOT NULL DEFAULT 'EURUSD' REFERENCES instruments(symbol);
+
+ALTER TABLE candles_weekly DROP CONSTRAINT IF EXISTS candles_weekly_timestamp_utc_key;
+ALTER TABLE candles_weekly
+    ADD CONSTRAINT candles_weekly_symbol_timestamp_utc_key UNIQUE (symbol, timestamp_utc);
+
+-- The unique index above serves every (symbol, timestamp) lookup
+DROP INDEX IF EXISTS idx_candles_timestamp;
+
+-- Trades: any catalogued symbol
+ALTER TABLE trades DROP CONSTRAINT IF EXISTS trades_symbol_check;
+ALTER TABLE trades
+    ADD CONSTRAINT trades_symbol_fkey FOREIGN KEY (symbol) REFERENCES instruments(symbol);
@@ -0,0 +1,30 @@
+-- Migration 017: FX Rates
+-- Date: 2026-10-17
+-- Description: Daily conversion rates, loaded from CSV (scripts/import_fx_rates).
+-- Pip value per lot is converted from the instrument's quote currency to the
+-- account currency; the rate used is snapshotted on the trade like the other
+-- *_at_setup fields, so PnL stays reproducible when rates are reloaded.
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    -- 1 base_currency = rate quote_currency (EUR/USD 1.0850)
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(18,8) NOT NULL CHECK (rate > 0),
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (base_currency, quote_currency, rate_date),
+    CONSTRAINT fx_rates_distinct_currencies CHECK (base_currency <> quote_currency)
+);
+
+COMMENT ON TABLE fx_rates IS 'Daily FX rates (1 base = rate quote). Either direction of a pair may be stored; lookups invert when needed.';
+
+-- Existing trades were USD-quoted EURUSD on USD accounts: rate 1
+ALTER TABLE trades
+    ADD COLUMN IF NOT EXISTS account_currency_at_setup CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS quote_to_account_rate_at_setup NUMERIC(18,8) NOT NULL DEFAULT 1
+        CHECK (quote_to_account_rate_at_setup > 0);
+
+COMMENT ON COLUMN trades.quote_to_account_rate_at_setup IS 'Quote currency → account currency rate used for sizing and PnL';
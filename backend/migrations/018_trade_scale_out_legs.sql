@@ -0,0 +1,22 @@
+-- Migration 018: Trade Scale-Out Plans
+-- Date: 2026-10-17
+-- Description: The planned exits of a trade, fixed at setup like the other
+-- planned fields (e.g. close 50% at 1R, the rest at TP). Analytics compares
+-- them with the partial closes and the final close actually executed.
+
+CREATE TABLE IF NOT EXISTS trade_scale_out_legs (
+    trade_id UUID NOT NULL REFERENCES trades(id) ON DELETE CASCADE,
+    leg SMALLINT NOT NULL CHECK (leg >= 1),
+
+    -- Share of the planned position closed by this leg
+    close_pct NUMERIC(5,2) NOT NULL CHECK (close_pct > 0 AND close_pct <= 100),
+
+    -- Target as a multiple of the planned risk; NULL = at the planned TP
+    target_r NUMERIC(6,2) CHECK (target_r > 0),
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (trade_id, leg)
+);
+
+COMMENT ON TABLE trade_scale_out_legs IS 'Planned exits of a trade, in leg order. Legs sum to 100% of the planned position; no rows = single exit.';
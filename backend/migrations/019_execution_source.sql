@@ -0,0 +1,11 @@
+-- Migration 019: Execution Source
+-- Date: 2026-10-17
+-- Description: Who recorded an execution. 'user' for fills journaled through
+-- the API, 'system' for SL/TP hits the trade monitor detects on ingested
+-- candles (at the level price, not a broker fill).
+
+ALTER TABLE trade_executions
+    ADD COLUMN IF NOT EXISTS source VARCHAR(10) NOT NULL DEFAULT 'user'
+        CHECK (source IN ('user', 'system'));
+
+COMMENT ON COLUMN trade_executions.source IS 'user = journaled fill, system = SL/TP hit detected from a candle';
@@ -0,0 +1,59 @@
+-- Migration 020: Trade Adjustments
+-- Date: 2026-10-17
+-- Description: Append-only log of SL/TP moves on an open trade (move SL,
+-- move TP, trail to a swing, break-even). planned_sl/planned_tp stay the
+-- original plan (planned R); trades.actual_sl/actual_tp hold the levels in
+-- force after the last adjustment.
+
+CREATE TYPE trade_adjustment_type AS ENUM (
+    'move_sl',
+    'move_tp',
+    'trail_to_swing',
+    'break_even'
+);
+
+CREATE TABLE IF NOT EXISTS trade_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trade_id UUID NOT NULL REFERENCES trades(id) ON DELETE CASCADE,
+    adjustment_type trade_adjustment_type NOT NULL,
+
+    -- The level before and after the move: the TP for move_tp, the SL otherwise
+    previous_price NUMERIC(12,5) NOT NULL,
+    new_price NUMERIC(12,5) NOT NULL,
+
+    -- Market price when the level was moved (a SL stays on the losing side of it)
+    market_price NUMERIC(12,5) NOT NULL,
+
+    -- The confirmed swing trailed to (trail_to_swing only)
+    swing_point_id UUID REFERENCES swing_points(id),
+
+    reason TEXT,
+    adjusted_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT trade_adjustments_prices CHECK (
+        previous_price > 0 AND new_price > 0 AND market_price > 0
+    ),
+    CONSTRAINT trade_adjustments_swing CHECK (
+        (adjustment_type = 'trail_to_swing') = (swing_point_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_adjustments_trade_time ON trade_adjustments (trade_id, adjusted_at);
+
+COMMENT ON TABLE trade_adjustments IS 'Append-only SL/TP moves of a trade. The levels in force at a time are the planned ones replayed with the adjustments up to it.';
+COMMENT ON COLUMN trades.actual_sl IS 'SL in force after the last adjustment; NULL = planned_sl';
+COMMENT ON COLUMN trades.actual_tp IS 'TP in force after the last adjustment; NULL = planned_tp';
+
+-- DB-LEVEL INVARIANT: adjustments are append-only (move the level again instead)
+CREATE OR REPLACE FUNCTION prevent_adjustment_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'trade_adjustments is append-only (adjustment %): record a new adjustment instead', OLD.id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_prevent_adjustment_change ON trade_adjustments;
+CREATE TRIGGER trg_prevent_adjustment_change
+BEFORE UPDATE OR DELETE ON trade_adjustments
+FOR EACH ROW EXECUTE FUNCTION prevent_adjustment_change();
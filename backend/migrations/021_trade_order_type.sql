@@ -0,0 +1,23 @@
+-- Migration 021: Trade Order Type and Expiry
+-- Date: 2026-10-17
+-- Description: How a planned trade is entered. Market orders are executed
+-- by the trader; limit and stop orders are pending: each ingested candle
+-- either triggers the entry at the order price, or invalidates the trade
+-- when the order expires or price hits the stop first.
+
+CREATE TYPE order_type AS ENUM (
+    'market',
+    'limit',
+    'stop'
+);
+
+ALTER TABLE trades
+    ADD COLUMN IF NOT EXISTS order_type order_type NOT NULL DEFAULT 'market',
+    -- NULL = good till cancelled
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ,
+    ADD CONSTRAINT trades_expiry_pending_only CHECK (expires_at IS NULL OR order_type <> 'market');
+
+CREATE INDEX IF NOT EXISTS idx_trades_pending_orders ON trades (symbol) WHERE order_type <> 'market';
+
+COMMENT ON COLUMN trades.order_type IS 'market = executed by the trader, limit/stop = pending order triggered by candles';
+COMMENT ON COLUMN trades.expires_at IS 'Pending order lapses (invalidate intent) once a candle closes at or after it; NULL = good till cancelled';
@@ -0,0 +1,13 @@
+-- Migration 022: Swing Confirmation at Candle Close
+-- Date: 2026-10-17
+-- Description: confirmed_at_utc held the open of the confirming candle (W1
+-- timestamps are the week's open), so a swing was visible during the week
+-- whose low/high confirms it. Move it to that candle's close (open + 7 days).
+
+UPDATE swing_points s
+SET confirmed_at_utc = c.timestamp_utc + INTERVAL '7 days'
+FROM candles_weekly c
+WHERE c.id = s.confirmed_candle_id
+  AND s.confirmed_at_utc = c.timestamp_utc;
+
+COMMENT ON TABLE swing_points IS 'Confirmed 2-bar swing highs/lows. swing_timestamp_utc = pivot candle, confirmed_at_utc = close of the candle that confirmed it (pivot + 2).';
@@ -0,0 +1,19 @@
+-- Migration 023: Signed Entry Slippage
+-- Date: 2026-10-17
+-- Description: slippage_pips held the absolute distance between the entry
+-- fill and the planned entry, so a better fill looked like a worse one.
+-- Store it signed instead: positive = adverse (above plan for a long, below
+-- for a short), negative = better than planned.
+
+UPDATE trade_executions e
+SET slippage_pips = ROUND(
+    (e.price - t.planned_entry) / i.pip_size
+        * CASE WHEN t.bias = 'short' THEN -1 ELSE 1 END,
+    2)
+FROM trades t
+JOIN instruments i ON i.symbol = t.symbol
+WHERE t.id = e.trade_id
+  AND e.event_type = 'entry'
+  AND e.slippage_pips IS NOT NULL;
+
+COMMENT ON COLUMN trade_executions.slippage_pips IS 'Entry slippage vs planned entry in pips, signed: positive = adverse';
@@ -53,13 +53,38 @@ CREATE TYPE public.execution_event_type AS ENUM (
 );
 
 
+--
+-- Name: ledger_entry_type; Type: TYPE; Schema: public; Owner: -
+--
+
+CREATE TYPE public.ledger_entry_type AS ENUM (
+    'trade_pnl',
+    'deposit',
+    'withdrawal',
+    'fee',
+    'adjustment'
+);
+
+
+--
+-- Name: order_type; Type: TYPE; Schema: public; Owner: -
+--
+
+CREATE TYPE public.order_type AS ENUM (
+    'market',
+    'limit',
+    'stop'
+);
+
+
 --
 -- Name: rule_result_type; Type: TYPE; Schema: public; Owner: -
 --
 
 CREATE TYPE public.rule_result_type AS ENUM (
     'PASS',
-    'FAIL'
+    'FAIL',
+    'INSUFFICIENT_DATA'
 );
 
 
@@ -84,6 +109,18 @@ CREATE TYPE public.session_type AS ENUM (
 );
 
 
+--
+-- Name: trade_adjustment_type; Type: TYPE; Schema: public; Owner: -
+--
+
+CREATE TYPE public.trade_adjustment_type AS ENUM (
+    'move_sl',
+    'move_tp',
+    'trail_to_swing',
+    'break_even'
+);
+
+
 --
 -- Name: trade_bias; Type: TYPE; Schema: public; Owner: -
 --
@@ -105,6 +142,36 @@ CREATE TYPE public.trade_result AS ENUM (
 );
 
 
+--
+-- Name: post_opening_balance(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.post_opening_balance() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF NEW.balance > 0 THEN
+        INSERT INTO account_ledger (account_id, entry_type, amount, description)
+        VALUES (NEW.id, 'deposit', NEW.balance, 'Opening balance');
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+
+--
+-- Name: prevent_adjustment_change(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.prevent_adjustment_change() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'trade_adjustments is append-only (adjustment %): record a new adjustment instead', OLD.id;
+END;
+$$;
+
+
 --
 -- Name: prevent_duplicate_entry(); Type: FUNCTION; Schema: public; Owner: -
 --
@@ -168,10 +235,74 @@ END;
 $$;
 
 
+--
+-- Name: prevent_ledger_change(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.prevent_ledger_change() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'account_ledger is append-only (entry %): post an adjustment instead', OLD.id;
+END;
+$$;
+
+
+--
+-- Name: prevent_rule_condition_result_update(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.prevent_rule_condition_result_update() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'rule_condition_results are immutable (rule result %)', OLD.rule_result_id;
+END;
+$$;
+
+
+--
+-- Name: prevent_rule_result_update(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.prevent_rule_result_update() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'rule_results are immutable: evaluate a new rule version instead (rule %, candle %)', OLD.rule_id, OLD.candle_id;
+END;
+$$;
+
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: account_ledger; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.account_ledger (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    account_id uuid NOT NULL,
+    entry_type public.ledger_entry_type NOT NULL,
+    amount numeric(15,2) NOT NULL,
+    trade_id uuid,
+    execution_id uuid,
+    description text,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT account_ledger_amount_sign CHECK ((((entry_type = 'deposit'::public.ledger_entry_type) AND (amount > (0)::numeric)) OR ((entry_type = ANY (ARRAY['withdrawal'::public.ledger_entry_type, 'fee'::public.ledger_entry_type])) AND (amount < (0)::numeric)) OR ((entry_type = ANY (ARRAY['trade_pnl'::public.ledger_entry_type, 'adjustment'::public.ledger_entry_type])) AND (amount <> (0)::numeric)))),
+    CONSTRAINT account_ledger_trade_pnl_execution CHECK (((entry_type = 'trade_pnl'::public.ledger_entry_type) = (execution_id IS NOT NULL)))
+);
+
+
+--
+-- Name: TABLE account_ledger; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.account_ledger IS 'Append-only account money movements; the current balance is the sum of amount.';
+
+
 --
 -- Name: accounts; Type: TABLE; Schema: public; Owner: -
 --
@@ -198,6 +329,13 @@ CREATE TABLE public.accounts (
 );
 
 
+--
+-- Name: COLUMN accounts.balance; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.accounts.balance IS 'Opening balance. Current balance = SUM(account_ledger.amount).';
+
+
 --
 -- Name: candles_weekly; Type: TABLE; Schema: public; Owner: -
 --
@@ -211,10 +349,53 @@ CREATE TABLE public.candles_weekly (
     close numeric(12,5) NOT NULL,
     volume bigint,
     created_at timestamp with time zone DEFAULT now(),
+    symbol text DEFAULT 'EURUSD'::text NOT NULL,
     CONSTRAINT candles_weekly_check CHECK ((low <= high))
 );
 
 
+--
+-- Name: fx_rates; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.fx_rates (
+    base_currency character(3) NOT NULL,
+    quote_currency character(3) NOT NULL,
+    rate_date date NOT NULL,
+    rate numeric(18,8) NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    updated_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT fx_rates_distinct_currencies CHECK ((base_currency <> quote_currency)),
+    CONSTRAINT fx_rates_rate_check CHECK ((rate > (0)::numeric))
+);
+
+
+--
+-- Name: TABLE fx_rates; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.fx_rates IS 'Daily FX rates (1 base = rate quote). Either direction of a pair may be stored; lookups invert when needed.';
+
+
+--
+-- Name: indicator_values; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.indicator_values (
+    candle_id uuid NOT NULL,
+    name character varying(64) NOT NULL,
+    value numeric(18,8) NOT NULL,
+    computed_at timestamp with time zone DEFAULT now()
+);
+
+
+--
+-- Name: TABLE indicator_values; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.indicator_values IS 'Registry indicator outputs per candle. Output names encode their parameters; no row = not warmed up.';
+
+
 --
 -- Name: indicators_weekly; Type: TABLE; Schema: public; Owner: -
 --
@@ -222,9 +403,9 @@ CREATE TABLE public.candles_weekly (
 CREATE TABLE public.indicators_weekly (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
     candle_id uuid NOT NULL,
-    ema20 numeric(12,5) NOT NULL,
-    ema50 numeric(12,5) NOT NULL,
-    ema200 numeric(12,5) NOT NULL,
+    ema20 numeric(12,5),
+    ema50 numeric(12,5),
+    ema200 numeric(12,5),
     range_size numeric(12,5) NOT NULL,
     body_size numeric(12,5) NOT NULL,
     upper_wick numeric(12,5) NOT NULL,
@@ -236,6 +417,66 @@ CREATE TABLE public.indicators_weekly (
 );
 
 
+--
+-- Name: COLUMN indicators_weekly.ema200; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.indicators_weekly.ema200 IS 'NULL until 200 candles are available (warm-up).';
+
+
+--
+-- Name: instruments; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.instruments (
+    symbol text NOT NULL,
+    description text NOT NULL,
+    pip_size numeric(10,5) NOT NULL,
+    price_precision smallint NOT NULL,
+    contract_size numeric(15,2) NOT NULL,
+    quote_currency character(3) NOT NULL,
+    min_lot numeric(10,5) NOT NULL,
+    lot_step numeric(10,5) NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT instruments_contract_size_check CHECK ((contract_size > (0)::numeric)),
+    CONSTRAINT instruments_lot_step_check CHECK ((lot_step > (0)::numeric)),
+    CONSTRAINT instruments_min_lot_check CHECK ((min_lot > (0)::numeric)),
+    CONSTRAINT instruments_pip_size_check CHECK ((pip_size > (0)::numeric)),
+    CONSTRAINT instruments_price_precision_check CHECK (((price_precision >= 0) AND (price_precision <= 5)))
+);
+
+
+--
+-- Name: TABLE instruments; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.instruments IS 'Trading specification per symbol. Risk and PnL math is parameterised by these rows.';
+
+
+--
+-- Name: rule_condition_results; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.rule_condition_results (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    rule_result_id uuid NOT NULL,
+    condition_code text NOT NULL,
+    "position" integer NOT NULL,
+    passed boolean NOT NULL,
+    inputs jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    insufficient_data boolean DEFAULT false NOT NULL,
+    CONSTRAINT rule_condition_results_position_check CHECK (("position" >= 0))
+);
+
+
+--
+-- Name: TABLE rule_condition_results; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.rule_condition_results IS 'Structured explanation of a rule result: one row per condition with its boolean and input values.';
+
+
 --
 -- Name: rule_results; Type: TABLE; Schema: public; Owner: -
 --
@@ -246,10 +487,36 @@ CREATE TABLE public.rule_results (
     candle_id uuid NOT NULL,
     result public.rule_result_type NOT NULL,
     evaluated_at timestamp with time zone DEFAULT now(),
-    confidence_score numeric(3,2)
+    confidence_score numeric(3,2),
+    rule_version integer DEFAULT 1 NOT NULL,
+    definition_hash text
 );
 
 
+--
+-- Name: rule_versions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.rule_versions (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    rule_id uuid NOT NULL,
+    version integer NOT NULL,
+    definition_hash text NOT NULL,
+    definition jsonb NOT NULL,
+    is_active boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    promoted_at timestamp with time zone,
+    CONSTRAINT rule_versions_version_check CHECK ((version >= 1))
+);
+
+
+--
+-- Name: TABLE rule_versions; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.rule_versions IS 'Frozen rule definitions. A (rule, version) hash never changes; new semantics require a new version.';
+
+
 --
 -- Name: rules; Type: TABLE; Schema: public; Owner: -
 --
@@ -264,6 +531,59 @@ CREATE TABLE public.rules (
 );
 
 
+--
+-- Name: swing_points; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.swing_points (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    candle_id uuid NOT NULL,
+    confirmed_candle_id uuid NOT NULL,
+    swing_type character varying(4) NOT NULL,
+    price numeric(12,5) NOT NULL,
+    swing_timestamp_utc timestamp with time zone NOT NULL,
+    confirmed_at_utc timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT swing_confirmed_after_pivot CHECK ((confirmed_at_utc > swing_timestamp_utc)),
+    CONSTRAINT swing_points_price_check CHECK ((price > (0)::numeric)),
+    CONSTRAINT swing_points_swing_type_check CHECK (((swing_type)::text = ANY ((ARRAY['high'::character varying, 'low'::character varying])::text[])))
+);
+
+
+--
+-- Name: TABLE swing_points; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.swing_points IS 'Confirmed 2-bar swing highs/lows. swing_timestamp_utc = pivot candle, confirmed_at_utc = close of the candle that confirmed it (pivot + 2).';
+
+
+--
+-- Name: trade_adjustments; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.trade_adjustments (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    trade_id uuid NOT NULL,
+    adjustment_type public.trade_adjustment_type NOT NULL,
+    previous_price numeric(12,5) NOT NULL,
+    new_price numeric(12,5) NOT NULL,
+    market_price numeric(12,5) NOT NULL,
+    swing_point_id uuid,
+    reason text,
+    adjusted_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT trade_adjustments_prices CHECK (((previous_price > (0)::numeric) AND (new_price > (0)::numeric) AND (market_price > (0)::numeric))),
+    CONSTRAINT trade_adjustments_swing CHECK (((adjustment_type = 'trail_to_swing'::public.trade_adjustment_type) = (swing_point_id IS NOT NULL)))
+);
+
+
+--
+-- Name: TABLE trade_adjustments; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.trade_adjustments IS 'Append-only SL/TP moves of a trade. The levels in force at a time are the planned ones replayed with the adjustments up to it.';
+
+
 --
 -- Name: trade_executions; Type: TABLE; Schema: public; Owner: -
 --
@@ -281,6 +601,8 @@ CREATE TABLE public.trade_executions (
     pnl numeric(12,2),
     pnl_pips numeric(12,2),
     created_at timestamp with time zone DEFAULT now(),
+    source character varying(10) DEFAULT 'user'::character varying NOT NULL,
+    CONSTRAINT trade_executions_source_check CHECK (((source)::text = ANY ((ARRAY['user'::character varying, 'system'::character varying])::text[]))),
     CONSTRAINT valid_execution_data CHECK (((price > (0)::numeric) AND (position_size > (0)::numeric)))
 );
 
@@ -292,6 +614,20 @@ CREATE TABLE public.trade_executions (
 COMMENT ON TABLE public.trade_executions IS 'Append-only execution event log.  Contains MARKET INTERACTIONS only.  State is computed via:  SELECT event_type FROM trade_executions WHERE trade_id = ?  ORDER BY executed_at';
 
 
+--
+-- Name: COLUMN trade_executions.slippage_pips; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trade_executions.slippage_pips IS 'Entry slippage vs planned entry in pips, signed: positive = adverse';
+
+
+--
+-- Name: COLUMN trade_executions.source; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trade_executions.source IS 'user = journaled fill, system = SL/TP hit detected from a candle';
+
+
 --
 -- Name: trade_feedback; Type: TABLE; Schema: public; Owner: -
 --
@@ -330,6 +666,29 @@ CREATE TABLE public.trade_intents (
 COMMENT ON TABLE public.trade_intents IS 'Records user/system intent to cancel or invalidate trades. Separate from executions because these are NOT market interactions. ';
 
 
+--
+-- Name: trade_scale_out_legs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.trade_scale_out_legs (
+    trade_id uuid NOT NULL,
+    leg smallint NOT NULL,
+    close_pct numeric(5,2) NOT NULL,
+    target_r numeric(6,2),
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT trade_scale_out_legs_close_pct_check CHECK (((close_pct > (0)::numeric) AND (close_pct <= (100)::numeric))),
+    CONSTRAINT trade_scale_out_legs_leg_check CHECK ((leg >= 1)),
+    CONSTRAINT trade_scale_out_legs_target_r_check CHECK ((target_r > (0)::numeric))
+);
+
+
+--
+-- Name: TABLE trade_scale_out_legs; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.trade_scale_out_legs IS 'Planned exits of a trade, in leg order. Legs sum to 100% of the planned position; no rows = single exit.';
+
+
 --
 -- Name: trades; Type: TABLE; Schema: public; Owner: -
 --
@@ -371,8 +730,13 @@ CREATE TABLE public.trades (
     duration_seconds integer,
     session public.session_type,
     created_at timestamp with time zone DEFAULT now(),
+    account_currency_at_setup character(3) DEFAULT 'USD'::bpchar NOT NULL,
+    quote_to_account_rate_at_setup numeric(18,8) DEFAULT 1 NOT NULL,
+    order_type public.order_type DEFAULT 'market'::public.order_type NOT NULL,
+    expires_at timestamp with time zone,
+    CONSTRAINT trades_expiry_pending_only CHECK (((expires_at IS NULL) OR (order_type <> 'market'::public.order_type))),
     CONSTRAINT trades_planned_rr_check CHECK ((planned_rr > (0)::numeric)),
-    CONSTRAINT trades_symbol_check CHECK ((symbol = 'EURUSD'::text)),
+    CONSTRAINT trades_quote_to_account_rate_at_setup_check CHECK ((quote_to_account_rate_at_setup > (0)::numeric)),
     CONSTRAINT trades_timeframe_check CHECK ((timeframe = 'W1'::text))
 );
 
@@ -384,6 +748,41 @@ CREATE TABLE public.trades (
 COMMENT ON TABLE public.trades IS 'Trade state derived from trade_executions and trade_intents. ';
 
 
+--
+-- Name: COLUMN trades.actual_sl; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trades.actual_sl IS 'SL in force after the last adjustment; NULL = planned_sl';
+
+
+--
+-- Name: COLUMN trades.actual_tp; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trades.actual_tp IS 'TP in force after the last adjustment; NULL = planned_tp';
+
+
+--
+-- Name: COLUMN trades.expires_at; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trades.expires_at IS 'Pending order lapses (invalidate intent) once a candle closes at or after it; NULL = good till cancelled';
+
+
+--
+-- Name: COLUMN trades.order_type; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trades.order_type IS 'market = executed by the trader, limit/stop = pending order triggered by candles';
+
+
+--
+-- Name: COLUMN trades.quote_to_account_rate_at_setup; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.trades.quote_to_account_rate_at_setup IS 'Quote currency → account currency rate used for sizing and PnL';
+
+
 --
 -- Name: users; Type: TABLE; Schema: public; Owner: -
 --
@@ -394,6 +793,22 @@ CREATE TABLE public.users (
 );
 
 
+--
+-- Name: account_ledger account_ledger_execution_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_ledger
+    ADD CONSTRAINT account_ledger_execution_id_key UNIQUE (execution_id);
+
+
+--
+-- Name: account_ledger account_ledger_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_ledger
+    ADD CONSTRAINT account_ledger_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: accounts accounts_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -411,11 +826,27 @@ ALTER TABLE ONLY public.candles_weekly
 
 
 --
--- Name: candles_weekly candles_weekly_timestamp_utc_key; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: candles_weekly candles_weekly_symbol_timestamp_utc_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.candles_weekly
-    ADD CONSTRAINT candles_weekly_timestamp_utc_key UNIQUE (timestamp_utc);
+    ADD CONSTRAINT candles_weekly_symbol_timestamp_utc_key UNIQUE (symbol, timestamp_utc);
+
+
+--
+-- Name: fx_rates fx_rates_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.fx_rates
+    ADD CONSTRAINT fx_rates_pkey PRIMARY KEY (base_currency, quote_currency, rate_date);
+
+
+--
+-- Name: indicator_values indicator_values_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.indicator_values
+    ADD CONSTRAINT indicator_values_pkey PRIMARY KEY (candle_id, name);
 
 
 --
@@ -434,6 +865,30 @@ ALTER TABLE ONLY public.indicators_weekly
     ADD CONSTRAINT indicators_weekly_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: instruments instruments_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.instruments
+    ADD CONSTRAINT instruments_pkey PRIMARY KEY (symbol);
+
+
+--
+-- Name: rule_condition_results rule_condition_results_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_condition_results
+    ADD CONSTRAINT rule_condition_results_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: rule_condition_results rule_condition_results_rule_result_id_condition_code_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_condition_results
+    ADD CONSTRAINT rule_condition_results_rule_result_id_condition_code_key UNIQUE (rule_result_id, condition_code);
+
+
 --
 -- Name: rule_results rule_results_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -443,11 +898,27 @@ ALTER TABLE ONLY public.rule_results
 
 
 --
--- Name: rule_results rule_results_rule_id_candle_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: rule_results rule_results_rule_id_candle_id_version_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.rule_results
-    ADD CONSTRAINT rule_results_rule_id_candle_id_key UNIQUE (rule_id, candle_id);
+    ADD CONSTRAINT rule_results_rule_id_candle_id_version_key UNIQUE (rule_id, candle_id, rule_version);
+
+
+--
+-- Name: rule_versions rule_versions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_versions
+    ADD CONSTRAINT rule_versions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: rule_versions rule_versions_rule_id_version_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_versions
+    ADD CONSTRAINT rule_versions_rule_id_version_key UNIQUE (rule_id, version);
 
 
 --
@@ -466,6 +937,30 @@ ALTER TABLE ONLY public.rules
     ADD CONSTRAINT rules_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: swing_points swing_points_candle_id_swing_type_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.swing_points
+    ADD CONSTRAINT swing_points_candle_id_swing_type_key UNIQUE (candle_id, swing_type);
+
+
+--
+-- Name: swing_points swing_points_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.swing_points
+    ADD CONSTRAINT swing_points_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: trade_adjustments trade_adjustments_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trade_adjustments
+    ADD CONSTRAINT trade_adjustments_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: trade_executions trade_executions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -498,6 +993,14 @@ ALTER TABLE ONLY public.trade_intents
     ADD CONSTRAINT trade_intents_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: trade_scale_out_legs trade_scale_out_legs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trade_scale_out_legs
+    ADD CONSTRAINT trade_scale_out_legs_pkey PRIMARY KEY (trade_id, leg);
+
+
 --
 -- Name: trades trades_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -515,10 +1018,10 @@ ALTER TABLE ONLY public.users
 
 
 --
--- Name: idx_candles_timestamp; Type: INDEX; Schema: public; Owner: -
+-- Name: idx_account_ledger_account_id; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX idx_candles_timestamp ON public.candles_weekly USING btree (timestamp_utc);
+CREATE INDEX idx_account_ledger_account_id ON public.account_ledger USING btree (account_id, created_at);
 
 
 --
@@ -528,6 +1031,13 @@ CREATE INDEX idx_candles_timestamp ON public.candles_weekly USING btree (timesta
 CREATE INDEX idx_executions_trade_time ON public.trade_executions USING btree (trade_id, executed_at);
 
 
+--
+-- Name: idx_rule_condition_results_code; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_rule_condition_results_code ON public.rule_condition_results USING btree (condition_code);
+
+
 --
 -- Name: idx_rule_results_candle_id; Type: INDEX; Schema: public; Owner: -
 --
@@ -542,6 +1052,27 @@ CREATE INDEX idx_rule_results_candle_id ON public.rule_results USING btree (cand
 CREATE INDEX idx_rule_results_rule_id ON public.rule_results USING btree (rule_id);
 
 
+--
+-- Name: idx_rule_versions_one_active; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE UNIQUE INDEX idx_rule_versions_one_active ON public.rule_versions USING btree (rule_id) WHERE is_active;
+
+
+--
+-- Name: idx_swing_points_confirmed_at; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_swing_points_confirmed_at ON public.swing_points USING btree (confirmed_at_utc);
+
+
+--
+-- Name: idx_trade_adjustments_trade_time; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_trade_adjustments_trade_time ON public.trade_adjustments USING btree (trade_id, adjusted_at);
+
+
 --
 -- Name: idx_trade_executions_event_type; Type: INDEX; Schema: public; Owner: -
 --
@@ -598,6 +1129,13 @@ CREATE INDEX idx_trades_bias ON public.trades USING btree (bias);
 CREATE INDEX idx_trades_candle_id ON public.trades USING btree (candle_id);
 
 
+--
+-- Name: idx_trades_pending_orders; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_trades_pending_orders ON public.trades USING btree (symbol) WHERE (order_type <> 'market'::public.order_type);
+
+
 --
 -- Name: idx_trades_result; Type: INDEX; Schema: public; Owner: -
 --
@@ -626,6 +1164,41 @@ CREATE INDEX idx_trades_user_id ON public.trades USING btree (user_id);
 CREATE UNIQUE INDEX uniq_trade_account_candle_bias ON public.trades USING btree (account_id, candle_id, bias);
 
 
+--
+-- Name: account_ledger trg_prevent_ledger_change; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER trg_prevent_ledger_change BEFORE DELETE OR UPDATE ON public.account_ledger FOR EACH ROW EXECUTE FUNCTION public.prevent_ledger_change();
+
+
+--
+-- Name: accounts trg_post_opening_balance; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER trg_post_opening_balance AFTER INSERT ON public.accounts FOR EACH ROW EXECUTE FUNCTION public.post_opening_balance();
+
+
+--
+-- Name: rule_condition_results trg_prevent_rule_condition_result_update; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER trg_prevent_rule_condition_result_update BEFORE UPDATE ON public.rule_condition_results FOR EACH ROW EXECUTE FUNCTION public.prevent_rule_condition_result_update();
+
+
+--
+-- Name: rule_results trg_prevent_rule_result_update; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER trg_prevent_rule_result_update BEFORE UPDATE ON public.rule_results FOR EACH ROW EXECUTE FUNCTION public.prevent_rule_result_update();
+
+
+--
+-- Name: trade_adjustments trg_prevent_adjustment_change; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER trg_prevent_adjustment_change BEFORE DELETE OR UPDATE ON public.trade_adjustments FOR EACH ROW EXECUTE FUNCTION public.prevent_adjustment_change();
+
+
 --
 -- Name: trade_executions trg_prevent_duplicate_entry; Type: TRIGGER; Schema: public; Owner: -
 --
@@ -647,6 +1220,30 @@ CREATE TRIGGER trg_prevent_execution_after_close BEFORE INSERT ON public.trade_e
 CREATE TRIGGER trg_prevent_intent_after_execution BEFORE INSERT ON public.trade_intents FOR EACH ROW EXECUTE FUNCTION public.prevent_intent_after_execution();
 
 
+--
+-- Name: account_ledger account_ledger_account_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_ledger
+    ADD CONSTRAINT account_ledger_account_id_fkey FOREIGN KEY (account_id) REFERENCES public.accounts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: account_ledger account_ledger_execution_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_ledger
+    ADD CONSTRAINT account_ledger_execution_id_fkey FOREIGN KEY (execution_id) REFERENCES public.trade_executions(id) ON DELETE CASCADE;
+
+
+--
+-- Name: account_ledger account_ledger_trade_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.account_ledger
+    ADD CONSTRAINT account_ledger_trade_id_fkey FOREIGN KEY (trade_id) REFERENCES public.trades(id) ON DELETE CASCADE;
+
+
 --
 -- Name: accounts accounts_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -655,6 +1252,22 @@ ALTER TABLE ONLY public.accounts
     ADD CONSTRAINT accounts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: candles_weekly candles_weekly_symbol_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.candles_weekly
+    ADD CONSTRAINT candles_weekly_symbol_fkey FOREIGN KEY (symbol) REFERENCES public.instruments(symbol);
+
+
+--
+-- Name: indicator_values indicator_values_candle_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.indicator_values
+    ADD CONSTRAINT indicator_values_candle_id_fkey FOREIGN KEY (candle_id) REFERENCES public.candles_weekly(id) ON DELETE CASCADE;
+
+
 --
 -- Name: indicators_weekly indicators_weekly_candle_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -663,6 +1276,14 @@ ALTER TABLE ONLY public.indicators_weekly
     ADD CONSTRAINT indicators_weekly_candle_id_fkey FOREIGN KEY (candle_id) REFERENCES public.candles_weekly(id) ON DELETE CASCADE;
 
 
+--
+-- Name: rule_condition_results rule_condition_results_rule_result_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_condition_results
+    ADD CONSTRAINT rule_condition_results_rule_result_id_fkey FOREIGN KEY (rule_result_id) REFERENCES public.rule_results(id) ON DELETE CASCADE;
+
+
 --
 -- Name: rule_results rule_results_candle_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -679,6 +1300,46 @@ ALTER TABLE ONLY public.rule_results
     ADD CONSTRAINT rule_results_rule_id_fkey FOREIGN KEY (rule_id) REFERENCES public.rules(id);
 
 
+--
+-- Name: rule_versions rule_versions_rule_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.rule_versions
+    ADD CONSTRAINT rule_versions_rule_id_fkey FOREIGN KEY (rule_id) REFERENCES public.rules(id) ON DELETE CASCADE;
+
+
+--
+-- Name: swing_points swing_points_candle_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.swing_points
+    ADD CONSTRAINT swing_points_candle_id_fkey FOREIGN KEY (candle_id) REFERENCES public.candles_weekly(id) ON DELETE CASCADE;
+
+
+--
+-- Name: swing_points swing_points_confirmed_candle_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.swing_points
+    ADD CONSTRAINT swing_points_confirmed_candle_id_fkey FOREIGN KEY (confirmed_candle_id) REFERENCES public.candles_weekly(id) ON DELETE CASCADE;
+
+
+--
+-- Name: trade_adjustments trade_adjustments_swing_point_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trade_adjustments
+    ADD CONSTRAINT trade_adjustments_swing_point_id_fkey FOREIGN KEY (swing_point_id) REFERENCES public.swing_points(id);
+
+
+--
+-- Name: trade_adjustments trade_adjustments_trade_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trade_adjustments
+    ADD CONSTRAINT trade_adjustments_trade_id_fkey FOREIGN KEY (trade_id) REFERENCES public.trades(id) ON DELETE CASCADE;
+
+
 --
 -- Name: trade_executions trade_executions_trade_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -703,6 +1364,14 @@ ALTER TABLE ONLY public.trade_intents
     ADD CONSTRAINT trade_intents_trade_id_fkey FOREIGN KEY (trade_id) REFERENCES public.trades(id) ON DELETE CASCADE;
 
 
+--
+-- Name: trade_scale_out_legs trade_scale_out_legs_trade_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trade_scale_out_legs
+    ADD CONSTRAINT trade_scale_out_legs_trade_id_fkey FOREIGN KEY (trade_id) REFERENCES public.trades(id) ON DELETE CASCADE;
+
+
 --
 -- Name: trades trades_account_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -719,6 +1388,14 @@ ALTER TABLE ONLY public.trades
     ADD CONSTRAINT trades_candle_id_fkey FOREIGN KEY (candle_id) REFERENCES public.candles_weekly(id);
 
 
+--
+-- Name: trades trades_symbol_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.trades
+    ADD CONSTRAINT trades_symbol_fkey FOREIGN KEY (symbol) REFERENCES public.instruments(symbol);
+
+
 --
 -- Name: trades trades_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"set-and-trend/backend/internal/backtest"
+	"set-and-trend/backend/internal/config"
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+// Replays candles_weekly through a rule and prints the simulated trades.
+// Read-only: nothing is written to trades (or anywhere else).
+func main() {
+	symbol := flag.String("symbol", constants.SymbolEURUSD, "instrument symbol")
+	ruleCode := flag.String("rule", string(rules.W1TrendBullish), "rule code")
+	ruleVersion := flag.Int("version", 0, "rule version (0 = active)")
+	bias := flag.String("bias", "long", "long or short")
+	entry := flag.String("entry", string(backtest.EntryNextOpen), "next_open or signal_close")
+	stop := flag.String("stop", string(backtest.StopSwing), "swing, fixed_pips or atr")
+	stopBuffer := flag.Float64("stop-buffer", 5, "swing stop buffer (pips)")
+	stopPips := flag.Float64("stop-pips", 100, "fixed stop distance (pips)")
+	stopATR := flag.Float64("stop-atr", 1.5, "atr stop multiple")
+	target := flag.Float64("target-r", 2, "take profit (R)")
+	balance := flag.Float64("balance", 10000, "initial balance")
+	riskPct := flag.Float64("risk", 1, "risk per trade (%)")
+	asJSON := flag.Bool("json", false, "print the full result as JSON")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("config.Load:", err)
+	}
+
+	queries, pool, err := config.NewDatabase(ctx, cfg)
+	if err != nil {
+		log.Fatal("database:", err)
+	}
+
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
+	inst, err := repositories.NewInstrumentRepository(pool).GetInstrument(ctx, *symbol)
+	if err != nil {
+		log.Fatalf("Failed to load instrument: %v", err)
+	}
+	stored, err := repositories.NewCandleRepository(queries).GetCandlesBySymbol(ctx, inst.Symbol)
+	if err != nil {
+		log.Fatalf("Failed to load candles: %v", err)
+	}
+	candles, err := services.ToComputeCandles(stored)
+	if err != nil {
+		log.Fatalf("Failed to parse candles: %v", err)
+	}
+
+	result, err := backtest.Run(candles, backtest.Config{
+		RuleCode:    rules.RuleCode(*ruleCode),
+		RuleVersion: *ruleVersion,
+		Bias:        *bias,
+		Entry:       backtest.EntryTemplate(*entry),
+		Stop: backtest.StopTemplate{
+			Kind:        backtest.StopKind(*stop),
+			BufferPips:  *stopBuffer,
+			Pips:        *stopPips,
+			ATRMultiple: *stopATR,
+		},
+		Target:         backtest.TargetTemplate{Kind: backtest.TargetFixedR, R: *target},
+		InitialBalance: *balance,
+		RiskPct:        *riskPct,
+		Instrument:     *inst,
+	})
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Printf("📊 %s %s over %d candles\n\n", *ruleCode, *bias, len(candles))
+	for _, t := range result.Trades {
+		fmt.Printf("%s → %s  entry %.5f  SL %.5f  TP %.5f  exit %.5f (%s)  %+.2fR  %+.2f\n",
+			t.EntryTime.Format("2006-01-02"), t.ExitTime.Format("2006-01-02"),
+			t.EntryPrice, t.StopLoss, t.TakeProfit, t.ExitPrice, t.ExitReason, t.RMultiple, t.PnLMoney)
+	}
+
+	s := result.Stats
+	fmt.Printf("\nSignals: %d (skipped %d: %v)\n", s.Signals, s.Skipped, result.Skipped)
+	fmt.Printf("Trades: %d  Wins: %d  Losses: %d  Win rate: %.1f%%\n", s.Trades, s.Wins, s.Losses, s.WinRate*100)
+	fmt.Printf("Net: %.2f (%.2fR)  Expectancy: %.2fR  Profit factor: %.2f\n", s.NetPnL, s.NetR, s.AverageR, s.ProfitFactor)
+	fmt.Printf("Max drawdown: %.2f (%.2f%%)  Max losing streak: %d\n", s.MaxDrawdown, s.MaxDrawdownPct, s.MaxConsecutiveLosses)
+	fmt.Printf("Final balance: %.2f\n", s.FinalBalance)
+}
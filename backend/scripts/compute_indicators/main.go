@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"set-and-trend/backend/internal/config"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/services"
+)
+
+// Recomputes indicators, swings and rule results for every candle through the
+// same incremental pipeline the API uses
+func main() {
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("config.Load:", err)
+	}
+
+	queries, pool, err := config.NewDatabase(ctx, cfg)
+	if err != nil {
+		log.Fatal("database:", err)
+	}
+
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
+	candleRepo := repositories.NewCandleRepository(queries)
+	indicatorRepo := repositories.NewIndicatorRepository(queries)
+
+	ruleService := services.NewRuleEvaluationService(
+		candleRepo,
+		indicatorRepo,
+		repositories.NewRuleResultRepository(queries, pool),
+	)
+	indicatorService := services.NewIndicatorService(
+		candleRepo,
+		indicatorRepo,
+		repositories.NewSwingRepository(pool),
+		ruleService,
+	)
+
+	fmt.Println("🚀 Computing indicators for all candles...")
+
+	processed, err := indicatorService.ProcessAll(ctx)
+	if err != nil {
+		log.Fatalf("❌ Indicator computation failed after %d candles: %v", processed, err)
+	}
+
+	fmt.Println("\n============================================================")
+	fmt.Printf("✅ Indicator Computation Complete!\n")
+	fmt.Printf("📊 Processed: %d candles\n", processed)
+	fmt.Println("============================================================")
+}
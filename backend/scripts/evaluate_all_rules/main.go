@@ -7,6 +7,7 @@ import (
 
 	"set-and-trend/backend/internal/config"
 	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
 	"set-and-trend/backend/internal/services"
 )
 
@@ -18,15 +19,19 @@ func main() {
 		log.Fatal("config.Load:", err)
 	}
 
-	queries, _, err := config.NewDatabase(ctx, cfg)
+	queries, pool, err := config.NewDatabase(ctx, cfg)
 	if err != nil {
 		log.Fatal("database:", err)
 	}
 
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
 	// Initialize repositories
 	candleRepo := repositories.NewCandleRepository(queries)
 	indicatorRepo := repositories.NewIndicatorRepository(queries)
-	ruleResultRepo := repositories.NewRuleResultRepository(queries)
+	ruleResultRepo := repositories.NewRuleResultRepository(queries, pool)
 
 	// Initialize service
 	ruleService := services.NewRuleEvaluationService(
@@ -38,7 +43,7 @@ func main() {
 	fmt.Println("🚀 Evaluating rules for all candles...")
 
 	// Get all candles
-	candles, err := candleRepo.GetLatestCandles(ctx, 10000) // Or use GetAllCandlesOrdered
+	candles, err := candleRepo.GetAllCandlesOrdered(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get candles: %v", err)
 	}
@@ -62,9 +67,30 @@ func main() {
 		}
 	}
 
+	// Candidate versions (e.g. a rule re-released under new semantics) are
+	// backfilled too, so they can be compared and promoted
+	candidateCount := 0
+	for code, active := range rules.RuleRegistry {
+		for _, spec := range rules.ListRuleVersions(code) {
+			if spec.Version == active.Version {
+				continue
+			}
+			evaluated, failed, err := ruleService.EvaluateVersionAll(ctx, code, spec.Version)
+			if err != nil {
+				log.Printf("❌ Failed to evaluate %s v%d: %v", code, spec.Version, err)
+				errorCount++
+				continue
+			}
+			fmt.Printf("🧪 %s v%d: %d evaluated, %d failed\n", code, spec.Version, evaluated, failed)
+			errorCount += failed
+			candidateCount++
+		}
+	}
+
 	fmt.Println("\n============================================================")
 	fmt.Printf("✅ Rule Evaluation Complete!\n")
 	fmt.Printf("📊 Success: %d/%d\n", successCount, len(candles))
+	fmt.Printf("🧪 Candidate versions: %d\n", candidateCount)
 	fmt.Printf("❌ Errors: %d\n", errorCount)
 	fmt.Println("============================================================")
 }
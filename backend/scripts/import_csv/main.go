@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"set-and-trend/backend/internal/config"
+	"set-and-trend/backend/internal/constants"
 	"set-and-trend/backend/internal/repositories"
 	"set-and-trend/backend/internal/services"
 )
@@ -25,13 +26,40 @@ func main() {
 	}
 
 	// Connect to database
-	queries, _, err := config.NewDatabase(ctx, cfg)
+	queries, pool, err := config.NewDatabase(ctx, cfg)
 	if err != nil {
 		log.Fatal("database:", err)
 	}
 
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
 	candleRepo := repositories.NewCandleRepository(queries)
 	indicatorRepo := repositories.NewIndicatorRepository(queries)
+	ruleService := services.NewRuleEvaluationService(
+		candleRepo,
+		indicatorRepo,
+		repositories.NewRuleResultRepository(queries, pool),
+	)
+	indicatorService := services.NewIndicatorService(
+		candleRepo,
+		indicatorRepo,
+		repositories.NewSwingRepository(pool),
+		ruleService,
+	)
+
+	// Pending orders and SL/TP hits, like CandleHandler.CreateCandle
+	execRepo := repositories.NewExecutionRepository(pool)
+	executionService := services.NewExecutionService(
+		repositories.NewTradeRepository(queries, pool),
+		execRepo,
+		repositories.NewIntentRepository(pool),
+		repositories.NewLedgerRepository(pool),
+		repositories.NewInstrumentRepository(pool),
+		pool,
+	)
+	tradeMonitor := services.NewTradeMonitor(candleRepo, execRepo, executionService, cfg.SameCandleRule)
 
 	// Open CSV file - UPDATE THIS PATH IF NEEDED
 	csvPath := "/home/set-and-trend/backend/mt4_ready/EURUSD_weekly_2015_2025.csv"
@@ -94,6 +122,7 @@ func main() {
 		// Insert candle
 		candle, err := candleRepo.CreateCandle(ctx, repositories.CandleCreateParams{
 			ID:           uuid.New(),
+			Symbol:       constants.SymbolEURUSD,
 			TimestampUTC: timestamp,
 			Open:         openStr,
 			High:         highStr,
@@ -107,38 +136,21 @@ func main() {
 			continue
 		}
 
-		// Parse OHLC for indicators
-		open, _ := strconv.ParseFloat(openStr, 64)
-		high, _ := strconv.ParseFloat(highStr, 64)
-		low, _ := strconv.ParseFloat(lowStr, 64)
-		close, _ := strconv.ParseFloat(closeStr, 64)
-
-		// Compute basic indicators
-		indicators := services.ComputeBasicIndicators(services.Candle{
-			Open:   open,
-			High:   high,
-			Low:    low,
-			Close:  close,
-			Volume: &volume,
-		})
-
-		// Insert indicators
-		_, err = indicatorRepo.CreateIndicator(ctx, repositories.IndicatorCreateParams{
-			ID:                 uuid.New(),
-			CandleID:           candle.ID,
-			EMA20:              indicators.EMA20,
-			EMA50:              indicators.EMA50,
-			EMA200:             indicators.EMA200,
-			RangeSize:          indicators.RangeSize,
-			BodySize:           indicators.BodySize,
-			UpperWick:          indicators.UpperWick,
-			LowerWick:          indicators.LowerWick,
-			MidPrice:           indicators.MidPrice,
-			LastSwingHighPrice: indicators.LastSwingHighPrice,
-			LastSwingLowPrice:  indicators.LastSwingLowPrice,
-		})
+		// Same incremental pipeline as the API (rows are imported oldest first)
+		if _, err := indicatorService.ProcessCandle(ctx, candle.ID); err != nil {
+			log.Printf("⚠️  Row %d: Failed to compute indicators for %s: %v\n", i+2, dateStr, err)
+		}
+		orderExecs, invalidations, err := tradeMonitor.CheckPendingOrders(ctx, candle.ID)
+		if err != nil {
+			log.Printf("⚠️  Row %d: Failed to check pending orders for %s: %v\n", i+2, dateStr, err)
+		}
+		hits, err := tradeMonitor.CheckCandle(ctx, candle.ID)
 		if err != nil {
-			log.Printf("⚠️  Row %d: Failed to insert indicators for %s: %v\n", i+2, dateStr, err)
+			log.Printf("⚠️  Row %d: Failed to check SL/TP for %s: %v\n", i+2, dateStr, err)
+		}
+		if len(orderExecs)+len(invalidations)+len(hits) > 0 {
+			fmt.Printf("🔔 %s: %d order executions, %d invalidations, %d SL/TP hits\n",
+				dateStr, len(orderExecs), len(invalidations), len(hits))
 		}
 
 		successCount++
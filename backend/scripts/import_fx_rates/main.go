@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"set-and-trend/backend/internal/config"
+	"set-and-trend/backend/internal/repositories"
+)
+
+// Loads daily FX rates into fx_rates. Re-importing a day replaces its rate.
+// CSV format (with header): date,base,quote,rate
+//
+//	2024-03-01,EUR,USD,1.08450
+func main() {
+	csvPath := flag.String("file", "fx_rates.csv", "CSV file (date,base,quote,rate)")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("config.Load:", err)
+	}
+
+	_, pool, err := config.NewDatabase(ctx, cfg)
+	if err != nil {
+		log.Fatal("database:", err)
+	}
+
+	file, err := os.Open(*csvPath)
+	if err != nil {
+		log.Fatalf("Failed to open CSV: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	if _, err := reader.Read(); err != nil {
+		log.Fatalf("Failed to read CSV header: %v", err)
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		log.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	fxRateRepo := repositories.NewFXRateRepository(pool)
+
+	successCount := 0
+	errorCount := 0
+
+	for i, record := range records {
+		if len(record) < 4 {
+			log.Printf("❌ Row %d: Invalid format (too few columns)\n", i+2)
+			errorCount++
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			log.Printf("❌ Row %d: Failed to parse date '%s': %v\n", i+2, record[0], err)
+			errorCount++
+			continue
+		}
+		rate, err := decimal.NewFromString(record[3])
+		if err != nil || !rate.IsPositive() {
+			log.Printf("❌ Row %d: Invalid rate '%s'\n", i+2, record[3])
+			errorCount++
+			continue
+		}
+
+		err = fxRateRepo.UpsertRate(ctx, repositories.FXRate{
+			BaseCurrency:  strings.ToUpper(strings.TrimSpace(record[1])),
+			QuoteCurrency: strings.ToUpper(strings.TrimSpace(record[2])),
+			RateDate:      date,
+			Rate:          rate,
+		})
+		if err != nil {
+			log.Printf("❌ Row %d: %v\n", i+2, err)
+			errorCount++
+			continue
+		}
+		successCount++
+	}
+
+	fmt.Printf("✅ Imported %d/%d rates (%d errors)\n", successCount, len(records), errorCount)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"set-and-trend/backend/internal/backtest"
+	"set-and-trend/backend/internal/config"
+	"set-and-trend/backend/internal/constants"
+	"set-and-trend/backend/internal/repositories"
+	"set-and-trend/backend/internal/rules"
+	"set-and-trend/backend/internal/services"
+)
+
+// Parameter sweep with walk-forward validation over candles_weekly.
+// Writes the ranked variants as CSV. Read-only: nothing is persisted.
+func main() {
+	symbol := flag.String("symbol", constants.SymbolEURUSD, "instrument symbol")
+	ruleCode := flag.String("rule", string(rules.W1TouchEMA20), "rule code")
+	bias := flag.String("bias", "long", "long or short")
+	inYears := flag.Int("in-sample", 4, "in-sample window (years)")
+	outYears := flag.Int("out-of-sample", 1, "out-of-sample window (years)")
+	workers := flag.Int("workers", 0, "parallel workers (0 = one per CPU)")
+	out := flag.String("out", "sweep_results.csv", "ranked results table (CSV)")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("config.Load:", err)
+	}
+
+	queries, pool, err := config.NewDatabase(ctx, cfg)
+	if err != nil {
+		log.Fatal("database:", err)
+	}
+
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
+	inst, err := repositories.NewInstrumentRepository(pool).GetInstrument(ctx, *symbol)
+	if err != nil {
+		log.Fatalf("Failed to load instrument: %v", err)
+	}
+	stored, err := repositories.NewCandleRepository(queries).GetCandlesBySymbol(ctx, inst.Symbol)
+	if err != nil {
+		log.Fatalf("Failed to load candles: %v", err)
+	}
+	candles, err := services.ToComputeCandles(stored)
+	if err != nil {
+		log.Fatalf("Failed to parse candles: %v", err)
+	}
+	if len(candles) == 0 {
+		log.Fatal("no candles")
+	}
+
+	// Around the MVP choices (EMA 20/50/200, θ = 0.3, swing stop, 2R)
+	grid := backtest.Grid{
+		EMAPeriods: []services.EMAPeriods{
+			{Fast: 10, Trend: 50, Slow: 200},
+			services.DefaultEMAPeriods,
+			{Fast: 30, Trend: 50, Slow: 200},
+			{Fast: 20, Trend: 40, Slow: 100},
+			{Fast: 20, Trend: 50, Slow: 150},
+		},
+		TouchProximity: []float64{0.1, 0.2, 0.3, 0.5},
+		Stops: []backtest.StopTemplate{
+			{Kind: backtest.StopSwing, BufferPips: 5},
+			{Kind: backtest.StopSwing, BufferPips: 20},
+			{Kind: backtest.StopATR, ATRMultiple: 1},
+			{Kind: backtest.StopATR, ATRMultiple: 1.5},
+		},
+		Targets: []backtest.TargetTemplate{
+			{Kind: backtest.TargetFixedR, R: 1.5},
+			{Kind: backtest.TargetFixedR, R: 2},
+			{Kind: backtest.TargetFixedR, R: 3},
+		},
+	}
+
+	splits := backtest.WalkForwardSplits(candles[0].TimestampUTC, candles[len(candles)-1].TimestampUTC, *inYears, *outYears)
+
+	fmt.Printf("🚀 Sweeping %d variants × %d walk-forward splits over %d candles\n",
+		len(grid.Variants()), len(splits), len(candles))
+
+	result, err := backtest.Sweep(candles, backtest.Config{
+		RuleCode:       rules.RuleCode(*ruleCode),
+		Bias:           *bias,
+		Entry:          backtest.EntryNextOpen,
+		InitialBalance: 10000,
+		RiskPct:        1,
+		Instrument:     *inst,
+	}, grid, splits, *workers)
+	if err != nil {
+		log.Fatalf("Sweep failed: %v", err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	defer f.Close()
+	if err := backtest.WriteTable(f, result); err != nil {
+		log.Fatalf("Failed to write table: %v", err)
+	}
+
+	fmt.Printf("\n🏆 Top variants (out-of-sample):\n")
+	for _, v := range result.Variants[:min(10, len(result.Variants))] {
+		fmt.Printf("%3d. %-40s  OOS %3d trades  %+.2fR/trade  (in-sample %+.2fR, degradation %+.2fR)\n",
+			v.Rank, v.Name, v.OutOfSample.Trades, v.OutOfSample.AverageR, v.InSampleAverageR, v.Degradation)
+	}
+
+	fmt.Printf("\n🔁 Walk-forward (best in-sample variant, then out of sample):\n")
+	for _, sel := range result.WalkForward {
+		fmt.Printf("%s → %s  %-40s  IS %+.2fR  OOS %+.2fR (%d trades)\n",
+			sel.Split.OutStart.Format("2006-01-02"), sel.Split.OutEnd.Format("2006-01-02"),
+			sel.Name, sel.InSample.AverageR, sel.OutOfSample.AverageR, sel.OutOfSample.Trades)
+	}
+	wf := result.WalkForwardStats
+	fmt.Printf("Walk-forward out-of-sample: %d trades, %+.2fR/trade, profit factor %.2f\n", wf.Trades, wf.AverageR, wf.ProfitFactor)
+	fmt.Printf("\n✅ Results written to %s\n", *out)
+}
@@ -19,15 +19,19 @@ func main() {
 		log.Fatal("config.Load:", err)
 	}
 
-	queries, _, err := config.NewDatabase(ctx, cfg)
+	queries, pool, err := config.NewDatabase(ctx, cfg)
 	if err != nil {
 		log.Fatal("database:", err)
 	}
 
+	if err := services.LoadRuleDefinitions(ctx, cfg.RulesDir, repositories.NewRuleRepository(pool)); err != nil {
+		log.Fatal("rules:", err)
+	}
+
 	// Initialize repositories
 	candleRepo := repositories.NewCandleRepository(queries)
 	indicatorRepo := repositories.NewIndicatorRepository(queries)
-	ruleResultRepo := repositories.NewRuleResultRepository(queries)
+	ruleResultRepo := repositories.NewRuleResultRepository(queries, pool)
 
 	// Initialize service
 	ruleService := services.NewRuleEvaluationService(
@@ -55,6 +59,6 @@ func main() {
 
 	fmt.Printf("\n📊 Results for candle %s:\n", candleID)
 	for _, r := range results {
-		fmt.Printf("  - %s: %s (confidence: %.2f)\n", r.RuleCode, r.Result, r.ConfidenceScore.InexactFloat64())
+		fmt.Printf("  - %s v%d: %s (confidence: %.2f)\n", r.RuleCode, r.RuleVersion, r.Result, r.ConfidenceScore.InexactFloat64())
 	}
 }